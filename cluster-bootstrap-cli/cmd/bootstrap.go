@@ -14,6 +14,7 @@ import (
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
 )
 
@@ -32,6 +33,8 @@ var (
 	healthTimeout     int
 	reportFormat      string
 	reportOutput      string
+	skipKnownHosts    bool
+	skipPreflight     []string
 )
 
 var bootstrapCmd = &cobra.Command{
@@ -60,6 +63,8 @@ func init() {
 	bootstrapCmd.Flags().IntVar(&healthTimeout, "health-timeout", 180, "timeout in seconds for health checks (default 180)")
 	bootstrapCmd.Flags().StringVar(&reportFormat, "report-format", "summary", "report format: summary, json, none")
 	bootstrapCmd.Flags().StringVar(&reportOutput, "report-output", "", "write JSON report to file")
+	bootstrapCmd.Flags().StringSliceVar(&skipPreflight, "skip-preflight", nil, "skip the preflight checks with these IDs or categories (see 'checks list --command preflight')")
+	bootstrapCmd.Flags().BoolVar(&skipKnownHosts, "skip-known-hosts", false, "do not verify SSH host keys or update argocd-ssh-known-hosts-cm")
 
 	rootCmd.AddCommand(bootstrapCmd)
 }
@@ -112,6 +117,10 @@ func runBootstrap(cmd *cobra.Command, args []string) error {
 		argoCDAppPath = appPath
 	}
 
+	if err := resolveEncryption(cmd, env, &encryption); err != nil {
+		return err
+	}
+
 	// Initialize bootstrap report
 	report := NewBootstrapReport(env)
 	report.Configuration = ConfigReport{
@@ -157,9 +166,20 @@ func runBootstrap(cmd *cobra.Command, args []string) error {
 	}()
 
 	// Run preflight checks
-	// Only require kubectl if we're going to use wait-for-health
 	preflightTimer := startStage("Preflight Checks")
-	if err := PreflightChecks(encryption, bootstrapAgeKey, verbose, waitForHealth); err != nil {
+	if err := PreflightChecks(PreflightOptions{
+		Env:               env,
+		Encryption:        encryption,
+		AgeKeyFile:        bootstrapAgeKey,
+		Kubeconfig:        kubeconfig,
+		KubeContext:       kubeContext,
+		Verbose:           verbose,
+		WaitForHealth:     waitForHealth,
+		DryRun:            dryRun,
+		SkipArgoCDInstall: skipArgoCDInstall,
+		SkipKnownHosts:    skipKnownHosts,
+		Skip:              skipPreflight,
+	}); err != nil {
 		bootstrapErr = err
 		report.AddStage(preflightTimer.complete(false, err))
 		return err
@@ -290,6 +310,33 @@ func runBootstrap(cmd *cobra.Command, args []string) error {
 
 	ctx := context.Background()
 
+	// Verify SSH host keys before changing anything in the cluster
+	var hosts []hostKeys
+	if !skipKnownHosts {
+		hostsTimer := startStage("Verifying SSH Host Keys")
+		hostsStage := logger.Stage("Verifying SSH Host Keys")
+		stepf("Verifying SSH host keys...")
+		current, _, err := client.GetSSHKnownHosts(ctx)
+		if err == nil {
+			hosts, err = fetchKnownHosts(ctx, envSecrets.Repo, knownhosts.Parse(current))
+		}
+		if err != nil {
+			bootstrapErr = err
+			report.AddStage(hostsTimer.complete(false, err))
+			return err
+		}
+		for _, h := range hosts {
+			for _, key := range h.Keys {
+				hostsStage.Detail("✓ %s %s %s", h.Host, key.Type(), knownhosts.Fingerprint(key))
+			}
+			if h.PinSource == "" {
+				warnf("Host keys of %s are not pinned; trusting them on first use\n  hint: run 'cluster-bootstrap repo known-hosts %s --pin' to pin them", h.Host, env)
+			}
+		}
+		hostsStage.Done()
+		report.AddStage(hostsTimer.complete(true, nil))
+	}
+
 	// Create Kubernetes secrets (before Helm install, as the chart may reference them)
 	secretsK8sTimer := startStage("Creating K8s Resources")
 	secretsK8sStage := logger.Stage("Creating K8s Secrets")
@@ -388,6 +435,24 @@ func runBootstrap(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Merge the verified host keys into the ConfigMap the ArgoCD chart creates
+	if len(hosts) > 0 {
+		stepf("Updating SSH known hosts...")
+		current, _, err := client.GetSSHKnownHosts(ctx)
+		if err != nil {
+			bootstrapErr = err
+			return err
+		}
+		file := knownhosts.Parse(current)
+		if mergeKnownHosts(file, hosts) {
+			if _, err := client.ApplySSHKnownHosts(ctx, file.String()); err != nil {
+				bootstrapErr = err
+				return err
+			}
+			successf("Updated argocd/%s", knownhosts.ConfigMapName)
+		}
+	}
+
 	// Apply App of Apps
 	appTimer := startStage("Deploying App of Apps")
 	appStage := logger.Stage("Deploying App of Apps")
@@ -0,0 +1,628 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/compat"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+// checkParallelism bounds how many checks doctor, validate and preflight run
+// at once.
+const checkParallelism = 8
+
+// clusterAccessTimeout bounds the discovery call of the cluster-access check.
+const clusterAccessTimeout = 10 * time.Second
+
+var (
+	checksListCommand string
+	checksListOutput  string
+)
+
+var checksCmd = &cobra.Command{
+	Use:   "checks",
+	Short: "Inspect the checks run by doctor, validate and bootstrap",
+	Long: `Inspect the checks run by doctor, validate and the bootstrap preflight.
+
+Every check has a stable ID and a category; doctor and validate run a subset
+with --only and leave checks out with --skip, by ID or category. Custom
+checks are declared in the checks section of .cluster-bootstrap.yaml.`,
+}
+
+var checksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in and custom checks",
+	Long: `List the built-in checks and the custom checks declared in
+.cluster-bootstrap.yaml under --base-dir, with the commands that run them,
+the encryption backends they apply to and the checks they depend on.
+
+Example:
+  cluster-bootstrap checks list
+  cluster-bootstrap checks list --command doctor
+  cluster-bootstrap checks list -o json`,
+	Args: cobra.NoArgs,
+	RunE: runChecksList,
+}
+
+func init() {
+	checksListCmd.Flags().StringVar(&checksListCommand, "command", "", "only list the checks of doctor, validate, preflight or preflight-cluster")
+	checksListCmd.Flags().StringVarP(&checksListOutput, "output", "o", "text", "output format: text or json")
+
+	checksCmd.AddCommand(checksListCmd)
+	rootCmd.AddCommand(checksCmd)
+}
+
+// checkRun holds the options of a doctor, validate or preflight run and the
+// state checks hand to the checks depending on them.
+type checkRun struct {
+	command     string
+	env         string
+	encryption  string
+	ageKeyFile  string
+	kubeconfig  string
+	kubeContext string
+	skipCluster bool
+	secretsPath string
+	// kubeVersion is checked against the charts without a cluster.
+	kubeVersion string
+	matrix      *compat.Matrix
+
+	// Set by app-path and secrets-content for helm-lint and the repo checks.
+	appPath string
+	appErr  error
+	secrets *config.EnvironmentSecrets
+	// Set by render-manifests for the preflight cluster checks.
+	rendered *render.Result
+	// rbac describes the bootstrap whose permissions are reviewed; nil
+	// skips the review.
+	rbac *rbacOptions
+
+	// kube is the cluster client, created once unless set up front.
+	kubeOnce sync.Once
+	kube     *k8s.Client
+	kubeErr  error
+
+	serverOnce    sync.Once
+	serverVersion string
+	serverErr     error
+}
+
+// builtinChecks returns the built-in checks in report order.
+func builtinChecks(run *checkRun) []checks.Check {
+	all := []string{"doctor", "validate", "preflight"}
+	cluster := []string{"doctor", "validate", "preflight", "preflight-cluster"}
+	return []checks.Check{
+		{
+			ID: "base-dir", Name: "base directory", Category: checks.CategoryConfig,
+			Description: "--base-dir exists and is a directory",
+			Commands:    []string{"validate"},
+			Run: checkFunc(func() (string, error) {
+				info, err := os.Stat(baseDir)
+				if err != nil {
+					return "", fmt.Errorf("base-dir %s not accessible: %w", baseDir, err)
+				}
+				if !info.IsDir() {
+					return "", fmt.Errorf("base-dir %s is not a directory", baseDir)
+				}
+				return baseDir, nil
+			}),
+		},
+		{
+			ID: "app-path", Name: "app path", Category: checks.CategoryConfig,
+			Description: "the App of Apps chart exists under the base directory",
+			Commands:    []string{"validate"},
+			Run: checkFunc(func() (string, error) {
+				run.appPath, run.appErr = resolveAppPath(baseDir, validateAppPath)
+				return run.appPath, run.appErr
+			}),
+		},
+		{
+			ID: "kubectl", Name: "kubectl available", Category: checks.CategoryTools,
+			Description: "kubectl is installed; it is optional",
+			Commands:    []string{"doctor", "validate"},
+			Run: func(context.Context) []checks.Result {
+				if err := CheckKubectlAvailable(); err != nil {
+					return checks.Warn("not installed", "kubectl is optional; install it to inspect the cluster by hand")
+				}
+				return checks.OK("")
+			},
+		},
+		{
+			ID: "kube-context", Name: "kubeconfig context", Category: checks.CategoryCluster,
+			Description: "the kubeconfig has the context the command uses",
+			Commands:    cluster,
+			Run: func(context.Context) []checks.Result {
+				if run.skipCluster {
+					return checks.Skip("skipped")
+				}
+				name, err := k8s.CurrentContext(run.kubeconfig, run.kubeContext)
+				if err != nil {
+					return checks.Fail(err)
+				}
+				return checks.OK(name)
+			},
+		},
+		{
+			ID: "auth-plugin", Name: "credential plugin", Category: checks.CategoryCluster,
+			Description: "the exec credential plugin of the context's user is installed",
+			Commands:    cluster,
+			DependsOn:   []string{"kube-context"},
+			Run: func(context.Context) []checks.Result {
+				if run.skipCluster {
+					return checks.Skip("skipped")
+				}
+				plugin, err := k8s.CheckAuthPlugin(run.kubeconfig, run.kubeContext)
+				if err != nil {
+					return checks.Fail(err)
+				}
+				if plugin == "" {
+					return checks.OK("none")
+				}
+				return checks.OK(plugin)
+			},
+		},
+		{
+			ID: "cluster-access", Name: "cluster access", Category: checks.CategoryCluster,
+			Description: "the cluster answers a discovery call with the configured kubeconfig and context",
+			Commands:    cluster,
+			DependsOn:   []string{"auth-plugin"},
+			Run: func(ctx context.Context) []checks.Result {
+				if run.skipCluster {
+					return checks.Skip("skipped")
+				}
+				version, err := k8s.Ping(ctx, run.kubeconfig, run.kubeContext, clusterAccessTimeout)
+				if err != nil {
+					return checks.Fail(err)
+				}
+				return checks.OK(version)
+			},
+		},
+		{
+			ID: "helm", Name: "helm available", Category: checks.CategoryTools,
+			Description: "helm is installed",
+			Commands:    all,
+			Run:         checkFunc(func() (string, error) { return "", CheckHelm() }),
+		},
+		{
+			ID: "tool-versions", Name: "tool versions", Category: checks.CategoryTools,
+			Description: "installed tools satisfy the version constraints",
+			Commands:    all,
+			Run:         func(ctx context.Context) []checks.Result { return toolVersionResults(ctx, run) },
+		},
+		{
+			ID: "kube-version", Name: "kubernetes version", Category: checks.CategoryCluster,
+			Description: "the cluster's Kubernetes version satisfies the version constraints",
+			Commands:    cluster,
+			DependsOn:   []string{"cluster-access"},
+			Run:         func(ctx context.Context) []checks.Result { return kubeVersionResult(ctx, run) },
+		},
+		{
+			ID: "sops", Name: "sops available", Category: checks.CategoryEncryption,
+			Description: "sops is installed",
+			Commands:    []string{"doctor"},
+			Backends:    []string{"sops"},
+			Run:         checkFunc(func() (string, error) { return "", CheckSOPS("sops") }),
+		},
+		{
+			ID: "age", Name: "age available", Category: checks.CategoryEncryption,
+			Description: "an age identity is available to SOPS",
+			Commands:    []string{"doctor"},
+			Backends:    []string{"sops"},
+			Run:         checkFunc(func() (string, error) { return "", CheckAge("sops", run.ageKeyFile) }),
+		},
+		{
+			ID: "git-crypt", Name: "git-crypt available", Category: checks.CategoryEncryption,
+			Description: "git-crypt is installed",
+			Commands:    []string{"doctor"},
+			Backends:    []string{"git-crypt"},
+			Run:         checkFunc(func() (string, error) { return "", CheckGitCrypt("git-crypt") }),
+		},
+		{
+			ID: "encryption-tools", Name: "encryption tooling", Category: checks.CategoryEncryption,
+			Description: "the tools of the encryption backend are installed",
+			Commands:    []string{"validate", "preflight"},
+			Run: checkFunc(func() (string, error) {
+				switch run.encryption {
+				case "sops":
+					if err := CheckSOPS("sops"); err != nil {
+						return "", err
+					}
+					return "sops", CheckAge("sops", run.ageKeyFile)
+				case "git-crypt":
+					return "git-crypt", CheckGitCrypt("git-crypt")
+				default:
+					return "", fmt.Errorf("unsupported encryption backend: %s", run.encryption)
+				}
+			}),
+		},
+		{
+			ID: "secrets-file", Name: "secrets file", Category: checks.CategorySecrets,
+			Description: "the secrets file exists with owner-only permissions",
+			Commands:    []string{"validate"},
+			Run: checkFunc(func() (string, error) {
+				if _, err := os.Stat(run.secretsPath); err != nil {
+					return "", fmt.Errorf("secrets file not found: %s", run.secretsPath)
+				}
+				if err := CheckFilePermissions(run.secretsPath, true); err != nil {
+					return "", err
+				}
+				return run.secretsPath, nil
+			}),
+		},
+		{
+			ID: "secrets-content", Name: "secrets content", Category: checks.CategorySecrets,
+			Description: "the secrets file decrypts and sets repo.url and repo.sshPrivateKey",
+			Commands:    []string{"validate"},
+			Run: func(context.Context) []checks.Result {
+				res := validateResult{id: "secrets-content", name: "secrets content", note: "repo credentials validated"}
+				secrets, err := loadCheckSecrets(run)
+				switch {
+				case err != nil:
+					res = validateResult{id: res.id, name: res.name, err: err}
+				case strings.TrimSpace(secrets.Repo.URL) == "":
+					res = validateResult{id: res.id, name: res.name, err: fmt.Errorf("repo.url is required in secrets file")}
+				case strings.TrimSpace(secrets.Repo.SSHPrivateKey) == "":
+					res = validateResult{id: res.id, name: res.name, err: fmt.Errorf("repo.sshPrivateKey is required in secrets file")}
+				default:
+					run.secrets = secrets
+				}
+				return []checks.Result{res.at(run.secretsPath).result()}
+			},
+		},
+		{
+			ID: "secrets-warnings", Name: "secrets warnings", Category: checks.CategorySecrets,
+			Description: "the secrets file sets repo.targetRevision",
+			Commands:    []string{"validate"},
+			Run:         validateFunc(func() validateResult { return validateSecretsWarnings(run.secretsPath) }),
+		},
+		{
+			ID: "sops-config", Name: ".sops.yaml", Category: checks.CategoryEncryption,
+			Description: ".sops.yaml has a creation rule for the environment",
+			Commands:    []string{"validate"},
+			Backends:    []string{"sops"},
+			Run:         validateFunc(func() validateResult { return validateSopsConfig(run.env) }),
+		},
+		{
+			ID: "sops-keys", Name: "sops keys", Category: checks.CategoryEncryption,
+			Description: "every .sops.yaml creation rule lists well-formed keys",
+			Commands:    []string{"validate"},
+			Backends:    []string{"sops"},
+			Run:         validateFunc(validateSopsKeys),
+		},
+		{
+			ID: "gitcrypt-attributes", Name: ".gitattributes", Category: checks.CategoryEncryption,
+			Description: ".gitattributes encrypts the secrets files with git-crypt",
+			Commands:    []string{"validate"},
+			Backends:    []string{"git-crypt"},
+			Run:         validateFunc(validateGitCryptAttributes),
+		},
+		{
+			ID: "repo-access", Name: "repo access", Category: checks.CategoryRepo,
+			Description: "the repository is reachable and its server is trusted",
+			Commands:    []string{"validate"},
+			DependsOn:   []string{"secrets-content"},
+			Timeout:     flagTimeout(validateRepoTimeout),
+			Run:         validateFunc(func() validateResult { return validateRepoAccess(run.secrets) }),
+		},
+		{
+			ID: "repo-key", Name: "repo key", Category: checks.CategoryRepo,
+			Description: "the repository SSH key is usable by ArgoCD",
+			Commands:    []string{"validate"},
+			DependsOn:   []string{"secrets-content"},
+			Run:         validateFunc(func() validateResult { return validateRepoKey(run.secrets) }),
+		},
+		{
+			ID: "ssh-repo-access", Name: "ssh repo access", Category: checks.CategoryRepo,
+			Description: "the SSH key can read the repository and its target revision",
+			Commands:    []string{"validate"},
+			DependsOn:   []string{"secrets-content", "repo-key"},
+			Timeout:     flagTimeout(validateRepoTimeout),
+			Run:         validateFunc(func() validateResult { return validateSSHRepoAccess(run.secrets) }),
+		},
+		{
+			ID: "helm-lint", Name: "helm lint", Category: checks.CategoryChart,
+			Description: "helm lint passes on the App of Apps chart with the environment values",
+			Commands:    []string{"validate"},
+			DependsOn:   []string{"app-path", "helm"},
+			Timeout:     flagTimeout(validateHelmTimeout),
+			Run: validateFunc(func() validateResult {
+				return validateHelmLint(run.env, run.appPath, run.appErr)
+			}),
+		},
+		{
+			ID: "chart-kube-versions", Name: "chart kubeVersion", Category: checks.CategoryChart,
+			Description: "every enabled component chart supports the cluster's Kubernetes version",
+			Commands:    []string{"validate"},
+			DependsOn:   []string{"cluster-access"},
+			Timeout:     10 * time.Minute,
+			Run:         func(context.Context) []checks.Result { return chartKubeVersionResults(run) },
+		},
+		{
+			ID: "argocd-crds", Name: "argocd crds", Category: checks.CategoryCluster,
+			Description: "the ArgoCD Application CRD is installed",
+			Commands:    []string{"validate"},
+			DependsOn:   []string{"cluster-access"},
+			Run:         validateFunc(validateArgoCDCRDs),
+		},
+		{
+			ID: "render-manifests", Name: "rendered manifests", Category: checks.CategoryChart,
+			Description: "the enabled components render for the cluster's Kubernetes version",
+			Commands:    []string{"preflight-cluster"},
+			Timeout:     10 * time.Minute,
+			Run:         func(context.Context) []checks.Result { return renderManifestResults(run) },
+		},
+		{
+			ID: "node-conditions", Name: "node conditions", Category: checks.CategoryCluster,
+			Description: "no node reports memory, disk or PID pressure",
+			Commands:    []string{"preflight-cluster"},
+			DependsOn:   []string{"cluster-access"},
+			Run:         func(ctx context.Context) []checks.Result { return nodeConditionResults(ctx, run) },
+		},
+		{
+			ID: "storage-class", Name: "storage class", Category: checks.CategoryCluster,
+			Description: "a default StorageClass exists, and every StorageClass the claims name",
+			Commands:    []string{"preflight-cluster"},
+			DependsOn:   []string{"cluster-access", "render-manifests"},
+			Run:         func(ctx context.Context) []checks.Result { return storageClassResults(ctx, run) },
+		},
+		{
+			ID: "capacity", Name: "capacity", Category: checks.CategoryCluster,
+			Description: "schedulable nodes allocate the CPU and memory the components request",
+			Commands:    []string{"preflight-cluster"},
+			DependsOn:   []string{"cluster-access", "render-manifests"},
+			Run:         func(ctx context.Context) []checks.Result { return capacityResults(ctx, run) },
+		},
+		{
+			ID: "crd-conflicts", Name: "crd conflicts", Category: checks.CategoryCluster,
+			Description: "the rendered CRDs are not owned by another tool or component",
+			Commands:    []string{"preflight-cluster"},
+			DependsOn:   []string{"cluster-access", "render-manifests"},
+			Run:         func(ctx context.Context) []checks.Result { return crdConflictResults(ctx, run) },
+		},
+		{
+			ID: "namespace-conflicts", Name: "namespace conflicts", Category: checks.CategoryCluster,
+			Description: "the components' namespaces are not owned by another tool",
+			Commands:    []string{"preflight-cluster"},
+			DependsOn:   []string{"cluster-access", "render-manifests"},
+			Run:         func(ctx context.Context) []checks.Result { return namespaceConflictResults(ctx, run) },
+		},
+		{
+			ID: "rbac", Name: "rbac permissions", Category: checks.CategoryCluster,
+			Description: "the current user may create everything the bootstrap creates",
+			Commands:    []string{"validate", "preflight"},
+			DependsOn:   []string{"cluster-access"},
+			Timeout:     5 * time.Minute,
+			Run:         func(ctx context.Context) []checks.Result { return rbacResults(ctx, run) },
+		},
+		{
+			ID: "policy", Name: "policy", Category: checks.CategoryPolicy,
+			Description: "rendered manifests pass the rules in policy.yaml",
+			Commands:    []string{"validate"},
+			Timeout:     10 * time.Minute,
+			Run: func(context.Context) []checks.Result {
+				var out []checks.Result
+				for _, res := range validatePolicy(run.env) {
+					out = append(out, res.result())
+				}
+				return out
+			},
+		},
+	}
+}
+
+// checkRegistry returns the built-in checks and the custom checks declared
+// under the base directory.
+func checkRegistry(run *checkRun) (*checks.Registry, error) {
+	versions, err := config.LoadVersionConstraints(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	run.matrix = compat.NewMatrix(versions)
+
+	registry := checks.NewRegistry()
+	if err := registry.Register(builtinChecks(run)...); err != nil {
+		return nil, err
+	}
+	custom, err := config.LoadCustomChecks(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	opts := checks.CustomOptions{BaseDir: baseDir, Env: run.env}
+	if !run.skipCluster {
+		opts.Kube = run.kubeClient
+	}
+	for _, c := range custom {
+		if err := registry.Register(checks.Custom(c, opts)); err != nil {
+			return nil, fmt.Errorf("%s: %w\n  hint: custom checks cannot reuse the ID of a built-in check", config.RepoConfigFile, err)
+		}
+	}
+	for _, c := range custom {
+		for _, dep := range c.DependsOn {
+			if _, ok := registry.Lookup(dep); !ok {
+				return nil, fmt.Errorf("%s: check %s depends on unknown check %s\n  hint: run 'cluster-bootstrap checks list' to see the check IDs", config.RepoConfigFile, c.ID, dep)
+			}
+		}
+	}
+	return registry, nil
+}
+
+// runChecks runs the checks of run.command selected by sel, logging each
+// result to stage.
+func runChecks(run *checkRun, sel checks.Selection, stage *StageLogger) ([]checks.Result, error) {
+	registry, err := checkRegistry(run)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := registry.Plan(run.command, run.encryption, sel)
+	if err != nil {
+		return nil, err
+	}
+	return plan.Run(context.Background(), checks.RunOptions{
+		Parallelism: checkParallelism,
+		OnResult: func(_ checks.Check, results []checks.Result) {
+			for _, res := range results {
+				stage.Detail("%s: %s", statusLabel(res.Status), res.Name)
+			}
+		},
+	}), nil
+}
+
+// loadCheckSecrets decrypts the secrets file of run.
+func loadCheckSecrets(run *checkRun) (*config.EnvironmentSecrets, error) {
+	if run.encryption == "git-crypt" {
+		return config.LoadSecretsPlaintext(run.secretsPath)
+	}
+	return config.LoadSecrets(run.secretsPath, &sops.Options{AgeKeyFile: run.ageKeyFile})
+}
+
+// checkFunc adapts a check returning a note or an error.
+func checkFunc(fn func() (string, error)) func(context.Context) []checks.Result {
+	return func(context.Context) []checks.Result {
+		note, err := fn()
+		if err != nil {
+			return []checks.Result{{Note: note, Err: err}}
+		}
+		return checks.OK(note)
+	}
+}
+
+// validateFunc adapts a validate check.
+func validateFunc(fn func() validateResult) func(context.Context) []checks.Result {
+	return func(context.Context) []checks.Result {
+		return []checks.Result{fn().result()}
+	}
+}
+
+// flagTimeout returns the check timeout for a command timeout flag in
+// seconds, leaving the command room to report its own timeout.
+func flagTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds)*time.Second + 5*time.Second
+}
+
+func statusLabel(status report.Status) string {
+	switch status {
+	case report.StatusFail:
+		return "FAIL"
+	case report.StatusWarn:
+		return "WARN"
+	case report.StatusSkip:
+		return "SKIP"
+	}
+	return "OK"
+}
+
+// printCheckReport prints results under title.
+func printCheckReport(title string, results []checks.Result) {
+	fmt.Println()
+	fmt.Println(title + ":")
+	for _, result := range results {
+		fmt.Printf("  - %s: %s", statusLabel(result.Status), result.Name)
+		if result.Note != "" {
+			fmt.Printf(" (%s)", result.Note)
+		}
+		fmt.Println()
+		if result.Err != nil {
+			printDoctorError(result.Err)
+		}
+		for _, detail := range result.Details {
+			fmt.Printf("      %s\n", detail)
+		}
+		if result.Err == nil && result.Hint != "" {
+			fmt.Printf("      hint: %s\n", result.Hint)
+		}
+	}
+}
+
+// checkReport converts results for machine-readable output.
+func checkReport(command, env string, results []checks.Result) *report.Report {
+	out := make([]report.Result, 0, len(results))
+	for _, res := range results {
+		out = append(out, res.Report())
+	}
+	return report.New(command, env, Version, out)
+}
+
+// checkListEntry is a check as printed by checks list -o json.
+type checkListEntry struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Category    string   `json:"category"`
+	Description string   `json:"description,omitempty"`
+	Commands    []string `json:"commands"`
+	Backends    []string `json:"backends,omitempty"`
+	DependsOn   []string `json:"dependsOn,omitempty"`
+	Timeout     string   `json:"timeout"`
+}
+
+func runChecksList(cmd *cobra.Command, args []string) error {
+	if checksListCommand != "" && !slices.Contains(config.CheckCommands, checksListCommand) {
+		return fmt.Errorf("unknown command %q (use %s)", checksListCommand, strings.Join(config.CheckCommands, ", "))
+	}
+	if checksListOutput != "text" && checksListOutput != "json" {
+		return fmt.Errorf("unsupported output format %q (use text or json)", checksListOutput)
+	}
+	registry, err := checkRegistry(&checkRun{})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]checkListEntry, 0, len(registry.Checks()))
+	for _, c := range registry.Checks() {
+		if checksListCommand != "" && !slices.Contains(c.Commands, checksListCommand) {
+			continue
+		}
+		timeout := c.Timeout
+		if timeout <= 0 {
+			timeout = checks.DefaultTimeout
+		}
+		entries = append(entries, checkListEntry{
+			ID:          c.ID,
+			Name:        c.Name,
+			Category:    c.Category,
+			Description: c.Description,
+			Commands:    c.Commands,
+			Backends:    c.Backends,
+			DependsOn:   c.DependsOn,
+			Timeout:     timeout.String(),
+		})
+	}
+
+	if checksListOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	fmt.Printf("%-20s %-11s %-26s %s\n", "ID", "CATEGORY", "COMMANDS", "DESCRIPTION")
+	for _, e := range entries {
+		fmt.Printf("%-20s %-11s %-26s %s\n", e.ID, e.Category, strings.Join(e.Commands, ","), e.Description)
+		var extra []string
+		if len(e.Backends) > 0 {
+			extra = append(extra, "backends: "+strings.Join(e.Backends, ","))
+		}
+		if len(e.DependsOn) > 0 {
+			extra = append(extra, "depends on: "+strings.Join(e.DependsOn, ","))
+		}
+		if len(extra) > 0 {
+			fmt.Printf("%-20s %s\n", "", strings.Join(extra, "; "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/compat"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+func withCheckBaseDir(t *testing.T, marker string) {
+	t.Helper()
+	prev := baseDir
+	t.Cleanup(func() { baseDir = prev })
+	baseDir = t.TempDir()
+	if marker != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(baseDir, config.RepoConfigFile), []byte(marker), 0644))
+	}
+}
+
+func planIDs(t *testing.T, registry *checks.Registry, command, backend string, sel checks.Selection) []string {
+	t.Helper()
+	plan, err := registry.Plan(command, backend, sel)
+	require.NoError(t, err)
+	var ids []string
+	for _, c := range plan.Checks() {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestBuiltinChecks(t *testing.T) {
+	withCheckBaseDir(t, "")
+	registry, err := checkRegistry(&checkRun{})
+	require.NoError(t, err)
+
+	for _, c := range registry.Checks() {
+		assert.NotEmpty(t, c.Category, c.ID)
+		assert.NotEmpty(t, c.Description, c.ID)
+		assert.NotEmpty(t, c.Commands, c.ID)
+		for _, dep := range c.DependsOn {
+			_, ok := registry.Lookup(dep)
+			assert.True(t, ok, "%s depends on unknown check %s", c.ID, dep)
+		}
+	}
+
+	assert.Equal(t, []string{"kubectl", "kube-context", "auth-plugin", "cluster-access", "helm", "tool-versions", "kube-version", "sops", "age"},
+		planIDs(t, registry, "doctor", "sops", checks.Selection{}))
+	assert.Equal(t, []string{"kube-context", "auth-plugin", "cluster-access", "helm", "tool-versions", "kube-version", "encryption-tools", "rbac"},
+		planIDs(t, registry, "preflight", "git-crypt", checks.Selection{}))
+	assert.Equal(t, []string{"app-path", "kube-context", "auth-plugin", "cluster-access", "helm", "helm-lint", "chart-kube-versions"},
+		planIDs(t, registry, "validate", "sops", checks.Selection{Only: []string{"chart"}}))
+	assert.NotContains(t, planIDs(t, registry, "validate", "git-crypt", checks.Selection{Skip: []string{"repo", "policy"}}), "sops-config")
+	assert.Equal(t, []string{"kube-context", "auth-plugin", "cluster-access", "kube-version", "render-manifests", "node-conditions", "storage-class", "capacity", "crd-conflicts", "namespace-conflicts"},
+		planIDs(t, registry, "preflight-cluster", "", checks.Selection{}))
+}
+
+func TestBuiltinChecks_SkipCluster(t *testing.T) {
+	withCheckBaseDir(t, "")
+	run := &checkRun{command: "doctor", skipCluster: true, kubeconfig: filepath.Join(t.TempDir(), "missing")}
+	registry, err := checkRegistry(run)
+	require.NoError(t, err)
+	plan, err := registry.Plan("doctor", "sops", checks.Selection{Only: []string{checks.CategoryCluster}})
+	require.NoError(t, err)
+
+	results := plan.Run(context.Background(), checks.RunOptions{Parallelism: 1})
+	require.NotEmpty(t, results)
+	for _, res := range results {
+		assert.Equal(t, report.StatusSkip, res.Status, "%s: %v", res.ID, res.Err)
+	}
+}
+
+func TestCheckRegistryCustom(t *testing.T) {
+	withCheckBaseDir(t, `checks:
+  - id: chart-docs
+    category: chart
+    dependsOn: [app-path]
+    exec:
+      command: [sh, -c, "echo docs up to date"]
+`)
+	registry, err := checkRegistry(&checkRun{})
+	require.NoError(t, err)
+	c, ok := registry.Lookup("chart-docs")
+	require.True(t, ok)
+	assert.Equal(t, []string{"validate"}, c.Commands)
+
+	stage := NewLogger(false).Stage("test")
+	results, err := runChecks(&checkRun{command: "validate", encryption: "sops"}, checks.Selection{Only: []string{"chart-docs"}}, stage)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "app-path", results[0].ID)
+	assert.Equal(t, report.StatusFail, results[0].Status)
+	assert.Equal(t, "skipped: app-path failed", results[1].Note)
+}
+
+func TestCheckRegistryCustomErrors(t *testing.T) {
+	withCheckBaseDir(t, "checks:\n  - id: helm\n    exec:\n      command: [helm, version]\n")
+	_, err := checkRegistry(&checkRun{})
+	assert.ErrorContains(t, err, "check helm is registered twice")
+
+	withCheckBaseDir(t, "checks:\n  - id: docs\n    dependsOn: [chart]\n    exec:\n      command: [make]\n")
+	_, err = checkRegistry(&checkRun{})
+	assert.ErrorContains(t, err, "check docs depends on unknown check chart")
+}
+
+func TestValidateResultConversion(t *testing.T) {
+	assert.Equal(t, report.StatusSkip, validateResult{note: "skipped", warn: true}.result().Status)
+	assert.Equal(t, report.StatusWarn, validateResult{note: "non-ssh url", warn: true}.result().Status)
+	assert.Equal(t, report.StatusOK, validateResult{note: "pattern found"}.result().Status)
+}
+
+func TestChartKubeVersionResults(t *testing.T) {
+	withCheckBaseDir(t, "versions:\n  charts:\n    legacy: \">=1.20.0-0 <1.26.0-0\"\n")
+	writeFile := func(rel, content string) {
+		path := filepath.Join(baseDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	writeFile("apps/values.yaml", `components:
+  demo:
+    enabled: true
+  legacy:
+    enabled: true
+  disabled:
+    enabled: false
+`)
+	writeFile("apps/values/dev.yaml", "environment: dev\n")
+	writeFile("components/demo/Chart.yaml", "apiVersion: v2\nname: demo\nversion: 1.0.0\nkubeVersion: \">=1.29.0-0\"\n")
+	writeFile("components/legacy/Chart.yaml", "apiVersion: v2\nname: legacy\nversion: 0.1.0\n")
+
+	versions, err := config.LoadVersionConstraints(baseDir)
+	require.NoError(t, err)
+	run := &checkRun{env: "dev", skipCluster: true, kubeVersion: "v1.30.1", matrix: compat.NewMatrix(versions)}
+
+	results := chartKubeVersionResults(run)
+	require.Len(t, results, 1)
+	assert.Equal(t, "chart-kube-versions/legacy", results[0].ID)
+	assert.ErrorContains(t, results[0].Err, "legacy 0.1.0 requires Kubernetes >=1.20.0-0 <1.26.0-0 (versions.charts.legacy)")
+	assert.Equal(t, "components/legacy/Chart.yaml", results[0].Locations[0].File)
+
+	run.kubeVersion = "v1.28.0"
+	results = chartKubeVersionResults(run)
+	require.Len(t, results, 2)
+	assert.Equal(t, "chart kubeVersion demo [demo]", results[0].Name)
+
+	run.kubeVersion = ""
+	assert.Equal(t, report.StatusSkip, chartKubeVersionResults(run)[0].Status)
+}
@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
+)
+
+var (
+	componentsEnv string
+
+	componentsAddChart       string
+	componentsAddVersion     string
+	componentsAddNamespace   string
+	componentsAddWave        string
+	componentsAddDescription string
+	componentsAddSkipVerify  bool
+)
+
+var componentsCmd = &cobra.Command{
+	Use:   "components",
+	Short: "Manage the App of Apps component catalog",
+	Long: `Inspect and edit the components deployed by the App of Apps chart.
+
+Components are declared in apps/values.yaml and can be overridden per
+environment in apps/values/<env>.yaml.`,
+}
+
+var componentsListCmd = &cobra.Command{
+	Use:   "list <environment>",
+	Short: "List components with their effective settings for an environment",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runComponentsList,
+}
+
+var componentsEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable a component in an environment",
+	Long: `Set components.<name>.enabled to true in apps/values/<env>.yaml.
+Comments and formatting in the file are preserved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runComponentsSetEnabled(args[0], true)
+	},
+}
+
+var componentsDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable a component in an environment",
+	Long: `Set components.<name>.enabled to false in apps/values/<env>.yaml.
+Comments and formatting in the file are preserved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runComponentsSetEnabled(args[0], false)
+	},
+}
+
+var componentsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a component's chart dependencies and value files",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runComponentsShow,
+}
+
+var componentsAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Scaffold a new component",
+	Long: `Create components/<name> as a wrapper chart around an upstream Helm chart
+and register it in apps/values.yaml.
+
+The generated chart contains:
+  - Chart.yaml with the upstream chart as its only dependency
+  - values/base.yaml and one values file per environment in apps/values/
+
+--chart takes <repo>/<chart>, where <repo> is a repository added with
+'helm repo add', or a full repository URL followed by /<chart>. The chart
+and version are looked up in the repository index before anything is
+written; --version may be a semver constraint, which is pinned to the
+newest matching release.
+
+Example:
+  cluster-bootstrap components add cert-manager --chart jetstack/cert-manager --version 1.16.2 --wave 2
+  cluster-bootstrap components add podinfo --chart https://stefanprodan.github.io/podinfo/podinfo --version "^6"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runComponentsAdd,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{componentsEnableCmd, componentsDisableCmd} {
+		c.Flags().StringVar(&componentsEnv, "env", "", "environment to edit (required)")
+		_ = c.MarkFlagRequired("env") //#nosec G104 -- error only occurs if flag doesn't exist, which is impossible here
+	}
+	componentsShowCmd.Flags().StringVar(&componentsEnv, "env", "", "environment whose overrides are applied (default: apps/values.yaml only)")
+
+	componentsAddCmd.Flags().StringVar(&componentsAddChart, "chart", "", "upstream chart as <repo>/<chart> (required)")
+	componentsAddCmd.Flags().StringVar(&componentsAddVersion, "version", "", "chart version or semver constraint (default: latest)")
+	componentsAddCmd.Flags().StringVar(&componentsAddNamespace, "namespace", "", "namespace to deploy into (default: component name)")
+	componentsAddCmd.Flags().StringVar(&componentsAddWave, "wave", "3", "ArgoCD sync wave")
+	componentsAddCmd.Flags().StringVar(&componentsAddDescription, "description", "", "Chart.yaml description")
+	componentsAddCmd.Flags().BoolVar(&componentsAddSkipVerify, "skip-verify", false, "do not look the chart up in its repository (requires an exact --version)")
+	_ = componentsAddCmd.MarkFlagRequired("chart") //#nosec G104 -- error only occurs if flag doesn't exist, which is impossible here
+
+	componentsCmd.AddCommand(componentsListCmd)
+	componentsCmd.AddCommand(componentsEnableCmd)
+	componentsCmd.AddCommand(componentsDisableCmd)
+	componentsCmd.AddCommand(componentsShowCmd)
+	componentsCmd.AddCommand(componentsAddCmd)
+	rootCmd.AddCommand(componentsCmd)
+}
+
+func runComponentsList(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	catalog, err := components.Load(baseDir, env)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Components for %s:\n\n", env)
+	fmt.Printf("  %-3s %-28s %-5s %-18s %s\n", "", "NAME", "WAVE", "NAMESPACE", "OPTIONS")
+	for _, c := range catalog.Components {
+		icon := "✓"
+		if !c.Enabled {
+			icon = "○"
+		}
+		fmt.Printf("  %-3s %-28s %-5s %-18s %s\n", icon, c.Name, c.SyncWave, c.Namespace, componentOptions(c))
+	}
+
+	fmt.Printf("\n%d of %d components enabled\n", len(catalog.Enabled()), len(catalog.Components))
+	return nil
+}
+
+// componentOptions summarises the non-default settings of a component.
+func componentOptions(c components.Component) string {
+	var opts []string
+	if !c.HasValues {
+		opts = append(opts, "no-values")
+	}
+	if !c.CreateNamespace {
+		opts = append(opts, "no-create-namespace")
+	}
+	opts = append(opts, c.SyncOptions...)
+	if len(c.IgnoreDifferences) > 0 {
+		opts = append(opts, fmt.Sprintf("ignoreDifferences(%d)", len(c.IgnoreDifferences)))
+	}
+	return strings.Join(opts, ", ")
+}
+
+func runComponentsSetEnabled(name string, enabled bool) error {
+	action := "enabled"
+	if !enabled {
+		action = "disabled"
+	}
+
+	changed, err := components.SetEnabled(baseDir, componentsEnv, name, enabled)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		successf("%s is already %s in %s", name, action, componentsEnv)
+		return nil
+	}
+
+	successf("%s %s in %s", name, action, components.EnvValuesFile(baseDir, componentsEnv))
+	fmt.Println("\nNext steps:")
+	fmt.Println("  1. Review and commit the change")
+	fmt.Println("  2. ArgoCD will sync the App of Apps and reconcile the Application")
+	return nil
+}
+
+func runComponentsAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if err := components.ValidateName(name); err != nil {
+		return err
+	}
+	if _, err := strconv.Atoi(componentsAddWave); err != nil {
+		return fmt.Errorf("invalid --wave %q: must be an integer", componentsAddWave)
+	}
+
+	settings := cli.New()
+	repoURL, chartName, err := helm.ResolveChartReference(settings, componentsAddChart)
+	if err != nil {
+		return err
+	}
+
+	version := componentsAddVersion
+	if componentsAddSkipVerify {
+		if version == "" {
+			return fmt.Errorf("--version is required with --skip-verify")
+		}
+		warnf("Skipping chart verification for %s/%s %s", repoURL, chartName, version)
+	} else {
+		stepf("Resolving %s from %s...", chartName, repoURL)
+		cv, err := helm.ResolveChartVersion(settings, repoURL, chartName, version)
+		if err != nil {
+			return err
+		}
+		if version != "" && version != cv.Version {
+			successf("Resolved %s %s to %s", chartName, version, cv.Version)
+		} else {
+			successf("Found %s %s", chartName, cv.Version)
+		}
+		version = cv.Version
+	}
+
+	files, err := components.Scaffold(baseDir, components.ScaffoldOptions{
+		Name:        name,
+		Description: componentsAddDescription,
+		Chart:       components.Dependency{Name: chartName, Version: version, Repository: repoURL},
+		Namespace:   componentsAddNamespace,
+		SyncWave:    componentsAddWave,
+	})
+	if err != nil {
+		return err
+	}
+
+	successf("Scaffolded component %s", name)
+	for _, f := range files {
+		fmt.Printf("  • %s\n", f)
+	}
+
+	fmt.Println("\nNext steps:")
+	fmt.Printf("  1. Configure the chart under %q in components/%s/values/*.yaml\n", chartName, name)
+	fmt.Printf("  2. Review with: cluster-bootstrap components show %s\n", name)
+	fmt.Println("  3. Commit and push; ArgoCD will create the Application")
+	return nil
+}
+
+func runComponentsShow(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	catalog, err := components.Load(baseDir, componentsEnv)
+	if err != nil {
+		return err
+	}
+	comp, err := catalog.Lookup(name)
+	if err != nil {
+		return err
+	}
+	info, err := components.LoadChartInfo(baseDir, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Component: %s\n", comp.Name)
+	if componentsEnv != "" {
+		fmt.Printf("Environment: %s\n", componentsEnv)
+	}
+	fmt.Printf("Enabled:   %t\n", comp.Enabled)
+	fmt.Printf("Namespace: %s\n", comp.Namespace)
+	fmt.Printf("Sync wave: %s\n", comp.SyncWave)
+	if opts := componentOptions(*comp); opts != "" {
+		fmt.Printf("Options:   %s\n", opts)
+	}
+
+	fmt.Println()
+	fmt.Printf("Chart: %s %s (%s)\n", info.Name, info.Version, info.Dir)
+	if info.Description != "" {
+		fmt.Printf("  %s\n", info.Description)
+	}
+
+	fmt.Println("\nDependencies:")
+	if len(info.Dependencies) == 0 {
+		fmt.Println("  none")
+	}
+	for _, dep := range info.Dependencies {
+		fmt.Printf("  • %s %s (%s)\n", dep.Name, dep.Version, dep.Repository)
+	}
+
+	fmt.Println("\nValue files:")
+	switch {
+	case !comp.HasValues:
+		fmt.Println("  not used (hasValues: false)")
+	case componentsEnv != "":
+		// ArgoCD passes exactly these two files to Helm.
+		for _, f := range []string{"values/base.yaml", fmt.Sprintf("values/%s.yaml", componentsEnv)} {
+			if slices.Contains(info.ValueFiles, f) {
+				fmt.Printf("  • %s\n", f)
+			} else {
+				fmt.Printf("  %s %s (missing)\n", warningColor("✗"), f)
+			}
+		}
+	case len(info.ValueFiles) == 0:
+		fmt.Println("  none")
+	default:
+		for _, f := range info.ValueFiles {
+			fmt.Printf("  • %s\n", f)
+		}
+	}
+
+	if len(info.Templates) > 0 {
+		fmt.Println("\nTemplates:")
+		for _, f := range info.Templates {
+			fmt.Printf("  • %s\n", f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/envdiff"
+)
+
+var (
+	diffEnvOutput      string
+	diffEnvComponents  []string
+	diffEnvSkipRender  bool
+	diffEnvKubeVersion string
+	diffEnvExitCode    bool
+)
+
+var diffEnvCmd = &cobra.Command{
+	Use:   "diff-env <from> <to>",
+	Short: "Show how two environments differ",
+	Long: `Compare two environments of the GitOps tree.
+
+Three layers are compared:
+  - the App of Apps: the components map and repo section of
+    apps/values.yaml merged with apps/values/<env>.yaml
+  - per component: values/base.yaml merged with values/<env>.yaml, the
+    environment file taking precedence as in the Application's valueFiles
+  - per component: the rendered manifests, resource by resource
+
+Rendering uses the Helm SDK, as the render command does; use --skip-render
+to compare values only.
+
+Example:
+  cluster-bootstrap diff-env staging prod
+  cluster-bootstrap diff-env staging prod --component vault --skip-render
+  cluster-bootstrap diff-env staging prod --output json > diff.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiffEnv,
+}
+
+func init() {
+	diffEnvCmd.Flags().StringVarP(&diffEnvOutput, "output", "o", "text", "output format: text or json")
+	diffEnvCmd.Flags().StringSliceVar(&diffEnvComponents, "component", nil, "compare only these components (repeatable)")
+	diffEnvCmd.Flags().BoolVar(&diffEnvSkipRender, "skip-render", false, "compare values only, without rendering manifests")
+	diffEnvCmd.Flags().StringVar(&diffEnvKubeVersion, "kube-version", "", "Kubernetes version to render for (default: Helm's built-in version)")
+	diffEnvCmd.Flags().BoolVar(&diffEnvExitCode, "exit-code", false, "exit with status 1 when the environments differ")
+
+	rootCmd.AddCommand(diffEnvCmd)
+}
+
+func runDiffEnv(cmd *cobra.Command, args []string) error {
+	from, to := args[0], args[1]
+	if diffEnvOutput != "text" && diffEnvOutput != "json" {
+		return fmt.Errorf("unsupported output format: %s (use text or json)", diffEnvOutput)
+	}
+	if diffEnvOutput == "text" {
+		stepf("Comparing %s with %s...", from, to)
+	}
+
+	report, err := envdiff.Compare(envdiff.Options{
+		BaseDir:     baseDir,
+		From:        from,
+		To:          to,
+		Only:        diffEnvComponents,
+		Render:      !diffEnvSkipRender,
+		KubeVersion: diffEnvKubeVersion,
+		Verbose:     verbose && diffEnvOutput == "text",
+	})
+	if err != nil {
+		return err
+	}
+
+	if diffEnvOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+	} else {
+		printEnvDiff(report)
+	}
+
+	if diffEnvExitCode && envDiffCount(report) > 0 {
+		return fmt.Errorf("%s and %s differ", from, to)
+	}
+	return nil
+}
+
+func printEnvDiff(report *envdiff.Report) {
+	fmt.Println()
+	fmt.Println(stepColor("App of Apps (apps/values.yaml)"))
+	printValueChanges(report.Apps)
+
+	identical := 0
+	for _, comp := range report.Components {
+		if comp.Empty() {
+			identical++
+			continue
+		}
+		fmt.Println()
+		title := comp.Name
+		if len(comp.EnabledIn) == 1 {
+			title += fmt.Sprintf(" (only enabled in %s)", comp.EnabledIn[0])
+		}
+		fmt.Println(stepColor(title))
+		if len(comp.Values) > 0 {
+			fmt.Println("  values:")
+			printValueChanges(comp.Values)
+		}
+		if len(comp.Resources) > 0 {
+			fmt.Println("  resources:")
+			for _, res := range comp.Resources {
+				switch res.Kind {
+				case envdiff.Added:
+					fmt.Printf("    %s\n", diffAddColor("+ "+res.ID))
+				case envdiff.Removed:
+					fmt.Printf("    %s\n", diffRemoveColor("- "+res.ID))
+				case envdiff.Changed:
+					fmt.Printf("    %s\n", warningColor("~ "+res.ID))
+					printUnifiedDiff(res.Diff)
+				}
+			}
+		}
+	}
+
+	fmt.Println()
+	if !report.Rendered {
+		warnf("Manifests not compared (--skip-render)")
+	}
+	if n := envDiffCount(report); n == 0 {
+		successf("%s and %s are identical", report.From, report.To)
+	} else {
+		fmt.Printf("%d difference(s) between %s (-) and %s (+); %d component(s) identical\n", n, report.From, report.To, identical)
+	}
+}
+
+func printValueChanges(changes []envdiff.Change) {
+	if len(changes) == 0 {
+		fmt.Println("    no differences")
+		return
+	}
+	for _, c := range changes {
+		switch c.Kind {
+		case envdiff.Added:
+			fmt.Printf("    %s\n", diffAddColor(fmt.Sprintf("+ %s: %s", c.Path, formatDiffValue(c.To))))
+		case envdiff.Removed:
+			fmt.Printf("    %s\n", diffRemoveColor(fmt.Sprintf("- %s: %s", c.Path, formatDiffValue(c.From))))
+		case envdiff.Changed:
+			fmt.Printf("    %s %s → %s\n", warningColor("~ "+c.Path+":"), formatDiffValue(c.From), formatDiffValue(c.To))
+		}
+	}
+}
+
+// formatDiffValue renders a value compactly as JSON, so strings are quoted
+// and lists or maps stay on one line.
+func formatDiffValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	s := string(data)
+	if len(s) > 120 {
+		s = s[:117] + "..."
+	}
+	return strings.TrimSpace(s)
+}
+
+func envDiffCount(report *envdiff.Report) int {
+	n := len(report.Apps)
+	for _, comp := range report.Components {
+		n += len(comp.Values) + len(comp.Resources)
+		if len(comp.EnabledIn) == 1 && len(comp.Values) == 0 && len(comp.Resources) == 0 {
+			n++
+		}
+	}
+	return n
+}
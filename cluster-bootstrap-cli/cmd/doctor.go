@@ -2,17 +2,14 @@ package cmd
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
-)
 
-type doctorResult struct {
-	name string
-	note string
-	err  error
-}
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
 
 var (
 	doctorEncryption       string
@@ -20,6 +17,9 @@ var (
 	doctorKubeconfig       string
 	doctorContext          string
 	doctorSkipClusterCheck bool
+	doctorOutput           string
+	doctorOnly             []string
+	doctorSkip             []string
 )
 
 var doctorCmd = &cobra.Command{
@@ -27,9 +27,17 @@ var doctorCmd = &cobra.Command{
 	Short: "Check local and cluster prerequisites",
 	Long: `Run prerequisite checks for cluster bootstrap.
 
-This validates local tooling (kubectl, helm, encryption tools) and optionally
-checks cluster access with the configured kubeconfig/context.`,
-	RunE: runDoctor,
+This validates local tooling (helm, encryption tools, and kubectl if
+installed) and optionally checks the kubeconfig, its credential plugin and
+cluster access with the configured kubeconfig/context. --only and
+--skip select checks by ID or category; see 'cluster-bootstrap checks list'.
+
+With --output json, junit, sarif or github the report is written to stdout
+in that format, each check carrying a stable ID. The exit status is 1 when a
+check failed.`,
+	RunE:          runDoctor,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 func init() {
@@ -37,93 +45,61 @@ func init() {
 	doctorCmd.Flags().StringVar(&doctorAgeKeyFile, "age-key-file", "", "path to age private key file for SOPS decryption")
 	doctorCmd.Flags().StringVar(&doctorKubeconfig, "kubeconfig", "", "path to kubeconfig file")
 	doctorCmd.Flags().StringVar(&doctorContext, "context", "", "kubeconfig context to use")
-	doctorCmd.Flags().BoolVar(&doctorSkipClusterCheck, "skip-cluster-check", false, "skip kubectl cluster access checks")
+	doctorCmd.Flags().BoolVar(&doctorSkipClusterCheck, "skip-cluster-check", false, "skip the kubeconfig and cluster access checks")
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "text", "output format: text, json, junit, sarif or github")
+	doctorCmd.Flags().StringSliceVar(&doctorOnly, "only", nil, "only run the checks with these IDs or categories, and the checks they depend on")
+	doctorCmd.Flags().StringSliceVar(&doctorSkip, "skip", nil, "skip the checks with these IDs or categories")
 
 	rootCmd.AddCommand(doctorCmd)
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
-	logger := NewLogger(verbose)
+	format, err := report.ParseFormat(doctorOutput)
+	if err != nil {
+		return err
+	}
+	logger := NewLogger(verbose && format == report.FormatText)
 	stage := logger.Stage("Doctor Checks")
 
 	if doctorEncryption != "sops" && doctorEncryption != "git-crypt" {
 		return fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", doctorEncryption)
 	}
 
-	results := make([]doctorResult, 0, 8)
-
-	results = append(results, runDoctorCheck(stage, "kubectl available", func() (string, error) {
-		return "", CheckKubectlAvailable(true)
-	}))
-
-	results = append(results, runDoctorCheck(stage, "kubectl current context", func() (string, error) {
-		return getKubectlCurrentContext(doctorKubeconfig)
-	}))
-
-	if !doctorSkipClusterCheck {
-		results = append(results, runDoctorCheck(stage, "kubectl cluster access", func() (string, error) {
-			return "", CheckKubectlClusterAccessWithConfig(doctorKubeconfig, doctorContext)
-		}))
+	run := &checkRun{
+		command:     "doctor",
+		encryption:  doctorEncryption,
+		ageKeyFile:  doctorAgeKeyFile,
+		kubeconfig:  doctorKubeconfig,
+		kubeContext: doctorContext,
+		skipCluster: doctorSkipClusterCheck,
 	}
-
-	results = append(results, runDoctorCheck(stage, "helm available", func() (string, error) {
-		return "", CheckHelm()
-	}))
-
-	if doctorEncryption == "sops" {
-		results = append(results, runDoctorCheck(stage, "sops available", func() (string, error) {
-			return "", CheckSOPS("sops")
-		}))
-		results = append(results, runDoctorCheck(stage, "age available", func() (string, error) {
-			return "", CheckAge("sops", doctorAgeKeyFile)
-		}))
-	}
-
-	if doctorEncryption == "git-crypt" {
-		results = append(results, runDoctorCheck(stage, "git-crypt available", func() (string, error) {
-			return "", CheckGitCrypt("git-crypt")
-		}))
+	results, err := runChecks(run, checks.Selection{Only: doctorOnly, Skip: doctorSkip}, stage)
+	if err != nil {
+		return err
 	}
 
 	stage.Done()
 
-	fmt.Println()
-	fmt.Println("Doctor report:")
-	failures := 0
-	for _, result := range results {
-		status := "OK"
-		if result.err != nil {
-			status = "FAIL"
-			failures++
+	rep := checkReport("doctor", "", results)
+	if format != report.FormatText {
+		if err := report.Write(os.Stdout, format, rep); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
 		}
-		fmt.Printf("  - %s: %s", status, result.name)
-		if result.note != "" {
-			fmt.Printf(" (%s)", result.note)
-		}
-		fmt.Println()
-		if result.err != nil {
-			printDoctorError(result.err)
+		if rep.Summary.Fail > 0 {
+			return &exitError{code: report.ExitFailure, err: fmt.Errorf("doctor found %d issue(s)", rep.Summary.Fail)}
 		}
+		return nil
 	}
 
-	if failures > 0 {
-		return fmt.Errorf("doctor found %d issue(s)", failures)
+	printCheckReport("Doctor report", results)
+	if rep.Summary.Fail > 0 {
+		return fmt.Errorf("doctor found %d issue(s)", rep.Summary.Fail)
 	}
 
 	successf("All checks passed")
 	return nil
 }
 
-func runDoctorCheck(stage *StageLogger, name string, fn func() (string, error)) doctorResult {
-	note, err := fn()
-	if err != nil {
-		stage.Detail("FAIL: %s", name)
-		return doctorResult{name: name, note: note, err: err}
-	}
-	stage.Detail("OK: %s", name)
-	return doctorResult{name: name, note: note, err: nil}
-}
-
 func printDoctorError(err error) {
 	lines := strings.Split(err.Error(), "\n")
 	for _, line := range lines {
@@ -134,29 +110,3 @@ func printDoctorError(err error) {
 		fmt.Printf("      %s\n", line)
 	}
 }
-
-func getKubectlCurrentContext(kubeconfig string) (string, error) {
-	path, err := exec.LookPath("kubectl")
-	if err != nil {
-		return "", fmt.Errorf("kubectl not found in PATH: %w", err)
-	}
-
-	args := make([]string, 0, 4)
-	if kubeconfig != "" {
-		args = append(args, "--kubeconfig", kubeconfig)
-	}
-	args = append(args, "config", "current-context")
-
-	cmd := exec.Command(path, args...) // #nosec G204
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to read current context: %w\n  output: %s", err, string(output))
-	}
-
-	context := strings.TrimSpace(string(output))
-	if context == "" {
-		return "", fmt.Errorf("no current context set\n  hint: set a kubectl context or pass --context to bootstrap")
-	}
-
-	return context, nil
-}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/drift"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+var (
+	driftKubeconfig  string
+	driftContext     string
+	driftEncryption  string
+	driftSecretsFile string
+	driftAgeKeyFile  string
+	driftSkipSecrets bool
+	driftKubeVersion string
+	driftTimeout     int
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift <environment>",
+	Short: "Compare the expected ArgoCD Applications with the live cluster",
+	Long: `Detect drift between the repository and the ArgoCD Applications in the cluster.
+
+The apps chart is rendered for the environment to get the Applications
+ArgoCD should have. They are compared with the live Applications in the
+argocd namespace:
+  - missing:       enabled in apps/values.yaml but not in the cluster
+  - unexpected:    in the cluster but no longer rendered (orphaned)
+  - changed:       different repoURL, path, targetRevision, namespace or sync wave
+  - repo-mismatch: repoURL differs from repo.url in the secrets file
+
+The command exits non-zero when drift is found, so it can gate CI.
+
+Example:
+  cluster-bootstrap drift dev
+  cluster-bootstrap drift prod --context prod-cluster --skip-secrets`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDrift,
+}
+
+func init() {
+	driftCmd.Flags().StringVar(&driftKubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	driftCmd.Flags().StringVar(&driftContext, "context", "", "kubeconfig context to use")
+	driftCmd.Flags().StringVar(&driftEncryption, "encryption", "sops", "encryption backend (sops|git-crypt)")
+	driftCmd.Flags().StringVar(&driftSecretsFile, "secrets-file", "", "path to secrets file (default: secrets.<env>.enc.yaml or secrets.<env>.yaml)")
+	driftCmd.Flags().StringVar(&driftAgeKeyFile, "age-key-file", "", "path to age private key file for SOPS decryption")
+	driftCmd.Flags().BoolVar(&driftSkipSecrets, "skip-secrets", false, "skip comparing repoURL with the secrets file")
+	driftCmd.Flags().StringVar(&driftKubeVersion, "kube-version", "", "Kubernetes version to render the apps chart for")
+	driftCmd.Flags().IntVar(&driftTimeout, "timeout", 30, "timeout in seconds for cluster requests")
+
+	rootCmd.AddCommand(driftCmd)
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	if err := resolveEncryption(cmd, env, &driftEncryption); err != nil {
+		return err
+	}
+
+	stepf("Rendering apps chart for %s...", env)
+	result, err := render.Applications(render.Options{
+		BaseDir:     baseDir,
+		Env:         env,
+		KubeVersion: driftKubeVersion,
+		Verbose:     verbose,
+	})
+	if err != nil {
+		return err
+	}
+
+	secretsRepoURL := ""
+	if !driftSkipSecrets {
+		secretsRepoURL, err = driftSecretsRepoURL(env)
+		if err != nil {
+			return err
+		}
+	}
+
+	stepf("Listing live Applications...")
+	client, err := k8s.NewClient(driftKubeconfig, driftContext)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(driftTimeout)*time.Second)
+	defer cancel()
+	items, err := client.ListApplications(ctx)
+	if err != nil {
+		return err
+	}
+	live := make([]render.Application, 0, len(items))
+	for _, item := range items {
+		live = append(live, render.ApplicationFromObject(render.Object{Name: item.GetName(), Content: item.Object}))
+	}
+
+	diffs := drift.Compare(result.Applications, live, secretsRepoURL)
+	fmt.Println()
+	if len(diffs) == 0 {
+		successf("No drift: %d Application(s) match the repository", len(result.Applications))
+		return nil
+	}
+
+	fmt.Printf("  %-26s %-14s %-15s %-40s %s\n", "APPLICATION", "DRIFT", "FIELD", "EXPECTED", "LIVE")
+	for _, d := range diffs {
+		kind := fmt.Sprintf("%-14s", d.Kind)
+		switch d.Kind {
+		case drift.Missing, drift.RepoMismatch:
+			kind = errorColor(kind)
+		default:
+			kind = warningColor(kind)
+		}
+		fmt.Printf("  %-26s %s %-15s %-40s %s\n", d.Application, kind, dash(d.Field), dash(d.Expected), dash(d.Live))
+	}
+	fmt.Println()
+	return fmt.Errorf("drift detected in %s: %d difference(s)\n  hint: sync the app-of-apps Application in ArgoCD, or update apps/values.yaml to match the cluster", env, len(diffs))
+}
+
+// driftSecretsRepoURL returns repo.url from the environment's secrets file.
+// A missing file skips the check with a warning.
+func driftSecretsRepoURL(env string) (string, error) {
+	path := driftSecretsFile
+	var secrets *config.EnvironmentSecrets
+	var err error
+	switch driftEncryption {
+	case "sops":
+		if path == "" {
+			path = filepath.Join(baseDir, config.SecretsFileName(env))
+		}
+		if _, statErr := os.Stat(path); statErr != nil {
+			warnf("Secrets file %s not found; skipping repoURL check", path)
+			return "", nil
+		}
+		secrets, err = config.LoadSecrets(path, &sops.Options{AgeKeyFile: driftAgeKeyFile})
+	case "git-crypt":
+		if path == "" {
+			path = filepath.Join(baseDir, config.SecretsFileNamePlain(env))
+		}
+		if _, statErr := os.Stat(path); statErr != nil {
+			warnf("Secrets file %s not found; skipping repoURL check", path)
+			return "", nil
+		}
+		secrets, err = config.LoadSecretsPlaintext(path)
+	default:
+		return "", fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", driftEncryption)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w\n  hint: use --skip-secrets to compare without the secrets file", err)
+	}
+	return secrets.Repo.URL, nil
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -5,10 +5,18 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/restmapper"
 )
 
 // HealthCheckResult holds the result of a health check
@@ -16,7 +24,9 @@ type HealthCheckResult struct {
 	Component string
 	Status    string
 	Message   string
-	Duration  time.Duration
+	// Duration is the time from the start of the health checks until the
+	// component was ready, or until its check gave up.
+	Duration time.Duration
 }
 
 // HealthStatus represents overall health
@@ -29,65 +39,45 @@ type HealthStatus struct {
 	Environment string
 }
 
-// WaitForHealth waits for critical components to be ready after bootstrap.
-// Timeout is in seconds. Returns detailed health status and any errors.
+// healthClients are the clients a health check reads the cluster with.
+type healthClients struct {
+	kube    kubernetes.Interface
+	dynamic dynamic.Interface
+	mapper  meta.RESTMapper
+}
+
+// WaitForHealth waits for the enabled components of environment to be
+// healthy, as the health sections of the App of Apps values describe them.
+// The components are checked concurrently and their state changes printed
+// as they happen. Timeout is in seconds and bounds the whole wait; a
+// component's health timeout bounds its own. Returns detailed health status
+// and any errors.
 func WaitForHealth(ctx context.Context, kubeconfig, kubeContext, environment string, timeoutSecs int) (*HealthStatus, error) {
 	status := &HealthStatus{
 		StartTime:   time.Now(),
 		Environment: environment,
 	}
 
-	// Build config using standard kubectl loading rules
-	loadingRules := &clientcmd.ClientConfigLoadingRules{
-		ExplicitPath: kubeconfig,
-	}
-	if kubeconfig == "" {
-		// Use default kubeconfig locations (~/.kube/config, KUBECONFIG env, etc)
-		loadingRules = clientcmd.NewDefaultClientConfigLoadingRules()
-	}
-
-	// Load the kubeconfig
-	kubeCfg, err := loadingRules.Load()
+	catalog, err := components.Load(baseDir, environment)
 	if err != nil {
-		return status, fmt.Errorf("failed to load kubeconfig: %w", err)
+		return status, err
 	}
-
-	// Create a context override if kubeContext is specified
-	configOverrides := &clientcmd.ConfigOverrides{}
-	if kubeContext != "" {
-		configOverrides.CurrentContext = kubeContext
-	}
-
-	// Create the client config
-	clientConfig := clientcmd.NewNonInteractiveClientConfig(*kubeCfg, kubeContext, configOverrides, loadingRules)
-	config, err := clientConfig.ClientConfig()
+	client, err := k8s.NewClient(kubeconfig, kubeContext)
 	if err != nil {
-		return status, fmt.Errorf("failed to build kubeconfig: %w", err)
+		return status, err
 	}
-
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return status, fmt.Errorf("failed to create kubernetes client: %w", err)
+	clients := healthClients{
+		kube:    client.Clientset,
+		dynamic: client.DynamicClient,
+		mapper:  restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client.Clientset.Discovery())),
 	}
 
 	timeout := time.Duration(timeoutSecs) * time.Second
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	// Check ArgoCD
-	result := checkArgoCDReady(ctx, clientset)
-	status.Results = append(status.Results, result)
-	if result.Status != "Ready" {
-		status.Healthy = false
-	}
-
-	// Check Vault (if installed)
-	result = checkVaultReady(ctx, clientset)
-	status.Results = append(status.Results, result)
-
-	// Check External Secrets (if installed)
-	result = checkExternalSecretsReady(ctx, clientset)
-	status.Results = append(status.Results, result)
+	engine := &healthEngine{clients: clients, onEvent: printHealthEvent}
+	status.Results = engine.run(ctx, catalog.Enabled())
 
 	// Determine overall health
 	status.Healthy = true
@@ -104,133 +94,122 @@ func WaitForHealth(ctx context.Context, kubeconfig, kubeContext, environment str
 	return status, nil
 }
 
-// checkArgoCDReady verifies ArgoCD deployment is ready
-func checkArgoCDReady(ctx context.Context, clientset kubernetes.Interface) HealthCheckResult {
-	start := time.Now()
-	result := HealthCheckResult{
-		Component: "ArgoCD",
+// discoverWorkloads returns the workloads labelled with the component's
+// instance label in its namespace, for components without health workloads.
+func discoverWorkloads(ctx context.Context, clientset kubernetes.Interface, comp components.Component) ([]components.HealthWorkload, error) {
+	opts := metav1.ListOptions{LabelSelector: components.InstanceLabel + "=" + comp.Name}
+	var out []components.HealthWorkload
+	add := func(kind, name string) {
+		out = append(out, components.HealthWorkload{Kind: kind, Name: name, Namespace: comp.Namespace})
 	}
 
-	// Wait for argocd-server deployment to be ready
-	err := waitForDeployment(ctx, clientset, "argocd", "argocd-server")
+	deployments, err := clientset.AppsV1().Deployments(comp.Namespace).List(ctx, opts)
 	if err != nil {
-		result.Status = "Timeout"
-		result.Message = fmt.Sprintf("argocd-server not ready: %v", err)
-	} else {
-		result.Status = "Ready"
-		result.Message = "argocd-server deployment is running"
+		return nil, fmt.Errorf("failed to list deployments in %s: %w", comp.Namespace, err)
 	}
-
-	result.Duration = time.Since(start)
-	return result
-}
-
-// checkVaultReady verifies Vault statefulset is ready
-func checkVaultReady(ctx context.Context, clientset kubernetes.Interface) HealthCheckResult {
-	start := time.Now()
-	result := HealthCheckResult{
-		Component: "Vault",
+	for _, d := range deployments.Items {
+		add("Deployment", d.Name)
 	}
-
-	// Check if vault namespace exists
-	_, err := clientset.CoreV1().Namespaces().Get(ctx, "vault", metav1.GetOptions{})
+	statefulSets, err := clientset.AppsV1().StatefulSets(comp.Namespace).List(ctx, opts)
 	if err != nil {
-		result.Status = "NotInstalled"
-		result.Message = "Vault namespace not found"
-		result.Duration = time.Since(start)
-		return result
+		return nil, fmt.Errorf("failed to list statefulsets in %s: %w", comp.Namespace, err)
 	}
-
-	// Wait for vault statefulset
-	err = waitForStatefulSet(ctx, clientset, "vault", "vault")
+	for _, s := range statefulSets.Items {
+		add("StatefulSet", s.Name)
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets(comp.Namespace).List(ctx, opts)
 	if err != nil {
-		result.Status = "Timeout"
-		result.Message = fmt.Sprintf("vault statefulset not ready: %v", err)
-	} else {
-		result.Status = "Ready"
-		result.Message = "vault statefulset is running"
+		return nil, fmt.Errorf("failed to list daemonsets in %s: %w", comp.Namespace, err)
+	}
+	for _, d := range daemonSets.Items {
+		add("DaemonSet", d.Name)
 	}
+	return out, nil
+}
 
-	result.Duration = time.Since(start)
-	return result
+// workloadState is the replica count of a workload.
+type workloadState struct {
+	desired int32
+	updated int32
+	ready   int32
+	image   string
 }
 
-// checkExternalSecretsReady verifies External Secrets operator is ready
-func checkExternalSecretsReady(ctx context.Context, clientset kubernetes.Interface) HealthCheckResult {
-	start := time.Now()
-	result := HealthCheckResult{
-		Component: "External Secrets",
-	}
+// isReady reports whether every desired replica is updated and ready.
+func (s workloadState) isReady() bool {
+	return s.updated >= s.desired && s.ready >= s.desired
+}
 
-	// Check if external-secrets namespace exists
-	_, err := clientset.CoreV1().Namespaces().Get(ctx, "external-secrets", metav1.GetOptions{})
-	if err != nil {
-		result.Status = "NotInstalled"
-		result.Message = "External Secrets namespace not found"
-		result.Duration = time.Since(start)
-		return result
+// readWorkload reads the replica counts of w.
+func readWorkload(ctx context.Context, clientset kubernetes.Interface, w components.HealthWorkload) (workloadState, error) {
+	var obj runtime.Object
+	var err error
+	switch w.Kind {
+	case "Deployment":
+		obj, err = clientset.AppsV1().Deployments(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+	case "StatefulSet":
+		obj, err = clientset.AppsV1().StatefulSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+	case "DaemonSet":
+		obj, err = clientset.AppsV1().DaemonSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+	default:
+		return workloadState{}, fmt.Errorf("unsupported workload kind %s", w.Kind)
 	}
-
-	// Wait for external-secrets deployment
-	err = waitForDeployment(ctx, clientset, "external-secrets", "external-secrets")
 	if err != nil {
-		result.Status = "Timeout"
-		result.Message = fmt.Sprintf("external-secrets deployment not ready: %v", err)
-	} else {
-		result.Status = "Ready"
-		result.Message = "external-secrets deployment is running"
+		return workloadState{}, err
 	}
-
-	result.Duration = time.Since(start)
-	return result
+	return workloadStateOf(obj), nil
 }
 
-// waitForDeployment waits for a deployment to have at least 1 ready replica
-func waitForDeployment(ctx context.Context, clientset kubernetes.Interface, namespace, name string) error {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+// workloadStateOf returns the replica counts of a Deployment, StatefulSet
+// or DaemonSet.
+func workloadStateOf(obj runtime.Object) workloadState {
+	var state workloadState
+	var containers []corev1.Container
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		state = workloadState{desired: replicas(o.Spec.Replicas), updated: o.Status.UpdatedReplicas, ready: o.Status.ReadyReplicas}
+		containers = o.Spec.Template.Spec.Containers
+	case *appsv1.StatefulSet:
+		state = workloadState{desired: replicas(o.Spec.Replicas), updated: o.Status.UpdatedReplicas, ready: o.Status.ReadyReplicas}
+		containers = o.Spec.Template.Spec.Containers
+	case *appsv1.DaemonSet:
+		state = workloadState{desired: o.Status.DesiredNumberScheduled, updated: o.Status.UpdatedNumberScheduled, ready: o.Status.NumberReady}
+		containers = o.Spec.Template.Spec.Containers
+	}
+	if len(containers) > 0 {
+		state.image = containers[0].Image
+	}
+	return state
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("timeout waiting for %s/%s", namespace, name)
-		case <-ticker.C:
-			deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				continue
-			}
-			// Check if at least 1 replica is ready
-			if deploy.Status.ReadyReplicas > 0 && deploy.Status.UpdatedReplicas > 0 {
-				if deploy.Status.ReadyReplicas == deploy.Status.Replicas {
-					return nil
-				}
-			}
-		}
+// replicas returns the desired replicas of a Deployment or StatefulSet,
+// which default to one.
+func replicas(n *int32) int32 {
+	if n == nil {
+		return 1
 	}
+	return *n
 }
 
-// waitForStatefulSet waits for a statefulset to have at least 1 ready replica
-func waitForStatefulSet(ctx context.Context, clientset kubernetes.Interface, namespace, name string) error {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return fmt.Errorf("timeout waiting for %s/%s", namespace, name)
-		case <-ticker.C:
-			ss, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				continue
-			}
-			// Check if at least 1 replica is ready
-			if ss.Status.ReadyReplicas > 0 && ss.Status.UpdatedReplicas > 0 {
-				if ss.Status.ReadyReplicas == ss.Status.Replicas {
-					return nil
-				}
-			}
+// falseConditions returns the conditions of want that obj does not report
+// with status True.
+func falseConditions(obj *unstructured.Unstructured, want []string) []string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	status := make(map[string]string, len(conditions))
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok {
+			t, _ := m["type"].(string)
+			s, _ := m["status"].(string)
+			status[t] = s
+		}
+	}
+	var out []string
+	for _, w := range want {
+		if status[w] != "True" {
+			out = append(out, w)
 		}
 	}
+	return out
 }
 
 // CheckArgoCDSync checks if ArgoCD applications are syncing
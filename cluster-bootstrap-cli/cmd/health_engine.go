@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+)
+
+// healthEvent is a state change the health engine reports while it waits,
+// e.g. a Deployment going from 0/2 to 1/2 ready replicas.
+type healthEvent struct {
+	Component string
+	// Object is what changed, e.g. deployment/argocd-server; empty when
+	// the component itself is done.
+	Object  string
+	State   string
+	Elapsed time.Duration
+}
+
+// healthEngine waits for components concurrently. Every workload, CRD and
+// custom resource of a component is watched, so a change is seen as soon as
+// the API server reports it and a slow component does not hold up the
+// others.
+type healthEngine struct {
+	clients healthClients
+	// onEvent is called for every state change, one call at a time.
+	onEvent func(healthEvent)
+
+	mu    sync.Mutex
+	start time.Time
+}
+
+// healthWait waits for one object of a component and returns an error
+// naming its last state when ctx ends first.
+type healthWait func(ctx context.Context) error
+
+// run checks comps concurrently until each is ready or its deadline passes:
+// the component's health timeout, within ctx's deadline. Results are in the
+// order of comps, and their Duration is the time from the start of the run
+// to ready, or to giving up.
+func (e *healthEngine) run(ctx context.Context, comps []components.Component) []HealthCheckResult {
+	e.start = time.Now()
+	results := make([]HealthCheckResult, len(comps))
+	var wg sync.WaitGroup
+	for i, comp := range comps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = e.checkComponent(ctx, comp)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func (e *healthEngine) emit(component, object, state string) {
+	if e.onEvent == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onEvent(healthEvent{Component: component, Object: object, State: state, Elapsed: time.Since(e.start)})
+}
+
+// checkComponent waits for the workloads, CRDs and custom resources of
+// comp's health spec at once. A component without a health spec whose
+// namespace does not exist is not installed; with one, its namespace is
+// waited for like the rest.
+func (e *healthEngine) checkComponent(ctx context.Context, comp components.Component) HealthCheckResult {
+	result := HealthCheckResult{
+		Component: comp.Name,
+	}
+	finish := func(status, format string, args ...interface{}) HealthCheckResult {
+		result.Status = status
+		result.Message = fmt.Sprintf(format, args...)
+		result.Duration = time.Since(e.start)
+		e.emit(comp.Name, "", status)
+		return result
+	}
+
+	spec := comp.HealthSpec()
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+
+	if _, err := e.clients.kube.CoreV1().Namespaces().Get(ctx, comp.Namespace, metav1.GetOptions{}); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return finish("Error", "failed to read namespace %s: %v", comp.Namespace, err)
+		}
+		if comp.Health == nil {
+			return finish("NotInstalled", "namespace %s not found", comp.Namespace)
+		}
+		if err := e.namespaceWait(comp.Name, comp.Namespace)(ctx); err != nil {
+			return finish("Timeout", "%v", err)
+		}
+	}
+
+	workloads := spec.Workloads
+	if workloads == nil {
+		var err error
+		if workloads, err = discoverWorkloads(ctx, e.clients.kube, comp); err != nil {
+			return finish("Error", "%v", err)
+		}
+	}
+
+	var waits []healthWait
+	for _, w := range workloads {
+		waits = append(waits, e.workloadWait(comp.Name, w))
+	}
+	for _, crd := range spec.CRDs {
+		waits = append(waits, e.crdWait(comp.Name, crd))
+	}
+	for _, r := range spec.Resources {
+		wait, err := e.resourceWait(comp.Name, r)
+		if err != nil {
+			return finish("Error", "%v", err)
+		}
+		waits = append(waits, wait)
+	}
+
+	errs := make([]error, len(waits))
+	var wg sync.WaitGroup
+	for i, wait := range waits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = wait(ctx)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return finish("Timeout", "%v", err)
+		}
+	}
+
+	if len(waits) == 0 {
+		return finish("Ready", "no workloads found")
+	}
+	return finish("Ready", "%s ready", healthSummary(len(workloads), len(spec.CRDs), len(spec.Resources)))
+}
+
+// healthSummary counts what a component's health check covered, e.g.
+// "2 workload(s), 1 CRD(s)".
+func healthSummary(workloads, crds, resources int) string {
+	var parts []string
+	if workloads > 0 {
+		parts = append(parts, fmt.Sprintf("%d workload(s)", workloads))
+	}
+	if crds > 0 {
+		parts = append(parts, fmt.Sprintf("%d CRD(s)", crds))
+	}
+	if resources > 0 {
+		parts = append(parts, fmt.Sprintf("%d resource(s)", resources))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// workloadWait waits for every desired replica of w to be updated and ready.
+func (e *healthEngine) workloadWait(component string, w components.HealthWorkload) healthWait {
+	apps := e.clients.kube.AppsV1()
+	var lw cache.ListerWatcher
+	var example runtime.Object
+	switch w.Kind {
+	case "Deployment":
+		c := apps.Deployments(w.Namespace)
+		lw = nameListWatch(e.clients.kube, w.Name, func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) { return c.List(ctx, o) }, c.Watch)
+		example = &appsv1.Deployment{}
+	case "StatefulSet":
+		c := apps.StatefulSets(w.Namespace)
+		lw = nameListWatch(e.clients.kube, w.Name, func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) { return c.List(ctx, o) }, c.Watch)
+		example = &appsv1.StatefulSet{}
+	default:
+		c := apps.DaemonSets(w.Namespace)
+		lw = nameListWatch(e.clients.kube, w.Name, func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) { return c.List(ctx, o) }, c.Watch)
+		example = &appsv1.DaemonSet{}
+	}
+	return func(ctx context.Context) error {
+		return e.watchUntil(ctx, component, w.String(), w.Name, lw, example, func(obj runtime.Object) (string, bool) {
+			state := workloadStateOf(obj)
+			return fmt.Sprintf("%d/%d ready", state.ready, state.desired), state.isReady()
+		})
+	}
+}
+
+// namespaceWait waits for the namespace name to be created. A terminating
+// namespace counts as missing.
+func (e *healthEngine) namespaceWait(component, name string) healthWait {
+	c := e.clients.kube.CoreV1().Namespaces()
+	lw := nameListWatch(e.clients.kube, name, func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) { return c.List(ctx, o) }, c.Watch)
+	return func(ctx context.Context) error {
+		return e.watchUntil(ctx, component, "namespace/"+name, name, lw, &corev1.Namespace{}, func(obj runtime.Object) (string, bool) {
+			if ns, ok := obj.(*corev1.Namespace); !ok || ns.Status.Phase == corev1.NamespaceTerminating {
+				return "not found", false
+			}
+			return "created", true
+		})
+	}
+}
+
+// crdWait waits for the CRD name to be Established.
+func (e *healthEngine) crdWait(component, name string) healthWait {
+	lw := e.dynamicListWatch(e.clients.dynamic.Resource(crdResource), name)
+	return func(ctx context.Context) error {
+		return e.watchUntil(ctx, component, "crd/"+name, name, lw, &unstructured.Unstructured{}, func(obj runtime.Object) (string, bool) {
+			return conditionState(obj, []string{"Established"})
+		})
+	}
+}
+
+// resourceWait waits for the conditions of r to be True. The kind of r must
+// be known to the API server.
+func (e *healthEngine) resourceWait(component string, r components.HealthResource) (healthWait, error) {
+	gv, err := schema.ParseGroupVersion(r.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion %s: %w", r.APIVersion, err)
+	}
+	mapping, err := e.clients.mapper.RESTMapping(gv.WithKind(r.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind %s: %w\n  hint: install its CRD or list the CRD under health.crds", r.Kind, err)
+	}
+	var resource dynamic.ResourceInterface = e.clients.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = e.clients.dynamic.Resource(mapping.Resource).Namespace(r.Namespace)
+	}
+	lw := e.dynamicListWatch(resource, r.Name)
+	return func(ctx context.Context) error {
+		return e.watchUntil(ctx, component, r.String(), r.Name, lw, &unstructured.Unstructured{}, func(obj runtime.Object) (string, bool) {
+			return conditionState(obj, r.Conditions)
+		})
+	}, nil
+}
+
+// watchUntil watches the object named name through lw until state reports
+// it ready, emitting every change of its state.
+func (e *healthEngine) watchUntil(ctx context.Context, component, object, name string, lw cache.ListerWatcher, example runtime.Object, state func(runtime.Object) (string, bool)) error {
+	last := "not found"
+	_, err := watchtools.UntilWithSync(ctx, lw, example, nil, func(ev watch.Event) (bool, error) {
+		obj, err := meta.Accessor(ev.Object)
+		if err != nil || obj.GetName() != name {
+			return false, nil
+		}
+		current, ready := "not found", false
+		if ev.Type != watch.Deleted {
+			current, ready = state(ev.Object)
+		}
+		if current != last {
+			last = current
+			e.emit(component, object, current)
+		}
+		return ready, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s not ready: %s", object, last)
+		}
+		return fmt.Errorf("failed to watch %s: %w", object, err)
+	}
+	return nil
+}
+
+// nameListWatch lists and watches the object called name with a resource
+// client of client, whose support for streaming lists it keeps.
+func nameListWatch(client any, name string, list cache.ListWithContextFunc, watchFunc cache.WatchFuncWithContext) cache.ListerWatcher {
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	return cache.ToListWatcherWithWatchListSemantics(&cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) {
+			o.FieldSelector = selector
+			return list(ctx, o)
+		},
+		WatchFuncWithContext: func(ctx context.Context, o metav1.ListOptions) (watch.Interface, error) {
+			o.FieldSelector = selector
+			return watchFunc(ctx, o)
+		},
+	}, client)
+}
+
+func (e *healthEngine) dynamicListWatch(resource dynamic.ResourceInterface, name string) cache.ListerWatcher {
+	return nameListWatch(e.clients.dynamic, name,
+		func(ctx context.Context, o metav1.ListOptions) (runtime.Object, error) { return resource.List(ctx, o) },
+		resource.Watch)
+}
+
+// conditionState describes the conditions of want that obj does not report
+// as True, and whether there are none.
+func conditionState(obj runtime.Object, want []string) (string, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return "not found", false
+	}
+	if pending := falseConditions(u, want); len(pending) > 0 {
+		return "waiting for " + strings.Join(pending, ", "), false
+	}
+	return strings.Join(want, ", "), true
+}
+
+// printHealthEvent prints a state change as WaitForHealth streams them.
+func printHealthEvent(ev healthEvent) {
+	object := ev.Component
+	if ev.Object != "" {
+		object += " " + ev.Object
+	}
+	fmt.Printf("  [%5s] %s: %s\n", ev.Elapsed.Round(time.Second), object, ev.State)
+}
@@ -2,16 +2,43 @@ package cmd
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
 )
 
+// checkHealth runs the health engine for one component.
+func checkHealth(ctx context.Context, clients healthClients, comp components.Component) HealthCheckResult {
+	return (&healthEngine{clients: clients}).run(ctx, []components.Component{comp})[0]
+}
+
+// testComponent returns an enabled component whose health spec lists one
+// workload.
+func testComponent(name, namespace, kind, workload string) components.Component {
+	return components.Component{
+		Name:      name,
+		Enabled:   true,
+		Namespace: namespace,
+		Health: &components.HealthSpec{
+			Workloads: []components.HealthWorkload{{Kind: kind, Name: workload}},
+		},
+	}
+}
+
 func TestWaitForHealth_ArgoCDReady(t *testing.T) {
 	//nolint:staticcheck
 	clientset := fake.NewSimpleClientset(
@@ -39,10 +66,10 @@ func TestWaitForHealth_ArgoCDReady(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	result := checkArgoCDReady(ctx, clientset)
-	assert.Equal(t, "ArgoCD", result.Component)
+	result := checkHealth(ctx, healthClients{kube: clientset}, testComponent("argocd", "argocd", "Deployment", "argocd-server"))
+	assert.Equal(t, "argocd", result.Component)
 	assert.Equal(t, "Ready", result.Status)
-	assert.Contains(t, result.Message, "running")
+	assert.Equal(t, "1 workload(s) ready", result.Message)
 }
 
 func TestWaitForHealth_ArgoCDTimeout(t *testing.T) {
@@ -72,8 +99,8 @@ func TestWaitForHealth_ArgoCDTimeout(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
 
-	result := checkArgoCDReady(ctx, clientset)
-	assert.Equal(t, "ArgoCD", result.Component)
+	result := checkHealth(ctx, healthClients{kube: clientset}, testComponent("argocd", "argocd", "Deployment", "argocd-server"))
+	assert.Equal(t, "argocd", result.Component)
 	assert.Equal(t, "Timeout", result.Status)
 	assert.Contains(t, result.Message, "not ready")
 }
@@ -105,10 +132,10 @@ func TestWaitForHealth_VaultReady(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	result := checkVaultReady(ctx, clientset)
-	assert.Equal(t, "Vault", result.Component)
+	result := checkHealth(ctx, healthClients{kube: clientset}, testComponent("vault", "vault", "StatefulSet", "vault"))
+	assert.Equal(t, "vault", result.Component)
 	assert.Equal(t, "Ready", result.Status)
-	assert.Contains(t, result.Message, "running")
+	assert.Equal(t, "1 workload(s) ready", result.Message)
 }
 
 func TestWaitForHealth_VaultNotInstalled(t *testing.T) {
@@ -118,8 +145,10 @@ func TestWaitForHealth_VaultNotInstalled(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	result := checkVaultReady(ctx, clientset)
-	assert.Equal(t, "Vault", result.Component)
+	// Without a health spec, a missing namespace means not installed.
+	vault := components.Component{Name: "vault", Enabled: true, Namespace: "vault"}
+	result := checkHealth(ctx, healthClients{kube: clientset}, vault)
+	assert.Equal(t, "vault", result.Component)
 	assert.Equal(t, "NotInstalled", result.Status)
 	assert.Contains(t, result.Message, "not found")
 }
@@ -151,23 +180,152 @@ func TestWaitForHealth_ExternalSecretsReady(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	result := checkExternalSecretsReady(ctx, clientset)
-	assert.Equal(t, "External Secrets", result.Component)
+	result := checkHealth(ctx, healthClients{kube: clientset}, testComponent("external-secrets", "external-secrets", "Deployment", "external-secrets"))
+	assert.Equal(t, "external-secrets", result.Component)
 	assert.Equal(t, "Ready", result.Status)
-	assert.Contains(t, result.Message, "running")
+	assert.Equal(t, "1 workload(s) ready", result.Message)
 }
 
-func TestWaitForHealth_ExternalSecretsNotInstalled(t *testing.T) {
+func TestWaitForHealth_SpecNamespaceMissing(t *testing.T) {
 	//nolint:staticcheck
 	clientset := fake.NewSimpleClientset()
 
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// A component with a health spec is expected: its namespace is waited for.
+	result := checkHealth(ctx, healthClients{kube: clientset}, testComponent("argocd", "argocd", "Deployment", "argocd-server"))
+	assert.Equal(t, "argocd", result.Component)
+	assert.Equal(t, "Timeout", result.Status)
+	assert.Equal(t, "namespace/argocd not ready: not found", result.Message)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = clientset.CoreV1().Namespaces().Create(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "external-secrets"}}, metav1.CreateOptions{})
+		_, _ = clientset.AppsV1().Deployments("external-secrets").Create(context.Background(), &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "external-secrets", Namespace: "external-secrets"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+			Status:     appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1},
+		}, metav1.CreateOptions{})
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result = checkHealth(ctx, healthClients{kube: clientset}, testComponent("external-secrets", "external-secrets", "Deployment", "external-secrets"))
+	assert.Equal(t, "Ready", result.Status)
+	assert.Equal(t, "1 workload(s) ready", result.Message)
+}
+
+func TestCheckComponentHealth_CRDsAndResources(t *testing.T) {
+	prometheusGVR := schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheuses"}
+	object := func(apiVersion, kind, namespace, name string, conditions ...string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		var list []interface{}
+		for _, c := range conditions {
+			typ, status, _ := strings.Cut(c, "=")
+			list = append(list, map[string]interface{}{"type": typ, "status": status})
+		}
+		_ = unstructured.SetNestedSlice(obj.Object, list, "status", "conditions")
+		return obj
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList", prometheusGVR: "PrometheusList"},
+		object("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "prometheuses.monitoring.coreos.com", "Established=True"),
+		object("monitoring.coreos.com/v1", "Prometheus", "monitoring", "main", "Available=True", "Reconciled=False"),
+	)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "Prometheus"}, meta.RESTScopeNamespace)
+	//nolint:staticcheck
+	clients := healthClients{kube: fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}}), dynamic: dyn, mapper: mapper}
+
+	comp := components.Component{
+		Name:      "monitoring",
+		Namespace: "monitoring",
+		Health: &components.HealthSpec{
+			Workloads: []components.HealthWorkload{},
+			CRDs:      []string{"prometheuses.monitoring.coreos.com"},
+			Resources: []components.HealthResource{{APIVersion: "monitoring.coreos.com/v1", Kind: "Prometheus", Name: "main", Conditions: []string{"Available"}}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := checkHealth(ctx, clients, comp)
+	assert.Equal(t, "Ready", result.Status)
+	assert.Equal(t, "1 CRD(s), 1 resource(s) ready", result.Message)
+
+	comp.Health.Resources[0].Conditions = []string{"Available", "Reconciled"}
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	result = checkHealth(ctx, clients, comp)
+	assert.Equal(t, "Timeout", result.Status)
+	assert.Equal(t, "prometheus/main not ready: waiting for Reconciled", result.Message)
+}
+
+func TestHealthEngine_WatchesConcurrently(t *testing.T) {
+	notReady := func(name, namespace string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		}
+	}
+	//nolint:staticcheck
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "slow"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "fast"}},
+		notReady("slow", "slow"),
+		notReady("fast", "fast"),
+	)
+	slow := testComponent("slow", "slow", "Deployment", "slow")
+	slow.Health.Timeout = 300 * time.Millisecond
+	fast := testComponent("fast", "fast", "Deployment", "fast")
+
+	var events []string
+	engine := &healthEngine{
+		clients: healthClients{kube: clientset},
+		onEvent: func(ev healthEvent) {
+			events = append(events, strings.TrimSpace(ev.Component+" "+ev.Object)+": "+ev.State)
+		},
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	go func() {
+		// Simulate the rollout of fast after the watches are set up.
+		time.Sleep(100 * time.Millisecond)
+		deployments := clientset.AppsV1().Deployments("fast")
+		for _, ready := range []int32{1, 2} {
+			d, err := deployments.Get(ctx, "fast", metav1.GetOptions{})
+			if err != nil {
+				return
+			}
+			d.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: ready}
+			_, _ = deployments.UpdateStatus(ctx, d, metav1.UpdateOptions{})
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
 
-	result := checkExternalSecretsReady(ctx, clientset)
-	assert.Equal(t, "External Secrets", result.Component)
-	assert.Equal(t, "NotInstalled", result.Status)
-	assert.Contains(t, result.Message, "not found")
+	results := engine.run(ctx, []components.Component{slow, fast})
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "slow", results[0].Component)
+	assert.Equal(t, "Timeout", results[0].Status)
+	assert.Equal(t, "deployment/slow not ready: 0/2 ready", results[0].Message)
+
+	assert.Equal(t, "fast", results[1].Component)
+	assert.Equal(t, "Ready", results[1].Status)
+	assert.GreaterOrEqual(t, results[1].Duration, 100*time.Millisecond)
+	assert.Less(t, results[1].Duration, 5*time.Second)
+
+	assert.Subset(t, events, []string{
+		"fast deployment/fast: 0/2 ready",
+		"fast deployment/fast: 1/2 ready",
+		"fast deployment/fast: 2/2 ready",
+		"fast: Ready",
+		"slow: Timeout",
+	})
 }
 
 func TestHealthCheckResult_Duration(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -60,7 +61,10 @@ var infoCmd = &cobra.Command{
 	Use:   "info <environment>",
 	Short: "Show bootstrap status and component information",
 	Long: `Display bootstrap status including installed components, ArgoCD sync state,
-and cluster health. Useful for diagnosing issues without re-running bootstrap.`,
+and cluster health. Useful for diagnosing issues without re-running bootstrap.
+
+The components are the ones enabled for the environment in apps/values.yaml,
+checked through the workloads of their health section.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runInfo,
 }
@@ -79,6 +83,11 @@ func runInfo(cmd *cobra.Command, args []string) error {
 
 	ctx := context.Background()
 
+	catalog, err := components.Load(baseDir, env)
+	if err != nil {
+		return err
+	}
+
 	// Create k8s client with dynamic client for CRDs
 	k8sClient, err := k8s.NewClient(infoKubeconfig, infoContext)
 	if err != nil {
@@ -99,25 +108,14 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		info.ClusterVersion = version.GitVersion
 	}
 
-	// Check ArgoCD
-	argoCDInfo := checkArgoCDInfo(ctx, k8sClient.Clientset)
-	info.Components = append(info.Components, argoCDInfo)
-	info.ArgoCDVersion = argoCDInfo.Version
-
-	// Check Vault
-	vaultInfo := checkComponentInfo(ctx, k8sClient.Clientset, "vault", "vault", "Vault", true)
-	info.Components = append(info.Components, vaultInfo)
-
-	// Check External Secrets
-	esInfo := checkComponentInfo(ctx, k8sClient.Clientset, "external-secrets", "external-secrets", "External Secrets", false)
-	info.Components = append(info.Components, esInfo)
-
-	// Check other common components
-	prometheusInfo := checkComponentInfo(ctx, k8sClient.Clientset, "monitoring", "kube-prometheus-stack", "Kube Prometheus Stack", false)
-	info.Components = append(info.Components, prometheusInfo)
-
-	trivyInfo := checkComponentInfo(ctx, k8sClient.Clientset, "trivy-system", "trivy-operator", "Trivy Operator", false)
-	info.Components = append(info.Components, trivyInfo)
+	// Check the enabled components
+	for _, comp := range catalog.Enabled() {
+		compInfo := checkComponentInfo(ctx, k8sClient.Clientset, comp)
+		info.Components = append(info.Components, compInfo)
+		if comp.Name == "argocd" {
+			info.ArgoCDVersion = compInfo.Version
+		}
+	}
 
 	// Get ArgoCD Applications
 	if apps, err := getArgoCDApplications(ctx, k8sClient); err == nil {
@@ -144,97 +142,64 @@ func runInfo(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// checkArgoCDInfo gathers ArgoCD-specific information
-func checkArgoCDInfo(ctx context.Context, clientset kubernetes.Interface) ComponentInfo {
+// checkComponentInfo gathers the readiness of a component's workloads: those
+// of its health spec, or the ones labelled with its instance label.
+func checkComponentInfo(ctx context.Context, clientset kubernetes.Interface, comp components.Component) ComponentInfo {
 	info := ComponentInfo{
-		Name:      "ArgoCD",
-		Namespace: "argocd",
+		Name:      comp.Name,
+		Namespace: comp.Namespace,
+		SyncWave:  comp.SyncWave,
 		Status:    "NotInstalled",
 	}
 
 	// Check namespace
-	_, err := clientset.CoreV1().Namespaces().Get(ctx, "argocd", metav1.GetOptions{})
+	_, err := clientset.CoreV1().Namespaces().Get(ctx, comp.Namespace, metav1.GetOptions{})
 	if err != nil {
 		return info
 	}
 
 	info.Installed = true
 
-	// Check deployment
-	deploy, err := clientset.AppsV1().Deployments("argocd").Get(ctx, "argocd-server", metav1.GetOptions{})
-	if err == nil {
-		info.ReadyReplicas = int(deploy.Status.ReadyReplicas)
-		info.DesiredReplicas = int(deploy.Status.Replicas)
-		if deploy.Status.ReadyReplicas > 0 && deploy.Status.ReadyReplicas == deploy.Status.Replicas {
-			info.Status = "Ready"
-		} else if deploy.Status.ReadyReplicas > 0 {
-			info.Status = "Progressing"
-		} else {
-			info.Status = "Pending"
-		}
-
-		// Extract version from image
-		if len(deploy.Spec.Template.Spec.Containers) > 0 {
-			image := deploy.Spec.Template.Spec.Containers[0].Image
-			info.Version = extractVersionFromImage(image)
+	workloads := comp.HealthSpec().Workloads
+	if workloads == nil {
+		if workloads, err = discoverWorkloads(ctx, clientset, comp); err != nil {
+			info.Status = "Unknown"
+			info.Message = err.Error()
+			return info
 		}
 	}
-
-	return info
-}
-
-// checkComponentInfo gathers information about a component deployment
-func checkComponentInfo(ctx context.Context, clientset kubernetes.Interface, namespace, resourceName, displayName string, isStatefulSet bool) ComponentInfo {
-	info := ComponentInfo{
-		Name:      displayName,
-		Namespace: namespace,
-		Status:    "NotInstalled",
-	}
-
-	// Check namespace
-	_, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
-	if err != nil {
+	if len(workloads) == 0 {
+		info.Status = "Ready"
+		info.Message = "no workloads"
 		return info
 	}
 
-	info.Installed = true
-
-	// For statefulsets (Vault)
-	if isStatefulSet {
-		ss, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, resourceName, metav1.GetOptions{})
-		if err == nil {
-			info.ReadyReplicas = int(ss.Status.ReadyReplicas)
-			info.DesiredReplicas = int(ss.Status.Replicas)
-			if ss.Status.ReadyReplicas > 0 && ss.Status.ReadyReplicas == ss.Status.Replicas {
-				info.Status = "Ready"
-			} else if ss.Status.ReadyReplicas > 0 {
-				info.Status = "Progressing"
-			} else {
-				info.Status = "Pending"
-			}
-			if len(ss.Spec.Template.Spec.Containers) > 0 {
-				info.Version = extractVersionFromImage(ss.Spec.Template.Spec.Containers[0].Image)
-			}
+	allReady := true
+	for _, w := range workloads {
+		state, err := readWorkload(ctx, clientset, w)
+		if err != nil {
+			// A workload that does not exist yet is still pending.
+			allReady = false
+			info.DesiredReplicas++
+			continue
 		}
-		return info
-	}
-
-	// For deployments
-	deploy, err := clientset.AppsV1().Deployments(namespace).Get(ctx, resourceName, metav1.GetOptions{})
-	if err == nil {
-		info.ReadyReplicas = int(deploy.Status.ReadyReplicas)
-		info.DesiredReplicas = int(deploy.Status.Replicas)
-		if deploy.Status.ReadyReplicas > 0 && deploy.Status.ReadyReplicas == deploy.Status.Replicas {
-			info.Status = "Ready"
-		} else if deploy.Status.ReadyReplicas > 0 {
-			info.Status = "Progressing"
-		} else {
-			info.Status = "Pending"
+		info.ReadyReplicas += int(state.ready)
+		info.DesiredReplicas += int(state.desired)
+		if !state.isReady() {
+			allReady = false
 		}
-		if len(deploy.Spec.Template.Spec.Containers) > 0 {
-			info.Version = extractVersionFromImage(deploy.Spec.Template.Spec.Containers[0].Image)
+		if info.Version == "" && state.image != "" {
+			info.Version = extractVersionFromImage(state.image)
 		}
 	}
+	switch {
+	case allReady:
+		info.Status = "Ready"
+	case info.ReadyReplicas > 0:
+		info.Status = "Progressing"
+	default:
+		info.Status = "Pending"
+	}
 
 	return info
 }
@@ -286,6 +251,10 @@ func printInfoResults(info *InfoResult) {
 				replicaStr = fmt.Sprintf(" - %d/%d replicas", comp.ReadyReplicas, comp.DesiredReplicas)
 			}
 
+			if comp.Message != "" {
+				replicaStr = " - " + comp.Message
+			}
+
 			fmt.Printf("  %s %-20s [%-12s]%s%s\n", statusColor, comp.Name, comp.Status, versionStr, replicaStr)
 			fmt.Printf("     Namespace: %s\n", comp.Namespace)
 		} else {
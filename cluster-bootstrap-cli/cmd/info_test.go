@@ -10,6 +10,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
 )
 
 func TestExtractVersionFromImage(t *testing.T) {
@@ -30,21 +32,21 @@ func TestExtractVersionFromImage(t *testing.T) {
 	}
 }
 
-func TestCheckArgoCDInfo_NotInstalled(t *testing.T) {
+func TestCheckComponentInfo_ArgoCD_NotInstalled(t *testing.T) {
 	//nolint:staticcheck
 	clientset := fake.NewSimpleClientset()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkArgoCDInfo(ctx, clientset)
-	assert.Equal(t, "ArgoCD", info.Name)
+	info := checkComponentInfo(ctx, clientset, testComponent("argocd", "argocd", "Deployment", "argocd-server"))
+	assert.Equal(t, "argocd", info.Name)
 	assert.Equal(t, "argocd", info.Namespace)
 	assert.Equal(t, "NotInstalled", info.Status)
 	assert.False(t, info.Installed)
 }
 
-func TestCheckArgoCDInfo_Ready(t *testing.T) {
+func TestCheckComponentInfo_ArgoCD_Ready(t *testing.T) {
 	//nolint:staticcheck
 	clientset := fake.NewSimpleClientset(
 		&corev1.Namespace{
@@ -79,8 +81,8 @@ func TestCheckArgoCDInfo_Ready(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkArgoCDInfo(ctx, clientset)
-	assert.Equal(t, "ArgoCD", info.Name)
+	info := checkComponentInfo(ctx, clientset, testComponent("argocd", "argocd", "Deployment", "argocd-server"))
+	assert.Equal(t, "argocd", info.Name)
 	assert.Equal(t, "Ready", info.Status)
 	assert.True(t, info.Installed)
 	assert.Equal(t, 1, info.ReadyReplicas)
@@ -88,7 +90,7 @@ func TestCheckArgoCDInfo_Ready(t *testing.T) {
 	assert.Equal(t, "v2.8.0", info.Version)
 }
 
-func TestCheckArgoCDInfo_Progressing(t *testing.T) {
+func TestCheckComponentInfo_ArgoCD_Progressing(t *testing.T) {
 	//nolint:staticcheck
 	clientset := fake.NewSimpleClientset(
 		&corev1.Namespace{
@@ -123,7 +125,7 @@ func TestCheckArgoCDInfo_Progressing(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkArgoCDInfo(ctx, clientset)
+	info := checkComponentInfo(ctx, clientset, testComponent("argocd", "argocd", "Deployment", "argocd-server"))
 	assert.Equal(t, "Progressing", info.Status)
 	assert.Equal(t, 1, info.ReadyReplicas)
 	assert.Equal(t, 2, info.DesiredReplicas)
@@ -164,8 +166,8 @@ func TestCheckComponentInfo_Deployment_Ready(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkComponentInfo(ctx, clientset, "external-secrets", "external-secrets", "External Secrets", false)
-	assert.Equal(t, "External Secrets", info.Name)
+	info := checkComponentInfo(ctx, clientset, testComponent("external-secrets", "external-secrets", "Deployment", "external-secrets"))
+	assert.Equal(t, "external-secrets", info.Name)
 	assert.Equal(t, "Ready", info.Status)
 	assert.True(t, info.Installed)
 	assert.Equal(t, "v0.9.0", info.Version)
@@ -206,8 +208,8 @@ func TestCheckComponentInfo_StatefulSet_Ready(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkComponentInfo(ctx, clientset, "vault", "vault", "Vault", true)
-	assert.Equal(t, "Vault", info.Name)
+	info := checkComponentInfo(ctx, clientset, testComponent("vault", "vault", "StatefulSet", "vault"))
+	assert.Equal(t, "vault", info.Name)
 	assert.Equal(t, "Ready", info.Status)
 	assert.True(t, info.Installed)
 	assert.Equal(t, "1.15.4", info.Version)
@@ -220,8 +222,8 @@ func TestCheckComponentInfo_NotInstalled(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkComponentInfo(ctx, clientset, "vault", "vault", "Vault", true)
-	assert.Equal(t, "Vault", info.Name)
+	info := checkComponentInfo(ctx, clientset, testComponent("vault", "vault", "StatefulSet", "vault"))
+	assert.Equal(t, "vault", info.Name)
 	assert.Equal(t, "NotInstalled", info.Status)
 	assert.False(t, info.Installed)
 }
@@ -261,11 +263,48 @@ func TestCheckComponentInfo_Pending(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	info := checkComponentInfo(ctx, clientset, "monitoring", "kube-prometheus-stack", "Kube Prometheus Stack", false)
+	info := checkComponentInfo(ctx, clientset, testComponent("kube-prometheus-stack", "monitoring", "Deployment", "kube-prometheus-stack"))
 	assert.Equal(t, "Pending", info.Status)
 	assert.Equal(t, 0, info.ReadyReplicas)
 }
 
+func TestCheckComponentInfo_DiscoversWorkloads(t *testing.T) {
+	labels := map[string]string{components.InstanceLabel: "reloader"}
+	//nolint:staticcheck
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "reloader"},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "reloader", Namespace: "reloader", Labels: labels},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(1)},
+			Status:     appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "reloader", Labels: labels},
+			Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, UpdatedNumberScheduled: 2, NumberReady: 1},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "reloader"},
+			Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(3)},
+		},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info := checkComponentInfo(ctx, clientset, components.Component{Name: "reloader", Namespace: "reloader", Enabled: true})
+	assert.Equal(t, "Progressing", info.Status)
+	assert.Equal(t, 2, info.ReadyReplicas)
+	assert.Equal(t, 3, info.DesiredReplicas)
+
+	info = checkComponentInfo(ctx, clientset, components.Component{
+		Name: "repo-secret", Namespace: "reloader", Enabled: true,
+	})
+	assert.Equal(t, "Ready", info.Status)
+	assert.Equal(t, "no workloads", info.Message)
+}
+
 func TestInfoResult_Creation(t *testing.T) {
 	info := &InfoResult{
 		Environment:    "dev",
@@ -274,7 +313,7 @@ func TestInfoResult_Creation(t *testing.T) {
 		Timestamp:      time.Now(),
 		Components: []ComponentInfo{
 			{
-				Name:      "ArgoCD",
+				Name:      "argocd",
 				Namespace: "argocd",
 				Installed: true,
 				Status:    "Ready",
@@ -6,42 +6,82 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
+	"filippo.io/age"
 	"github.com/charmbracelet/huh"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v3"
 
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/agekey"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
 )
 
 var (
-	provider   string
-	ageKeyFile string
-	kmsARN     string
-	gcpKMSKey  string
-	outputDir  string
+	provider        string
+	ageKeyFile      string
+	kmsARN          string
+	gcpKMSKey       string
+	pgpFingerprints string
+	azureKVURL      string
+	vaultTransitURI string
+	keyGroups       []string
+	shamirThreshold int
+	outputDir       string
+
+	initRepoURL        string
+	initTargetRevision string
+	initSSHKeyFile     string
+	initFromFile       string
+	initNonInteractive bool
+	initForce          bool
 )
 
 var initCmd = &cobra.Command{
 	Use:   "init [environments...]",
-	Short: "Interactive setup to create .sops.yaml and per-environment secrets files",
-	Long: `Interactively configure encryption and create per-environment secrets files.
-Prompts for the encryption provider, encryption key, and per-environment secrets.
+	Short: "Set up .sops.yaml and per-environment secrets files",
+	Long: `Configure encryption and create per-environment secrets files.
+Prompts for the encryption provider, encryption key, and per-environment secrets
+that are not given as flags or in an answers file (--from-file). Without a
+terminal, or with --non-interactive, init never prompts: it fails listing the
+missing inputs instead.
 
 Supported providers:
-  - age, aws-kms, gcp-kms: uses SOPS encryption (secrets.<env>.enc.yaml)
-  - git-crypt: uses git-crypt transparent encryption (secrets.<env>.yaml)`,
+  - age, pgp, aws-kms, gcp-kms, azure-kv, hc-vault-transit: uses SOPS
+    encryption (secrets.<env>.enc.yaml). Pass several keys separated by
+    commas to let any of them decrypt.
+  - key-groups: SOPS key groups mixing providers; with --shamir-threshold,
+    keys from that many groups are needed to decrypt
+  - git-crypt: uses git-crypt transparent encryption (secrets.<env>.yaml)
+
+Example:
+  cluster-bootstrap init dev --provider age --age-key-file age.pub
+  cluster-bootstrap init prod --key-group age1... --key-group arn:aws:kms:... --shamir-threshold 2
+  cluster-bootstrap init dev --provider age --repo-url git@github.com:org/repo.git --ssh-key-file ./deploy-key --non-interactive
+  cluster-bootstrap init --from-file answers.yaml`,
 	RunE: runInit,
 }
 
 func init() {
-	initCmd.Flags().StringVar(&provider, "provider", "", "encryption provider (age|aws-kms|gcp-kms|git-crypt)")
-	initCmd.Flags().StringVar(&ageKeyFile, "age-key-file", "", "path to age public key file (for age provider)")
-	initCmd.Flags().StringVar(&kmsARN, "kms-arn", "", "AWS KMS key ARN (for aws-kms provider)")
-	initCmd.Flags().StringVar(&gcpKMSKey, "gcp-kms-key", "", "GCP KMS key resource ID (for gcp-kms provider)")
+	initCmd.Flags().StringVar(&provider, "provider", "", "encryption provider (age|pgp|aws-kms|gcp-kms|azure-kv|hc-vault-transit|key-groups|git-crypt)")
+	initCmd.Flags().StringVar(&ageKeyFile, "age-key-file", "", "path to age recipients file, one public key per line (for age provider; default: the local identity, generated if missing)")
+	initCmd.Flags().StringVar(&kmsARN, "kms-arn", "", "AWS KMS key ARNs, comma-separated (for aws-kms provider)")
+	initCmd.Flags().StringVar(&gcpKMSKey, "gcp-kms-key", "", "GCP KMS key resource IDs, comma-separated (for gcp-kms provider)")
+	initCmd.Flags().StringVar(&pgpFingerprints, "pgp-fingerprint", "", "PGP key fingerprints, comma-separated (for pgp provider)")
+	initCmd.Flags().StringVar(&azureKVURL, "azure-kv-url", "", "Azure Key Vault key URLs, comma-separated (for azure-kv provider)")
+	initCmd.Flags().StringVar(&vaultTransitURI, "vault-transit-uri", "", "Vault transit key URIs, comma-separated (for hc-vault-transit provider)")
+	initCmd.Flags().StringArrayVar(&keyGroups, "key-group", nil, "comma-separated keys of one key group, any provider (repeatable; implies --provider key-groups)")
+	initCmd.Flags().IntVar(&shamirThreshold, "shamir-threshold", 0, "number of key groups needed to decrypt (for key-groups)")
 	initCmd.Flags().StringVar(&outputDir, "output-dir", ".", "directory for secrets files")
+	initCmd.Flags().StringVar(&initRepoURL, "repo-url", "", "repository SSH or HTTPS URL")
+	initCmd.Flags().StringVar(&initTargetRevision, "target-revision", "", "branch or tag to deploy (default \"main\")")
+	initCmd.Flags().StringVar(&initSSHKeyFile, "ssh-key-file", "", "path to the repository SSH private key")
+	initCmd.Flags().StringVar(&initFromFile, "from-file", "", "answers file listing environments and their settings")
+	initCmd.Flags().BoolVar(&initNonInteractive, "non-interactive", false, "never prompt; fail listing missing inputs")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "overwrite existing secrets files without asking")
 
 	rootCmd.AddCommand(initCmd)
 }
@@ -55,27 +95,43 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	sopsConfigPath := filepath.Join(effectiveOutputDir, ".sops.yaml")
 
-	// Determine environment names: from positional args or interactive prompt
-	var environments []string
-	if len(args) > 0 {
-		environments = args
-	} else {
+	if len(keyGroups) > 0 {
+		if provider != "" && provider != "key-groups" {
+			return fmt.Errorf("--key-group cannot be combined with --provider %s", provider)
+		}
+		provider = "key-groups"
+	}
+
+	answers, err := loadInitAnswers(args)
+	if err != nil {
+		return err
+	}
+	interactive := !initNonInteractive && term.IsTerminal(int(os.Stdin.Fd())) // #nosec G115
+	if !interactive {
+		if missing := missingInputs(answers.Environments); len(missing) > 0 {
+			return fmt.Errorf("missing inputs:\n  - %s\n  hint: pass them as flags or in --from-file, or run in a terminal to be prompted", strings.Join(missing, "\n  - "))
+		}
+	}
+
+	// Prompt for environment names when none were given
+	if len(answers.Environments) == 0 {
 		for {
-			var env string
+			env := initEnvironment{}
 			err := huh.NewForm(
 				huh.NewGroup(
 					huh.NewInput().
 						Title("Environment name").
 						Description("Name for this environment (e.g. dev, staging, prod)").
 						Validate(requiredValidator("environment name is required")).
-						Value(&env),
+						Value(&env.Name),
 				),
 			).Run()
 			if err != nil {
 				return fmt.Errorf("prompt failed: %w", err)
 			}
-			environments = append(environments, env)
-			fmt.Printf("  Added environment: %s\n", env)
+			env.fillFrom(answers.Defaults)
+			answers.Environments = append(answers.Environments, env)
+			fmt.Printf("  Added environment: %s\n", env.Name)
 
 			var addMore bool
 			err = huh.NewForm(
@@ -99,18 +155,24 @@ func runInit(cmd *cobra.Command, args []string) error {
 	var createdFiles []string
 	sopsConfigUpdated := false
 
-	for _, env := range environments {
+	for i := range answers.Environments {
+		answer := &answers.Environments[i]
+		env := answer.Name
 		fmt.Printf("\n--- Environment: %s ---\n", env)
 
 		// Select encryption provider for this environment
-		envProvider := provider
+		envProvider := answer.Provider
 		if envProvider == "" {
 			err := huh.NewSelect[string]().
 				Title(fmt.Sprintf("Select encryption provider for %s", env)).
 				Options(
 					huh.NewOption("age", "age"),
+					huh.NewOption("PGP", "pgp"),
 					huh.NewOption("AWS KMS", "aws-kms"),
 					huh.NewOption("GCP KMS", "gcp-kms"),
+					huh.NewOption("Azure Key Vault", "azure-kv"),
+					huh.NewOption("HashiCorp Vault Transit", "hc-vault-transit"),
+					huh.NewOption("Key groups (several providers, Shamir threshold)", "key-groups"),
 					huh.NewOption("git-crypt", "git-crypt"),
 				).
 				Value(&envProvider).
@@ -121,47 +183,52 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 
 		if envProvider == "git-crypt" {
-			count, createdFile, err := initGitCrypt(effectiveOutputDir, env)
+			count, createdFile, err := initGitCrypt(effectiveOutputDir, answer, interactive)
 			if err != nil {
 				return err
 			}
 			if createdFile != "" {
 				createdFiles = append(createdFiles, createdFile)
+				if err := config.SetEncryptionBackend(effectiveOutputDir, env, config.EncryptionGitCrypt); err != nil {
+					return err
+				}
 			}
 			created += count
 			continue
 		}
 
-		// SOPS path
-		key, err := getProviderKey(envProvider)
-		if err != nil {
-			return err
-		}
-
-		// Upsert SOPS creation rule for this environment
-		if err := config.UpsertSopsRule(sopsConfigPath, envProvider, key, env); err != nil {
-			return err
+		// SOPS path: upsert the creation rule for this environment
+		if envProvider == "key-groups" {
+			groups, threshold, err := getKeyGroups(answer)
+			if err != nil {
+				return err
+			}
+			if err := config.UpsertSopsKeyGroups(sopsConfigPath, groups, threshold, env); err != nil {
+				return err
+			}
+		} else {
+			keys, err := getProviderKeys(envProvider, answer, interactive)
+			if err != nil {
+				return err
+			}
+			if err := config.UpsertSopsRule(sopsConfigPath, envProvider, keys, env); err != nil {
+				return err
+			}
 		}
 		fmt.Printf("Updated %s with rule for %s\n", sopsConfigPath, env)
 		sopsConfigUpdated = true
 
 		// Create encrypted secrets file
 		outputFile := filepath.Join(effectiveOutputDir, config.SecretsFileName(env))
-		if _, statErr := os.Stat(outputFile); statErr == nil {
-			var overwrite bool
-			err := huh.NewConfirm().
-				Title(fmt.Sprintf("%s already exists. Overwrite?", config.SecretsFileName(env))).
-				Value(&overwrite).
-				Run()
-			if err != nil {
-				return fmt.Errorf("prompt failed: %w", err)
-			}
-			if !overwrite {
-				continue
-			}
+		overwrite, err := confirmOverwrite(outputFile, interactive)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			continue
 		}
 
-		envSecrets, err := promptEnvironmentSecrets(env)
+		envSecrets, err := promptEnvironmentSecrets(answer, interactive)
 		if err != nil {
 			return err
 		}
@@ -188,6 +255,9 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 		fmt.Printf("Created %s (encrypted)\n", outputFile)
 		createdFiles = append(createdFiles, outputFile)
+		if err := config.SetEncryptionBackend(effectiveOutputDir, env, config.EncryptionSOPS); err != nil {
+			return err
+		}
 		created++
 	}
 
@@ -212,6 +282,7 @@ func runInit(cmd *cobra.Command, args []string) error {
 			fmt.Printf("  Updated config: %s\n", sopsConfigPath)
 		}
 		if len(createdFiles) > 0 {
+			fmt.Printf("  Recorded encryption backends: %s\n", filepath.Join(effectiveOutputDir, config.RepoConfigFile))
 			fmt.Println("  Created secrets files:")
 			for _, f := range createdFiles {
 				fmt.Printf("    - %s\n", f)
@@ -225,7 +296,8 @@ func runInit(cmd *cobra.Command, args []string) error {
 }
 
 // initGitCrypt handles the git-crypt provider path for a single environment.
-func initGitCrypt(outputDir, env string) (int, string, error) {
+func initGitCrypt(outputDir string, answer *initEnvironment, interactive bool) (int, string, error) {
+	env := answer.Name
 	// Verify git-crypt is initialised in the repo
 	gitCryptDir := filepath.Join(outputDir, ".git", "git-crypt")
 	if _, err := os.Stat(gitCryptDir); os.IsNotExist(err) {
@@ -240,21 +312,15 @@ func initGitCrypt(outputDir, env string) (int, string, error) {
 
 	// Create plaintext secrets file (git-crypt encrypts on commit)
 	outputFile := filepath.Join(outputDir, config.SecretsFileNamePlain(env))
-	if _, statErr := os.Stat(outputFile); statErr == nil {
-		var overwrite bool
-		err := huh.NewConfirm().
-			Title(fmt.Sprintf("%s already exists. Overwrite?", config.SecretsFileNamePlain(env))).
-			Value(&overwrite).
-			Run()
-		if err != nil {
-			return 0, "", fmt.Errorf("prompt failed: %w", err)
-		}
-		if !overwrite {
-			return 0, "", nil
-		}
+	overwrite, err := confirmOverwrite(outputFile, interactive)
+	if err != nil {
+		return 0, "", err
+	}
+	if !overwrite {
+		return 0, "", nil
 	}
 
-	envSecrets, err := promptEnvironmentSecrets(env)
+	envSecrets, err := promptEnvironmentSecrets(answer, interactive)
 	if err != nil {
 		return 0, "", err
 	}
@@ -272,98 +338,258 @@ func initGitCrypt(outputDir, env string) (int, string, error) {
 	return 1, outputFile, nil
 }
 
-func getProviderKey(provider string) (string, error) {
+// providerPrompts are the flag, flag name and prompt title for each SOPS
+// provider other than age.
+var providerPrompts = map[string]struct {
+	flag     *string
+	flagName string
+	title    string
+}{
+	"aws-kms":          {&kmsARN, "kms-arn", "Enter AWS KMS key ARNs"},
+	"gcp-kms":          {&gcpKMSKey, "gcp-kms-key", "Enter GCP KMS key resource IDs"},
+	"pgp":              {&pgpFingerprints, "pgp-fingerprint", "Enter PGP key fingerprints"},
+	"azure-kv":         {&azureKVURL, "azure-kv-url", "Enter Azure Key Vault key URLs (https://<vault>.vault.azure.net/keys/<name>/<version>)"},
+	"hc-vault-transit": {&vaultTransitURI, "vault-transit-uri", "Enter Vault transit key URIs (https://<host>:8200/v1/<engine>/keys/<name>)"},
+}
+
+// getProviderKeys returns the recipients for a single-provider rule, from
+// the answers, flags or a prompt. Every key is checked to be well formed for
+// provider.
+func getProviderKeys(provider string, answer *initEnvironment, interactive bool) ([]string, error) {
+	if len(answer.Keys) > 0 {
+		return splitProviderKeys(provider, strings.Join(answer.Keys, ","))
+	}
+	var value string
 	switch provider {
 	case "age":
-		if ageKeyFile != "" {
-			data, err := os.ReadFile(ageKeyFile) // #nosec G304
+		if answer.AgeKeyFile != "" {
+			data, err := os.ReadFile(answer.AgeKeyFile) // #nosec G304
 			if err != nil {
-				return "", fmt.Errorf("failed to read age key file: %w", err)
+				return nil, fmt.Errorf("failed to read age key file: %w", err)
 			}
-			return string(data), nil
-		}
-		var key string
-		err := huh.NewInput().
-			Title("Enter age public key (age1...)").
-			Value(&key).
-			Validate(requiredValidator("age public key is required")).
-			Run()
+			return parseRecipientsFile(provider, answer.AgeKeyFile, string(data))
+		}
+		// Use the local identity, generating one where SOPS looks for it.
+		keyFile, err := agekey.DefaultKeyFile()
 		if err != nil {
-			return "", fmt.Errorf("prompt failed: %w", err)
+			return nil, err
+		}
+		identity, created, err := agekey.Ensure(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			fmt.Printf("Generated age identity %s in %s\n", identity.Recipient, keyFile)
+		} else {
+			fmt.Printf("Using age identity %s from %s\n", identity.Recipient, keyFile)
+		}
+		value = identity.Recipient
+		if interactive {
+			if err := promptKeys("Enter age public keys (age1...)", provider, &value); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		p, ok := providerPrompts[provider]
+		if !ok {
+			return nil, fmt.Errorf("unsupported provider: %s", provider)
+		}
+		value = *p.flag
+		if value == "" {
+			if err := promptKeys(p.title, provider, &value); err != nil {
+				return nil, err
+			}
 		}
-		return key, nil
+	}
+	return splitProviderKeys(provider, value)
+}
 
-	case "aws-kms":
-		if kmsARN != "" {
-			return kmsARN, nil
+// parseRecipientsFile reads one recipient per line, skipping blank lines and
+// # comments, as in an age recipients file. Identities (AGE-SECRET-KEY-...)
+// are replaced by their public key, so an age key file works too.
+func parseRecipientsFile(provider, path, data string) ([]string, error) {
+	var keys []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
-		var key string
-		err := huh.NewInput().
-			Title("Enter AWS KMS key ARN").
-			Value(&key).
-			Validate(requiredValidator("KMS ARN is required")).
-			Run()
-		if err != nil {
-			return "", fmt.Errorf("prompt failed: %w", err)
+		if strings.HasPrefix(line, "AGE-SECRET-KEY-") {
+			identity, err := age.ParseX25519Identity(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid age identity in %s: %w", path, err)
+			}
+			line = identity.Recipient().String()
 		}
-		return key, nil
+		if err := config.ValidateKey(provider, line); err != nil {
+			return nil, err
+		}
+		keys = append(keys, line)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no %s recipients found in %s", provider, path)
+	}
+	return keys, nil
+}
+
+func splitProviderKeys(provider, value string) ([]string, error) {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if err := config.ValidateKey(provider, key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one %s key is required", provider)
+	}
+	return keys, nil
+}
+
+func promptKeys(title, provider string, value *string) error {
+	err := huh.NewInput().
+		Title(title).
+		Description("Separate several keys with commas; any of them can decrypt").
+		Value(value).
+		Validate(func(s string) error {
+			_, err := splitProviderKeys(provider, s)
+			return err
+		}).
+		Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	return nil
+}
+
+// getKeyGroups returns the key groups and Shamir threshold from the answers,
+// or prompts for them one group at a time.
+func getKeyGroups(answer *initEnvironment) ([]config.KeyGroup, int, error) {
+	specs := answer.KeyGroups
+	threshold := answer.ShamirThreshold
+	if len(specs) == 0 {
+		for {
+			var spec string
+			err := huh.NewInput().
+				Title(fmt.Sprintf("Keys for key group %d", len(specs)+1)).
+				Description("Comma-separated; age, PGP, AWS KMS, GCP KMS, Azure Key Vault or Vault transit keys").
+				Value(&spec).
+				Validate(func(s string) error {
+					_, err := config.NewKeyGroup(config.SplitKeys(s))
+					return err
+				}).
+				Run()
+			if err != nil {
+				return nil, 0, fmt.Errorf("prompt failed: %w", err)
+			}
+			specs = append(specs, config.SplitKeys(spec))
 
-	case "gcp-kms":
-		if gcpKMSKey != "" {
-			return gcpKMSKey, nil
+			var addMore bool
+			if err := huh.NewConfirm().Title("Add another key group?").Value(&addMore).Run(); err != nil {
+				return nil, 0, fmt.Errorf("prompt failed: %w", err)
+			}
+			if !addMore {
+				break
+			}
 		}
-		var key string
-		err := huh.NewInput().
-			Title("Enter GCP KMS key resource ID").
-			Value(&key).
-			Validate(requiredValidator("GCP KMS key is required")).
-			Run()
+		if len(specs) > 1 {
+			value := strconv.Itoa(len(specs))
+			err := huh.NewInput().
+				Title("Shamir threshold").
+				Description(fmt.Sprintf("Number of key groups (1-%d) needed to decrypt", len(specs))).
+				Value(&value).
+				Validate(func(s string) error {
+					n, err := strconv.Atoi(strings.TrimSpace(s))
+					if err != nil || n < 1 || n > len(specs) {
+						return fmt.Errorf("enter a number between 1 and %d", len(specs))
+					}
+					return nil
+				}).
+				Run()
+			if err != nil {
+				return nil, 0, fmt.Errorf("prompt failed: %w", err)
+			}
+			threshold, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+
+	groups := make([]config.KeyGroup, 0, len(specs))
+	for i, spec := range specs {
+		group, err := config.NewKeyGroup(spec)
 		if err != nil {
-			return "", fmt.Errorf("prompt failed: %w", err)
+			return nil, 0, fmt.Errorf("key group %d: %w", i+1, err)
 		}
-		return key, nil
+		groups = append(groups, group)
+	}
+	return groups, threshold, nil
+}
 
-	default:
-		return "", fmt.Errorf("unsupported provider: %s", provider)
+// confirmOverwrite reports whether path may be written: it does not exist,
+// --force was given, or the user agrees. Without prompts an existing file is
+// kept.
+func confirmOverwrite(path string, interactive bool) (bool, error) {
+	if _, err := os.Stat(path); err != nil || initForce {
+		return true, nil
+	}
+	if !interactive {
+		warnf("%s already exists; skipping (pass --force to overwrite it)", path)
+		return false, nil
+	}
+	var overwrite bool
+	err := huh.NewConfirm().
+		Title(fmt.Sprintf("%s already exists. Overwrite?", filepath.Base(path))).
+		Value(&overwrite).
+		Run()
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
 	}
+	return overwrite, nil
 }
 
-func promptEnvironmentSecrets(env string) (*config.EnvironmentSecrets, error) {
-	var repoURL string
-	targetRevision := "main"
-	var sshKeyPath string
-
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Title("Repository SSH URL").
-				Value(&repoURL).
-				Validate(validateRepoURL),
-			huh.NewInput().
+// promptEnvironmentSecrets prompts for the repository settings missing from
+// answer and reads the SSH key. The target revision defaults to main.
+func promptEnvironmentSecrets(answer *initEnvironment, interactive bool) (*config.EnvironmentSecrets, error) {
+	var fields []huh.Field
+	if answer.RepoURL == "" {
+		fields = append(fields, huh.NewInput().
+			Title("Repository SSH URL").
+			Value(&answer.RepoURL).
+			Validate(validateRepoURL))
+	}
+	if answer.TargetRevision == "" {
+		answer.TargetRevision = "main"
+		if interactive {
+			fields = append(fields, huh.NewInput().
 				Title("Target revision (branch/tag)").
-				Value(&targetRevision).
-				Validate(validateTargetRevision),
-			huh.NewInput().
-				Title("Path to SSH private key file").
-				Value(&sshKeyPath).
-				Validate(requiredValidator("SSH key path is required")),
-		),
-	)
-
-	if err := form.Run(); err != nil {
-		return nil, fmt.Errorf("prompt failed: %w", err)
+				Value(&answer.TargetRevision).
+				Validate(validateTargetRevision))
+		}
+	}
+	if answer.SSHKeyFile == "" {
+		fields = append(fields, huh.NewInput().
+			Title("Path to SSH private key file").
+			Value(&answer.SSHKeyFile).
+			Validate(requiredValidator("SSH key path is required")))
+	}
+	if len(fields) > 0 {
+		if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+			return nil, fmt.Errorf("prompt failed: %w", err)
+		}
 	}
 
 	// Read SSH key from filesystem
-	sshKeyData, err := os.ReadFile(sshKeyPath) // #nosec G304
+	sshKeyData, err := os.ReadFile(answer.SSHKeyFile) // #nosec G304
 	if err != nil {
-		return nil, fmt.Errorf("failed to read SSH key at %s: %w", sshKeyPath, err)
+		return nil, fmt.Errorf("failed to read SSH key at %s: %w", answer.SSHKeyFile, err)
 	}
 
 	envSecrets := &config.EnvironmentSecrets{
 		Repo: config.RepoSecrets{
-			URL:            repoURL,
-			TargetRevision: targetRevision,
+			URL:            answer.RepoURL,
+			TargetRevision: answer.TargetRevision,
 			SSHPrivateKey:  string(sshKeyData),
 		},
 	}
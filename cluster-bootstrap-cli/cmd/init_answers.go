@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+)
+
+// initProviders are the values accepted for --provider and provider in an
+// answers file.
+var initProviders = []string{"age", "pgp", "aws-kms", "gcp-kms", "azure-kv", "hc-vault-transit", "key-groups", "git-crypt"}
+
+// initEnvironment holds the answers for one environment. Empty fields are
+// taken from the answers file defaults, then from flags, and are otherwise
+// prompted for.
+type initEnvironment struct {
+	Name     string   `yaml:"name"`
+	Provider string   `yaml:"provider,omitempty"`
+	Keys     []string `yaml:"keys,omitempty"`
+	// AgeKeyFile is an age recipients file, as for --age-key-file.
+	AgeKeyFile      string     `yaml:"ageKeyFile,omitempty"`
+	KeyGroups       [][]string `yaml:"keyGroups,omitempty"`
+	ShamirThreshold int        `yaml:"shamirThreshold,omitempty"`
+	RepoURL         string     `yaml:"repoURL,omitempty"`
+	TargetRevision  string     `yaml:"targetRevision,omitempty"`
+	SSHKeyFile      string     `yaml:"sshKeyFile,omitempty"`
+}
+
+// initAnswers is the format of the --from-file answers file.
+type initAnswers struct {
+	Defaults     initEnvironment   `yaml:"defaults"`
+	Environments []initEnvironment `yaml:"environments"`
+}
+
+// fillFrom sets the empty fields of e from defaults.
+func (e *initEnvironment) fillFrom(defaults initEnvironment) {
+	if e.Provider == "" {
+		e.Provider = defaults.Provider
+	}
+	if len(e.Keys) == 0 {
+		e.Keys = defaults.Keys
+	}
+	if e.AgeKeyFile == "" {
+		e.AgeKeyFile = defaults.AgeKeyFile
+	}
+	if len(e.KeyGroups) == 0 {
+		e.KeyGroups = defaults.KeyGroups
+	}
+	if e.ShamirThreshold == 0 {
+		e.ShamirThreshold = defaults.ShamirThreshold
+	}
+	if e.RepoURL == "" {
+		e.RepoURL = defaults.RepoURL
+	}
+	if e.TargetRevision == "" {
+		e.TargetRevision = defaults.TargetRevision
+	}
+	if e.SSHKeyFile == "" {
+		e.SSHKeyFile = defaults.SSHKeyFile
+	}
+}
+
+// validate checks the answers given so far, with the same validators the
+// prompts use. All problems are returned joined.
+func (e *initEnvironment) validate() error {
+	var errs []error
+	if e.Provider != "" && !slices.Contains(initProviders, e.Provider) {
+		errs = append(errs, fmt.Errorf("unsupported provider %q", e.Provider))
+	}
+	if len(e.KeyGroups) > 0 && e.Provider != "key-groups" {
+		errs = append(errs, fmt.Errorf("key groups require provider key-groups, not %q", e.Provider))
+	}
+	if len(e.Keys) > 0 && (e.Provider == "key-groups" || e.Provider == "git-crypt") {
+		errs = append(errs, fmt.Errorf("keys do not apply to provider %s", e.Provider))
+	}
+	if e.Provider != "" && slices.Contains(initProviders, e.Provider) {
+		for _, key := range e.Keys {
+			errs = append(errs, config.ValidateKey(e.Provider, key))
+		}
+	}
+	for i, group := range e.KeyGroups {
+		if _, err := config.NewKeyGroup(group); err != nil {
+			errs = append(errs, fmt.Errorf("key group %d: %w", i+1, err))
+		}
+	}
+	if e.ShamirThreshold < 0 || (len(e.KeyGroups) > 0 && e.ShamirThreshold > len(e.KeyGroups)) {
+		errs = append(errs, fmt.Errorf("shamir threshold must be 0 (none) or between 1 and the number of key groups (%d)", len(e.KeyGroups)))
+	}
+	if e.RepoURL != "" {
+		errs = append(errs, validateRepoURL(e.RepoURL))
+	}
+	if e.TargetRevision != "" {
+		errs = append(errs, validateTargetRevision(e.TargetRevision))
+	}
+	if e.SSHKeyFile != "" {
+		if _, err := os.Stat(e.SSHKeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("SSH key file: %w", err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("environment %s: %w", e.Name, err)
+	}
+	return nil
+}
+
+// initFlagDefaults returns the answers given as flags, which apply to every
+// environment.
+func initFlagDefaults() initEnvironment {
+	defaults := initEnvironment{
+		Provider:        provider,
+		AgeKeyFile:      ageKeyFile,
+		ShamirThreshold: shamirThreshold,
+		RepoURL:         initRepoURL,
+		TargetRevision:  initTargetRevision,
+		SSHKeyFile:      initSSHKeyFile,
+	}
+	for _, spec := range keyGroups {
+		defaults.KeyGroups = append(defaults.KeyGroups, config.SplitKeys(spec))
+	}
+	if p, ok := providerPrompts[provider]; ok {
+		defaults.Keys = config.SplitKeys(*p.flag)
+	}
+	return defaults
+}
+
+// loadInitAnswers returns the answers from flags and, with --from-file, the
+// answers file. Environment arguments name the environments to set up; with
+// an answers file they select from the environments it lists.
+func loadInitAnswers(args []string) (*initAnswers, error) {
+	flagDefaults := initFlagDefaults()
+	answers := &initAnswers{}
+	if initFromFile == "" {
+		for _, name := range args {
+			answers.Environments = append(answers.Environments, initEnvironment{Name: name})
+		}
+	} else {
+		data, err := os.ReadFile(initFromFile) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answers file: %w", err)
+		}
+		if answers, err = parseInitAnswers(data); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", initFromFile, err)
+		}
+		if len(args) > 0 {
+			selected := make([]initEnvironment, 0, len(args))
+			for _, name := range args {
+				i := slices.IndexFunc(answers.Environments, func(e initEnvironment) bool { return e.Name == name })
+				if i < 0 {
+					return nil, fmt.Errorf("environment %s is not listed in %s", name, initFromFile)
+				}
+				selected = append(selected, answers.Environments[i])
+			}
+			answers.Environments = selected
+		}
+	}
+
+	answers.Defaults.fillFrom(flagDefaults)
+	var errs []error
+	for i := range answers.Environments {
+		env := &answers.Environments[i]
+		env.fillFrom(answers.Defaults)
+		errs = append(errs, env.validate())
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
+
+// parseInitAnswers decodes an answers file, rejecting unknown fields and
+// environments without a name or listed twice.
+func parseInitAnswers(data []byte) (*initAnswers, error) {
+	answers := &initAnswers{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(answers); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if answers.Defaults.Name != "" {
+		return nil, errors.New("defaults cannot have a name")
+	}
+	seen := make(map[string]bool, len(answers.Environments))
+	for i, env := range answers.Environments {
+		if env.Name == "" {
+			return nil, fmt.Errorf("environment %d has no name", i+1)
+		}
+		if seen[env.Name] {
+			return nil, fmt.Errorf("environment %s is listed twice", env.Name)
+		}
+		seen[env.Name] = true
+	}
+	return answers, nil
+}
+
+// missingInputs lists the inputs init would have to prompt for.
+func missingInputs(envs []initEnvironment) []string {
+	if len(envs) == 0 {
+		return []string{"environment names (arguments or environments in --from-file)"}
+	}
+	var missing []string
+	for _, env := range envs {
+		switch env.Provider {
+		case "":
+			missing = append(missing, fmt.Sprintf("%s: encryption provider (--provider)", env.Name))
+		case "key-groups":
+			if len(env.KeyGroups) == 0 {
+				missing = append(missing, fmt.Sprintf("%s: key groups (--key-group)", env.Name))
+			}
+		case "age", "git-crypt":
+			// age falls back to the local identity; git-crypt needs no keys.
+		default:
+			if p := providerPrompts[env.Provider]; len(env.Keys) == 0 && *p.flag == "" {
+				missing = append(missing, fmt.Sprintf("%s: %s keys (--%s)", env.Name, env.Provider, p.flagName))
+			}
+		}
+		if env.RepoURL == "" {
+			missing = append(missing, fmt.Sprintf("%s: repository URL (--repo-url)", env.Name))
+		}
+		if env.SSHKeyFile == "" {
+			missing = append(missing, fmt.Sprintf("%s: SSH private key file (--ssh-key-file)", env.Name))
+		}
+	}
+	return missing
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAgeRecipient = "age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"
+
+func TestParseInitAnswers(t *testing.T) {
+	answers, err := parseInitAnswers([]byte(`
+defaults:
+  repoURL: git@github.com:org/repo.git
+environments:
+  - name: dev
+    provider: age
+  - name: prod
+    provider: key-groups
+    keyGroups:
+      - [` + testAgeRecipient + `]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "git@github.com:org/repo.git", answers.Defaults.RepoURL)
+	require.Len(t, answers.Environments, 2)
+	assert.Equal(t, [][]string{{testAgeRecipient}}, answers.Environments[1].KeyGroups)
+
+	_, err = parseInitAnswers([]byte("environments:\n  - name: dev\n    repoUrl: x\n"))
+	assert.ErrorContains(t, err, "field repoUrl not found")
+	_, err = parseInitAnswers([]byte("environments:\n  - provider: age\n"))
+	assert.ErrorContains(t, err, "environment 1 has no name")
+	_, err = parseInitAnswers([]byte("environments:\n  - name: dev\n  - name: dev\n"))
+	assert.ErrorContains(t, err, "listed twice")
+}
+
+func TestLoadInitAnswers(t *testing.T) {
+	prevProvider := provider
+	prevRepoURL := initRepoURL
+	prevFromFile := initFromFile
+	t.Cleanup(func() {
+		provider = prevProvider
+		initRepoURL = prevRepoURL
+		initFromFile = prevFromFile
+	})
+
+	tmpDir := t.TempDir()
+	sshKey := filepath.Join(tmpDir, "id_ed25519")
+	require.NoError(t, os.WriteFile(sshKey, []byte("key"), 0600))
+	initFromFile = filepath.Join(tmpDir, "answers.yaml")
+	require.NoError(t, os.WriteFile(initFromFile, []byte(`
+defaults:
+  sshKeyFile: `+sshKey+`
+environments:
+  - name: dev
+  - name: prod
+    provider: pgp
+    keys: [85D77543B3D624B63CEA9E6DBC17301B491B3F21]
+    targetRevision: v1.0.0
+`), 0600))
+
+	// Flags fill what the file leaves out; arguments select environments.
+	provider = "age"
+	initRepoURL = "git@github.com:org/repo.git"
+	answers, err := loadInitAnswers([]string{"prod"})
+	require.NoError(t, err)
+	require.Len(t, answers.Environments, 1)
+	prod := answers.Environments[0]
+	assert.Equal(t, "pgp", prod.Provider)
+	assert.Equal(t, "git@github.com:org/repo.git", prod.RepoURL)
+	assert.Equal(t, "v1.0.0", prod.TargetRevision)
+	assert.Equal(t, sshKey, prod.SSHKeyFile)
+
+	answers, err = loadInitAnswers(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "age", answers.Environments[0].Provider)
+
+	_, err = loadInitAnswers([]string{"staging"})
+	assert.ErrorContains(t, err, "environment staging is not listed")
+
+	// Flag values go through the prompt validators.
+	initRepoURL = "not a url"
+	_, err = loadInitAnswers(nil)
+	assert.ErrorContains(t, err, "repository URL must not contain spaces")
+}
+
+func TestMissingInputs(t *testing.T) {
+	assert.Equal(t, []string{"environment names (arguments or environments in --from-file)"}, missingInputs(nil))
+
+	missing := missingInputs([]initEnvironment{
+		{Name: "dev", Provider: "age", RepoURL: "git@github.com:org/repo.git", SSHKeyFile: "key"},
+		{Name: "prod", Provider: "aws-kms"},
+		{Name: "qa", RepoURL: "git@github.com:org/repo.git", SSHKeyFile: "key"},
+	})
+	assert.Equal(t, []string{
+		"prod: aws-kms keys (--kms-arn)",
+		"prod: repository URL (--repo-url)",
+		"prod: SSH private key file (--ssh-key-file)",
+		"qa: encryption provider (--provider)",
+	}, missing)
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"testing"
 
+	"filippo.io/age"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidateRepoURL(t *testing.T) {
@@ -33,3 +35,29 @@ func TestValidateTargetRevision(t *testing.T) {
 	assert.Error(t, validateTargetRevision(""))
 	assert.Error(t, validateTargetRevision("bad rev"))
 }
+
+func TestSplitProviderKeys(t *testing.T) {
+	keys, err := splitProviderKeys("pgp", "85D77543B3D624B63CEA9E6DBC17301B491B3F21, 1D1C4A2B3A0E8A7F6E5D4C3B2A1908F7E6D5C4B3")
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	_, err = splitProviderKeys("aws-kms", "arn:aws:kms:eu-west-1:123456789012:key/abc,not-an-arn")
+	assert.ErrorContains(t, err, "invalid aws-kms key")
+	_, err = splitProviderKeys("age", " , ")
+	assert.ErrorContains(t, err, "at least one age key")
+}
+
+func TestParseRecipientsFile(t *testing.T) {
+	keys, err := parseRecipientsFile("age", "age.pub", "# alice\nage1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8\n\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"}, keys)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	keys, err = parseRecipientsFile("age", "age.pub", "# created: 2026-01-01\n# public key: "+identity.Recipient().String()+"\n"+identity.String()+"\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{identity.Recipient().String()}, keys)
+
+	_, err = parseRecipientsFile("age", "age.pub", "# empty\n")
+	assert.ErrorContains(t, err, "no age recipients")
+}
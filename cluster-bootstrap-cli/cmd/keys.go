@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/agekey"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+var keysAgeKeyFile string
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Inspect local age identities",
+	Long: `Inspect the age identities SOPS uses on this machine: $SOPS_AGE_KEY and
+the key file at $SOPS_AGE_KEY_FILE, or sops/age/keys.txt in the user config
+directory. 'cluster-bootstrap init --provider age' creates that file when it
+does not exist.`,
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List local age identities and the environments each can decrypt",
+	Long: `List local age identities by public key. For each one, the environments
+whose secrets.<env>.enc.yaml under --base-dir is encrypted to it are shown.
+
+Example:
+  cluster-bootstrap keys list
+  cluster-bootstrap keys list --age-key-file ./age-key.txt`,
+	Args: cobra.NoArgs,
+	RunE: runKeysList,
+}
+
+var keysExportRecipientCmd = &cobra.Command{
+	Use:   "export-recipient",
+	Short: "Print the public key of the local age identity",
+	Long: `Print the public key (age1...) of each local age identity, one per line,
+to share with a teammate who adds it with 'secrets rotate --add-recipient'.
+
+Example:
+  cluster-bootstrap keys export-recipient
+  cluster-bootstrap secrets rotate --all --add-recipient "$(cluster-bootstrap keys export-recipient)"`,
+	Args: cobra.NoArgs,
+	RunE: runKeysExportRecipient,
+}
+
+func init() {
+	keysCmd.PersistentFlags().StringVar(&keysAgeKeyFile, "age-key-file", "", "age key file to read instead of the default locations")
+
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysExportRecipientCmd)
+	rootCmd.AddCommand(keysCmd)
+}
+
+// localIdentities returns the identities in --age-key-file, or those SOPS
+// finds by default.
+func localIdentities() ([]agekey.Identity, error) {
+	var identities []agekey.Identity
+	var err error
+	if keysAgeKeyFile != "" {
+		identities, err = agekey.Load(keysAgeKeyFile)
+	} else {
+		identities, err = agekey.Local()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		path := keysAgeKeyFile
+		if path == "" {
+			if path, err = agekey.DefaultKeyFile(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("no age identity found in %s\n  hint: run 'cluster-bootstrap init --provider age' to generate one", path)
+	}
+	return identities, nil
+}
+
+func runKeysList(cmd *cobra.Command, args []string) error {
+	identities, err := localIdentities()
+	if err != nil {
+		return err
+	}
+	files, err := sopsFileRecipients()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-64s %-12s %s\n", "RECIPIENT", "ENVIRONMENTS", "SOURCE")
+	for _, id := range identities {
+		envs := decryptableEnvironments(id.Recipient, files)
+		// Pad before colouring so the columns stay aligned.
+		list := fmt.Sprintf("%-12s", dash(strings.Join(envs, ",")))
+		if len(envs) > 0 {
+			list = successColor(list)
+		}
+		fmt.Printf("%-64s %s %s\n", id.Recipient, list, id.Source)
+	}
+	if len(files) == 0 {
+		fmt.Println()
+		fmt.Printf("No %s files found in %s\n", config.SecretsFileName("<env>"), baseDir)
+	}
+	return nil
+}
+
+func runKeysExportRecipient(cmd *cobra.Command, args []string) error {
+	identities, err := localIdentities()
+	if err != nil {
+		return err
+	}
+	for _, id := range identities {
+		fmt.Println(id.Recipient)
+	}
+	return nil
+}
+
+// sopsFileRecipients reads the recipients of every secrets.<env>.enc.yaml in
+// baseDir from its sops metadata, keyed by environment.
+func sopsFileRecipients() (map[string][]string, error) {
+	matches, err := filepath.Glob(filepath.Join(baseDir, config.SecretsFileName("*")))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]string, len(matches))
+	for _, path := range matches {
+		data, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		recipients, err := sops.Recipients(data)
+		if err != nil {
+			warnf("Skipping %s: %v", path, err)
+			continue
+		}
+		env := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "secrets."), ".enc.yaml")
+		files[env] = recipients
+	}
+	return files, nil
+}
+
+// decryptableEnvironments lists, sorted, the environments whose file is
+// encrypted to recipient.
+func decryptableEnvironments(recipient string, files map[string][]string) []string {
+	var envs []string
+	for env, recipients := range files {
+		if slices.Contains(recipients, recipient) {
+			envs = append(envs, env)
+		}
+	}
+	slices.Sort(envs)
+	return envs
+}
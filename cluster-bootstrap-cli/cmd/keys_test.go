@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecryptableEnvironments(t *testing.T) {
+	files := map[string][]string{
+		"prod":    {"age1bob"},
+		"staging": {"age1alice", "age1bob"},
+		"dev":     {"age1alice"},
+	}
+	assert.Equal(t, []string{"dev", "staging"}, decryptableEnvironments("age1alice", files))
+	assert.Empty(t, decryptableEnvironments("age1carol", files))
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+)
+
+// knownHostsTimeout bounds each connection made to fetch host keys.
+const knownHostsTimeout = 10 * time.Second
+
+// Sources of pinned fingerprints, in order of precedence.
+const (
+	pinSourceSecrets = "secrets file"
+	pinSourceConfig  = config.RepoConfigFile
+	pinSourceCluster = "cluster"
+)
+
+// hostKeys are the verified host keys of one Git server.
+type hostKeys struct {
+	// Host is the known_hosts name: host, or [host]:port.
+	Host string
+	Keys []ssh.PublicKey
+	// PinSource is where the fingerprints the keys were checked against
+	// come from; empty when the host was trusted on first use.
+	PinSource string
+}
+
+type knownHostsTarget struct {
+	host   string
+	pins   []string
+	source string
+}
+
+// knownHostsTargets returns the Git servers to manage: the repository's,
+// pinned by repo.hostKeyFingerprints or the settings file, and every other
+// host pinned in the settings file.
+func knownHostsTargets(repo config.RepoSecrets) ([]knownHostsTarget, error) {
+	pins, err := config.LoadKnownHostPins(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	var targets []knownHostsTarget
+	if addr, ok := knownhosts.Address(repo.URL); ok {
+		target := knownHostsTarget{host: knownhosts.Host(addr)}
+		switch {
+		case len(repo.HostKeyFingerprints) > 0:
+			target.pins, target.source = repo.HostKeyFingerprints, pinSourceSecrets
+		case len(pins[target.host]) > 0:
+			target.pins, target.source = pins[target.host], pinSourceConfig
+		}
+		targets = append(targets, target)
+	}
+	hosts := make([]string, 0, len(pins))
+	for host := range pins {
+		hosts = append(hosts, host)
+	}
+	slices.Sort(hosts)
+	for _, host := range hosts {
+		if len(targets) > 0 && targets[0].host == host {
+			continue
+		}
+		targets = append(targets, knownHostsTarget{host: host, pins: pins[host], source: pinSourceConfig})
+	}
+	return targets, nil
+}
+
+// fetchKnownHosts fetches the host keys of every Git server to manage and
+// verifies them against the pinned fingerprints. Hosts without pins are
+// checked against the keys current already lists for them, if any. Any
+// mismatch is an error.
+func fetchKnownHosts(ctx context.Context, repo config.RepoSecrets, current *knownhosts.File) ([]hostKeys, error) {
+	targets, err := knownHostsTargets(repo)
+	if err != nil {
+		return nil, err
+	}
+	var result []hostKeys
+	for _, target := range targets {
+		keys, err := knownhosts.Fetch(ctx, knownhosts.HostAddress(target.host), knownHostsTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  hint: check that %s is reachable over SSH from this machine", err, target.host)
+		}
+		if target.source == "" {
+			for _, key := range current.Keys(target.host) {
+				target.pins = append(target.pins, knownhosts.Fingerprint(key))
+				target.source = pinSourceCluster
+			}
+		}
+		verified, err := knownhosts.Verify(target.host, keys, target.pins)
+		if err != nil {
+			var mismatch *knownhosts.MismatchError
+			if errors.As(err, &mismatch) {
+				return nil, fmt.Errorf("%w (from %s)\n  hint: if the server's host keys were rotated, update the pinned fingerprints; otherwise the connection may be intercepted", err, target.source)
+			}
+			return nil, err
+		}
+		result = append(result, hostKeys{Host: target.host, Keys: verified, PinSource: target.source})
+	}
+	return result, nil
+}
+
+// mergeKnownHosts replaces the entries of every host in current and reports
+// whether anything changed.
+func mergeKnownHosts(current *knownhosts.File, hosts []hostKeys) bool {
+	changed := false
+	for _, h := range hosts {
+		if current.Set(h.Host, h.Keys) {
+			changed = true
+		}
+	}
+	return changed
+}
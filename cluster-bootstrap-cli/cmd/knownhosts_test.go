@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+)
+
+// serveHostKey runs an SSH server with a fresh ed25519 host key that rejects
+// every client, and returns its address and key.
+func serveHostKey(t *testing.T) (string, ssh.PublicKey) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, errors.New("denied")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _, _, _ = ssh.NewServerConn(conn, serverConfig)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	return ln.Addr().String(), signer.PublicKey()
+}
+
+func TestFetchKnownHosts(t *testing.T) {
+	prevBaseDir := baseDir
+	t.Cleanup(func() { baseDir = prevBaseDir })
+	baseDir = t.TempDir()
+
+	addr, key := serveHostKey(t)
+	host := knownhosts.Host(addr)
+	repo := config.RepoSecrets{URL: "ssh://git@" + addr + "/org/repo.git"}
+	ctx := context.Background()
+
+	// Unpinned and not in the cluster: trusted on first use.
+	hosts, err := fetchKnownHosts(ctx, repo, knownhosts.Parse(""))
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, host, hosts[0].Host)
+	assert.Empty(t, hosts[0].PinSource)
+
+	// Keys already in the cluster act as pins.
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(other)
+	require.NoError(t, err)
+	_, err = fetchKnownHosts(ctx, repo, knownhosts.Parse(knownhosts.Line(host, otherSigner.PublicKey())))
+	assert.ErrorContains(t, err, "host key mismatch")
+	assert.ErrorContains(t, err, "from cluster")
+
+	// The secrets file pin wins over the settings file.
+	require.NoError(t, config.SetKnownHostPins(baseDir, host, []string{knownhosts.Fingerprint(otherSigner.PublicKey())}))
+	_, err = fetchKnownHosts(ctx, repo, knownhosts.Parse(""))
+	assert.ErrorContains(t, err, "from "+config.RepoConfigFile)
+
+	repo.HostKeyFingerprints = []string{knownhosts.Fingerprint(key)}
+	hosts, err = fetchKnownHosts(ctx, repo, knownhosts.Parse(""))
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, pinSourceSecrets, hosts[0].PinSource)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -36,17 +37,21 @@ func (l *Logger) Stage(name string) *StageLogger {
 	}
 }
 
-// StageLogger is a helper for logging a single stage.
+// StageLogger is a helper for logging a single stage. Details may be added
+// from concurrent checks.
 type StageLogger struct {
 	logger  *Logger
 	name    string
 	start   time.Time
+	mu      sync.Mutex
 	details []string
 }
 
 // Detail adds a detail line to the current stage.
 func (s *StageLogger) Detail(format string, args ...interface{}) {
 	detail := fmt.Sprintf(format, args...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.details = append(s.details, detail)
 	if s.logger.verbose {
 		fmt.Printf("    • %s\n", detail)
@@ -56,6 +61,8 @@ func (s *StageLogger) Detail(format string, args ...interface{}) {
 // SecretDetail logs a secret-related operation without exposing values.
 func (s *StageLogger) SecretDetail(operation, secretName, namespace string) {
 	detail := fmt.Sprintf("%s secret '%s' in namespace '%s'", operation, secretName, namespace)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.details = append(s.details, detail)
 	if s.logger.verbose {
 		fmt.Printf("    • 🔐 %s\n", detail)
@@ -4,52 +4,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-)
-
-// CheckKubectlAvailable verifies that kubectl is installed and accessible.
-// Returns nil if kubectl is available, but only logs a warning if not available
-// (unless strict mode is enabled).
-func CheckKubectlAvailable(strict bool) error {
-	// Use kubectl --version which is simpler and more reliable
-	cmd := exec.Command("kubectl", "version")
-	err := cmd.Run()
-	if err != nil {
-		if strict {
-			return fmt.Errorf("kubectl not found or not accessible: %w\n  hint: ensure kubectl is installed and in your PATH\n  tip: install from https://kubernetes.io/docs/tasks/tools/", err)
-		}
-		// In non-strict mode, just warn but don't fail
-		return nil
-	}
-	return nil
-}
-
-// CheckKubectlClusterAccess verifies that kubectl can connect to the cluster.
-// This checks if the current context is valid and accessible.
-func CheckKubectlClusterAccess() error {
-	return CheckKubectlClusterAccessWithConfig("", "")
-}
 
-// CheckKubectlClusterAccessWithConfig verifies that kubectl can connect to the cluster
-// using the provided kubeconfig and context.
-func CheckKubectlClusterAccessWithConfig(kubeconfig, kubeContext string) error {
-	path, err := exec.LookPath("kubectl")
-	if err != nil {
-		return fmt.Errorf("kubectl not found in PATH: %w", err)
-	}
-
-	args := make([]string, 0, 6)
-	if kubeconfig != "" {
-		args = append(args, "--kubeconfig", kubeconfig)
-	}
-	if kubeContext != "" {
-		args = append(args, "--context", kubeContext)
-	}
-	args = append(args, "cluster-info")
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/agekey"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
 
-	cmd := exec.Command(path, args...) // #nosec G204
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("cannot connect to cluster: %w\n  output: %s\n  hint: verify kubeconfig/context and cluster access", err, string(output))
+// CheckKubectlAvailable reports whether kubectl is installed. kubectl is
+// optional: the CLI talks to the cluster through client-go.
+func CheckKubectlAvailable() error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return fmt.Errorf("kubectl not found in PATH: %w\n  hint: kubectl is optional; install it to inspect the cluster by hand\n  tip: install from https://kubernetes.io/docs/tasks/tools/", err)
 	}
 	return nil
 }
@@ -86,27 +51,24 @@ func CheckSOPS(encryptionBackend string) error {
 	return nil
 }
 
-// CheckAge verifies that age is installed (if using age encryption).
+// CheckAge verifies that the age key file, if given, holds an age identity.
+// The file is parsed in-process; the age binaries are not needed.
 func CheckAge(encryptionBackend string, ageKeyFile string) error {
 	if encryptionBackend != "sops" || ageKeyFile == "" {
 		return nil
 	}
 
-	// Check if age-keygen is available
-	path, err := exec.LookPath("age-keygen")
-	if err != nil {
-		return fmt.Errorf("age not found in PATH: %w\n  hint: ensure age is installed and in your PATH\n  tip: install from https://github.com/FiloSottile/age", err)
+	// Check if the age key file is readable
+	if _, err := os.Stat(ageKeyFile); err != nil {
+		return fmt.Errorf("age key file not accessible: %w\n  hint: verify the path exists and you have read permissions\n  path: %s", err, ageKeyFile)
 	}
 
-	cmd := exec.Command(path, "--version") // #nosec G204
-	err = cmd.Run()
+	identities, err := agekey.Load(ageKeyFile)
 	if err != nil {
-		return fmt.Errorf("age found at %s but failed to run: %w", path, err)
+		return fmt.Errorf("invalid age key file: %w", err)
 	}
-
-	// Check if the age key file is readable
-	if _, err := os.Stat(ageKeyFile); err != nil {
-		return fmt.Errorf("age key file not accessible: %w\n  hint: verify the path exists and you have read permissions\n  path: %s", err, ageKeyFile)
+	if len(identities) == 0 {
+		return fmt.Errorf("no age identity found in %s\n  hint: the file must contain an AGE-SECRET-KEY-1... line; 'cluster-bootstrap init --provider age' generates one", ageKeyFile)
 	}
 
 	return nil
@@ -163,48 +125,66 @@ func CheckSSHKey(keyPath string) error {
 	return CheckFilePermissions(keyPath, true)
 }
 
-// PreflightChecks performs all prerequisite checks before bootstrap.
-// strict mode is enabled when --wait-for-health is true, requiring kubectl cluster access.
-func PreflightChecks(encryption, ageKeyFile string, verbose bool, requireKubectl bool) error {
-	logger := NewLogger(verbose)
+// PreflightOptions configures PreflightChecks.
+type PreflightOptions struct {
+	Env         string
+	Encryption  string
+	AgeKeyFile  string
+	Kubeconfig  string
+	KubeContext string
+	Verbose     bool
+	// WaitForHealth is set with --wait-for-health, whose health checks
+	// need permissions of their own.
+	WaitForHealth bool
+	// DryRun, SkipArgoCDInstall and SkipKnownHosts mirror the bootstrap
+	// flags and decide which permissions are reviewed. A dry run does not
+	// touch the cluster, so its cluster checks are skipped.
+	DryRun            bool
+	SkipArgoCDInstall bool
+	SkipKnownHosts    bool
+	// Skip lists check IDs or categories not to run.
+	Skip []string
+}
+
+// PreflightChecks performs all prerequisite checks before bootstrap: the
+// preflight checks of the registry, including custom checks. It returns the
+// first failure, after printing its details, e.g. missing permissions.
+func PreflightChecks(opts PreflightOptions) error {
+	logger := NewLogger(opts.Verbose)
 	checksStage := logger.Stage("Prerequisite Checks")
 
-	checks := []struct {
-		name string
-		fn   func() error
-	}{
-		{"kubectl available", func() error {
-			return CheckKubectlAvailable(requireKubectl)
-		}},
-		{"kubectl cluster access", func() error {
-			// Only check cluster access if required (e.g., when using --wait-for-health)
-			if !requireKubectl {
-				return nil
-			}
-			return CheckKubectlClusterAccess()
-		}},
-		{"helm available", CheckHelm},
-		{"sops/age for encryption", func() error {
-			if err := CheckSOPS(encryption); err != nil {
-				return err
-			}
-			return CheckAge(encryption, ageKeyFile)
-		}},
-		{"git-crypt for encryption", func() error {
-			return CheckGitCrypt(encryption)
-		}},
-	}
-
-	for _, check := range checks {
-		if err := check.fn(); err != nil {
-			checksStage.Detail("✗ %s: %v", check.name, err)
-			checksStage.Done()
-			return err
+	run := &checkRun{
+		command:     "preflight",
+		env:         opts.Env,
+		encryption:  opts.Encryption,
+		ageKeyFile:  opts.AgeKeyFile,
+		kubeconfig:  opts.Kubeconfig,
+		kubeContext: opts.KubeContext,
+		skipCluster: opts.DryRun,
+	}
+	if !opts.DryRun {
+		run.rbac = &rbacOptions{
+			installArgoCD: !opts.SkipArgoCDInstall,
+			knownHosts:    !opts.SkipKnownHosts,
+			waitForHealth: opts.WaitForHealth,
 		}
-		checksStage.Detail("✓ %s", check.name)
 	}
-
+	results, err := runChecks(run, checks.Selection{Skip: opts.Skip}, checksStage)
 	checksStage.Done()
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			for _, detail := range res.Details {
+				fmt.Printf("      %s\n", detail)
+			}
+			return res.Err
+		}
+		if res.Status == report.StatusWarn {
+			warnf("%s: %s", res.Name, res.Note)
+		}
+	}
 	logger.PrintStageSummary()
 	return nil
 }
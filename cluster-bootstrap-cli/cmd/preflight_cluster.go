@@ -0,0 +1,473 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/preflight"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+// argoCDRelease is the Helm release bootstrap installs ArgoCD as.
+const argoCDRelease = "argocd/argocd"
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+var (
+	preflightKubeconfig string
+	preflightContext    string
+	preflightOutput     string
+	preflightOnly       []string
+	preflightSkip       []string
+)
+
+var preflightCmd = &cobra.Command{
+	Use:   "preflight",
+	Short: "Check a cluster before bootstrapping an environment",
+}
+
+var preflightClusterCmd = &cobra.Command{
+	Use:   "cluster <environment>",
+	Short: "Check cluster capacity and prerequisites for an environment",
+	Long: `Check that the cluster can take the enabled components of an environment.
+
+The components are rendered with the Helm SDK for the cluster's Kubernetes
+version and compared with the cluster:
+
+  - a default StorageClass exists, and every StorageClass a claim names
+  - the summed requests of the rendered workloads fit in the allocatable
+    CPU and memory of the schedulable nodes; DaemonSets count once per node
+  - no node reports memory, disk or PID pressure
+  - CRDs and namespaces the components create are not owned by another
+    tool, such as a Helm release or another ArgoCD Application
+
+The report is printed in the validate format; --only, --skip and --output
+work as they do for validate. The exit status is 0 when every check passed,
+1 when a check failed and 2 when checks only warned.
+
+Example:
+  cluster-bootstrap preflight cluster dev
+  cluster-bootstrap preflight cluster prod --context prod -o json`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          runPreflightCluster,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+func init() {
+	preflightClusterCmd.Flags().StringVar(&preflightKubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	preflightClusterCmd.Flags().StringVar(&preflightContext, "context", "", "kubeconfig context to use")
+	preflightClusterCmd.Flags().StringVarP(&preflightOutput, "output", "o", "text", "output format: text, json, junit, sarif or github")
+	preflightClusterCmd.Flags().StringSliceVar(&preflightOnly, "only", nil, "only run the checks with these IDs or categories, and the checks they depend on")
+	preflightClusterCmd.Flags().StringSliceVar(&preflightSkip, "skip", nil, "skip the checks with these IDs or categories")
+
+	preflightCmd.AddCommand(preflightClusterCmd)
+	rootCmd.AddCommand(preflightCmd)
+}
+
+func runPreflightCluster(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	format, err := report.ParseFormat(preflightOutput)
+	if err != nil {
+		return err
+	}
+	logger := NewLogger(verbose && format == report.FormatText)
+	stage := logger.Stage("Cluster preflight")
+
+	run := &checkRun{
+		command:     "preflight-cluster",
+		env:         env,
+		kubeconfig:  preflightKubeconfig,
+		kubeContext: preflightContext,
+	}
+	results, err := runChecks(run, checks.Selection{Only: preflightOnly, Skip: preflightSkip}, stage)
+	if err != nil {
+		return err
+	}
+	stage.Done()
+
+	rep := checkReport("preflight-cluster", env, results)
+	if format == report.FormatText {
+		printCheckReport("Cluster preflight report", results)
+	} else if err := report.Write(os.Stdout, format, rep); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	switch rep.ExitCode() {
+	case report.ExitFailure:
+		return &exitError{code: report.ExitFailure, err: fmt.Errorf("preflight found %d issue(s)", rep.Summary.Fail)}
+	case report.ExitWarning:
+		return &exitError{code: report.ExitWarning, err: fmt.Errorf("preflight found %d warning(s)", rep.Summary.Warn)}
+	}
+
+	if format == report.FormatText {
+		successf("Cluster preflight passed")
+	}
+	return nil
+}
+
+// renderManifestResults renders the environment for the cluster's version
+// and keeps the result for the checks depending on it.
+func renderManifestResults(run *checkRun) []checks.Result {
+	opts := render.Options{BaseDir: baseDir, Env: run.env, KubeVersion: run.kubeVersion, Verbose: verbose}
+	if !run.skipCluster {
+		if v, err := run.clusterVersion(); err == nil {
+			opts.KubeVersion = v
+		}
+	}
+	result, err := render.Environment(opts)
+	if err != nil {
+		return checks.Fail(fmt.Errorf("failed to render %s: %w\n  hint: run 'cluster-bootstrap render %s' to reproduce the error", run.env, err, run.env))
+	}
+	run.rendered = result
+	objects := 0
+	for _, comp := range result.Components {
+		objects += len(comp.Objects)
+	}
+	return checks.OK(fmt.Sprintf("%d component(s), %d object(s)", len(result.Components), objects))
+}
+
+// nodeConditionResults fails on node pressure and warns on nodes that are
+// not ready.
+func nodeConditionResults(ctx context.Context, run *checkRun) []checks.Result {
+	nodes, err := listNodes(ctx, run)
+	if err != nil {
+		return checks.Fail(err)
+	}
+	if len(nodes) == 0 {
+		return checks.Fail(fmt.Errorf("the cluster has no nodes\n  hint: add nodes before bootstrapping"))
+	}
+
+	var pressure, notReady []string
+	for _, p := range preflight.NodeProblems(nodes) {
+		line := fmt.Sprintf("%s: %s", p.Node, p.Condition)
+		if p.Message != "" {
+			line += ": " + p.Message
+		}
+		if p.Pressure {
+			pressure = append(pressure, line)
+		} else {
+			notReady = append(notReady, line)
+		}
+	}
+	note := fmt.Sprintf("%d node(s), %d schedulable", len(nodes), len(preflight.Schedulable(nodes)))
+	switch {
+	case len(pressure) > 0:
+		return []checks.Result{{
+			Note:    note,
+			Err:     fmt.Errorf("%d node condition(s) report pressure\n  hint: free resources on the nodes or replace them before bootstrapping", len(pressure)),
+			Details: append(pressure, notReady...),
+		}}
+	case len(notReady) > 0:
+		return []checks.Result{{
+			Status:  report.StatusWarn,
+			Note:    fmt.Sprintf("%s, %d not ready", note, len(notReady)),
+			Hint:    "pods are not scheduled on nodes that are not ready",
+			Details: notReady,
+		}}
+	}
+	return checks.OK(note)
+}
+
+// storageClassResults checks that the StorageClasses the rendered claims
+// rely on exist, including the default class for claims that name none.
+func storageClassResults(ctx context.Context, run *checkRun) []checks.Result {
+	client, err := run.kubeClient()
+	if err != nil {
+		return checks.Fail(err)
+	}
+	list, err := client.Clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return checks.Fail(fmt.Errorf("failed to list StorageClasses: %w", err))
+	}
+	defaults := preflight.DefaultClasses(list.Items)
+	exists := make(map[string]bool, len(list.Items))
+	for _, sc := range list.Items {
+		exists[sc.Name] = true
+	}
+
+	claims := 0
+	var defaultClaims []string
+	named := make(map[string][]string)
+	for _, comp := range run.rendered.Components {
+		for _, c := range preflight.Claims(comp.Objects) {
+			claims++
+			line := fmt.Sprintf("%s: %s/%s claim %s", comp.Name, c.Object.Kind, c.Object.Name, c.Name)
+			switch {
+			case c.Class == nil:
+				defaultClaims = append(defaultClaims, line)
+			case *c.Class != "" && !exists[*c.Class]:
+				named[*c.Class] = append(named[*c.Class], line)
+			}
+		}
+	}
+
+	var results []checks.Result
+	for _, class := range sortedKeys(named) {
+		results = append(results, checks.Result{
+			ID:      "storage-class/" + class,
+			Name:    fmt.Sprintf("storage class %s", class),
+			Err:     fmt.Errorf("StorageClass %s does not exist; %d claim(s) use it\n  hint: create the StorageClass or set storageClassName in the component values", class, len(named[class])),
+			Details: named[class],
+		})
+	}
+	switch {
+	case len(defaults) == 0 && len(defaultClaims) > 0:
+		results = append(results, checks.Result{
+			Err:     fmt.Errorf("no default StorageClass; %d claim(s) rely on one\n  hint: kubectl annotate storageclass <name> %s=true", len(defaultClaims), preflight.DefaultClassAnnotation),
+			Details: defaultClaims,
+		})
+	case len(defaults) == 0:
+		results = append(results, checks.Warn(
+			fmt.Sprintf("no default StorageClass, %d claim(s)", claims),
+			fmt.Sprintf("components added later that claim storage need one: kubectl annotate storageclass <name> %s=true", preflight.DefaultClassAnnotation),
+		)...)
+	case len(defaults) > 1:
+		results = append(results, checks.Warn(
+			fmt.Sprintf("%d default StorageClasses: %s", len(defaults), strings.Join(defaults, ", ")),
+			"claims without a storageClassName get the newest default; keep one default class",
+		)...)
+	}
+	if len(results) == 0 {
+		return checks.OK(fmt.Sprintf("default %s, %d claim(s)", defaults[0], claims))
+	}
+	return results
+}
+
+// capacityResults compares the summed requests of the rendered workloads
+// with the allocatable resources of the schedulable nodes. Pods already on
+// the nodes outside the components' namespaces only lead to a warning.
+func capacityResults(ctx context.Context, run *checkRun) []checks.Result {
+	nodes, err := listNodes(ctx, run)
+	if err != nil {
+		return checks.Fail(err)
+	}
+	schedulable := preflight.Schedulable(nodes)
+	if len(schedulable) == 0 {
+		return checks.Fail(fmt.Errorf("none of the %d node(s) is schedulable\n  hint: uncordon a node, or check node readiness and NoSchedule taints", len(nodes)))
+	}
+
+	var need preflight.Resources
+	var details []string
+	for _, comp := range run.rendered.Components {
+		var sum preflight.Resources
+		for _, w := range preflight.Workloads(comp.Objects) {
+			sum.Add(w.Total(len(schedulable)))
+		}
+		if !sum.IsZero() {
+			details = append(details, fmt.Sprintf("%s: %s", comp.Name, sum))
+		}
+		need.Add(sum)
+	}
+	allocatable := preflight.Allocatable(schedulable)
+	note := fmt.Sprintf("%s requested of %s allocatable on %d node(s)",
+		need, allocatable, len(schedulable))
+
+	if !need.Fits(allocatable) {
+		return []checks.Result{{
+			Note:    note,
+			Err:     fmt.Errorf("the enabled components request more than the schedulable nodes allocate\n  hint: add nodes or lower the requests in the component values"),
+			Details: details,
+		}}
+	}
+
+	client, err := run.kubeClient()
+	if err != nil {
+		return checks.Fail(err)
+	}
+	pods, err := client.Clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return checks.Fail(fmt.Errorf("failed to list pods: %w", err))
+	}
+	own := renderedNamespaces(run.rendered)
+	var others []corev1.Pod
+	for _, pod := range pods.Items {
+		if own[pod.Namespace] == nil {
+			others = append(others, pod)
+		}
+	}
+	used := preflight.ScheduledRequests(others, schedulable)
+	free := allocatable
+	free.Sub(used)
+	if !need.Fits(free) {
+		return []checks.Result{{
+			Status:  report.StatusWarn,
+			Note:    note,
+			Hint:    fmt.Sprintf("other pods already request %s; not every component pod may be scheduled", used),
+			Details: details,
+		}}
+	}
+	return checks.OK(note)
+}
+
+// crdConflictResults fails on CRDs rendered by more than one component and
+// on existing CRDs another tool owns. CRDs nothing owns are adopted by
+// ArgoCD on sync and only lead to a warning.
+func crdConflictResults(ctx context.Context, run *checkRun) []checks.Result {
+	client, err := run.kubeClient()
+	if err != nil {
+		return checks.Fail(err)
+	}
+	renderedBy := make(map[string][]string)
+	for _, comp := range run.rendered.Components {
+		for _, obj := range comp.Objects {
+			if obj.Kind == "CustomResourceDefinition" && !slices.Contains(renderedBy[obj.Name], comp.Name) {
+				renderedBy[obj.Name] = append(renderedBy[obj.Name], comp.Name)
+			}
+		}
+	}
+
+	var results []checks.Result
+	var unowned []string
+	for _, name := range sortedKeys(renderedBy) {
+		comps := renderedBy[name]
+		res := checks.Result{ID: "crd-conflicts/" + name, Name: fmt.Sprintf("crd %s", name)}
+		if len(comps) > 1 {
+			res.Err = fmt.Errorf("CRD %s is rendered by %s\n  hint: install the CRDs from one component and disable them in the others", name, strings.Join(comps, ", "))
+			results = append(results, res)
+			continue
+		}
+		existing, err := client.DynamicClient.Resource(crdResource).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			res.Err = fmt.Errorf("failed to read CRD %s: %w", name, err)
+			results = append(results, res)
+			continue
+		}
+		owner := preflight.OwnerOf(existing.GetLabels(), existing.GetAnnotations())
+		switch {
+		case ownedByComponents(owner, comps):
+		case owner.IsZero():
+			unowned = append(unowned, fmt.Sprintf("%s: rendered by %s", name, comps[0]))
+		default:
+			res.Err = fmt.Errorf("CRD %s is managed by %s; component %s would take it over\n  hint: uninstall it from %s or disable the CRDs of %s", name, owner, comps[0], owner, comps[0])
+			results = append(results, res)
+		}
+	}
+	if len(unowned) > 0 {
+		results = append(results, checks.Result{
+			Status:  report.StatusWarn,
+			Note:    fmt.Sprintf("%d existing CRD(s) without an owner", len(unowned)),
+			Hint:    "ArgoCD adopts them on sync; make sure no other tool installed them",
+			Details: unowned,
+		})
+	}
+	if len(results) == 0 {
+		return checks.OK(fmt.Sprintf("%d CRD(s) rendered", len(renderedBy)))
+	}
+	return results
+}
+
+// namespaceConflictResults fails on namespaces the components deploy into
+// that are terminating or owned by another tool. Components may share a
+// namespace, so any enabled component owning it is accepted.
+func namespaceConflictResults(ctx context.Context, run *checkRun) []checks.Result {
+	client, err := run.kubeClient()
+	if err != nil {
+		return checks.Fail(err)
+	}
+	namespaces := renderedNamespaces(run.rendered)
+	var enabled []string
+	for _, comp := range run.rendered.Components {
+		enabled = append(enabled, comp.Name)
+	}
+
+	var results []checks.Result
+	existing := 0
+	for _, name := range sortedKeys(namespaces) {
+		ns, err := client.Clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		res := checks.Result{ID: "namespace-conflicts/" + name, Name: fmt.Sprintf("namespace %s", name)}
+		if err != nil {
+			res.Err = fmt.Errorf("failed to read namespace %s: %w", name, err)
+			results = append(results, res)
+			continue
+		}
+		existing++
+		comps := strings.Join(namespaces[name], ", ")
+		if ns.Status.Phase == corev1.NamespaceTerminating {
+			res.Err = fmt.Errorf("namespace %s is terminating; %s deploy into it\n  hint: wait for the deletion to finish or remove its finalizers", name, comps)
+			results = append(results, res)
+			continue
+		}
+		owner := preflight.OwnerOf(ns.Labels, ns.Annotations)
+		if owner.IsZero() || ownedByComponents(owner, enabled) {
+			continue
+		}
+		res.Err = fmt.Errorf("namespace %s is managed by %s; %s deploy into it\n  hint: deploy the components elsewhere or remove the namespace from %s", name, owner, comps, owner)
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		return checks.OK(fmt.Sprintf("%d namespace(s), %d existing", len(namespaces), existing))
+	}
+	return results
+}
+
+// ownedByComponents reports whether owner is the ArgoCD Application of one
+// of comps, or the Helm release bootstrap installs ArgoCD with.
+func ownedByComponents(owner preflight.Owner, comps []string) bool {
+	switch owner.Tool {
+	case "argocd":
+		return slices.Contains(comps, owner.Name)
+	case "helm":
+		return owner.Name == argoCDRelease && slices.Contains(comps, "argocd")
+	}
+	return false
+}
+
+// renderedNamespaces maps the namespaces the components deploy into to the
+// components deploying there.
+func renderedNamespaces(result *render.Result) map[string][]string {
+	out := make(map[string][]string)
+	add := func(ns, comp string) {
+		if ns != "" && !slices.Contains(out[ns], comp) {
+			out[ns] = append(out[ns], comp)
+		}
+	}
+	for _, comp := range result.Components {
+		add(comp.Namespace, comp.Name)
+		for _, obj := range comp.Objects {
+			if obj.Kind == "Namespace" {
+				add(obj.Name, comp.Name)
+			}
+			add(obj.Namespace, comp.Name)
+		}
+	}
+	return out
+}
+
+func listNodes(ctx context.Context, run *checkRun) ([]corev1.Node, error) {
+	client, err := run.kubeClient()
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w\n  hint: verify kubeconfig/context and cluster access", err)
+	}
+	return list.Items, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
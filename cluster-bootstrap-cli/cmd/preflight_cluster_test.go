@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+func preflightRun(t *testing.T, objects []runtime.Object, crds []runtime.Object, components map[string]string) *checkRun {
+	t.Helper()
+	result := &render.Result{Environment: "dev"}
+	for _, name := range sortedKeys(components) {
+		objs, err := render.ParseManifest(components[name], name)
+		require.NoError(t, err)
+		result.Components = append(result.Components, render.Component{
+			Application: render.Application{Name: name, Namespace: name},
+			Objects:     objs,
+		})
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdResource: "CustomResourceDefinitionList"}, crds...)
+	run := &checkRun{command: "preflight-cluster", env: "dev", rendered: result}
+	run.kube = &k8s.Client{Clientset: fake.NewSimpleClientset(objects...), DynamicClient: dyn}
+	return run
+}
+
+func testNode(name, cpu, memory string, conditions ...corev1.NodeCondition) *corev1.Node {
+	if len(conditions) == 0 {
+		conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+			Conditions: conditions,
+		},
+	}
+}
+
+const testDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: %d
+  template:
+    spec:
+      containers:
+        - name: web
+          resources:
+            requests:
+              cpu: 500m
+              memory: 1Gi
+`
+
+func TestCapacityResults(t *testing.T) {
+	busy := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "busy", Namespace: "kube-system"},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Containers: []corev1.Container{{
+				Name:      "busy",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	nodes := []runtime.Object{testNode("node-1", "2", "4Gi"), busy}
+
+	run := preflightRun(t, nodes, nil, map[string]string{"web": fmt.Sprintf(testDeployment, 2)})
+	results := capacityResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, report.StatusOK, results[0].Status)
+	assert.Equal(t, "1 CPU, 2.0Gi memory requested of 2 CPU, 4.0Gi memory allocatable on 1 node(s)", results[0].Note)
+
+	run = preflightRun(t, nodes, nil, map[string]string{"web": fmt.Sprintf(testDeployment, 3)})
+	results = capacityResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, report.StatusWarn, results[0].Status)
+	assert.Contains(t, results[0].Hint, "other pods already request 1 CPU")
+
+	run = preflightRun(t, nodes, nil, map[string]string{"web": fmt.Sprintf(testDeployment, 5)})
+	results = capacityResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "request more than the schedulable nodes allocate")
+	assert.Equal(t, []string{"web: 2.5 CPU, 5.0Gi memory"}, results[0].Details)
+}
+
+func TestNodeConditionResults(t *testing.T) {
+	pressured := testNode("node-2", "2", "4Gi",
+		corev1.NodeCondition{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+		corev1.NodeCondition{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue, Message: "disk full"})
+	run := preflightRun(t, []runtime.Object{testNode("node-1", "2", "4Gi"), pressured}, nil, nil)
+
+	results := nodeConditionResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "1 node condition(s) report pressure")
+	assert.Equal(t, []string{"node-2: DiskPressure: disk full"}, results[0].Details)
+}
+
+func TestStorageClassResults(t *testing.T) {
+	pvc := "apiVersion: v1\nkind: PersistentVolumeClaim\nmetadata:\n  name: data\nspec:\n  resources:\n    requests:\n      storage: 1Gi\n"
+	run := preflightRun(t, nil, nil, map[string]string{"db": pvc})
+	results := storageClassResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "no default StorageClass; 1 claim(s) rely on one")
+	assert.Equal(t, []string{"db: PersistentVolumeClaim/data claim data"}, results[0].Details)
+
+	standard := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+		Name:        "standard",
+		Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+	}}
+	run = preflightRun(t, []runtime.Object{standard}, nil, map[string]string{"db": pvc + "  storageClassName: fast\n"})
+	results = storageClassResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, "storage-class/fast", results[0].ID)
+	assert.ErrorContains(t, results[0].Err, "StorageClass fast does not exist")
+
+	run = preflightRun(t, []runtime.Object{standard}, nil, map[string]string{"db": pvc})
+	results = storageClassResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, "default standard, 1 claim(s)", results[0].Note)
+}
+
+func TestConflictResults(t *testing.T) {
+	crd := func(name string, labels, annotations map[string]string) runtime.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("apiextensions.k8s.io/v1")
+		obj.SetKind("CustomResourceDefinition")
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		obj.SetAnnotations(annotations)
+		return obj
+	}
+	manifest := func(names ...string) string {
+		out := ""
+		for _, name := range names {
+			out += "---\napiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: " + name + "\n"
+		}
+		return out
+	}
+	crds := []runtime.Object{
+		crd("foreign.example.com", nil, map[string]string{"meta.helm.sh/release-name": "other", "meta.helm.sh/release-namespace": "tools"}),
+		crd("owned.example.com", map[string]string{"app.kubernetes.io/instance": "operator"}, nil),
+		crd("loose.example.com", nil, nil),
+	}
+	namespaces := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "operator", Labels: map[string]string{"kustomize.toolkit.fluxcd.io/name": "infra", "kustomize.toolkit.fluxcd.io/namespace": "flux-system"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "argocd", Annotations: map[string]string{"meta.helm.sh/release-name": "argocd", "meta.helm.sh/release-namespace": "argocd"}}},
+	}
+	run := preflightRun(t, namespaces, crds, map[string]string{
+		"argocd":   "",
+		"operator": manifest("foreign.example.com", "owned.example.com", "loose.example.com", "shared.example.com"),
+		"extra":    manifest("shared.example.com"),
+	})
+
+	results := crdConflictResults(context.Background(), run)
+	require.Len(t, results, 3)
+	assert.Equal(t, "crd-conflicts/foreign.example.com", results[0].ID)
+	assert.ErrorContains(t, results[0].Err, "CRD foreign.example.com is managed by helm release tools/other")
+	assert.ErrorContains(t, results[1].Err, "CRD shared.example.com is rendered by extra, operator")
+	assert.Equal(t, report.StatusWarn, results[2].Status)
+	assert.Equal(t, []string{"loose.example.com: rendered by operator"}, results[2].Details)
+
+	results = namespaceConflictResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, "namespace-conflicts/operator", results[0].ID)
+	assert.ErrorContains(t, results[0].Err, "namespace operator is managed by flux kustomization flux-system/infra")
+}
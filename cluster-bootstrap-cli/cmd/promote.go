@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/diff"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/promote"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+var (
+	promoteComponents     []string
+	promoteKeys           []string
+	promoteTargetRevision string
+	promoteEncryption     string
+	promoteSecretsFile    string
+	promoteAgeKeyFile     string
+	promoteDryRun         bool
+	promoteForce          bool
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote <from> <to>",
+	Short: "Promote component values and the target revision between environments",
+	Long: `Copy values from one environment to the next.
+
+For each --component, values set in components/<name>/values/<from>.yaml are
+written to values/<to>.yaml. With --keys, only those paths are promoted, using
+their effective value in <from> (base.yaml merged with <from>.yaml). Values
+that already match are skipped, keys only set in <to> are kept, and comments
+and formatting of the target file are preserved.
+
+--target-revision sets repo.targetRevision in the target environment's
+secrets file. SOPS files are decrypted and re-encrypted in memory with the
+.sops.yaml rule for the file.
+
+The resulting diff is printed and confirmed before anything is written.
+
+Example:
+  cluster-bootstrap promote dev staging --component vault
+  cluster-bootstrap promote staging prod --component argocd --keys argo-cd.server.replicas
+  cluster-bootstrap promote staging prod --target-revision v1.4.0 --dry-run`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPromote,
+}
+
+func init() {
+	promoteCmd.Flags().StringSliceVar(&promoteComponents, "component", nil, "component whose values to promote (repeatable)")
+	promoteCmd.Flags().StringSliceVar(&promoteKeys, "keys", nil, "promote only these dotted values paths, e.g. argo-cd.server.replicas (repeatable)")
+	promoteCmd.Flags().StringVar(&promoteTargetRevision, "target-revision", "", "set repo.targetRevision in the target secrets file")
+	promoteCmd.Flags().StringVar(&promoteEncryption, "encryption", "sops", "encryption backend of the secrets file (sops|git-crypt)")
+	promoteCmd.Flags().StringVar(&promoteSecretsFile, "secrets-file", "", "path to the target secrets file (default: secrets.<to>.enc.yaml or secrets.<to>.yaml)")
+	promoteCmd.Flags().StringVar(&promoteAgeKeyFile, "age-key-file", "", "path to age private key file for SOPS decryption")
+	promoteCmd.Flags().BoolVar(&promoteDryRun, "dry-run", false, "print the diff without writing")
+	promoteCmd.Flags().BoolVar(&promoteForce, "force", false, "skip confirmation prompt")
+
+	rootCmd.AddCommand(promoteCmd)
+}
+
+func runPromote(cmd *cobra.Command, args []string) error {
+	from, to := args[0], args[1]
+	if len(promoteComponents) == 0 && promoteTargetRevision == "" {
+		return fmt.Errorf("nothing to promote\n  hint: pass --component <name> and/or --target-revision <rev>")
+	}
+	if len(promoteKeys) > 0 && len(promoteComponents) == 0 {
+		return fmt.Errorf("--keys requires --component")
+	}
+
+	stepf("Planning promotion from %s to %s...", from, to)
+	changes, err := promote.Plan(promote.Options{
+		BaseDir:    baseDir,
+		From:       from,
+		To:         to,
+		Components: promoteComponents,
+		Keys:       promoteKeys,
+	})
+	if err != nil {
+		return err
+	}
+
+	var secretsChange *promote.SecretsChange
+	if promoteTargetRevision != "" {
+		if err := resolveEncryption(cmd, to, &promoteEncryption); err != nil {
+			return err
+		}
+		secretsPath := promoteSecretsFile
+		if secretsPath == "" {
+			secretsPath = defaultSecretsPath(to, promoteEncryption)
+		}
+		secretsChange, err = promote.PlanTargetRevision(secretsPath, promoteEncryption, promoteTargetRevision, &sops.Options{AgeKeyFile: promoteAgeKeyFile})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(changes) == 0 && secretsChange == nil {
+		successf("%s is already in sync with %s; nothing to promote", to, from)
+		return nil
+	}
+
+	fmt.Println()
+	for _, change := range changes {
+		unified, err := diff.Unified(string(change.Original), string(change.Updated), "a/"+filepath.ToSlash(change.File), "b/"+filepath.ToSlash(change.File))
+		if err != nil {
+			return err
+		}
+		if change.Created {
+			fmt.Printf("%s (new file)\n", change.File)
+		}
+		printUnifiedDiff(unified)
+		fmt.Println()
+	}
+	if secretsChange != nil {
+		// Never print the decrypted file; only the changed key.
+		fmt.Printf("%s\n", stepColor(secretsChange.File))
+		fmt.Println(diffRemoveColor(fmt.Sprintf("-  repo.targetRevision: %s", dash(secretsChange.Old))))
+		fmt.Println(diffAddColor(fmt.Sprintf("+  repo.targetRevision: %s", secretsChange.New)))
+		fmt.Println()
+	}
+
+	edits := 0
+	for _, change := range changes {
+		edits += len(change.Edits)
+	}
+	summary := fmt.Sprintf("%d value(s) in %d file(s)", edits, len(changes))
+	if secretsChange != nil {
+		summary += " and repo.targetRevision"
+	}
+
+	if promoteDryRun {
+		successf("Dry run: would promote %s from %s to %s", summary, from, to)
+		return nil
+	}
+	if !promoteForce {
+		ok, err := confirm(fmt.Sprintf("Write %s to %s?", summary, to))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			warnf("Promotion cancelled")
+			return nil
+		}
+	}
+
+	for _, change := range changes {
+		if err := change.Write(baseDir); err != nil {
+			return err
+		}
+	}
+	if secretsChange != nil {
+		if err := secretsChange.Write(); err != nil {
+			return err
+		}
+	}
+	successf("Promoted %s from %s to %s", summary, from, to)
+	return nil
+}
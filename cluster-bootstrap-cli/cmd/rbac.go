@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/rbac"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+// rbacOptions describes what a bootstrap will change, for the permissions
+// it needs.
+type rbacOptions struct {
+	installArgoCD bool
+	knownHosts    bool
+	waitForHealth bool
+}
+
+// bootstrapPermissions returns the permissions a bootstrap with opts needs.
+// argoCD is the rendered ArgoCD chart, whose objects Helm gets, creates,
+// patches and deletes on upgrade. health are the components whose health
+// specs --wait-for-health reads.
+func bootstrapPermissions(opts rbacOptions, mapper meta.RESTMapper, argoCD []render.Object, health []components.Component) *rbac.Set {
+	set := rbac.NewSet()
+	set.Add("", "namespaces", "", "argocd namespace", "get", "create")
+	set.Add("", "secrets", "argocd", "repository credentials", "get", "create", "update")
+	if opts.knownHosts {
+		set.Add("", "configmaps", "argocd", "SSH known hosts", "get", "create", "update")
+	}
+	set.Add("argoproj.io", "applications", "argocd", "App of Apps", "get", "create", "patch")
+	if opts.installArgoCD {
+		set.Add("", "secrets", "argocd", "Helm release", "list", "get", "create", "update")
+		set.AddObjects(mapper, argoCD, "argocd", "ArgoCD chart", "get", "create", "patch", "delete")
+		set.Add("", "pods", "argocd", "Helm --wait", "list", "get")
+		set.Add("apps", "replicasets", "argocd", "Helm --wait", "list")
+	}
+	if opts.waitForHealth {
+		for _, comp := range health {
+			addHealthPermissions(set, mapper, comp)
+		}
+	}
+	return set
+}
+
+// addHealthPermissions adds what the health check of comp reads: its
+// namespace, which it watches when comp has a health spec, then the
+// workloads it discovers and the workloads, CRDs and custom resources it
+// watches.
+func addHealthPermissions(set *rbac.Set, mapper meta.RESTMapper, comp components.Component) {
+	const reason = "health checks"
+	set.Add("", "namespaces", "", reason, "get")
+	if comp.Health != nil {
+		set.Add("", "namespaces", "", reason, "list", "watch")
+	}
+	spec := comp.HealthSpec()
+	if spec.Workloads == nil {
+		for _, resource := range []string{"deployments", "statefulsets", "daemonsets"} {
+			set.Add("apps", resource, comp.Namespace, reason, "list", "watch")
+		}
+	}
+	for _, w := range spec.Workloads {
+		set.Add("apps", strings.ToLower(w.Kind)+"s", w.Namespace, reason, "list", "watch")
+	}
+	if len(spec.CRDs) > 0 {
+		set.Add("apiextensions.k8s.io", "customresourcedefinitions", "", reason, "list", "watch")
+	}
+	objs := make([]render.Object, 0, len(spec.Resources))
+	for _, r := range spec.Resources {
+		objs = append(objs, render.Object{APIVersion: r.APIVersion, Kind: r.Kind, Name: r.Name, Namespace: r.Namespace})
+	}
+	set.AddObjects(mapper, objs, comp.Namespace, reason, "list", "watch")
+}
+
+// rbacResults reviews the permissions the bootstrap needs and, when some
+// are denied, lists them with a ClusterRole that grants them.
+func rbacResults(ctx context.Context, run *checkRun) []checks.Result {
+	if run.rbac == nil {
+		return checks.Skip("skipped")
+	}
+	client, err := run.kubeClient()
+	if err != nil {
+		return checks.Fail(err)
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client.Clientset.Discovery()))
+	var argoCD []render.Object
+	if run.rbac.installArgoCD {
+		kubeVersion, _ := run.clusterVersion()
+		manifest, err := helm.RenderArgoCD(baseDir, run.env, kubeVersion, verbose)
+		if err != nil {
+			return checks.Fail(fmt.Errorf("failed to render the ArgoCD chart: %w", err))
+		}
+		if argoCD, err = render.ParseManifest(manifest, "argocd"); err != nil {
+			return checks.Fail(fmt.Errorf("failed to parse the ArgoCD chart: %w", err))
+		}
+	}
+	var health []components.Component
+	if run.rbac.waitForHealth {
+		catalog, err := components.Load(baseDir, run.env)
+		if err != nil {
+			return checks.Fail(err)
+		}
+		health = catalog.Enabled()
+	}
+
+	set := bootstrapPermissions(*run.rbac, mapper, argoCD, health)
+	denials, reviewed, err := rbac.Review(ctx, client.Clientset, set.Requirements())
+	if err != nil {
+		return checks.Fail(fmt.Errorf("%w\n  hint: verify kubeconfig/context and cluster access", err))
+	}
+	if len(denials) == 0 {
+		return checks.OK(fmt.Sprintf("%d permission(s) allowed", reviewed))
+	}
+
+	roleName := "cluster-bootstrap-" + run.env
+	role, err := rbac.ClusterRole(roleName, denials)
+	if err != nil {
+		return checks.Fail(err)
+	}
+	details := rbac.Table(denials)
+	details = append(details, "", "ClusterRole granting them:")
+	details = append(details, strings.Split(strings.TrimRight(role, "\n"), "\n")...)
+	return []checks.Result{{
+		Note:    fmt.Sprintf("%d of %d denied", len(denials), reviewed),
+		Err:     fmt.Errorf("%d permission(s) the bootstrap needs are missing\n  hint: apply the ClusterRole below and bind it to your user, e.g. kubectl create clusterrolebinding %s --clusterrole %s --user <user>", len(denials), roleName, roleName),
+		Details: details,
+	}}
+}
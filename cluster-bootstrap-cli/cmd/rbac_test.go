@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+func TestBootstrapPermissions(t *testing.T) {
+	argoCD, err := render.ParseManifest("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: argocd-server\n", "argocd")
+	require.NoError(t, err)
+
+	health := []components.Component{
+		{Name: "reloader", Namespace: "reloader"},
+		{Name: "argocd", Namespace: "argocd", Health: &components.HealthSpec{
+			Workloads: []components.HealthWorkload{{Kind: "Deployment", Name: "argocd-server"}},
+			CRDs:      []string{"applications.argoproj.io"},
+			Resources: []components.HealthResource{{APIVersion: "argoproj.io/v1alpha1", Kind: "AppProject", Name: "default"}},
+		}},
+	}
+	resources := func(opts rbacOptions) []string {
+		var out []string
+		for _, req := range bootstrapPermissions(opts, nil, argoCD, health).Requirements() {
+			out = append(out, req.Group+"/"+req.Resource+"@"+req.Namespace)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"/namespaces@", "/secrets@argocd", "argoproj.io/applications@argocd"}, resources(rbacOptions{}))
+	assert.Equal(t, []string{
+		"/configmaps@argocd", "/namespaces@", "/pods@argocd", "/secrets@argocd",
+		"apiextensions.k8s.io/customresourcedefinitions@",
+		"apps/daemonsets@reloader", "apps/deployments@argocd", "apps/deployments@reloader", "apps/replicasets@argocd", "apps/statefulsets@reloader",
+		"argoproj.io/applications@argocd", "argoproj.io/appprojects@argocd",
+	}, resources(rbacOptions{installArgoCD: true, knownHosts: true, waitForHealth: true}))
+}
+
+func TestRbacResults(t *testing.T) {
+	assert.Equal(t, checks.Skip("skipped"), rbacResults(context.Background(), &checkRun{}))
+
+	clientset := fake.NewSimpleClientset()
+	allow := true
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = allow || review.Spec.ResourceAttributes.Resource != "applications"
+		return true, review, nil
+	})
+	run := &checkRun{env: "dev", rbac: &rbacOptions{knownHosts: true}, kube: &k8s.Client{Clientset: clientset}}
+
+	results := rbacResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, report.StatusOK, results[0].Status)
+	assert.Equal(t, "11 permission(s) allowed", results[0].Note)
+
+	allow = false
+	results = rbacResults(context.Background(), run)
+	require.Len(t, results, 1)
+	assert.Equal(t, "3 of 11 denied", results[0].Note)
+	assert.ErrorContains(t, results[0].Err, "3 permission(s) the bootstrap needs are missing")
+	assert.ErrorContains(t, results[0].Err, "kubectl create clusterrolebinding cluster-bootstrap-dev --clusterrole cluster-bootstrap-dev")
+	assert.Contains(t, results[0].Details, "applications.argoproj.io  argocd     get,create,patch  App of Apps")
+	assert.Contains(t, results[0].Details, "  - apiGroups: [argoproj.io]")
+}
@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/secrets"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sshkey"
+)
+
+var (
+	repoEncryption     string
+	repoSecretsFile    string
+	repoAgeKeyFile     string
+	repoURL            string
+	repoTargetRevision string
+	repoForce          bool
+	repoKubeconfig     string
+	repoContext        string
+	repoTimeout        int
+	repoYes            bool
+	repoRefresh        bool
+	repoPin            bool
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Manage the repository deploy key",
+	Long: `Manage the SSH deploy key ArgoCD uses to read the GitOps repository.
+The private key is stored as repo.sshPrivateKey in the environment's
+secrets file and applied to the cluster as the argocd/repo-ssh-key secret.`,
+}
+
+var repoKeygenCmd = &cobra.Command{
+	Use:   "keygen <environment>",
+	Short: "Generate a deploy key and store it in the secrets file",
+	Long: `Generate an ed25519 key pair, write the private key to repo.sshPrivateKey
+in the environment's secrets file and print the public key with its OpenSSH
+fingerprint, to be added to the repository as a read-only deploy key.
+
+If the secrets file does not exist yet, it is created; pass --repo-url.
+An existing key is only replaced with --force; to replace the key of a
+bootstrapped cluster, use 'repo rotate-key'.
+
+Example:
+  cluster-bootstrap repo keygen dev --repo-url git@github.com:org/platform.git
+  cluster-bootstrap repo keygen staging --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRepoKeygen,
+}
+
+var repoRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key <environment>",
+	Short: "Replace the deploy key and verify ArgoCD can still reach the repository",
+	Long: `Rotate the deploy key of a bootstrapped environment:
+
+  1. generate a new ed25519 key pair and print the public key
+  2. wait until you have added it to the repository as a deploy key
+  3. write the private key to the secrets file
+  4. apply the argocd/repo-ssh-key secret
+  5. hard-refresh the app-of-apps Application and check that ArgoCD can
+     fetch the repository with the new key
+
+Only when the check passes are you told to remove the old deploy key. If it
+fails, the previous key is restored in the secrets file and the cluster.
+
+Example:
+  cluster-bootstrap repo rotate-key prod
+  cluster-bootstrap repo rotate-key prod --context prod-cluster --timeout 300`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRepoRotateKey,
+}
+
+var repoKnownHostsCmd = &cobra.Command{
+	Use:   "known-hosts <environment>",
+	Short: "Show and refresh the SSH host keys ArgoCD trusts",
+	Long: `Fetch the SSH host keys of the repository server and of every host pinned
+in .cluster-bootstrap.yaml, check them against the pinned fingerprints and
+compare them with the argocd-ssh-known-hosts-cm ConfigMap.
+
+Fingerprints are pinned by repo.hostKeyFingerprints in the secrets file, or
+per host under knownHosts in .cluster-bootstrap.yaml. Hosts without pins are
+checked against the keys already in the cluster. A mismatch is an error.
+
+With --refresh the keys are merged into the ConfigMap; entries for other
+hosts are kept. With --pin the fingerprints of hosts not pinned yet are
+recorded in .cluster-bootstrap.yaml.
+
+Example:
+  cluster-bootstrap repo known-hosts dev
+  cluster-bootstrap repo known-hosts dev --refresh
+  cluster-bootstrap repo known-hosts dev --pin`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRepoKnownHosts,
+}
+
+func init() {
+	repoCmd.PersistentFlags().StringVar(&repoEncryption, "encryption", "sops", "encryption backend (sops|git-crypt)")
+	repoCmd.PersistentFlags().StringVar(&repoSecretsFile, "secrets-file", "", "path to the secrets file (default: secrets.<env>.enc.yaml or secrets.<env>.yaml)")
+	repoCmd.PersistentFlags().StringVar(&repoAgeKeyFile, "age-key-file", "", "path to age private key file for SOPS")
+
+	repoKeygenCmd.Flags().StringVar(&repoURL, "repo-url", "", "repository URL, required when the secrets file does not exist")
+	repoKeygenCmd.Flags().StringVar(&repoTargetRevision, "target-revision", "main", "target revision for a new secrets file")
+	repoKeygenCmd.Flags().BoolVar(&repoForce, "force", false, "replace an existing key")
+
+	repoRotateKeyCmd.Flags().StringVar(&repoKubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	repoRotateKeyCmd.Flags().StringVar(&repoContext, "context", "", "kubeconfig context to use")
+	repoRotateKeyCmd.Flags().IntVar(&repoTimeout, "timeout", 120, "timeout in seconds for ArgoCD to fetch the repository with the new key")
+	repoRotateKeyCmd.Flags().BoolVar(&repoYes, "yes", false, "do not wait for confirmation that the deploy key was added")
+
+	repoKnownHostsCmd.Flags().StringVar(&repoKubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	repoKnownHostsCmd.Flags().StringVar(&repoContext, "context", "", "kubeconfig context to use")
+	repoKnownHostsCmd.Flags().BoolVar(&repoRefresh, "refresh", false, "merge the host keys into the argocd-ssh-known-hosts-cm ConfigMap")
+	repoKnownHostsCmd.Flags().BoolVar(&repoPin, "pin", false, "record the fingerprints of unpinned hosts in .cluster-bootstrap.yaml")
+
+	repoCmd.AddCommand(repoKeygenCmd)
+	repoCmd.AddCommand(repoRotateKeyCmd)
+	repoCmd.AddCommand(repoKnownHostsCmd)
+	rootCmd.AddCommand(repoCmd)
+}
+
+func repoSecretsFileFor(env string) *secrets.File {
+	path := repoSecretsFile
+	if path == "" {
+		path = defaultSecretsPath(env, repoEncryption)
+	}
+	return &secrets.File{
+		Path:       path,
+		Encryption: repoEncryption,
+		SopsOpts:   &sops.Options{AgeKeyFile: repoAgeKeyFile},
+	}
+}
+
+func deployKeyComment(env string) string {
+	return "cluster-bootstrap-" + env
+}
+
+func runRepoKeygen(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	if err := resolveEncryption(cmd, env, &repoEncryption); err != nil {
+		return err
+	}
+	file := repoSecretsFileFor(env)
+
+	var plaintext []byte
+	if _, err := os.Stat(file.Path); errors.Is(err, fs.ErrNotExist) {
+		if repoURL == "" {
+			return fmt.Errorf("%s does not exist\n  hint: pass --repo-url to create it", file.Path)
+		}
+		if err := validateRepoURL(repoURL); err != nil {
+			return err
+		}
+		plaintext, err = yaml.Marshal(config.EnvironmentSecrets{Repo: config.RepoSecrets{URL: repoURL, TargetRevision: repoTargetRevision}})
+		if err != nil {
+			return fmt.Errorf("failed to marshal secrets: %w", err)
+		}
+	} else {
+		if repoURL != "" {
+			return fmt.Errorf("%s already exists; --repo-url only applies to new files\n  hint: use 'cluster-bootstrap secrets set %s repo.url=<url>'", file.Path, env)
+		}
+		plaintext, err = file.Read()
+		if err != nil {
+			return err
+		}
+		var current config.EnvironmentSecrets
+		if err := yaml.Unmarshal(plaintext, &current); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file.Path, err)
+		}
+		if current.Repo.SSHPrivateKey != "" && !repoForce {
+			return fmt.Errorf("%s already has a deploy key\n  hint: use 'cluster-bootstrap repo rotate-key %s' for a bootstrapped cluster, or --force to replace it", file.Path, env)
+		}
+	}
+
+	key, err := sshkey.Generate(deployKeyComment(env))
+	if err != nil {
+		return err
+	}
+	updated, err := secrets.Set(plaintext, []secrets.Assignment{{Path: []string{"repo", "sshPrivateKey"}, Value: key.PrivateKey}})
+	if err != nil {
+		return err
+	}
+	if err := file.Write(updated); err != nil {
+		return err
+	}
+	successf("Wrote new deploy key to %s", file.Path)
+
+	printDeployKey(key)
+	return nil
+}
+
+func printDeployKey(key *sshkey.KeyPair) {
+	fmt.Println()
+	fmt.Println("Add this public key to the repository as a read-only deploy key:")
+	fmt.Println()
+	fmt.Printf("  %s\n", key.AuthorizedKey)
+	fmt.Println()
+	fmt.Printf("  Fingerprint: %s\n", key.Fingerprint)
+	fmt.Println()
+}
+
+func runRepoRotateKey(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	if err := resolveEncryption(cmd, env, &repoEncryption); err != nil {
+		return err
+	}
+	file := repoSecretsFileFor(env)
+
+	original, err := file.Read()
+	if err != nil {
+		return err
+	}
+	current, err := config.ParseSecrets(original)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Path, err)
+	}
+	oldFingerprint, err := sshkey.Fingerprint(current.Repo.SSHPrivateKey)
+	if err != nil {
+		warnf("Could not read the current key: %v", err)
+		oldFingerprint = "unknown"
+	}
+
+	client, err := k8s.NewClient(repoKubeconfig, repoContext)
+	if err != nil {
+		return err
+	}
+	lookupCtx, cancelLookup := context.WithTimeout(context.Background(), time.Duration(repoTimeout)*time.Second)
+	err = requireAppOfApps(lookupCtx, client)
+	cancelLookup()
+	if err != nil {
+		return err
+	}
+
+	key, err := sshkey.Generate(deployKeyComment(env))
+	if err != nil {
+		return err
+	}
+	stepf("Generated new deploy key for %s", env)
+	printDeployKey(key)
+	fmt.Printf("Keep the current key (%s) registered until the rotation has been verified.\n", oldFingerprint)
+
+	if !repoYes {
+		ok, err := confirm("Has the new deploy key been added to the repository?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			warnf("Rotation cancelled; nothing was changed")
+			return nil
+		}
+	}
+
+	updated, err := secrets.Set(original, []secrets.Assignment{{Path: []string{"repo", "sshPrivateKey"}, Value: key.PrivateKey}})
+	if err != nil {
+		return err
+	}
+	if err := file.Write(updated); err != nil {
+		return err
+	}
+	stepf("Updated %s", file.Path)
+
+	// The timeout starts once the key is in place, not while the prompt waits.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(repoTimeout)*time.Second)
+	defer cancel()
+	if err := applyAndVerifyDeployKey(ctx, client, current.Repo.URL, key.PrivateKey); err != nil {
+		errorf("%v", err)
+		stepf("Restoring the previous key...")
+		if _, _, restoreErr := client.CreateRepoSSHSecret(context.Background(), current.Repo.URL, current.Repo.SSHPrivateKey, false); restoreErr != nil {
+			return fmt.Errorf("rotation failed and restoring argocd/repo-ssh-key also failed: %w", restoreErr)
+		}
+		if restoreErr := file.Write(original); restoreErr != nil {
+			return fmt.Errorf("rotation failed and restoring %s also failed: %w", file.Path, restoreErr)
+		}
+		return fmt.Errorf("deploy key rotation failed; the previous key was restored: %w\n  hint: check that the new public key is added to the repository with read access", err)
+	}
+
+	successf("ArgoCD fetched the repository with the new key")
+	fmt.Println()
+	fmt.Printf("You can now remove the old deploy key from the repository: %s\n", oldFingerprint)
+	return nil
+}
+
+func requireAppOfApps(ctx context.Context, client *k8s.Client) error {
+	apps, err := client.ListApplications(ctx)
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if app.GetName() == k8s.AppOfAppsName {
+			return nil
+		}
+	}
+	return fmt.Errorf("application %s not found in the argocd namespace\n  hint: rotate-key needs a bootstrapped cluster to verify the key; use 'repo keygen --force' otherwise", k8s.AppOfAppsName)
+}
+
+func applyAndVerifyDeployKey(ctx context.Context, client *k8s.Client, url, privateKey string) error {
+	if _, _, err := client.CreateRepoSSHSecret(ctx, url, privateKey, false); err != nil {
+		return err
+	}
+	stepf("Applied argocd/repo-ssh-key")
+
+	stepf("Waiting for ArgoCD to fetch %s with the new key...", url)
+	if err := client.HardRefreshApplication(ctx, k8s.AppOfAppsName); err != nil {
+		return err
+	}
+	state, err := client.WaitForRepoConnection(ctx, k8s.AppOfAppsName, 3*time.Second)
+	if err != nil {
+		return err
+	}
+	if state.Status != k8s.ConnectionSuccessful {
+		return fmt.Errorf("ArgoCD could not fetch the repository: %s", state.Message)
+	}
+	return nil
+}
+
+func runRepoKnownHosts(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	if err := resolveEncryption(cmd, env, &repoEncryption); err != nil {
+		return err
+	}
+	file := repoSecretsFileFor(env)
+	plaintext, err := file.Read()
+	if err != nil {
+		return err
+	}
+	envSecrets, err := config.ParseSecrets(plaintext)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file.Path, err)
+	}
+
+	ctx := context.Background()
+	var client *k8s.Client
+	current := knownhosts.Parse("")
+	data, found, err := readClusterKnownHosts(ctx, &client)
+	if err != nil {
+		if repoRefresh {
+			return err
+		}
+		warnf("Not comparing with the cluster: %v", err)
+	} else {
+		current = knownhosts.Parse(data)
+	}
+
+	hosts, err := fetchKnownHosts(ctx, envSecrets.Repo, current)
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		fmt.Printf("%s is not an SSH URL and no hosts are pinned in %s\n", envSecrets.Repo.URL, config.RepoConfigFile)
+		return nil
+	}
+
+	fmt.Printf("%-28s %-20s %-51s %-24s %s\n", "HOST", "TYPE", "FINGERPRINT", "PINNED", "CLUSTER")
+	for _, h := range hosts {
+		inCluster := current.Keys(h.Host)
+		for _, key := range h.Keys {
+			status := "-"
+			if client != nil {
+				status = "missing"
+				if slices.ContainsFunc(inCluster, func(k ssh.PublicKey) bool { return bytes.Equal(k.Marshal(), key.Marshal()) }) {
+					status = "present"
+				}
+			}
+			fmt.Printf("%-28s %-20s %-51s %-24s %s\n", h.Host, key.Type(), knownhosts.Fingerprint(key), dash(h.PinSource), status)
+		}
+	}
+	fmt.Println()
+
+	if repoPin {
+		for _, h := range hosts {
+			if h.PinSource == pinSourceSecrets || h.PinSource == pinSourceConfig {
+				continue
+			}
+			fingerprints := make([]string, 0, len(h.Keys))
+			for _, key := range h.Keys {
+				fingerprints = append(fingerprints, knownhosts.Fingerprint(key))
+			}
+			if err := config.SetKnownHostPins(baseDir, h.Host, fingerprints); err != nil {
+				return err
+			}
+			successf("Pinned %s in %s", h.Host, config.RepoConfigFile)
+		}
+	}
+
+	if repoRefresh {
+		if !mergeKnownHosts(current, hosts) && found {
+			successf("%s is up to date", knownhosts.ConfigMapName)
+			return nil
+		}
+		if _, err := client.ApplySSHKnownHosts(ctx, current.String()); err != nil {
+			return err
+		}
+		successf("Updated argocd/%s", knownhosts.ConfigMapName)
+	} else if client != nil {
+		fmt.Println("Run with --refresh to merge these keys into the cluster.")
+	}
+	return nil
+}
+
+// readClusterKnownHosts connects to the cluster and returns the content of
+// the known hosts ConfigMap, setting *client on success.
+func readClusterKnownHosts(ctx context.Context, client **k8s.Client) (string, bool, error) {
+	c, err := k8s.NewClient(repoKubeconfig, repoContext)
+	if err != nil {
+		return "", false, err
+	}
+	data, found, err := c.GetSSHKnownHosts(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	*client = c
+	return data, found, nil
+}
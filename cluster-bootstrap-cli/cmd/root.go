@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
 )
 
 var (
@@ -30,11 +33,30 @@ and deploy the App of Apps pattern.`,
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s %v\n", errorColor("ERROR"), err)
-		os.Exit(1)
+		code := 1
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.code
+		}
+		if code == report.ExitWarning {
+			fmt.Fprintf(os.Stderr, "%s %v\n", warningColor("WARN"), err)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s %v\n", errorColor("ERROR"), err)
+		}
+		os.Exit(code)
 	}
 }
 
+// exitError ends the command with a specific exit status instead of 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
 func stepf(format string, args ...interface{}) {
 	fmt.Printf("%s %s\n", stepColor("==>"), fmt.Sprintf(format, args...))
 }
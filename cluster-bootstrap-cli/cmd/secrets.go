@@ -0,0 +1,502 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/secrets"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+var (
+	secretsEncryption string
+	secretsFilePath   string
+	secretsAgeKeyFile string
+	secretsReveal     bool
+	secretsFromFile   []string
+	secretsRotateAll  bool
+	secretsAdd        []string
+	secretsRemove     []string
+	secretsSkipVerify bool
+	secretsMigrateTo  string
+	secretsRecipients []string
+	secretsForce      bool
+)
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "View and edit encrypted environment secrets",
+	Long: `View and edit the per-environment secrets file (secrets.<env>.enc.yaml
+for SOPS, secrets.<env>.yaml for git-crypt) without running sops by hand.
+
+SOPS files are decrypted and re-encrypted in memory with the .sops.yaml rule
+for the file. git-crypt files must be unlocked; they are encrypted by
+git-crypt when committed. Changes are validated against the secrets schema
+before anything is written.`,
+}
+
+var secretsViewCmd = &cobra.Command{
+	Use:   "view <environment>",
+	Short: "Print the secrets file with secret values redacted",
+	Long: `Print the decrypted secrets file. Values other than repo.url and
+repo.targetRevision are replaced with <redacted> unless --reveal is passed.
+
+Example:
+  cluster-bootstrap secrets view dev
+  cluster-bootstrap secrets view dev --reveal`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretsView,
+}
+
+var secretsEditCmd = &cobra.Command{
+	Use:   "edit <environment>",
+	Short: "Edit the secrets file in $EDITOR",
+	Long: `Decrypt the secrets file to a private temporary file, open it in
+$VISUAL or $EDITOR (default: vi), validate the result and re-encrypt it in
+place. The temporary file is readable only by you and is removed afterwards.
+
+Example:
+  cluster-bootstrap secrets edit staging
+  EDITOR="code --wait" cluster-bootstrap secrets edit staging`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretsEdit,
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <environment> [path=value...]",
+	Short: "Set values in the secrets file",
+	Long: `Set one or more values non-interactively. Paths are dotted, e.g.
+repo.targetRevision. Use --from-file to read a value from a file, such as
+an SSH private key.
+
+Example:
+  cluster-bootstrap secrets set prod repo.targetRevision=v2
+  cluster-bootstrap secrets set prod --from-file repo.sshPrivateKey=./id_ed25519`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSecretsSet,
+}
+
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate <environment...|--all>",
+	Short: "Add or remove SOPS recipients and rotate the data key",
+	Long: `Update the .sops.yaml creation rule of each environment and re-encrypt
+its secrets file under a fresh data key.
+
+--add-recipient and --remove-recipient take age public keys, AWS KMS key ARNs
+or GCP KMS key resource IDs. Without them only the data key is rotated.
+
+Every file is re-encrypted and verified in memory first: its metadata must
+list exactly the new recipients, and it must decrypt to the same content
+with your key. If any environment fails, .sops.yaml is restored and no file
+is written. Removing your own key makes verification impossible; pass
+--skip-verify in that case.
+
+Example:
+  cluster-bootstrap secrets rotate prod --add-recipient age1...
+  cluster-bootstrap secrets rotate --all --remove-recipient age1...
+  cluster-bootstrap secrets rotate staging`,
+	RunE: runSecretsRotate,
+}
+
+var secretsMigrateCmd = &cobra.Command{
+	Use:   "migrate <environment> --to sops|git-crypt",
+	Short: "Move an environment to another encryption backend",
+	Long: `Re-write an environment's secrets file with another encryption backend:
+secrets.<env>.yaml (git-crypt) becomes secrets.<env>.enc.yaml (SOPS) or the
+other way around.
+
+Migrating to SOPS needs a .sops.yaml creation rule for the new file; pass
+--recipient to add or update the environment's rule. Migrating to git-crypt
+needs an initialised git-crypt repository; the git-crypt pattern is added to
+.gitattributes and the environment's SOPS rule is removed.
+
+The new file is read back and compared with the old one before the old file
+is removed. The backend is then recorded in .cluster-bootstrap.yaml in the
+base directory, and later commands use it when --encryption is not given.
+
+Example:
+  cluster-bootstrap secrets migrate dev --to sops --recipient age1...
+  cluster-bootstrap secrets migrate prod --to git-crypt`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSecretsMigrate,
+}
+
+func init() {
+	secretsCmd.PersistentFlags().StringVar(&secretsEncryption, "encryption", "sops", "encryption backend (sops|git-crypt)")
+	secretsCmd.PersistentFlags().StringVar(&secretsFilePath, "secrets-file", "", "path to the secrets file (default: secrets.<env>.enc.yaml or secrets.<env>.yaml)")
+	secretsCmd.PersistentFlags().StringVar(&secretsAgeKeyFile, "age-key-file", "", "path to age private key file for SOPS")
+	secretsViewCmd.Flags().BoolVar(&secretsReveal, "reveal", false, "print secret values in plaintext")
+	secretsSetCmd.Flags().StringArrayVar(&secretsFromFile, "from-file", nil, "set path to the contents of a file, as path=file (repeatable)")
+
+	secretsCmd.AddCommand(secretsViewCmd)
+	secretsCmd.AddCommand(secretsEditCmd)
+	secretsRotateCmd.Flags().BoolVar(&secretsRotateAll, "all", false, "rotate every secrets.<env>.enc.yaml in the base directory")
+	secretsRotateCmd.Flags().StringSliceVar(&secretsAdd, "add-recipient", nil, "recipient to add (repeatable)")
+	secretsRotateCmd.Flags().StringSliceVar(&secretsRemove, "remove-recipient", nil, "recipient to remove (repeatable)")
+	secretsRotateCmd.Flags().BoolVar(&secretsSkipVerify, "skip-verify", false, "do not decrypt the re-encrypted files with the local key")
+
+	secretsCmd.AddCommand(secretsSetCmd)
+	secretsCmd.AddCommand(secretsRotateCmd)
+	secretsMigrateCmd.Flags().StringVar(&secretsMigrateTo, "to", "", "target encryption backend (sops|git-crypt)")
+	secretsMigrateCmd.Flags().StringSliceVar(&secretsRecipients, "recipient", nil, "SOPS recipient for the environment's creation rule (repeatable)")
+	secretsMigrateCmd.Flags().BoolVar(&secretsForce, "force", false, "overwrite an existing target file")
+	_ = secretsMigrateCmd.MarkFlagRequired("to") //#nosec G104 -- error only occurs if flag doesn't exist, which is impossible here
+	secretsCmd.AddCommand(secretsMigrateCmd)
+	rootCmd.AddCommand(secretsCmd)
+}
+
+// resolveEncryption sets *backend from the repository config file when the
+// command's --encryption flag was not given, so environments migrated with
+// 'secrets migrate' are picked up automatically.
+func resolveEncryption(cmd *cobra.Command, env string, backend *string) error {
+	if flag := cmd.Flag("encryption"); flag != nil && flag.Changed {
+		return config.ValidateEncryption(*backend)
+	}
+	recorded, ok, err := config.EncryptionBackend(baseDir, env)
+	if err != nil {
+		return err
+	}
+	if ok {
+		*backend = recorded
+	}
+	return config.ValidateEncryption(*backend)
+}
+
+// defaultSecretsPath returns the conventional secrets file for env under baseDir.
+func defaultSecretsPath(env, encryption string) string {
+	if encryption == "git-crypt" {
+		return filepath.Join(baseDir, config.SecretsFileNamePlain(env))
+	}
+	return filepath.Join(baseDir, config.SecretsFileName(env))
+}
+
+func secretsFileFor(env string) *secrets.File {
+	path := secretsFilePath
+	if path == "" {
+		path = defaultSecretsPath(env, secretsEncryption)
+	}
+	return &secrets.File{
+		Path:       path,
+		Encryption: secretsEncryption,
+		SopsOpts:   &sops.Options{AgeKeyFile: secretsAgeKeyFile},
+	}
+}
+
+func runSecretsView(cmd *cobra.Command, args []string) error {
+	if err := resolveEncryption(cmd, args[0], &secretsEncryption); err != nil {
+		return err
+	}
+	file := secretsFileFor(args[0])
+	plaintext, err := file.Read()
+	if err != nil {
+		return err
+	}
+	if !secretsReveal {
+		if plaintext, err = secrets.Redact(plaintext); err != nil {
+			return err
+		}
+	}
+	_, err = os.Stdout.Write(plaintext)
+	return err
+}
+
+func runSecretsEdit(cmd *cobra.Command, args []string) error {
+	if err := resolveEncryption(cmd, args[0], &secretsEncryption); err != nil {
+		return err
+	}
+	file := secretsFileFor(args[0])
+	plaintext, err := file.Read()
+	if err != nil {
+		return err
+	}
+
+	// MkdirTemp creates the directory with 0700, so only the current user
+	// can read the decrypted copy.
+	dir, err := os.MkdirTemp("", "cluster-bootstrap-secrets-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	tmpPath := filepath.Join(dir, config.SecretsFileNamePlain(args[0]))
+	if err := os.WriteFile(tmpPath, plaintext, 0600); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(tmpPath) // #nosec G304 -- tmpPath is inside our own temp directory
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+		if bytes.Equal(edited, plaintext) {
+			warnf("No changes; %s left untouched", file.Path)
+			return nil
+		}
+
+		_, err = config.ParseSecrets(edited)
+		if err == nil {
+			if err := file.Write(edited); err != nil {
+				return err
+			}
+			successf("Updated %s", file.Path)
+			return nil
+		}
+
+		errorf("%v", err)
+		again, confirmErr := confirm("Re-open the editor?")
+		if confirmErr != nil {
+			return confirmErr
+		}
+		if !again {
+			return fmt.Errorf("%s left untouched: %w", file.Path, err)
+		}
+	}
+}
+
+// runEditor opens path in $VISUAL, $EDITOR or vi, attached to the terminal.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		editor, fields = "vi", []string{"vi"}
+	}
+	c := exec.Command(fields[0], append(fields[1:], path)...) // #nosec G204 -- the editor is chosen by the user
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w\n  hint: set EDITOR to your editor, e.g. EDITOR=\"code --wait\"", editor, err)
+	}
+	return nil
+}
+
+func runSecretsSet(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	if err := resolveEncryption(cmd, env, &secretsEncryption); err != nil {
+		return err
+	}
+	var assignments []secrets.Assignment
+	for _, arg := range args[1:] {
+		a, err := secrets.ParseAssignment(arg)
+		if err != nil {
+			return err
+		}
+		assignments = append(assignments, a)
+	}
+	for _, arg := range secretsFromFile {
+		a, err := secrets.ParseAssignment(arg)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(a.Value) // #nosec G304 -- the path is provided by the user
+		if err != nil {
+			return fmt.Errorf("failed to read %s for %s: %w", a.Value, yamledit.JoinPath(a.Path...), err)
+		}
+		a.Value = string(data)
+		assignments = append(assignments, a)
+	}
+	if len(assignments) == 0 {
+		return fmt.Errorf("nothing to set\n  hint: pass path=value arguments or --from-file path=file")
+	}
+
+	file := secretsFileFor(env)
+	plaintext, err := file.Read()
+	if err != nil {
+		return err
+	}
+	updated, err := secrets.Set(plaintext, assignments)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(updated, plaintext) {
+		successf("%s is already up to date", file.Path)
+		return nil
+	}
+	if err := file.Write(updated); err != nil {
+		return err
+	}
+	for _, a := range assignments {
+		stepf("Set %s", yamledit.JoinPath(a.Path...))
+	}
+	successf("Updated %s", file.Path)
+	return nil
+}
+
+func runSecretsRotate(cmd *cobra.Command, args []string) error {
+	if secretsEncryption != "sops" {
+		return fmt.Errorf("rotate only supports sops\n  hint: git-crypt keys are managed with git-crypt add-gpg-user and a new repository key")
+	}
+	if secretsFilePath != "" {
+		return fmt.Errorf("--secrets-file is not supported by rotate; environments are resolved as secrets.<env>.enc.yaml under --base-dir")
+	}
+
+	envs := args
+	if secretsRotateAll {
+		if len(args) > 0 {
+			return fmt.Errorf("pass either environments or --all, not both")
+		}
+		var err error
+		if envs, err = sopsEnvironments(); err != nil {
+			return err
+		}
+	}
+	if len(envs) == 0 {
+		return fmt.Errorf("no environment given\n  hint: pass one or more environments, or --all")
+	}
+
+	stepf("Rotating %s...", strings.Join(envs, ", "))
+	results, err := secrets.Rotate(secrets.RotateOptions{
+		BaseDir:    baseDir,
+		Envs:       envs,
+		Add:        secretsAdd,
+		Remove:     secretsRemove,
+		SopsOpts:   &sops.Options{AgeKeyFile: secretsAgeKeyFile},
+		SkipVerify: secretsSkipVerify,
+	})
+	for _, r := range results {
+		printRotation(r)
+	}
+	if err != nil {
+		return err
+	}
+
+	if secretsSkipVerify {
+		warnf("Decryption not verified (--skip-verify)")
+	}
+	successf("Re-encrypted %d file(s) with a new data key", len(results))
+	return nil
+}
+
+func runSecretsMigrate(cmd *cobra.Command, args []string) error {
+	env := args[0]
+	if secretsFilePath != "" {
+		return fmt.Errorf("--secrets-file is not supported by migrate; files are resolved under --base-dir")
+	}
+	if err := config.ValidateEncryption(secretsMigrateTo); err != nil {
+		return err
+	}
+	from, err := migrationSource(cmd, env)
+	if err != nil {
+		return err
+	}
+	if len(secretsRecipients) > 0 && secretsMigrateTo != config.EncryptionSOPS {
+		return fmt.Errorf("--recipient only applies to --to sops")
+	}
+
+	stepf("Migrating %s from %s to %s...", env, from, secretsMigrateTo)
+	m, err := secrets.Migrate(secrets.MigrateOptions{
+		BaseDir:    baseDir,
+		Env:        env,
+		From:       from,
+		To:         secretsMigrateTo,
+		Recipients: secretsRecipients,
+		SopsOpts:   &sops.Options{AgeKeyFile: secretsAgeKeyFile},
+		Force:      secretsForce,
+	})
+	if err != nil {
+		return err
+	}
+
+	if m.SopsRule {
+		if secretsMigrateTo == config.EncryptionSOPS {
+			stepf("Updated the %s rule in .sops.yaml", env)
+		} else {
+			stepf("Removed the %s rule from .sops.yaml", env)
+		}
+	}
+	for _, w := range m.Warnings {
+		warnf("%s", w)
+	}
+	successf("Wrote %s and removed %s", m.Target, m.Source)
+	successf("Recorded %s for %s in %s", secretsMigrateTo, env, config.RepoConfigFile)
+	if secretsMigrateTo == config.EncryptionGitCrypt {
+		fmt.Println("git-crypt encrypts the file when it is committed.")
+	}
+	return nil
+}
+
+// migrationSource returns the current backend of env: the --encryption flag,
+// the repository config file, or the backend whose secrets file exists.
+func migrationSource(cmd *cobra.Command, env string) (string, error) {
+	if cmd.Flag("encryption").Changed {
+		return secretsEncryption, config.ValidateEncryption(secretsEncryption)
+	}
+	if backend, ok, err := config.EncryptionBackend(baseDir, env); err != nil || ok {
+		return backend, err
+	}
+	var found []string
+	for _, backend := range []string{config.EncryptionSOPS, config.EncryptionGitCrypt} {
+		if _, err := os.Stat(defaultSecretsPath(env, backend)); err == nil {
+			found = append(found, backend)
+		}
+	}
+	switch len(found) {
+	case 1:
+		return found[0], nil
+	case 0:
+		return "", fmt.Errorf("no secrets file found for %s in %s\n  hint: run 'cluster-bootstrap init' to create it", env, baseDir)
+	default:
+		return "", fmt.Errorf("both %s and %s exist\n  hint: pass --encryption to choose the source", config.SecretsFileName(env), config.SecretsFileNamePlain(env))
+	}
+}
+
+// sopsEnvironments lists environments with a secrets.<env>.enc.yaml file in baseDir.
+func sopsEnvironments() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(baseDir, config.SecretsFileName("*")))
+	if err != nil {
+		return nil, err
+	}
+	var envs []string
+	for _, m := range matches {
+		name := filepath.Base(m)
+		envs = append(envs, strings.TrimSuffix(strings.TrimPrefix(name, "secrets."), ".enc.yaml"))
+	}
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", config.SecretsFileName("<env>"), baseDir)
+	}
+	return envs, nil
+}
+
+func printRotation(r secrets.Rotation) {
+	fmt.Println()
+	fmt.Printf("%s (%s, %s)\n", stepColor(r.Env), filepath.Base(r.File), r.Provider)
+	fmt.Printf("  %-64s %-7s %s\n", "RECIPIENT", "BEFORE", "AFTER")
+
+	recipients := append([]string{}, r.Before...)
+	for _, key := range r.After {
+		if !slices.Contains(recipients, key) {
+			recipients = append(recipients, key)
+		}
+	}
+	for _, key := range recipients {
+		before, after := slices.Contains(r.Before, key), slices.Contains(r.After, key)
+		mark := func(ok bool) string {
+			if ok {
+				return "✓"
+			}
+			return "-"
+		}
+		line := fmt.Sprintf("  %-64s %-7s %s", key, mark(before), mark(after))
+		switch {
+		case before && !after:
+			line = diffRemoveColor(line)
+		case !before && after:
+			line = diffAddColor(line)
+		}
+		fmt.Println(line)
+	}
+}
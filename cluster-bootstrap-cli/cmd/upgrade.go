@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+)
+
+var (
+	upgradeTo               string
+	upgradeEnv              string
+	upgradeApply            bool
+	upgradeBackupDir        string
+	upgradeKubeconfig       string
+	upgradeContext          string
+	upgradeSkipClusterCheck bool
+	upgradeDryRun           bool
+	upgradeForce            bool
+)
+
+var (
+	diffAddColor    = color.New(color.FgGreen).SprintFunc()
+	diffRemoveColor = color.New(color.FgRed).SprintFunc()
+	diffHunkColor   = color.New(color.FgCyan).SprintFunc()
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade platform components",
+	Long:  `Commands for upgrading the charts that make up the platform.`,
+}
+
+var upgradeArgoCDCmd = &cobra.Command{
+	Use:   "argocd",
+	Short: "Upgrade the ArgoCD chart version",
+	Long: `Plan and apply an upgrade of the argo-cd chart pinned in components/argocd/Chart.yaml.
+
+The command downloads the current and target chart versions and shows:
+  - the diff of the chart's default values
+  - whether the target chart's kubeVersion accepts the live cluster
+  - CustomResourceDefinitions that are added, removed or changed
+
+It then rewrites the dependency version in Chart.yaml. With --apply it also
+backs up all ArgoCD Applications and AppProjects and runs the Helm upgrade.
+
+Example:
+  cluster-bootstrap upgrade argocd --to 9.5.0 --dry-run
+  cluster-bootstrap upgrade argocd --to 9.5.0
+  cluster-bootstrap upgrade argocd --to 9.5.0 --apply --env dev`,
+	RunE: runUpgradeArgoCD,
+}
+
+func init() {
+	upgradeArgoCDCmd.Flags().StringVar(&upgradeTo, "to", "", "target argo-cd chart version (required)")
+	upgradeArgoCDCmd.Flags().StringVar(&upgradeEnv, "env", "", "environment whose values are used with --apply")
+	upgradeArgoCDCmd.Flags().BoolVar(&upgradeApply, "apply", false, "run the Helm upgrade after updating Chart.yaml")
+	upgradeArgoCDCmd.Flags().StringVar(&upgradeBackupDir, "backup-dir", ".", "directory for the Applications/AppProjects backup written before --apply")
+	upgradeArgoCDCmd.Flags().StringVar(&upgradeKubeconfig, "kubeconfig", "", "path to kubeconfig file")
+	upgradeArgoCDCmd.Flags().StringVar(&upgradeContext, "context", "", "kubeconfig context to use")
+	upgradeArgoCDCmd.Flags().BoolVar(&upgradeSkipClusterCheck, "skip-cluster-check", false, "skip the kubeVersion check against the live cluster")
+	upgradeArgoCDCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "show the upgrade plan without modifying files or the cluster")
+	upgradeArgoCDCmd.Flags().BoolVar(&upgradeForce, "force", false, "skip confirmation prompt")
+	_ = upgradeArgoCDCmd.MarkFlagRequired("to") //#nosec G104 -- error only occurs if flag doesn't exist, which is impossible here
+
+	upgradeCmd.AddCommand(upgradeArgoCDCmd)
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func runUpgradeArgoCD(cmd *cobra.Command, args []string) error {
+	target := strings.TrimPrefix(strings.TrimSpace(upgradeTo), "v")
+	if target == "" {
+		return fmt.Errorf("--to is required")
+	}
+	if upgradeApply && upgradeEnv == "" {
+		return fmt.Errorf("--env is required with --apply (values are loaded from components/argocd/values/<env>.yaml)")
+	}
+	if upgradeApply && upgradeSkipClusterCheck {
+		return fmt.Errorf("--apply cannot be combined with --skip-cluster-check")
+	}
+
+	stepf("Planning ArgoCD chart upgrade to %s...", target)
+	plan, err := helm.PlanArgoCDUpgrade(baseDir, target, verbose)
+	if err != nil {
+		return err
+	}
+
+	if plan.CurrentVersion == plan.TargetVersion {
+		successf("components/argocd/Chart.yaml already pins %s %s", plan.ChartName, plan.TargetVersion)
+		return nil
+	}
+
+	printUpgradePlan(plan)
+
+	ctx := context.Background()
+	var client *k8s.Client
+	if upgradeSkipClusterCheck {
+		warnf("Skipping kubeVersion check against the cluster")
+	} else {
+		client, err = k8s.NewClient(upgradeKubeconfig, upgradeContext)
+		if err != nil {
+			return err
+		}
+		serverVersion, err := client.Clientset.Discovery().ServerVersion()
+		if err != nil {
+			return fmt.Errorf("failed to read cluster version: %w\n  hint: pass --skip-cluster-check to plan without cluster access", err)
+		}
+		if err := plan.CheckKubeVersion(serverVersion.GitVersion); err != nil {
+			return err
+		}
+		if plan.KubeVersion != "" {
+			successf("Cluster %s satisfies kubeVersion %s", serverVersion.GitVersion, plan.KubeVersion)
+		} else {
+			successf("Target chart declares no kubeVersion constraint (cluster %s)", serverVersion.GitVersion)
+		}
+	}
+
+	if len(plan.CRDChanges) > 0 {
+		warnf("%d CRD change(s) in this upgrade; review them before syncing the argocd component", len(plan.CRDChanges))
+	}
+
+	if upgradeDryRun {
+		fmt.Println("\n🔍 DRY RUN MODE - No files were modified")
+		return nil
+	}
+
+	if !upgradeForce {
+		ok, err := confirm(fmt.Sprintf("Update %s from %s to %s?", plan.ChartName, plan.CurrentVersion, plan.TargetVersion))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("❌ Upgrade cancelled")
+			return nil
+		}
+	}
+
+	if err := helm.SetArgoCDChartVersion(baseDir, plan.TargetVersion); err != nil {
+		return err
+	}
+	successf("Updated components/argocd/Chart.yaml: %s → %s", plan.CurrentVersion, plan.TargetVersion)
+
+	if !upgradeApply {
+		fmt.Println("\nNext steps:")
+		fmt.Println("  1. Commit components/argocd/Chart.yaml")
+		fmt.Println("  2. ArgoCD will sync the argocd component to the new chart version")
+		fmt.Println("  Or run again with --apply --env <env> to upgrade the release directly")
+		return nil
+	}
+
+	stepf("Backing up ArgoCD Applications and AppProjects...")
+	backupPath, count, err := writeArgoCDBackup(ctx, client, upgradeBackupDir)
+	if err != nil {
+		return err
+	}
+	successf("Saved %d object(s) to %s", count, backupPath)
+
+	stepf("Upgrading ArgoCD release via Helm...")
+	if _, err := helm.InstallArgoCD(ctx, upgradeKubeconfig, upgradeContext, upgradeEnv, baseDir, verbose); err != nil {
+		return fmt.Errorf("failed to upgrade ArgoCD: %w\n  hint: restore Applications with: kubectl apply -f %s", err, backupPath)
+	}
+	successf("ArgoCD upgraded to chart %s", plan.TargetVersion)
+	return nil
+}
+
+func printUpgradePlan(plan *helm.UpgradePlan) {
+	fmt.Println()
+	fmt.Println("📝 ArgoCD Upgrade Plan")
+	fmt.Println("═══════════════════════════════════")
+	fmt.Printf("Chart:        %s (%s)\n", plan.ChartName, plan.RepoURL)
+	fmt.Printf("Version:      %s → %s\n", plan.CurrentVersion, plan.TargetVersion)
+	if plan.CurrentAppVersion != "" || plan.TargetAppVersion != "" {
+		fmt.Printf("App version:  %s → %s\n", plan.CurrentAppVersion, plan.TargetAppVersion)
+	}
+	if plan.KubeVersion != "" {
+		fmt.Printf("kubeVersion:  %s\n", plan.KubeVersion)
+	}
+
+	fmt.Println()
+	fmt.Println("CRD changes:")
+	if len(plan.CRDChanges) == 0 {
+		fmt.Println("  none")
+	}
+	for _, change := range plan.CRDChanges {
+		icon := "~"
+		switch change.Change {
+		case helm.CRDAdded:
+			icon = "+"
+		case helm.CRDRemoved:
+			icon = "-"
+		}
+		fmt.Printf("  %s %-40s %s\n", icon, change.Name, change.Change)
+	}
+
+	fmt.Println()
+	fmt.Println("Default values diff:")
+	if plan.ValuesDiff == "" {
+		fmt.Println("  no changes")
+	} else {
+		printUnifiedDiff(plan.ValuesDiff)
+	}
+	fmt.Println()
+}
+
+// printUnifiedDiff prints a unified diff with added/removed lines colored.
+func printUnifiedDiff(text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Println(stepColor(line))
+		case strings.HasPrefix(line, "@@"):
+			fmt.Println(diffHunkColor(line))
+		case strings.HasPrefix(line, "+"):
+			fmt.Println(diffAddColor(line))
+		case strings.HasPrefix(line, "-"):
+			fmt.Println(diffRemoveColor(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
+
+func writeArgoCDBackup(ctx context.Context, client *k8s.Client, dir string) (string, int, error) {
+	data, count, err := client.BackupArgoCDResources(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", 0, fmt.Errorf("failed to create backup directory %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("argocd-backup-%s.yaml", time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", 0, fmt.Errorf("failed to write backup %s: %w", path, err)
+	}
+	return path, count, nil
+}
+
+// confirm asks a yes/no question on stdin and reports whether the answer was yes.
+func confirm(question string) (bool, error) {
+	fmt.Printf("\n%s [y/N]: ", question)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes", nil
+}
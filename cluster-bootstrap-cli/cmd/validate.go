@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -10,17 +12,34 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
 
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/gitremote"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/policy"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sshkey"
 )
 
 type validateResult struct {
+	// id is the stable check ID used in machine-readable output.
+	id   string
 	name string
 	note string
 	err  error
 	warn bool
+	// hint is printed under warnings; errors carry their own hint.
+	hint string
+	// details are extra lines printed under the result, e.g. policy findings.
+	details []string
+	// locations are the files the result points at, e.g. policy findings.
+	locations []report.Location
 }
 
 var (
@@ -37,6 +56,12 @@ var (
 	validateSkipCRDCheck     bool
 	validateRepoTimeout      int
 	validateHelmTimeout      int
+	validatePolicyFile       string
+	validateSkipPolicy       bool
+	validateKubeVersion      string
+	validateOutput           string
+	validateOnly             []string
+	validateSkip             []string
 )
 
 var validateCmd = &cobra.Command{
@@ -45,9 +70,25 @@ var validateCmd = &cobra.Command{
 	Long: `Validate local configuration, secrets, and optional cluster access.
 
 This command performs deeper checks than doctor, including reading secrets
-files, validating .sops.yaml rules, and verifying repo credentials.`,
-	Args: cobra.ExactArgs(1),
-	RunE: runValidate,
+files, validating .sops.yaml rules, and verifying repo credentials.
+
+Policy checks render the apps chart and every enabled component with the
+Helm SDK and inspect the manifests for images tagged latest, missing
+resource requests and limits, privileged containers, hostPath volumes and
+namespaces without Pod Security labels. Severities and waivers are read
+from policy.yaml in the base directory.
+
+Checks run in parallel where their dependencies allow. --only and --skip
+select checks by ID or category, and custom checks are declared in
+.cluster-bootstrap.yaml; see 'cluster-bootstrap checks list'.
+
+With --output json, junit, sarif or github the report is written to stdout
+in that format, each check carrying a stable ID. The exit status is 0 when
+every check passed, 1 when a check failed and 2 when checks only warned.`,
+	Args:          cobra.ExactArgs(1),
+	RunE:          runValidate,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
 func init() {
@@ -57,138 +98,73 @@ func init() {
 	validateCmd.Flags().StringVar(&validateAppPath, "app-path", "apps", "path inside the Git repo for the App of Apps source")
 	validateCmd.Flags().StringVar(&validateKubeconfig, "kubeconfig", "", "path to kubeconfig file")
 	validateCmd.Flags().StringVar(&validateContext, "context", "", "kubeconfig context to use")
-	validateCmd.Flags().BoolVar(&validateSkipClusterCheck, "skip-cluster-check", false, "skip kubectl cluster access checks")
+	validateCmd.Flags().BoolVar(&validateSkipClusterCheck, "skip-cluster-check", false, "skip the kubeconfig and cluster access checks")
 	validateCmd.Flags().BoolVar(&validateSkipRepoCheck, "skip-repo-check", false, "skip repo reachability checks")
 	validateCmd.Flags().BoolVar(&validateSkipSSHCheck, "skip-ssh-check", false, "skip SSH key repo access checks")
 	validateCmd.Flags().BoolVar(&validateSkipHelmLint, "skip-helm-lint", false, "skip Helm lint checks")
 	validateCmd.Flags().BoolVar(&validateSkipCRDCheck, "skip-crd-check", false, "skip ArgoCD CRD checks")
 	validateCmd.Flags().IntVar(&validateRepoTimeout, "repo-timeout", 10, "timeout in seconds for repo checks")
 	validateCmd.Flags().IntVar(&validateHelmTimeout, "helm-timeout", 20, "timeout in seconds for helm lint checks")
+	validateCmd.Flags().StringVar(&validatePolicyFile, "policy-file", "", "path to policy config (default: <base-dir>/policy.yaml)")
+	validateCmd.Flags().BoolVar(&validateSkipPolicy, "skip-policy", false, "skip policy checks on rendered manifests")
+	validateCmd.Flags().StringVar(&validateKubeVersion, "kube-version", "", "Kubernetes version to render policy manifests for and check chart kubeVersions against without a cluster")
+	validateCmd.Flags().StringVarP(&validateOutput, "output", "o", "text", "output format: text, json, junit, sarif or github")
+	validateCmd.Flags().StringSliceVar(&validateOnly, "only", nil, "only run the checks with these IDs or categories, and the checks they depend on")
+	validateCmd.Flags().StringSliceVar(&validateSkip, "skip", nil, "skip the checks with these IDs or categories")
 
 	rootCmd.AddCommand(validateCmd)
 }
 
 func runValidate(cmd *cobra.Command, args []string) error {
 	env := args[0]
-	logger := NewLogger(verbose)
+	format, err := report.ParseFormat(validateOutput)
+	if err != nil {
+		return err
+	}
+	logger := NewLogger(verbose && format == report.FormatText)
 	stage := logger.Stage("Validation")
 
-	if validateEncryption != "sops" && validateEncryption != "git-crypt" {
-		return fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", validateEncryption)
+	if err := resolveEncryption(cmd, env, &validateEncryption); err != nil {
+		return err
 	}
 
-	results := make([]validateResult, 0, 12)
-	var secretsData *config.EnvironmentSecrets
-
-	results = append(results, runValidateCheck(stage, "base directory", func() (string, error) {
-		info, err := os.Stat(baseDir)
-		if err != nil {
-			return "", fmt.Errorf("base-dir %s not accessible: %w", baseDir, err)
-		}
-		if !info.IsDir() {
-			return "", fmt.Errorf("base-dir %s is not a directory", baseDir)
-		}
-		return baseDir, nil
-	}))
-
-	resolvedAppPath, appErr := resolveAppPath(baseDir, validateAppPath)
-	if appErr != nil {
-		results = append(results, validateResult{name: "app path", err: appErr})
-	} else {
-		results = append(results, validateResult{name: "app path", note: resolvedAppPath})
+	run := &checkRun{
+		command:     "validate",
+		env:         env,
+		encryption:  validateEncryption,
+		ageKeyFile:  validateAgeKeyFile,
+		kubeconfig:  validateKubeconfig,
+		kubeContext: validateContext,
+		skipCluster: validateSkipClusterCheck,
+		secretsPath: secretsFileForEnv(env),
+		kubeVersion: validateKubeVersion,
 	}
-
-	results = append(results, runValidateCheck(stage, "helm available", func() (string, error) {
-		return "", CheckHelm()
-	}))
-
-	results = append(results, runValidateCheck(stage, "kubectl available", func() (string, error) {
-		return "", CheckKubectlAvailable(true)
-	}))
-
-	results = append(results, runValidateCheck(stage, "kubectl current context", func() (string, error) {
-		return getKubectlCurrentContext(validateKubeconfig)
-	}))
-
 	if !validateSkipClusterCheck {
-		results = append(results, runValidateCheck(stage, "kubectl cluster access", func() (string, error) {
-			return "", CheckKubectlClusterAccessWithConfig(validateKubeconfig, validateContext)
-		}))
+		run.rbac = &rbacOptions{installArgoCD: true, knownHosts: true}
 	}
-
-	results = append(results, runValidateCheck(stage, "encryption tooling", func() (string, error) {
-		switch validateEncryption {
-		case "sops":
-			if err := CheckSOPS("sops"); err != nil {
-				return "", err
-			}
-			return "sops", CheckAge("sops", validateAgeKeyFile)
-		case "git-crypt":
-			return "git-crypt", CheckGitCrypt("git-crypt")
-		default:
-			return "", fmt.Errorf("unsupported encryption backend: %s", validateEncryption)
-		}
-	}))
-
-	secretsPath := validateSecretsFile
-	if secretsPath == "" {
-		switch validateEncryption {
-		case "sops":
-			secretsPath = filepath.Join(baseDir, config.SecretsFileName(env))
-		case "git-crypt":
-			secretsPath = filepath.Join(baseDir, config.SecretsFileNamePlain(env))
-		}
+	results, err := runChecks(run, checks.Selection{Only: validateOnly, Skip: validateSkip}, stage)
+	if err != nil {
+		return err
 	}
 
-	results = append(results, runValidateCheck(stage, "secrets file", func() (string, error) {
-		if _, err := os.Stat(secretsPath); err != nil {
-			return "", fmt.Errorf("secrets file not found: %s", secretsPath)
-		}
-		if err := CheckFilePermissions(secretsPath, true); err != nil {
-			return "", err
-		}
-		return secretsPath, nil
-	}))
-
-	results = append(results, runValidateCheck(stage, "secrets content", func() (string, error) {
-		var secrets *config.EnvironmentSecrets
-		var err error
-		switch validateEncryption {
-		case "sops":
-			secrets, err = config.LoadSecrets(secretsPath, &sops.Options{AgeKeyFile: validateAgeKeyFile})
-		case "git-crypt":
-			secrets, err = config.LoadSecretsPlaintext(secretsPath)
-		}
-		if err != nil {
-			return "", err
-		}
-		if strings.TrimSpace(secrets.Repo.URL) == "" {
-			return "", fmt.Errorf("repo.url is required in secrets file")
-		}
-		if strings.TrimSpace(secrets.Repo.SSHPrivateKey) == "" {
-			return "", fmt.Errorf("repo.sshPrivateKey is required in secrets file")
-		}
-		secretsData = secrets
-		return "repo credentials validated", nil
-	}))
-
-	results = append(results, validateSecretsWarnings(secretsPath))
-
-	results = append(results, validateSopsConfig(env))
-	results = append(results, validateGitCryptAttributes())
-	results = append(results, validateRepoAccess(secretsData))
-	results = append(results, validateSSHRepoAccess(secretsData))
-	results = append(results, validateHelmLint(env, resolvedAppPath, appErr))
-	results = append(results, validateArgoCDCRDs())
-
 	stage.Done()
 
-	printValidateReport(env, results)
-	if countValidateErrors(results) > 0 {
-		return fmt.Errorf("validate found %d issue(s)", countValidateErrors(results))
+	rep := checkReport("validate", env, results)
+	if format == report.FormatText {
+		printCheckReport("Validate report", results)
+	} else if err := report.Write(os.Stdout, format, rep); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	switch rep.ExitCode() {
+	case report.ExitFailure:
+		return &exitError{code: report.ExitFailure, err: fmt.Errorf("validate found %d issue(s)", rep.Summary.Fail)}
+	case report.ExitWarning:
+		return &exitError{code: report.ExitWarning, err: fmt.Errorf("validate found %d warning(s)", rep.Summary.Warn)}
 	}
 
-	successf("Validation passed")
+	if format == report.FormatText {
+		successf("Validation passed")
+	}
 	return nil
 }
 
@@ -212,154 +188,265 @@ func resolveAppPath(base, appPath string) (string, error) {
 
 func validateSopsConfig(env string) validateResult {
 	if validateEncryption != "sops" {
-		return validateResult{name: ".sops.yaml", note: "skipped", warn: true}
+		return validateResult{id: "sops-config", name: ".sops.yaml", note: "skipped", warn: true}
 	}
 
-	sopsPath := filepath.Join(baseDir, ".sops.yaml")
-	if envPath, ok := os.LookupEnv("SOPS_CONFIG"); ok && strings.TrimSpace(envPath) != "" {
-		sopsPath = envPath
-	}
-	cfg, err := config.ReadSopsConfig(sopsPath)
+	cfg, err := config.ReadSopsConfig(validateSopsConfigPath())
 	if err != nil {
-		return validateResult{name: ".sops.yaml", note: "missing or unreadable", warn: true}
+		return validateResult{id: "sops-config", name: ".sops.yaml", note: "missing or unreadable", warn: true}
 	}
 
 	expected := config.EnvPathRegex(env)
 	for _, rule := range cfg.CreationRules {
 		if rule.PathRegex == expected {
-			return validateResult{name: ".sops.yaml", note: "creation rule found"}
+			return validateResult{id: "sops-config", name: ".sops.yaml", note: "creation rule found"}
 		}
 	}
 
-	return validateResult{name: ".sops.yaml", note: "missing creation rule for environment", warn: true}
+	return validateResult{id: "sops-config", name: ".sops.yaml", note: "missing creation rule for environment", warn: true,
+		hint: fmt.Sprintf("run 'cluster-bootstrap init %s' or update .sops.yaml", env), locations: []report.Location{{File: validateSopsConfigPath()}}}
+}
+
+// validateSopsConfigPath returns the .sops.yaml validate reads, honouring SOPS_CONFIG.
+func validateSopsConfigPath() string {
+	if envPath, ok := os.LookupEnv("SOPS_CONFIG"); ok && strings.TrimSpace(envPath) != "" {
+		return envPath
+	}
+	return filepath.Join(baseDir, ".sops.yaml")
+}
+
+// validateSopsKeys checks that every creation rule lists well-formed keys.
+func validateSopsKeys() validateResult {
+	if validateEncryption != "sops" {
+		return validateResult{id: "sops-keys", name: "sops keys", note: "skipped", warn: true}
+	}
+	cfg, err := config.ReadSopsConfig(validateSopsConfigPath())
+	if err != nil {
+		return validateResult{id: "sops-keys", name: "sops keys", note: "skipped", warn: true}
+	}
+	if err := cfg.Validate(); err != nil {
+		return validateResult{id: "sops-keys", name: "sops keys", err: err}.at(validateSopsConfigPath())
+	}
+	return validateResult{id: "sops-keys", name: "sops keys", note: fmt.Sprintf("%d rule(s) well formed", len(cfg.CreationRules))}
 }
 
 func validateGitCryptAttributes() validateResult {
 	if validateEncryption != "git-crypt" {
-		return validateResult{name: ".gitattributes", note: "skipped", warn: true}
+		return validateResult{id: "gitcrypt-attributes", name: ".gitattributes", note: "skipped", warn: true}
 	}
 
 	attrsPath := filepath.Join(baseDir, ".gitattributes")
 	data, err := os.ReadFile(attrsPath) // #nosec G304
 	if err != nil {
-		return validateResult{name: ".gitattributes", err: fmt.Errorf("failed to read %s: %w", attrsPath, err)}
+		return validateResult{id: "gitcrypt-attributes", name: ".gitattributes", err: fmt.Errorf("failed to read %s: %w", attrsPath, err)}
 	}
 
 	content := string(data)
 	if !strings.Contains(content, config.GitCryptAttributesPattern) {
-		return validateResult{name: ".gitattributes", note: "missing git-crypt pattern", warn: true}
+		return validateResult{id: "gitcrypt-attributes", name: ".gitattributes", note: "missing git-crypt pattern", warn: true,
+			hint: "run 'cluster-bootstrap init --provider git-crypt' or update .gitattributes", locations: []report.Location{{File: attrsPath}}}
 	}
 
-	return validateResult{name: ".gitattributes", note: "pattern found"}
+	return validateResult{id: "gitcrypt-attributes", name: ".gitattributes", note: "pattern found"}
 }
 
 func validateRepoAccess(secrets *config.EnvironmentSecrets) validateResult {
 	if validateSkipRepoCheck {
-		return validateResult{name: "repo access", note: "skipped", warn: true}
+		return validateResult{id: "repo-access", name: "repo access", note: "skipped", warn: true}
 	}
 	if secrets == nil {
-		return validateResult{name: "repo access", note: "skipped", warn: true}
-	}
-	path, err := exec.LookPath("git")
-	if err != nil {
-		return validateResult{name: "repo access", err: fmt.Errorf("git not found in PATH: %w", err)}
-	}
-
-	ref := strings.TrimSpace(secrets.Repo.TargetRevision)
-	if ref == "" {
-		ref = "HEAD"
+		return validateResult{id: "repo-access", name: "repo access", note: "skipped", warn: true}
 	}
 
 	ctx, cancel := contextWithTimeout(validateRepoTimeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, path, "ls-remote", "--exit-code", "--", secrets.Repo.URL, ref) // #nosec G204
-
-	// Disable interactive prompts so validation fails fast instead of hanging for credentials.
-	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
-
-	repoURL := secrets.Repo.URL
-	if strings.HasPrefix(repoURL, "ssh://") || strings.Contains(repoURL, "@") {
-		sshCommand := os.Getenv("GIT_SSH_COMMAND")
-		if sshCommand == "" {
-			sshCommand = "ssh -o BatchMode=yes"
+	repoURL := strings.TrimSpace(secrets.Repo.URL)
+	addr, isSSH := knownhosts.Address(repoURL)
+	if !isSSH {
+		// Without credentials only public repositories can be listed; the
+		// revision is checked along the way.
+		refs, err := gitremote.ListRefs(ctx, gitremote.Options{URL: repoURL})
+		if err != nil {
+			return validateResult{id: "repo-access", name: "repo access", err: repoAccessError(err)}
+		}
+		rev, err := refs.Resolve(secrets.Repo.TargetRevision)
+		if err != nil {
+			return validateResult{id: "repo-access", name: "repo access", err: repoAccessError(err)}
 		}
-		env = append(env, "GIT_SSH_COMMAND="+sshCommand)
+		return validateResult{id: "repo-access", name: "repo access", note: revisionNote(rev), warn: !rev.Verified}
 	}
 
-	cmd.Env = env
-	output, err := cmd.CombinedOutput()
+	// SSH servers only list refs to authenticated clients: check that the
+	// server answers with a trusted host key.
+	callback, err := repoHostKeyCallback(secrets.Repo)
 	if err != nil {
-		return validateResult{name: "repo access", err: fmt.Errorf("git ls-remote failed: %w\n  output: %s", err, string(output))}
+		return validateResult{id: "repo-access", name: "repo access", err: err}
 	}
-
-	return validateResult{name: "repo access", note: "reachable"}
+	keys, err := knownhosts.Fetch(ctx, addr, time.Duration(validateRepoTimeout)*time.Second)
+	if err != nil {
+		return validateResult{id: "repo-access", name: "repo access", err: repoAccessError(&gitremote.Error{Kind: gitremote.ErrUnreachable, URL: repoURL, Err: err})}
+	}
+	remote, _ := net.ResolveTCPAddr("tcp", addr)
+	for _, key := range keys {
+		if err = callback(addr, remote, key); err == nil {
+			return validateResult{id: "repo-access", name: "repo access", note: "reachable, host key " + knownhosts.Fingerprint(key)}
+		}
+	}
+	return validateResult{id: "repo-access", name: "repo access", err: repoAccessError(&gitremote.Error{Kind: hostKeyErrorKind(err), URL: repoURL, Err: err})}
 }
 
 func validateSSHRepoAccess(secrets *config.EnvironmentSecrets) validateResult {
 	if validateSkipSSHCheck {
-		return validateResult{name: "ssh repo access", note: "skipped", warn: true}
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", note: "skipped", warn: true}
 	}
 	if secrets == nil {
-		return validateResult{name: "ssh repo access", note: "skipped", warn: true}
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", note: "skipped", warn: true}
 	}
 
 	repoURL := strings.TrimSpace(secrets.Repo.URL)
 	if !strings.HasPrefix(repoURL, "git@") && !strings.HasPrefix(repoURL, "ssh://") {
-		return validateResult{name: "ssh repo access", note: "non-ssh url", warn: true}
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", note: "non-ssh url", warn: true}
 	}
 
 	key := strings.TrimSpace(secrets.Repo.SSHPrivateKey)
 	if key == "" {
-		return validateResult{name: "ssh repo access", err: fmt.Errorf("repo.sshPrivateKey is empty")}
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", err: fmt.Errorf("repo.sshPrivateKey is empty")}
 	}
 
-	path, err := exec.LookPath("git")
+	callback, err := repoHostKeyCallback(secrets.Repo)
 	if err != nil {
-		return validateResult{name: "ssh repo access", err: fmt.Errorf("git not found in PATH: %w", err)}
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", err: err}
 	}
 
-	keyFile, err := os.CreateTemp("", "cluster-bootstrap-ssh-*")
+	ctx, cancel := contextWithTimeout(validateRepoTimeout)
+	defer cancel()
+
+	// The key is only held in memory.
+	refs, err := gitremote.ListRefs(ctx, gitremote.Options{URL: repoURL, PrivateKey: key, HostKeyCallback: callback})
 	if err != nil {
-		return validateResult{name: "ssh repo access", err: fmt.Errorf("failed to create temp ssh key: %w", err)}
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", err: repoAccessError(err)}
 	}
-	defer func() {
-		_ = keyFile.Close()           // best effort cleanup
-		_ = os.Remove(keyFile.Name()) //#nosec G703 -- keyFile.Name() is from os.CreateTemp, not user input
-	}()
-	if err := os.WriteFile(keyFile.Name(), []byte(key), 0600); err != nil { //#nosec G703 -- keyFile.Name() is from os.CreateTemp, not user input
-		return validateResult{name: "ssh repo access", err: fmt.Errorf("failed to write temp ssh key: %w", err)}
+	rev, err := refs.Resolve(secrets.Repo.TargetRevision)
+	if err != nil {
+		return validateResult{id: "ssh-repo-access", name: "ssh repo access", err: repoAccessError(err)}
 	}
+	return validateResult{id: "ssh-repo-access", name: "ssh repo access", note: revisionNote(rev), warn: !rev.Verified}
+}
 
-	ref := strings.TrimSpace(secrets.Repo.TargetRevision)
-	if ref == "" {
-		ref = "HEAD"
+// validateRepoKey reports the type and size of the repository SSH key. ArgoCD
+// cannot use passphrase-protected keys.
+func validateRepoKey(secrets *config.EnvironmentSecrets) validateResult {
+	if secrets == nil || strings.TrimSpace(secrets.Repo.SSHPrivateKey) == "" {
+		return validateResult{id: "repo-key", name: "repo key", note: "skipped", warn: true}
 	}
+	info, err := sshkey.Inspect(secrets.Repo.SSHPrivateKey)
+	if err != nil {
+		return validateResult{id: "repo-key", name: "repo key", err: fmt.Errorf("repo.sshPrivateKey: %w\n  hint: generate a new deploy key with 'cluster-bootstrap repo keygen <env>'", err)}
+	}
+	if info.Encrypted {
+		return validateResult{id: "repo-key", name: "repo key", err: fmt.Errorf("repo.sshPrivateKey is passphrase protected (%s)\n  hint: ArgoCD cannot use passphrase-protected keys; remove the passphrase with 'ssh-keygen -p' or generate a new key with 'cluster-bootstrap repo keygen <env>'", info)}
+	}
+	if info.Type == ssh.KeyAlgoRSA && info.Bits < 2048 {
+		return validateResult{id: "repo-key", name: "repo key", note: info.String() + ", RSA keys under 2048 bits are rejected by most Git servers", warn: true}
+	}
+	return validateResult{id: "repo-key", name: "repo key", note: info.String()}
+}
 
-	ctx, cancel := contextWithTimeout(validateRepoTimeout)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, path, "ls-remote", "--exit-code", "--", repoURL, ref) // #nosec G204
-	cmd.Env = append(os.Environ(), fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %q -o BatchMode=yes -o StrictHostKeyChecking=yes", keyFile.Name()))
-	output, err := cmd.CombinedOutput()
+// repoHostKeyCallback verifies the repository's SSH server against the
+// fingerprints pinned for it, or ~/.ssh/known_hosts when none are.
+func repoHostKeyCallback(repo config.RepoSecrets) (ssh.HostKeyCallback, error) {
+	targets, err := knownHostsTargets(repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > 0 && len(targets[0].pins) > 0 {
+		target := targets[0]
+		return func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			if _, err := knownhosts.Verify(target.host, []ssh.PublicKey{key}, target.pins); err != nil {
+				return fmt.Errorf("%w: %s offers %s, pinned in %s: %s", gitremote.ErrHostKeyMismatch,
+					target.host, knownhosts.Fingerprint(key), target.source, strings.Join(target.pins, ", "))
+			}
+			return nil
+		}, nil
+	}
+	callback, err := gitremote.UserKnownHosts()
 	if err != nil {
-		return validateResult{name: "ssh repo access", err: fmt.Errorf("git ls-remote via ssh failed: %w\n  output: %s", err, string(output))}
+		return nil, fmt.Errorf("failed to read ~/.ssh/known_hosts: %w", err)
 	}
+	if callback == nil {
+		return func(host string, _ net.Addr, _ ssh.PublicKey) error {
+			return fmt.Errorf("%w: %s is not pinned and there is no ~/.ssh/known_hosts", gitremote.ErrUnknownHost, knownhosts.Host(host))
+		}, nil
+	}
+	return callback, nil
+}
+
+// hostKeyErrorKind returns the gitremote error kind of a failed host key
+// check.
+func hostKeyErrorKind(err error) error {
+	var keyErr *xknownhosts.KeyError
+	switch {
+	case errors.Is(err, gitremote.ErrHostKeyMismatch):
+		return gitremote.ErrHostKeyMismatch
+	case errors.As(err, &keyErr) && len(keyErr.Want) > 0:
+		return gitremote.ErrHostKeyMismatch
+	}
+	return gitremote.ErrUnknownHost
+}
 
-	return validateResult{name: "ssh repo access", note: "reachable"}
+// repoAccessError adds a hint matching the kind of a gitremote error.
+func repoAccessError(err error) error {
+	var hint string
+	switch {
+	case errors.Is(err, gitremote.ErrAuth):
+		hint = "check that the public half of repo.sshPrivateKey is added to the repository as a deploy key with read access"
+	case errors.Is(err, gitremote.ErrUnknownHost):
+		hint = "pin the server's host key with 'cluster-bootstrap repo known-hosts <env> --pin' or add it to ~/.ssh/known_hosts"
+	case errors.Is(err, gitremote.ErrHostKeyMismatch):
+		hint = "if the server's host keys were rotated, update the pinned fingerprints; otherwise the connection may be intercepted"
+	case errors.Is(err, gitremote.ErrRepoNotFound):
+		hint = "check repo.url; Git servers also report private repositories as missing when the key has no access"
+	case errors.Is(err, gitremote.ErrRefNotFound):
+		hint = "check repo.targetRevision, or push the branch or tag before bootstrapping"
+	case errors.Is(err, gitremote.ErrTimeout):
+		hint = fmt.Sprintf("the server did not answer within %ds; raise --repo-timeout or check the network", validateRepoTimeout)
+	default:
+		hint = "check network access to the Git server"
+	}
+	return fmt.Errorf("%w\n  hint: %s", err, hint)
+}
+
+// revisionNote describes a resolved target revision, e.g. "branch main at
+// 1a2b3c4".
+func revisionNote(rev *gitremote.Revision) string {
+	short := rev.SHA
+	if len(short) > 7 {
+		short = short[:7]
+	}
+	switch rev.Kind {
+	case "branch", "tag":
+		return fmt.Sprintf("%s %s at %s", rev.Kind, strings.TrimPrefix(strings.TrimPrefix(rev.Ref, "refs/heads/"), "refs/tags/"), short)
+	case "commit":
+		if !rev.Verified {
+			return fmt.Sprintf("commit %s is not a branch or tag tip and cannot be checked without fetching", short)
+		}
+		return fmt.Sprintf("commit %s (%s)", short, rev.Ref)
+	}
+	return fmt.Sprintf("%s at %s", rev.Ref, short)
 }
 
 func validateHelmLint(env, appPath string, appErr error) validateResult {
 	if validateSkipHelmLint {
-		return validateResult{name: "helm lint", note: "skipped", warn: true}
+		return validateResult{id: "helm-lint", name: "helm lint", note: "skipped", warn: true}
 	}
 	if appErr != nil {
-		return validateResult{name: "helm lint", note: "skipped", warn: true}
+		return validateResult{id: "helm-lint", name: "helm lint", note: "skipped", warn: true}
 	}
 	chartPath := filepath.Join(baseDir, appPath)
 	valuesPath := filepath.Join(chartPath, "values.yaml")
 	if _, err := os.Stat(valuesPath); err != nil {
-		return validateResult{name: "helm lint", err: fmt.Errorf("values.yaml not found in %s", chartPath)}
+		return validateResult{id: "helm-lint", name: "helm lint", err: fmt.Errorf("values.yaml not found in %s", chartPath)}
 	}
 
 	args := []string{"lint", chartPath, "-f", valuesPath}
@@ -367,12 +454,12 @@ func validateHelmLint(env, appPath string, appErr error) validateResult {
 	if _, err := os.Stat(envValues); err == nil {
 		args = append(args, "-f", envValues)
 	} else {
-		return validateResult{name: "helm lint", note: "missing values/<env>.yaml", warn: true}
+		return validateResult{id: "helm-lint", name: "helm lint", note: "missing values/<env>.yaml", warn: true}
 	}
 
 	path, err := exec.LookPath("helm")
 	if err != nil {
-		return validateResult{name: "helm lint", err: fmt.Errorf("helm not found in PATH: %w", err)}
+		return validateResult{id: "helm-lint", name: "helm lint", err: fmt.Errorf("helm not found in PATH: %w", err)}
 	}
 
 	ctx, cancel := contextWithTimeout(validateHelmTimeout)
@@ -381,37 +468,37 @@ func validateHelmLint(env, appPath string, appErr error) validateResult {
 	cmd := exec.CommandContext(ctx, path, args...) // #nosec G204
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return validateResult{name: "helm lint", err: fmt.Errorf("helm lint failed: %w\n  output: %s", err, string(output))}
+		return validateResult{id: "helm-lint", name: "helm lint", err: fmt.Errorf("helm lint failed: %w\n  output: %s", err, string(output))}
 	}
 
-	return validateResult{name: "helm lint", note: "passed"}
+	return validateResult{id: "helm-lint", name: "helm lint", note: "passed"}
 }
 
 func validateArgoCDCRDs() validateResult {
 	if validateSkipCRDCheck {
-		return validateResult{name: "argocd crds", note: "skipped", warn: true}
+		return validateResult{id: "argocd-crds", name: "argocd crds", note: "skipped", warn: true}
 	}
 	if validateSkipClusterCheck {
-		return validateResult{name: "argocd crds", note: "skipped", warn: true}
+		return validateResult{id: "argocd-crds", name: "argocd crds", note: "skipped", warn: true}
 	}
 
 	client, err := k8s.NewClient(validateKubeconfig, validateContext)
 	if err != nil {
-		return validateResult{name: "argocd crds", err: err}
+		return validateResult{id: "argocd-crds", name: "argocd crds", err: err}
 	}
 
 	resources, err := client.Clientset.Discovery().ServerResourcesForGroupVersion("argoproj.io/v1alpha1")
 	if err != nil {
-		return validateResult{name: "argocd crds", note: "applications.argoproj.io not found", warn: true}
+		return validateResult{id: "argocd-crds", name: "argocd crds", note: "applications.argoproj.io not found", warn: true}
 	}
 
 	for _, res := range resources.APIResources {
 		if res.Name == "applications" {
-			return validateResult{name: "argocd crds", note: "applications found"}
+			return validateResult{id: "argocd-crds", name: "argocd crds", note: "applications found"}
 		}
 	}
 
-	return validateResult{name: "argocd crds", note: "applications resource missing", warn: true}
+	return validateResult{id: "argocd-crds", name: "argocd crds", note: "applications resource missing", warn: true}
 }
 
 func contextWithTimeout(seconds int) (context.Context, context.CancelFunc) {
@@ -421,76 +508,60 @@ func contextWithTimeout(seconds int) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), time.Duration(seconds)*time.Second)
 }
 
-func runValidateCheck(stage *StageLogger, name string, fn func() (string, error)) validateResult {
-	note, err := fn()
-	if err != nil {
-		stage.Detail("FAIL: %s", name)
-		return validateResult{name: name, note: note, err: err}
+// at points the result at file when it failed or warned.
+func (r validateResult) at(file string) validateResult {
+	if r.err != nil || r.warn {
+		r.locations = append(r.locations, report.Location{File: file})
 	}
-	stage.Detail("OK: %s", name)
-	return validateResult{name: name, note: note}
+	return r
 }
 
-func printValidateReport(env string, results []validateResult) {
-	fmt.Println()
-	fmt.Println("Validate report:")
-	for _, result := range results {
-		status := "OK"
-		if result.err != nil {
-			status = "FAIL"
-		} else if result.warn {
-			status = "WARN"
-		}
-		fmt.Printf("  - %s: %s", status, result.name)
-		if result.note != "" {
-			fmt.Printf(" (%s)", result.note)
-		}
-		fmt.Println()
-		if result.err != nil {
-			printDoctorError(result.err)
-		}
-		if result.warn && result.note == "missing creation rule for environment" {
-			fmt.Printf("      hint: run 'cluster-bootstrap init %s' or update .sops.yaml\n", env)
-		}
-		if result.warn && result.note == "missing git-crypt pattern" {
-			fmt.Printf("      hint: run 'cluster-bootstrap init --provider git-crypt' or update .gitattributes\n")
-		}
-		if result.warn && result.note == "repo.targetRevision is empty" {
-			fmt.Printf("      hint: set repo.targetRevision in %s\n", secretsFileForEnv(env))
-		}
-	}
-}
-
-func countValidateErrors(results []validateResult) int {
-	count := 0
-	for _, result := range results {
-		if result.err != nil {
-			count++
-		}
-	}
-	return count
+// result converts r for the check runner. Results noted as skipped are
+// reported as skipped rather than as warnings.
+func (r validateResult) result() checks.Result {
+	res := checks.Result{
+		ID:        r.id,
+		Name:      r.name,
+		Status:    report.StatusOK,
+		Note:      r.note,
+		Err:       r.err,
+		Hint:      r.hint,
+		Details:   r.details,
+		Locations: r.locations,
+	}
+	switch {
+	case r.err != nil:
+		res.Status = report.StatusFail
+	case r.warn && r.note == "skipped":
+		res.Status = report.StatusSkip
+	case r.warn:
+		res.Status = report.StatusWarn
+	}
+	return res
 }
 
 func validateSecretsWarnings(secretsPath string) validateResult {
 	if validateEncryption == "sops" {
 		secrets, err := config.LoadSecrets(secretsPath, &sops.Options{AgeKeyFile: validateAgeKeyFile})
 		if err != nil {
-			return validateResult{name: "secrets warnings", note: "skipped", warn: true}
+			return validateResult{id: "secrets-warnings", name: "secrets warnings", note: "skipped", warn: true}
 		}
 		if strings.TrimSpace(secrets.Repo.TargetRevision) == "" {
-			return validateResult{name: "secrets warnings", note: "repo.targetRevision is empty", warn: true}
+			return validateResult{id: "secrets-warnings", name: "secrets warnings", note: "repo.targetRevision is empty", warn: true,
+				hint: "set repo.targetRevision in " + secretsPath, locations: []report.Location{{File: secretsPath}}}
 		}
-		return validateResult{name: "secrets warnings", note: "none"}
+		return validateResult{id: "secrets-warnings", name: "secrets warnings", note: "none"}
 	}
 
 	secrets, err := config.LoadSecretsPlaintext(secretsPath)
 	if err != nil {
-		return validateResult{name: "secrets warnings", note: "skipped", warn: true}
+		return validateResult{id: "secrets-warnings", name: "secrets warnings", note: "skipped", warn: true}
 	}
 	if strings.TrimSpace(secrets.Repo.TargetRevision) == "" {
-		return validateResult{name: "secrets warnings", note: "repo.targetRevision is empty", warn: true}
+		return validateResult{id: "secrets-warnings", name: "secrets warnings", note: "repo.targetRevision is empty", warn: true,
+			hint: "set repo.targetRevision in " + secretsPath, locations: []report.Location{{File: secretsPath}}}
 	}
-	return validateResult{name: "secrets warnings", note: "none"}
+	return validateResult{id: "secrets-warnings", name: "secrets warnings", note: "none"}
 }
 
 func secretsFileForEnv(env string) string {
@@ -502,3 +573,93 @@ func secretsFileForEnv(env string) string {
 	}
 	return filepath.Join(baseDir, config.SecretsFileName(env))
 }
+
+// validatePolicy renders the environment and reports policy findings as one
+// result per rule and component, with file:line details.
+func validatePolicy(env string) []validateResult {
+	if validateSkipPolicy {
+		return []validateResult{{id: "policy", name: "policy", note: "skipped", warn: true}}
+	}
+
+	policyPath := validatePolicyFile
+	if policyPath == "" {
+		policyPath = filepath.Join(baseDir, policy.DefaultConfigFile)
+	}
+	cfg, err := policy.LoadConfig(policyPath)
+	if err != nil {
+		return []validateResult{{id: "policy", name: "policy", err: err}}
+	}
+
+	result, err := render.Environment(render.Options{
+		BaseDir:     baseDir,
+		Env:         env,
+		KubeVersion: validateKubeVersion,
+		Verbose:     verbose,
+	})
+	if err != nil {
+		return []validateResult{{id: "policy", name: "policy", err: fmt.Errorf("failed to render %s: %w\n  hint: fix the render error or rerun with --skip-policy", env, err)}}
+	}
+
+	findings := policy.Evaluate(result, baseDir, cfg, time.Now())
+	if len(findings) == 0 {
+		return []validateResult{{id: "policy", name: "policy", note: fmt.Sprintf("%d component(s), no findings", len(result.Components))}}
+	}
+
+	type groupKey struct{ rule, component string }
+	groups := make(map[groupKey][]policy.Finding)
+	var order []groupKey
+	for _, f := range findings {
+		key := groupKey{f.Rule, f.Component}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], f)
+	}
+
+	results := make([]validateResult, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		res := validateResult{id: "policy/" + key.rule, name: fmt.Sprintf("policy %s [%s]", key.rule, key.component)}
+		waived := 0
+		for _, f := range group {
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			detail := fmt.Sprintf("%s: %s/%s: %s", location, f.Kind, f.Name, f.Message)
+			if f.Waived {
+				waived++
+				detail += fmt.Sprintf(" (waived: %s)", f.WaiverReason)
+			} else if f.File != "" {
+				res.locations = append(res.locations, report.Location{File: f.File, Line: f.Line, Message: fmt.Sprintf("%s/%s: %s", f.Kind, f.Name, f.Message)})
+			}
+			res.details = append(res.details, detail)
+		}
+		active := len(group) - waived
+		res.note = fmt.Sprintf("%d finding(s)", len(group))
+		if waived > 0 {
+			res.note = fmt.Sprintf("%d finding(s), %d waived", len(group), waived)
+		}
+		severity := group[0].Severity
+		switch {
+		case active == 0:
+		case severity == policy.SeverityError:
+			res.err = fmt.Errorf("%s\n  hint: fix the chart values or add a waiver with a reason to %s", ruleDescription(key.rule), policyPath)
+		case severity == policy.SeverityWarning:
+			res.warn = true
+		default:
+			res.note += ", " + string(severity)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func ruleDescription(id string) string {
+	for _, rule := range policy.Rules {
+		if rule.ID == id {
+			return rule.Description
+		}
+	}
+	return id
+}
@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,8 +14,13 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
 	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/gitremote"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
 )
 
 func TestValidateSopsConfig(t *testing.T) {
@@ -116,6 +124,30 @@ func TestValidateSopsConfig(t *testing.T) {
 	}
 }
 
+func TestValidateSopsKeys(t *testing.T) {
+	oldBaseDir, oldEncryption := baseDir, validateEncryption
+	defer func() { baseDir, validateEncryption = oldBaseDir, oldEncryption }()
+	t.Setenv("SOPS_CONFIG", "")
+	baseDir = t.TempDir()
+	validateEncryption = "sops"
+
+	sopsPath := filepath.Join(baseDir, ".sops.yaml")
+	require.NoError(t, os.WriteFile(sopsPath, []byte(`creation_rules:
+  - path_regex: secrets\.dev\.enc\.yaml$
+    age: age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8
+`), 0600))
+	result := validateSopsKeys()
+	assert.NoError(t, result.err)
+	assert.Equal(t, "1 rule(s) well formed", result.note)
+
+	require.NoError(t, os.WriteFile(sopsPath, []byte(`creation_rules:
+  - path_regex: secrets\.dev\.enc\.yaml$
+    pgp: not-a-fingerprint
+`), 0600))
+	result = validateSopsKeys()
+	assert.ErrorContains(t, result.err, "invalid pgp key")
+}
+
 func TestValidateGitCryptAttributes(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -289,79 +321,59 @@ func TestContextWithTimeout(t *testing.T) {
 	}
 }
 
-func TestCountValidateErrors(t *testing.T) {
-	tests := []struct {
-		name     string
-		results  []validateResult
-		expected int
-	}{
-		{
-			name:     "no errors",
-			results:  []validateResult{{name: "test", note: "ok"}},
-			expected: 0,
-		},
-		{
-			name: "one error",
-			results: []validateResult{
-				{name: "test1", note: "ok"},
-				{name: "test2", err: fmt.Errorf("error")},
-			},
-			expected: 1,
-		},
-		{
-			name: "multiple errors",
-			results: []validateResult{
-				{name: "test1", err: fmt.Errorf("error1")},
-				{name: "test2", note: "ok"},
-				{name: "test3", err: fmt.Errorf("error2")},
-				{name: "test4", warn: true},
-			},
-			expected: 2,
-		},
-		{
-			name:     "empty results",
-			results:  []validateResult{},
-			expected: 0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			count := countValidateErrors(tt.results)
-			assert.Equal(t, tt.expected, count)
-		})
-	}
-}
-
-func TestRunValidateCheck(t *testing.T) {
-	logger := NewLogger(false)
-	stage := logger.Stage("test")
-
+func TestCheckFunc(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
-		result := runValidateCheck(stage, "test check", func() (string, error) {
+		results := checkFunc(func() (string, error) {
 			return "success note", nil
-		})
+		})(context.Background())
 
-		assert.Equal(t, "test check", result.name)
-		assert.Equal(t, "success note", result.note)
-		assert.NoError(t, result.err)
-		assert.False(t, result.warn)
+		require.Len(t, results, 1)
+		assert.Equal(t, report.StatusOK, results[0].Status)
+		assert.Equal(t, "success note", results[0].Note)
+		assert.NoError(t, results[0].Err)
 	})
 
 	t.Run("failure", func(t *testing.T) {
 		expectedErr := fmt.Errorf("test error")
-		result := runValidateCheck(stage, "failing check", func() (string, error) {
+		results := checkFunc(func() (string, error) {
 			return "fail note", expectedErr
-		})
+		})(context.Background())
 
-		assert.Equal(t, "failing check", result.name)
-		assert.Equal(t, "fail note", result.note)
-		assert.Error(t, result.err)
-		assert.Equal(t, expectedErr, result.err)
-		assert.False(t, result.warn)
+		require.Len(t, results, 1)
+		assert.Equal(t, "fail note", results[0].Note)
+		assert.Equal(t, expectedErr, results[0].Err)
 	})
 }
 
+func TestCheckReport(t *testing.T) {
+	var checkResults []checks.Result
+	for _, res := range []validateResult{
+		{id: "helm", name: "helm available"},
+		{id: "helm-lint", name: "helm lint", note: "skipped", warn: true},
+		{id: "sops-config", name: ".sops.yaml", note: "missing creation rule for environment", warn: true,
+			hint: "update .sops.yaml", locations: []report.Location{{File: ".sops.yaml"}}},
+		{id: "repo-access", name: "repo access", err: fmt.Errorf("repository not found\n  hint: check repo.url")},
+	} {
+		checkResults = append(checkResults, res.result())
+	}
+	results := checkReport("validate", "dev", checkResults).Results
+
+	require.Len(t, results, 4)
+	assert.Equal(t, report.StatusOK, results[0].Status)
+	assert.Equal(t, report.StatusSkip, results[1].Status)
+	assert.Equal(t, report.StatusWarn, results[2].Status)
+	assert.Equal(t, "update .sops.yaml", results[2].Hint)
+	assert.Equal(t, ".sops.yaml", results[2].Locations[0].File)
+	assert.Equal(t, report.StatusFail, results[3].Status)
+	assert.Equal(t, "repository not found", results[3].Error)
+	assert.Equal(t, "check repo.url", results[3].Hint)
+
+	rep := report.New("validate", "dev", "dev", results)
+	assert.Equal(t, report.ExitFailure, rep.ExitCode())
+	assert.Equal(t, report.ExitWarning, report.New("validate", "dev", "dev", results[:3]).ExitCode())
+	assert.Equal(t, report.ExitOK, report.New("validate", "dev", "dev", results[:2]).ExitCode())
+}
+
 func TestSecretsFileForEnv(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -627,6 +639,64 @@ func TestValidateSSHRepoAccess(t *testing.T) {
 	})
 }
 
+func TestValidateRepoAccess_SSH(t *testing.T) {
+	prevBaseDir, prevTimeout := baseDir, validateRepoTimeout
+	t.Cleanup(func() { baseDir, validateRepoTimeout = prevBaseDir, prevTimeout })
+	baseDir = t.TempDir()
+	validateRepoTimeout = 5
+
+	addr, hostKey := serveHostKey(t)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	secrets := &config.EnvironmentSecrets{Repo: config.RepoSecrets{
+		URL:                 "ssh://git@" + addr + "/org/repo.git",
+		SSHPrivateKey:       string(pem.EncodeToMemory(block)),
+		HostKeyFingerprints: []string{knownhosts.Fingerprint(hostKey)},
+	}}
+
+	result := validateRepoAccess(secrets)
+	require.NoError(t, result.err)
+	assert.Equal(t, "reachable, host key "+knownhosts.Fingerprint(hostKey), result.note)
+
+	// The server rejects every key.
+	result = validateSSHRepoAccess(secrets)
+	assert.ErrorIs(t, result.err, gitremote.ErrAuth)
+	assert.ErrorContains(t, result.err, "deploy key with read access")
+
+	secrets.Repo.HostKeyFingerprints = []string{"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU"}
+	result = validateRepoAccess(secrets)
+	assert.ErrorIs(t, result.err, gitremote.ErrHostKeyMismatch)
+	result = validateSSHRepoAccess(secrets)
+	assert.ErrorIs(t, result.err, gitremote.ErrHostKeyMismatch)
+	assert.ErrorContains(t, result.err, "connection may be intercepted")
+}
+
+func TestValidateRepoKey(t *testing.T) {
+	assert.Equal(t, "skipped", validateRepoKey(nil).note)
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	result := validateRepoKey(&config.EnvironmentSecrets{Repo: config.RepoSecrets{SSHPrivateKey: string(pem.EncodeToMemory(block))}})
+	require.NoError(t, result.err)
+	assert.Contains(t, result.note, "ssh-ed25519, 256-bit, SHA256:")
+
+	block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	require.NoError(t, err)
+	result = validateRepoKey(&config.EnvironmentSecrets{Repo: config.RepoSecrets{SSHPrivateKey: string(pem.EncodeToMemory(block))}})
+	assert.ErrorContains(t, result.err, "passphrase protected")
+}
+
+func TestRevisionNote(t *testing.T) {
+	sha := "1a2b3c4d5e6f1a2b3c4d5e6f1a2b3c4d5e6f1a2b"
+	assert.Equal(t, "branch main at 1a2b3c4", revisionNote(&gitremote.Revision{Kind: "branch", Ref: "refs/heads/main", SHA: sha, Verified: true}))
+	assert.Equal(t, "tag v1.0.0 at 1a2b3c4", revisionNote(&gitremote.Revision{Kind: "tag", Ref: "refs/tags/v1.0.0", SHA: sha, Verified: true}))
+	assert.Contains(t, revisionNote(&gitremote.Revision{Kind: "commit", SHA: sha}), "cannot be checked without fetching")
+}
+
 func TestValidateHelmLint(t *testing.T) {
 	t.Run("skip when flag set", func(t *testing.T) {
 		oldSkip := validateSkipHelmLint
@@ -707,35 +777,31 @@ func TestValidateArgoCDCRDs(t *testing.T) {
 	})
 }
 
-func TestPrintValidateReport(t *testing.T) {
+func TestPrintCheckReport(t *testing.T) {
 	tests := []struct {
 		name    string
-		env     string
-		results []validateResult
+		results []checks.Result
 	}{
 		{
 			name: "all successful",
-			env:  "dev",
-			results: []validateResult{
-				{name: "check1", note: "ok"},
-				{name: "check2", note: "passed"},
+			results: []checks.Result{
+				{Name: "check1", Status: report.StatusOK, Note: "ok"},
+				{Name: "check2", Status: report.StatusOK, Note: "passed"},
 			},
 		},
 		{
 			name: "with errors",
-			env:  "dev",
-			results: []validateResult{
-				{name: "check1", note: "ok"},
-				{name: "check2", err: fmt.Errorf("failed")},
+			results: []checks.Result{
+				{Name: "check1", Status: report.StatusOK, Note: "ok"},
+				{Name: "check2", Status: report.StatusFail, Err: fmt.Errorf("failed")},
 			},
 		},
 		{
-			name: "with warnings",
-			env:  "dev",
-			results: []validateResult{
-				{name: "check1", note: "ok"},
-				{name: ".sops.yaml", note: "missing creation rule for environment", warn: true},
-				{name: "secrets warnings", note: "repo.targetRevision is empty", warn: true},
+			name: "with warnings and skips",
+			results: []checks.Result{
+				{Name: "check1", Status: report.StatusOK, Note: "ok"},
+				{Name: ".sops.yaml", Status: report.StatusWarn, Note: "missing creation rule for environment", Hint: "update .sops.yaml"},
+				{Name: "repo access", Status: report.StatusSkip, Note: "skipped: secrets-content failed"},
 			},
 		},
 	}
@@ -743,7 +809,7 @@ func TestPrintValidateReport(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Just verify it doesn't panic
-			printValidateReport(tt.env, tt.results)
+			printCheckReport("Validate report", tt.results)
 		})
 	}
 }
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/checks"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/compat"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+// kubeClient returns the cluster client of the run, creating it once.
+func (run *checkRun) kubeClient() (*k8s.Client, error) {
+	run.kubeOnce.Do(func() {
+		if run.kube == nil {
+			run.kube, run.kubeErr = k8s.NewClient(run.kubeconfig, run.kubeContext)
+		}
+	})
+	return run.kube, run.kubeErr
+}
+
+// clusterVersion returns the Kubernetes version of the cluster, asking the
+// server once per run.
+func (run *checkRun) clusterVersion() (string, error) {
+	run.serverOnce.Do(func() {
+		client, err := run.kubeClient()
+		if err != nil {
+			run.serverErr = err
+			return
+		}
+		info, err := client.Clientset.Discovery().ServerVersion()
+		if err != nil {
+			run.serverErr = fmt.Errorf("failed to read the cluster version: %w\n  hint: verify kubeconfig/context and cluster access", err)
+			return
+		}
+		run.serverVersion = info.GitVersion
+	})
+	return run.serverVersion, run.serverErr
+}
+
+// toolVersionResults checks the installed tools of the run's encryption
+// backend against the version matrix. Tools that are not installed are left
+// to the availability checks.
+func toolVersionResults(ctx context.Context, run *checkRun) []checks.Result {
+	var results []checks.Result
+	for _, tool := range run.matrix.ToolsFor(run.encryption) {
+		res := checks.Result{ID: "tool-versions/" + tool.Name, Name: tool.Name + " version"}
+		v, err := compat.ToolVersion(ctx, tool)
+		switch {
+		case errors.Is(err, compat.ErrNotInstalled):
+			continue
+		case err != nil:
+			res.Status = report.StatusWarn
+			res.Note = "version unknown"
+			res.Hint = err.Error()
+		default:
+			res.Note = v.String()
+			res.Err = compat.CheckTool(tool, v)
+		}
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		return checks.OK("no tools found")
+	}
+	return results
+}
+
+// kubeVersionResult checks the cluster's Kubernetes version against the
+// version matrix, and the kubectl client against the supported skew.
+func kubeVersionResult(ctx context.Context, run *checkRun) []checks.Result {
+	if run.skipCluster {
+		return checks.Skip("skipped")
+	}
+	gitVersion, err := run.clusterVersion()
+	if err != nil {
+		return checks.Fail(err)
+	}
+	server, err := semver.NewVersion(gitVersion)
+	if err != nil {
+		return checks.Warn(gitVersion, "cannot parse the cluster version: "+err.Error())
+	}
+	if err := run.matrix.CheckKubernetes(server); err != nil {
+		return []checks.Result{{Note: gitVersion, Err: err}}
+	}
+	if client, err := compat.ToolVersion(ctx, compat.Tool{Name: "kubectl", Args: []string{"version", "--client"}}); err == nil {
+		if err := compat.KubectlSkew(client, server); err != nil {
+			return checks.Warn(gitVersion+", "+err.Error(), fmt.Sprintf("use kubectl %d.%d", server.Major(), server.Minor()))
+		}
+	}
+	return checks.OK(gitVersion)
+}
+
+// chartKubeVersionResults checks the kubeVersion of every enabled component
+// chart and its dependencies against the cluster, or --kube-version without
+// one. Charts are loaded as render loads them, fetching missing dependencies.
+func chartKubeVersionResults(run *checkRun) []checks.Result {
+	server := run.kubeVersion
+	if !run.skipCluster {
+		var err error
+		if server, err = run.clusterVersion(); err != nil {
+			return checks.Fail(err)
+		}
+	}
+	if server == "" {
+		return checks.Skip("skipped: no cluster; pass --kube-version to check the charts against a version")
+	}
+
+	catalog, err := components.Load(baseDir, run.env)
+	if err != nil {
+		return checks.Fail(err)
+	}
+	settings := cli.New()
+	var results []checks.Result
+	checked := 0
+	for _, comp := range catalog.Enabled() {
+		chartFile := filepath.Join(components.ComponentsDir, comp.Name, "Chart.yaml")
+		ch, err := helm.LoadChartWithDependencies(settings, components.ChartDir(baseDir, comp.Name), false)
+		if err != nil {
+			results = append(results, checks.Result{
+				ID:        "chart-kube-versions/" + comp.Name,
+				Name:      fmt.Sprintf("chart kubeVersion [%s]", comp.Name),
+				Status:    report.StatusWarn,
+				Note:      "chart not loaded",
+				Hint:      "run 'helm dependency build' in " + filepath.Dir(chartFile) + " or check access to the chart repository",
+				Details:   strings.Split(err.Error(), "\n"),
+				Locations: []report.Location{{File: chartFile}},
+			})
+			continue
+		}
+		for _, r := range run.matrix.ChartRanges(comp.Name, ch) {
+			checked++
+			if err := r.Check(server); err != nil {
+				results = append(results, checks.Result{
+					ID:        "chart-kube-versions/" + comp.Name,
+					Name:      fmt.Sprintf("chart kubeVersion %s [%s]", r.Chart, comp.Name),
+					Err:       err,
+					Locations: []report.Location{{File: chartFile}},
+				})
+			}
+		}
+	}
+	if len(results) == 0 {
+		return checks.OK(fmt.Sprintf("%d chart(s) with a kubeVersion accept %s", checked, server))
+	}
+	return results
+}
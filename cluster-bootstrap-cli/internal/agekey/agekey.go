@@ -0,0 +1,145 @@
+// Package agekey generates and reads age identities for SOPS, without the
+// age binaries.
+package agekey
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+const (
+	// KeyFileEnv is the variable SOPS reads the age key file path from.
+	KeyFileEnv = "SOPS_AGE_KEY_FILE"
+	// KeyEnv is the variable SOPS reads age identities from directly.
+	KeyEnv = "SOPS_AGE_KEY"
+	// userConfigPath is the key file SOPS reads under the user config directory.
+	userConfigPath = "sops/age/keys.txt"
+)
+
+// Identity is an age identity found on this machine.
+type Identity struct {
+	Recipient string
+	// Source is the file or environment variable the identity was read from.
+	Source string
+}
+
+// DefaultKeyFile returns the key file SOPS uses: $SOPS_AGE_KEY_FILE, or
+// sops/age/keys.txt in the user config directory ($XDG_CONFIG_HOME is also
+// honoured on macOS, as SOPS does).
+func DefaultKeyFile() (string, error) {
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		return path, nil
+	}
+	dir := ""
+	if runtime.GOOS == "darwin" {
+		dir = os.Getenv("XDG_CONFIG_HOME")
+	}
+	if dir == "" {
+		var err error
+		if dir, err = os.UserConfigDir(); err != nil {
+			return "", fmt.Errorf("failed to locate the user config directory: %w\n  hint: set %s", err, KeyFileEnv)
+		}
+	}
+	return filepath.Join(dir, filepath.FromSlash(userConfigPath)), nil
+}
+
+// Parse reads the X25519 identities in an age key file. Comments and blank
+// lines are skipped; other identity types are ignored, as SOPS only
+// encrypts to X25519 recipients.
+func Parse(data []byte, source string) ([]Identity, error) {
+	var identities []Identity
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || !strings.HasPrefix(line, "AGE-SECRET-KEY-1") {
+			continue
+		}
+		id, err := age.ParseX25519Identity(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", source, n+1, err)
+		}
+		identities = append(identities, Identity{Recipient: id.Recipient().String(), Source: source})
+	}
+	return identities, nil
+}
+
+// Load reads the identities in an age key file.
+func Load(path string) ([]Identity, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age key file: %w", err)
+	}
+	return Parse(data, path)
+}
+
+// Local returns the identities SOPS would use on this machine: those in
+// $SOPS_AGE_KEY and in the default key file. A missing key file is not an
+// error.
+func Local() ([]Identity, error) {
+	var identities []Identity
+	if value := os.Getenv(KeyEnv); value != "" {
+		ids, err := Parse([]byte(value), "$"+KeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, ids...)
+	}
+	path, err := DefaultKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := Load(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return append(identities, ids...), nil
+}
+
+// Ensure returns the first identity in the key file at path, generating one
+// when the file is missing or holds none. A generated identity is appended
+// in age-keygen format and the file is made readable only by its owner.
+func Ensure(path string) (*Identity, bool, error) {
+	existing, err := Load(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, err
+	}
+	if len(existing) > 0 {
+		return &existing[0], false, nil
+	}
+
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to generate age identity: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	var buf bytes.Buffer
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 { // #nosec G304
+		buf.Write(data)
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	fmt.Fprintf(&buf, "# created: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "# public key: %s\n", id.Recipient())
+	fmt.Fprintf(&buf, "%s\n", id)
+
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return nil, false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(path, 0600); err != nil {
+		return nil, false, fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	return &Identity{Recipient: id.Recipient().String(), Source: path}, true, nil
+}
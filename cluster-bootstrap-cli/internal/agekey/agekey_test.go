@@ -0,0 +1,63 @@
+package agekey
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sops", "age", "keys.txt")
+
+	id, created, err := Ensure(path)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, strings.HasPrefix(id.Recipient, "age1"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# public key: "+id.Recipient+"\n")
+
+	again, created, err := Ensure(path)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, id.Recipient, again.Recipient)
+
+	identities, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	assert.Equal(t, path, identities[0].Source)
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse([]byte("AGE-SECRET-KEY-1NOTVALID\n"), "keys.txt")
+	assert.ErrorContains(t, err, "keys.txt line 1")
+
+	ids, err := Parse([]byte("# comment\n\nAGE-PLUGIN-YUBIKEY-1ABC\n"), "keys.txt")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	t.Setenv(KeyFileEnv, path)
+	t.Setenv(KeyEnv, "")
+
+	ids, err := Local()
+	require.NoError(t, err)
+	assert.Empty(t, ids, "a missing key file is not an error")
+
+	id, _, err := Ensure(path)
+	require.NoError(t, err)
+	ids, err = Local()
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.Equal(t, id.Recipient, ids[0].Recipient)
+}
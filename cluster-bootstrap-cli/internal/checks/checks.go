@@ -0,0 +1,414 @@
+// Package checks runs the prerequisite and validation checks of doctor,
+// validate and bootstrap: checks declare an ID, category, dependencies,
+// timeout and the encryption backends they apply to, and run in parallel
+// as far as their dependencies allow.
+package checks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+// Categories of the built-in checks.
+const (
+	CategoryTools      = "tools"
+	CategoryCluster    = "cluster"
+	CategoryConfig     = "config"
+	CategorySecrets    = "secrets"
+	CategoryEncryption = "encryption"
+	CategoryRepo       = "repo"
+	CategoryChart      = "chart"
+	CategoryPolicy     = "policy"
+	CategoryCustom     = "custom"
+)
+
+// DefaultTimeout bounds checks that do not declare a timeout.
+const DefaultTimeout = 2 * time.Minute
+
+// Check is a registered check.
+type Check struct {
+	ID          string
+	Name        string
+	Category    string
+	Description string
+	// Commands are the commands that run the check: doctor, validate,
+	// preflight or preflight-cluster.
+	Commands []string
+	// Backends are the encryption backends the check applies to; empty for
+	// all.
+	Backends []string
+	// DependsOn lists the IDs of checks that must pass first. Dependencies
+	// that do not apply, e.g. to another backend, are ignored.
+	DependsOn []string
+	Timeout   time.Duration
+	// Run performs the check. Most checks return one result; checks such as
+	// the policy check return one per finding group, with their own IDs.
+	Run func(ctx context.Context) []Result
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	// ID and Name default to the check's.
+	ID        string
+	Name      string
+	Status    report.Status
+	Note      string
+	Err       error
+	Hint      string
+	Details   []string
+	Locations []report.Location
+}
+
+// OK returns a passing result.
+func OK(note string) []Result {
+	return []Result{{Status: report.StatusOK, Note: note}}
+}
+
+// Warn returns a warning.
+func Warn(note, hint string) []Result {
+	return []Result{{Status: report.StatusWarn, Note: note, Hint: hint}}
+}
+
+// Fail returns a failure.
+func Fail(err error) []Result {
+	return []Result{{Status: report.StatusFail, Err: err}}
+}
+
+// Skip returns a result for a check that did not run.
+func Skip(note string) []Result {
+	return []Result{{Status: report.StatusSkip, Note: note}}
+}
+
+// Report converts the result for machine-readable output, splitting the
+// hint from the error.
+func (r Result) Report() report.Result {
+	res := report.Result{
+		ID:        r.ID,
+		Name:      r.Name,
+		Status:    r.Status,
+		Note:      r.Note,
+		Hint:      r.Hint,
+		Details:   r.Details,
+		Locations: r.Locations,
+	}
+	if r.Err != nil {
+		res.Status = report.StatusFail
+		res.Error, res.Hint = report.SplitHint(r.Err.Error())
+	}
+	return res
+}
+
+// Registry holds checks in registration order, which is also the order of
+// their results.
+type Registry struct {
+	checks []*Check
+	byID   map[string]*Check
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byID: map[string]*Check{}}
+}
+
+// Register adds checks. IDs must be unique.
+func (r *Registry) Register(checks ...Check) error {
+	for _, c := range checks {
+		if c.ID == "" {
+			return fmt.Errorf("check %q has no ID", c.Name)
+		}
+		if _, ok := r.byID[c.ID]; ok {
+			return fmt.Errorf("check %s is registered twice", c.ID)
+		}
+		if c.Name == "" {
+			c.Name = c.ID
+		}
+		check := c
+		r.checks = append(r.checks, &check)
+		r.byID[c.ID] = &check
+	}
+	return nil
+}
+
+// Checks returns the registered checks.
+func (r *Registry) Checks() []Check {
+	out := make([]Check, len(r.checks))
+	for i, c := range r.checks {
+		out[i] = *c
+	}
+	return out
+}
+
+// Lookup returns the check registered with id.
+func (r *Registry) Lookup(id string) (Check, bool) {
+	c, ok := r.byID[id]
+	if !ok {
+		return Check{}, false
+	}
+	return *c, true
+}
+
+// Selection filters checks by ID or category.
+type Selection struct {
+	// Only keeps the matching checks and the checks they depend on.
+	Only []string
+	// Skip drops the matching checks; checks depending on them are
+	// reported as skipped.
+	Skip []string
+}
+
+func (c *Check) matches(selector string) bool {
+	return c.ID == selector || c.Category == selector
+}
+
+func (c *Check) appliesTo(command, backend string) bool {
+	if len(c.Commands) > 0 && !slices.Contains(c.Commands, command) {
+		return false
+	}
+	return len(c.Backends) == 0 || backend == "" || slices.Contains(c.Backends, backend)
+}
+
+// Plan is the set of checks a command runs.
+type Plan struct {
+	checks []*Check
+	// skipped are checks dropped by Selection.Skip, which dependents
+	// report.
+	skipped map[string]bool
+}
+
+// Checks returns the planned checks.
+func (p *Plan) Checks() []Check {
+	out := make([]Check, len(p.checks))
+	for i, c := range p.checks {
+		out[i] = *c
+	}
+	return out
+}
+
+// Plan selects the checks command runs for backend. Selectors that match no
+// check of the command are an error.
+func (r *Registry) Plan(command, backend string, sel Selection) (*Plan, error) {
+	var applicable []*Check
+	for _, c := range r.checks {
+		if c.appliesTo(command, backend) {
+			applicable = append(applicable, c)
+		}
+	}
+	for _, selector := range append(slices.Clone(sel.Only), sel.Skip...) {
+		if !slices.ContainsFunc(applicable, func(c *Check) bool { return c.matches(selector) }) {
+			return nil, fmt.Errorf("no %s check has ID or category %q\n  hint: run 'cluster-bootstrap checks list --command %s' to see the checks", command, selector, command)
+		}
+	}
+
+	selected := map[string]bool{}
+	if len(sel.Only) == 0 {
+		for _, c := range applicable {
+			selected[c.ID] = true
+		}
+	} else {
+		var include func(c *Check)
+		include = func(c *Check) {
+			if selected[c.ID] {
+				return
+			}
+			selected[c.ID] = true
+			for _, dep := range c.DependsOn {
+				if d, ok := r.byID[dep]; ok && d.appliesTo(command, backend) {
+					include(d)
+				}
+			}
+		}
+		for _, c := range applicable {
+			if slices.ContainsFunc(sel.Only, c.matches) {
+				include(c)
+			}
+		}
+	}
+
+	plan := &Plan{skipped: map[string]bool{}}
+	for _, c := range applicable {
+		switch {
+		case !selected[c.ID]:
+		case slices.ContainsFunc(sel.Skip, c.matches):
+			plan.skipped[c.ID] = true
+		default:
+			plan.checks = append(plan.checks, c)
+		}
+	}
+	if err := checkCycles(plan.checks); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// checkCycles reports dependency cycles among checks.
+func checkCycles(checks []*Check) error {
+	byID := map[string]*Check{}
+	for _, c := range checks {
+		byID[c.ID] = c
+	}
+	state := map[string]int{} // 1: visiting, 2: done
+	var visit func(c *Check, path []string) error
+	visit = func(c *Check, path []string) error {
+		switch state[c.ID] {
+		case 1:
+			return fmt.Errorf("checks depend on each other: %s", strings.Join(append(path, c.ID), " -> "))
+		case 2:
+			return nil
+		}
+		state[c.ID] = 1
+		for _, dep := range c.DependsOn {
+			if d, ok := byID[dep]; ok {
+				if err := visit(d, append(path, c.ID)); err != nil {
+					return err
+				}
+			}
+		}
+		state[c.ID] = 2
+		return nil
+	}
+	for _, c := range checks {
+		if err := visit(c, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunOptions configures Run.
+type RunOptions struct {
+	// Parallelism bounds how many checks run at once; 1 runs them
+	// sequentially.
+	Parallelism int
+	// OnResult is called as each check completes, one call at a time.
+	OnResult func(Check, []Result)
+}
+
+// Run runs the planned checks, each once its dependencies completed.
+// Checks whose dependencies failed or were skipped, by the selection or for
+// a failure further up, are reported as skipped. Results are returned in
+// registration order.
+func (p *Plan) Run(ctx context.Context, opts RunOptions) []Result {
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	done := make(map[string]chan struct{}, len(p.checks))
+	for _, c := range p.checks {
+		done[c.ID] = make(chan struct{})
+	}
+	results := make([][]Result, len(p.checks))
+	failed := make([]bool, len(p.checks))
+	blocked := make([]bool, len(p.checks))
+	index := map[string]int{}
+	for i, c := range p.checks {
+		index[c.ID] = i
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallelism)
+	for i, c := range p.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[c.ID])
+
+			var reason string
+			for _, dep := range c.DependsOn {
+				if p.skipped[dep] {
+					reason = dep + " was skipped"
+					continue
+				}
+				ch, ok := done[dep]
+				if !ok {
+					continue
+				}
+				<-ch
+				switch {
+				case reason != "":
+				case failed[index[dep]]:
+					reason = dep + " failed"
+				case blocked[index[dep]]:
+					reason = dep + " was skipped"
+				}
+			}
+
+			var res []Result
+			if reason != "" {
+				blocked[i] = true
+				res = Skip("skipped: " + reason)
+			} else {
+				slots <- struct{}{}
+				res = runCheck(ctx, c)
+				<-slots
+			}
+			for j := range res {
+				if res[j].ID == "" {
+					res[j].ID = c.ID
+				}
+				if res[j].Name == "" {
+					res[j].Name = c.Name
+				}
+				if res[j].Err != nil {
+					res[j].Status = report.StatusFail
+				}
+				if res[j].Status == "" {
+					res[j].Status = report.StatusOK
+				}
+				if res[j].Status == report.StatusFail {
+					failed[i] = true
+				}
+			}
+			results[i] = res
+
+			if opts.OnResult != nil {
+				mu.Lock()
+				opts.OnResult(*c, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var out []Result
+	for _, res := range results {
+		out = append(out, res...)
+	}
+	return out
+}
+
+// runCheck runs c with its timeout. A check that does not return in time is
+// abandoned and reported as failed.
+func runCheck(ctx context.Context, c *Check) []Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ch := make(chan []Result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- Fail(fmt.Errorf("check panicked: %v", r))
+			}
+		}()
+		ch <- c.Run(ctx)
+	}()
+	select {
+	case res := <-ch:
+		if len(res) == 0 {
+			return OK("")
+		}
+		return res
+	case <-ctx.Done():
+		return Fail(fmt.Errorf("did not complete within %s\n  hint: the check may be waiting on a slow tool, network or cluster", timeout))
+	}
+}
@@ -0,0 +1,176 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+func testRegistry(t *testing.T, checks ...Check) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	require.NoError(t, r.Register(checks...))
+	return r
+}
+
+func ok(note string) func(context.Context) []Result {
+	return func(context.Context) []Result { return OK(note) }
+}
+
+func fail(msg string) func(context.Context) []Result {
+	return func(context.Context) []Result { return Fail(errors.New(msg)) }
+}
+
+func ids(checks []Check) []string {
+	var out []string
+	for _, c := range checks {
+		out = append(out, c.ID)
+	}
+	return out
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(Check{ID: "helm", Run: ok("")}))
+	assert.ErrorContains(t, r.Register(Check{ID: "helm"}), "registered twice")
+	assert.ErrorContains(t, r.Register(Check{Name: "nameless"}), "has no ID")
+
+	c, found := r.Lookup("helm")
+	require.True(t, found)
+	assert.Equal(t, "helm", c.Name)
+}
+
+func TestPlan(t *testing.T) {
+	r := testRegistry(t,
+		Check{ID: "kubectl", Category: CategoryTools, Run: ok("")},
+		Check{ID: "cluster-access", Category: CategoryCluster, DependsOn: []string{"kubectl"}, Run: ok("")},
+		Check{ID: "helm", Category: CategoryTools, Commands: []string{"doctor"}, Run: ok("")},
+		Check{ID: "sops-config", Category: CategoryEncryption, Backends: []string{"sops"}, Run: ok("")},
+	)
+
+	plan, err := r.Plan("validate", "git-crypt", Selection{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubectl", "cluster-access"}, ids(plan.Checks()))
+
+	plan, err = r.Plan("doctor", "sops", Selection{Only: []string{"cluster"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubectl", "cluster-access"}, ids(plan.Checks()), "dependencies are included")
+
+	plan, err = r.Plan("doctor", "sops", Selection{Skip: []string{"tools"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cluster-access", "sops-config"}, ids(plan.Checks()))
+
+	_, err = r.Plan("validate", "sops", Selection{Only: []string{"helm"}})
+	assert.ErrorContains(t, err, `no validate check has ID or category "helm"`)
+}
+
+func TestPlanCycle(t *testing.T) {
+	r := testRegistry(t,
+		Check{ID: "a", DependsOn: []string{"b"}, Run: ok("")},
+		Check{ID: "b", DependsOn: []string{"a"}, Run: ok("")},
+	)
+	_, err := r.Plan("validate", "", Selection{})
+	assert.ErrorContains(t, err, "a -> b -> a")
+}
+
+func TestRunDependencies(t *testing.T) {
+	var order []string
+	r := testRegistry(t,
+		Check{ID: "secrets", DependsOn: []string{"tools"}, Run: fail("cannot decrypt")},
+		Check{ID: "tools", Run: ok("installed")},
+		Check{ID: "repo", DependsOn: []string{"secrets"}, Run: ok("")},
+		Check{ID: "key", DependsOn: []string{"skipped"}, Run: ok("")},
+		Check{ID: "skipped", Run: ok("")},
+		Check{ID: "deploy", DependsOn: []string{"repo"}, Run: ok("")},
+	)
+	plan, err := r.Plan("validate", "", Selection{Skip: []string{"skipped"}})
+	require.NoError(t, err)
+
+	results := plan.Run(context.Background(), RunOptions{
+		Parallelism: 4,
+		OnResult:    func(c Check, _ []Result) { order = append(order, c.ID) },
+	})
+
+	require.Len(t, results, 5)
+	assert.Equal(t, []string{"secrets", "tools", "repo", "key"}, []string{results[0].ID, results[1].ID, results[2].ID, results[3].ID})
+	assert.Equal(t, report.StatusFail, results[0].Status)
+	assert.Equal(t, report.StatusOK, results[1].Status)
+	assert.Equal(t, "installed", results[1].Note)
+	assert.Equal(t, report.StatusSkip, results[2].Status)
+	assert.Equal(t, "skipped: secrets failed", results[2].Note)
+	assert.Equal(t, "skipped: skipped was skipped", results[3].Note)
+	assert.Equal(t, "skipped: repo was skipped", results[4].Note, "skips propagate to dependents")
+	assert.Less(t, indexOf(order, "tools"), indexOf(order, "secrets"))
+	assert.Less(t, indexOf(order, "secrets"), indexOf(order, "repo"))
+}
+
+func indexOf(ids []string, id string) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRunParallel(t *testing.T) {
+	var running, peak atomic.Int32
+	slow := func(context.Context) []Result {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		running.Add(-1)
+		return OK("")
+	}
+	r := testRegistry(t,
+		Check{ID: "a", Run: slow},
+		Check{ID: "b", Run: slow},
+		Check{ID: "c", Run: slow},
+		Check{ID: "d", Run: slow},
+	)
+	plan, err := r.Plan("validate", "", Selection{})
+	require.NoError(t, err)
+
+	plan.Run(context.Background(), RunOptions{Parallelism: 2})
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestRunTimeoutAndPanic(t *testing.T) {
+	r := testRegistry(t,
+		Check{ID: "slow", Timeout: 20 * time.Millisecond, Run: func(ctx context.Context) []Result {
+			time.Sleep(time.Second)
+			return OK("")
+		}},
+		Check{ID: "broken", Run: func(context.Context) []Result { panic("boom") }},
+		Check{ID: "empty", Run: func(context.Context) []Result { return nil }},
+		Check{ID: "policy", Run: func(context.Context) []Result {
+			return []Result{{ID: "policy/latest-tag", Status: report.StatusWarn}, {ID: "policy/host-path", Err: errors.New("hostPath volume")}}
+		}},
+	)
+	plan, err := r.Plan("validate", "", Selection{})
+	require.NoError(t, err)
+
+	results := plan.Run(context.Background(), RunOptions{Parallelism: 4})
+	require.Len(t, results, 5)
+	assert.ErrorContains(t, results[0].Err, "did not complete within 20ms")
+	assert.ErrorContains(t, results[1].Err, "check panicked: boom")
+	assert.Equal(t, report.StatusOK, results[2].Status)
+	assert.Equal(t, "policy/latest-tag", results[3].ID)
+	assert.Equal(t, "policy", results[3].Name)
+	assert.Equal(t, report.StatusFail, results[4].Status)
+
+	rep := results[4].Report()
+	assert.Equal(t, "hostPath volume", rep.Error)
+}
@@ -0,0 +1,142 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/k8s"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+// maxOutputLines bounds the command output kept in a result.
+const maxOutputLines = 20
+
+// CustomOptions are the context custom checks run in.
+type CustomOptions struct {
+	BaseDir string
+	Env     string
+	// Kube returns the client Kubernetes queries use.
+	Kube func() (*k8s.Client, error)
+}
+
+// Custom returns the check declared by c.
+func Custom(c config.CustomCheck, opts CustomOptions) Check {
+	check := Check{
+		ID:          c.ID,
+		Name:        c.Name,
+		Category:    c.Category,
+		Description: c.Description,
+		Commands:    c.Commands,
+		Backends:    c.Backends,
+		DependsOn:   c.DependsOn,
+		Timeout:     c.TimeoutDuration(),
+	}
+	if check.Name == "" {
+		check.Name = c.ID
+	}
+	switch {
+	case c.Exec != nil:
+		if check.Description == "" {
+			check.Description = "runs " + strings.Join(c.Exec.Command, " ")
+		}
+		check.Run = func(ctx context.Context) []Result {
+			return severity(c, runExec(ctx, c.Exec, opts))
+		}
+	case c.Kubernetes != nil:
+		q := k8s.ResourceQuery{
+			APIVersion:    c.Kubernetes.APIVersion,
+			Kind:          c.Kubernetes.Kind,
+			Namespace:     c.Kubernetes.Namespace,
+			Name:          c.Kubernetes.Name,
+			LabelSelector: c.Kubernetes.LabelSelector,
+		}
+		if check.Description == "" {
+			check.Description = "queries " + q.String()
+		}
+		check.Run = func(ctx context.Context) []Result {
+			return severity(c, runKubernetes(ctx, q, c.Kubernetes, opts))
+		}
+	}
+	return check
+}
+
+// severity turns failures of checks declared with severity warning into
+// warnings.
+func severity(c config.CustomCheck, res []Result) []Result {
+	if c.Severity != "warning" {
+		return res
+	}
+	for i := range res {
+		if res[i].Err != nil {
+			text, hint := report.SplitHint(res[i].Err.Error())
+			res[i] = Result{Status: report.StatusWarn, Note: text, Hint: hint, Details: res[i].Details}
+		}
+	}
+	return res
+}
+
+func runExec(ctx context.Context, spec *config.ExecCheck, opts CustomOptions) []Result {
+	cmd := exec.CommandContext(ctx, spec.Command[0], spec.Command[1:]...) // #nosec G204 -- declared by the repository owner
+	cmd.Dir = opts.BaseDir
+	cmd.Env = append(os.Environ(),
+		"CLUSTER_BOOTSTRAP_ENV="+opts.Env,
+		"CLUSTER_BOOTSTRAP_BASE_DIR="+opts.BaseDir,
+	)
+	output, err := cmd.CombinedOutput()
+	lines := outputLines(string(output))
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("%s exited with status %d", spec.Command[0], exitErr.ExitCode())
+		} else {
+			err = fmt.Errorf("failed to run %s: %w", spec.Command[0], err)
+		}
+		return []Result{{Err: err, Details: lines}}
+	}
+	note := ""
+	if len(lines) > 0 {
+		note = lines[len(lines)-1]
+	}
+	return OK(note)
+}
+
+func outputLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line = strings.TrimRight(line, "\r "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > maxOutputLines {
+		lines = append(lines[:maxOutputLines], fmt.Sprintf("... %d more line(s)", len(lines)-maxOutputLines))
+	}
+	return lines
+}
+
+func runKubernetes(ctx context.Context, q k8s.ResourceQuery, spec *config.KubernetesCheck, opts CustomOptions) []Result {
+	if opts.Kube == nil {
+		return Skip("skipped: no cluster access")
+	}
+	client, err := opts.Kube()
+	if err != nil {
+		return Fail(err)
+	}
+	count, err := client.CountResources(ctx, q)
+	if err != nil {
+		return Fail(err)
+	}
+	switch {
+	case spec.Absent && count > 0:
+		return Fail(fmt.Errorf("found %d %s, expected none", count, q))
+	case spec.Absent:
+		return OK("no " + q.String())
+	case count < spec.MinCount:
+		return Fail(fmt.Errorf("found %d %s, expected at least %d", count, q, spec.MinCount))
+	}
+	return OK(fmt.Sprintf("%d %s", count, q))
+}
@@ -0,0 +1,53 @@
+package checks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/report"
+)
+
+func TestCustomExec(t *testing.T) {
+	dir := t.TempDir()
+	opts := CustomOptions{BaseDir: dir, Env: "dev"}
+
+	check := Custom(config.CustomCheck{
+		ID:   "env",
+		Exec: &config.ExecCheck{Command: []string{"sh", "-c", `echo checking; echo "$CLUSTER_BOOTSTRAP_ENV in $(pwd)"`}},
+	}, opts)
+	assert.Equal(t, "env", check.Name)
+	assert.Contains(t, check.Description, "runs sh -c")
+	res := check.Run(context.Background())
+	require.Len(t, res, 1)
+	assert.Equal(t, report.StatusOK, res[0].Status)
+	assert.Contains(t, res[0].Note, "dev in ")
+
+	failing := config.CustomCheck{
+		ID:   "lint",
+		Exec: &config.ExecCheck{Command: []string{"sh", "-c", "echo 'chart docs are stale'; exit 3"}},
+	}
+	res = Custom(failing, opts).Run(context.Background())
+	require.Len(t, res, 1)
+	assert.EqualError(t, res[0].Err, "sh exited with status 3")
+	assert.Equal(t, []string{"chart docs are stale"}, res[0].Details)
+
+	failing.Severity = "warning"
+	res = Custom(failing, opts).Run(context.Background())
+	assert.NoError(t, res[0].Err)
+	assert.Equal(t, report.StatusWarn, res[0].Status)
+	assert.Equal(t, "sh exited with status 3", res[0].Note)
+}
+
+func TestCustomKubernetesWithoutCluster(t *testing.T) {
+	check := Custom(config.CustomCheck{
+		ID:         "cert-manager",
+		Kubernetes: &config.KubernetesCheck{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "cert-manager", MinCount: 1},
+	}, CustomOptions{})
+	assert.Contains(t, check.Description, "queries ")
+	res := check.Run(context.Background())
+	assert.Equal(t, report.StatusSkip, res[0].Status)
+}
@@ -0,0 +1,233 @@
+// Package compat checks tool, cluster and chart versions against a
+// compatibility matrix: built-in constraints, overridden per repository in
+// the versions section of .cluster-bootstrap.yaml.
+package compat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+)
+
+// DefaultKubernetes is the oldest Kubernetes version supported by default.
+const DefaultKubernetes = ">= 1.28"
+
+// Tool is a command line tool whose version is checked.
+type Tool struct {
+	Name string
+	// Args print the version.
+	Args []string
+	// Constraint is the built-in version constraint.
+	Constraint string
+	// Backends are the encryption backends that use the tool; empty for all.
+	Backends []string
+	// Install is where to get a newer version.
+	Install string
+}
+
+// Tools are the tools with built-in constraints. kubectl is optional: the
+// cluster is reached through client-go, so it is only checked when installed.
+var Tools = []Tool{
+	{Name: "kubectl", Args: []string{"version", "--client"}, Constraint: ">= 1.28", Install: "https://kubernetes.io/docs/tasks/tools/"},
+	{Name: "helm", Args: []string{"version", "--short"}, Constraint: ">= 3.14", Install: "https://helm.sh/docs/intro/install/"},
+	{Name: "sops", Args: []string{"--version"}, Constraint: ">= 3.8", Backends: []string{"sops"}, Install: "https://github.com/getsops/sops/releases"},
+	{Name: "git-crypt", Args: []string{"--version"}, Constraint: ">= 0.7", Backends: []string{"git-crypt"}, Install: "https://github.com/AGWA/git-crypt"},
+}
+
+// ErrNotInstalled is returned by ToolVersion for tools not in PATH.
+var ErrNotInstalled = errors.New("not installed")
+
+// Matrix holds the version constraints in effect.
+type Matrix struct {
+	Kubernetes string
+	// Tools maps tool names to constraints.
+	Tools map[string]string
+	// Charts maps chart names to the Kubernetes versions they support,
+	// replacing the chart's kubeVersion.
+	Charts map[string]string
+}
+
+// NewMatrix returns the built-in constraints with overrides applied; vc may
+// be nil.
+func NewMatrix(vc *config.VersionConstraints) *Matrix {
+	m := &Matrix{Kubernetes: DefaultKubernetes, Tools: map[string]string{}, Charts: map[string]string{}}
+	for _, t := range Tools {
+		m.Tools[t.Name] = t.Constraint
+	}
+	if vc == nil {
+		return m
+	}
+	if vc.Kubernetes != "" {
+		m.Kubernetes = vc.Kubernetes
+	}
+	for name, c := range vc.Tools {
+		m.Tools[name] = c
+	}
+	for name, c := range vc.Charts {
+		m.Charts[name] = c
+	}
+	return m
+}
+
+// ToolsFor returns the tools used with backend, in a stable order. Tools
+// constrained only in the versions section are checked with --version.
+func (m *Matrix) ToolsFor(backend string) []Tool {
+	var out []Tool
+	known := map[string]bool{}
+	for _, t := range Tools {
+		known[t.Name] = true
+		if len(t.Backends) == 0 || backend == "" || slices.Contains(t.Backends, backend) {
+			t.Constraint = m.Tools[t.Name]
+			out = append(out, t)
+		}
+	}
+	var extra []string
+	for name := range m.Tools {
+		if !known[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		out = append(out, Tool{Name: name, Args: []string{"--version"}, Constraint: m.Tools[name]})
+	}
+	return out
+}
+
+var versionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+
+// ParseVersion returns the first version number in a tool's version output,
+// e.g. "Client Version: v1.30.2" or "sops 3.9.0 (latest)".
+func ParseVersion(output string) (*semver.Version, error) {
+	match := versionPattern.FindString(output)
+	if match == "" {
+		line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+		return nil, fmt.Errorf("no version number in %q", line)
+	}
+	return semver.NewVersion(match)
+}
+
+// ToolVersion runs the version command of t.
+func ToolVersion(ctx context.Context, t Tool) (*semver.Version, error) {
+	path, err := exec.LookPath(t.Name)
+	if err != nil {
+		return nil, ErrNotInstalled
+	}
+	output, err := exec.CommandContext(ctx, path, t.Args...).CombinedOutput() // #nosec G204 -- tool names are fixed or declared by the repository owner
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w", t.Name, strings.Join(t.Args, " "), err)
+	}
+	return ParseVersion(string(output))
+}
+
+// Satisfies reports whether v meets constraint. Pre-release and build
+// suffixes such as -gke.100 are ignored, so that vendor builds of a release
+// count as that release.
+func Satisfies(constraint string, v *semver.Version) (bool, error) {
+	if constraint == "" {
+		return true, nil
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	release := semver.New(v.Major(), v.Minor(), v.Patch(), "", "")
+	return c.Check(release), nil
+}
+
+// CheckTool checks the version of t against its constraint.
+func CheckTool(t Tool, v *semver.Version) error {
+	ok, err := Satisfies(t.Constraint, v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", t.Name, err)
+	}
+	if ok {
+		return nil
+	}
+	hint := fmt.Sprintf("upgrade %s to a version matching %s", t.Name, t.Constraint)
+	if t.Install != "" {
+		hint += " from " + t.Install
+	}
+	return fmt.Errorf("%s %s does not satisfy %s\n  hint: %s", t.Name, v, t.Constraint, hint)
+}
+
+// CheckKubernetes checks the cluster's server version.
+func (m *Matrix) CheckKubernetes(server *semver.Version) error {
+	ok, err := Satisfies(m.Kubernetes, server)
+	if err != nil {
+		return fmt.Errorf("versions.kubernetes: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("cluster runs Kubernetes %s, which does not satisfy %s\n  hint: upgrade the cluster, or relax versions.kubernetes in %s if the components support it", server, m.Kubernetes, config.RepoConfigFile)
+	}
+	return nil
+}
+
+// KubectlSkew reports a kubectl client more than one minor version away from
+// the server, which Kubernetes does not support.
+func KubectlSkew(client, server *semver.Version) error {
+	if client.Major() != server.Major() {
+		return fmt.Errorf("kubectl %s cannot be used with Kubernetes %s", client, server)
+	}
+	skew := int64(client.Minor()) - int64(server.Minor())
+	if skew > 1 || skew < -1 {
+		return fmt.Errorf("kubectl %s is more than one minor version away from Kubernetes %s", client, server)
+	}
+	return nil
+}
+
+// ChartRange is the range of Kubernetes versions a chart supports.
+type ChartRange struct {
+	Component string
+	Chart     string
+	Version   string
+	// KubeVersion is the chart's kubeVersion, or the versions override.
+	KubeVersion string
+	// Override is set when KubeVersion comes from the versions section.
+	Override bool
+}
+
+// ChartRanges returns the ranges of ch and its dependencies that declare
+// one, directly or through an override.
+func (m *Matrix) ChartRanges(component string, ch *chart.Chart) []ChartRange {
+	var out []ChartRange
+	var walk func(ch *chart.Chart)
+	walk = func(ch *chart.Chart) {
+		r := ChartRange{Component: component, Chart: ch.Name(), Version: ch.Metadata.Version, KubeVersion: ch.Metadata.KubeVersion}
+		if override, ok := m.Charts[ch.Name()]; ok {
+			r.KubeVersion, r.Override = override, true
+		}
+		if r.KubeVersion != "" {
+			out = append(out, r)
+		}
+		for _, dep := range ch.Dependencies() {
+			walk(dep)
+		}
+	}
+	walk(ch)
+	return out
+}
+
+// Check checks the range against the cluster's server version, with the
+// semantics Helm applies when it renders the chart.
+func (r ChartRange) Check(server string) error {
+	if chartutil.IsCompatibleRange(r.KubeVersion, server) {
+		return nil
+	}
+	source := "kubeVersion"
+	if r.Override {
+		source = "versions.charts." + r.Chart
+	}
+	return fmt.Errorf("%s %s requires Kubernetes %s (%s) but the cluster runs %s\n  hint: upgrade the cluster, or move %s to a chart version that supports %s", r.Chart, r.Version, r.KubeVersion, source, server, r.Chart, server)
+}
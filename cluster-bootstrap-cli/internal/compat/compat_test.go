@@ -0,0 +1,90 @@
+package compat
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"Client Version: v1.30.2\nKustomize Version: v5.0.4-0.20230601165947-6ce0bf390ce3\n", "1.30.2"},
+		{"v3.14.4+g81c902a\n", "3.14.4+g81c902a"},
+		{"sops 3.9.0 (latest)\n", "3.9.0"},
+		{"git-crypt 0.7.0\n", "0.7.0"},
+		{"v1.1.1", "1.1.1"},
+	}
+	for _, tt := range tests {
+		v, err := ParseVersion(tt.output)
+		require.NoError(t, err, tt.output)
+		assert.Equal(t, tt.want, v.String())
+	}
+
+	_, err := ParseVersion("(devel)\n")
+	assert.ErrorContains(t, err, `no version number in "(devel)"`)
+}
+
+func TestNewMatrix(t *testing.T) {
+	m := NewMatrix(&config.VersionConstraints{
+		Kubernetes: ">= 1.30",
+		Tools:      map[string]string{"helm": ">= 3.16", "kustomize": ">= 5"},
+		Charts:     map[string]string{"argo-cd": ">= 1.29"},
+	})
+	assert.Equal(t, ">= 1.30", m.Kubernetes)
+	assert.Equal(t, ">= 3.16", m.Tools["helm"])
+	assert.Equal(t, ">= 1.28", m.Tools["kubectl"])
+
+	var names []string
+	for _, tool := range m.ToolsFor("git-crypt") {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{"kubectl", "helm", "git-crypt", "kustomize"}, names)
+	assert.Equal(t, ">= 3.16", m.ToolsFor("sops")[1].Constraint)
+
+	assert.Equal(t, DefaultKubernetes, NewMatrix(nil).Kubernetes)
+}
+
+func TestCheckTool(t *testing.T) {
+	helm := Tool{Name: "helm", Constraint: ">= 3.14", Install: "https://helm.sh/docs/intro/install/"}
+	assert.NoError(t, CheckTool(helm, semver.MustParse("3.16.1")))
+	err := CheckTool(helm, semver.MustParse("3.12.0"))
+	assert.EqualError(t, err, "helm 3.12.0 does not satisfy >= 3.14\n  hint: upgrade helm to a version matching >= 3.14 from https://helm.sh/docs/intro/install/")
+}
+
+func TestCheckKubernetes(t *testing.T) {
+	m := NewMatrix(nil)
+	assert.NoError(t, m.CheckKubernetes(semver.MustParse("v1.30.2-gke.1587003")), "vendor suffixes are ignored")
+	assert.ErrorContains(t, m.CheckKubernetes(semver.MustParse("v1.27.9")), "cluster runs Kubernetes 1.27.9, which does not satisfy >= 1.28")
+}
+
+func TestKubectlSkew(t *testing.T) {
+	assert.NoError(t, KubectlSkew(semver.MustParse("1.31.0"), semver.MustParse("1.30.2")))
+	assert.ErrorContains(t, KubectlSkew(semver.MustParse("1.32.0"), semver.MustParse("1.30.2")), "more than one minor version")
+}
+
+func TestChartRanges(t *testing.T) {
+	argo := &chart.Chart{Metadata: &chart.Metadata{Name: "argo-cd", Version: "9.4.17", KubeVersion: ">=1.25.0-0"}}
+	redis := &chart.Chart{Metadata: &chart.Metadata{Name: "redis", Version: "1.0.0"}}
+	argo.AddDependency(redis)
+	wrapper := &chart.Chart{Metadata: &chart.Metadata{Name: "argocd", Version: "1.0.0"}}
+	wrapper.AddDependency(argo)
+
+	ranges := NewMatrix(nil).ChartRanges("argocd", wrapper)
+	require.Len(t, ranges, 1)
+	assert.Equal(t, ChartRange{Component: "argocd", Chart: "argo-cd", Version: "9.4.17", KubeVersion: ">=1.25.0-0"}, ranges[0])
+	assert.NoError(t, ranges[0].Check("v1.30.2-eks-1234"))
+	assert.ErrorContains(t, ranges[0].Check("v1.24.0"), "argo-cd 9.4.17 requires Kubernetes >=1.25.0-0 (kubeVersion) but the cluster runs v1.24.0")
+
+	ranges = NewMatrix(&config.VersionConstraints{Charts: map[string]string{"argo-cd": ">=1.31.0-0"}}).ChartRanges("argocd", wrapper)
+	require.Len(t, ranges, 1)
+	assert.True(t, ranges[0].Override)
+	assert.ErrorContains(t, ranges[0].Check("v1.30.2"), "(versions.charts.argo-cd)")
+}
@@ -0,0 +1,273 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+const (
+	// AppsValuesFile is the App of Apps values file, relative to the base directory.
+	AppsValuesFile = "apps/values.yaml"
+	// ComponentsDir holds one wrapper chart per component, relative to the base directory.
+	ComponentsDir = "components"
+)
+
+// Component is an entry of the components map in the App of Apps values,
+// after merging apps/values.yaml with apps/values/<env>.yaml.
+type Component struct {
+	Name              string
+	Enabled           bool
+	Namespace         string
+	SyncWave          string
+	HasValues         bool
+	CreateNamespace   bool
+	SyncOptions       []string
+	IgnoreDifferences []map[string]interface{}
+	// Health is the health section; nil when the component has none.
+	Health *HealthSpec
+}
+
+// Repo is the repo section of the App of Apps values.
+type Repo struct {
+	URL            string `yaml:"url"`
+	TargetRevision string `yaml:"targetRevision"`
+	BasePath       string `yaml:"basePath"`
+}
+
+// Catalog is the effective App of Apps configuration for one environment.
+type Catalog struct {
+	Environment string
+	Repo        Repo
+	// Components is sorted by sync wave, then name.
+	Components []Component
+}
+
+// rawComponent mirrors the YAML shape; pointers distinguish unset keys so
+// template defaults (hasValues, createNamespace) can be applied.
+type rawComponent struct {
+	Enabled           bool                     `yaml:"enabled"`
+	Namespace         string                   `yaml:"namespace"`
+	SyncWave          string                   `yaml:"syncWave"`
+	HasValues         *bool                    `yaml:"hasValues"`
+	CreateNamespace   *bool                    `yaml:"createNamespace"`
+	SyncOptions       []string                 `yaml:"syncOptions"`
+	IgnoreDifferences []map[string]interface{} `yaml:"ignoreDifferences"`
+	Health            *HealthSpec              `yaml:"health"`
+}
+
+type rawValues struct {
+	Environment string                  `yaml:"environment"`
+	Repo        Repo                    `yaml:"repo"`
+	Components  map[string]rawComponent `yaml:"components"`
+}
+
+// EnvValuesFile returns the path of the App of Apps values override for env.
+func EnvValuesFile(baseDir, env string) string {
+	return filepath.Join(baseDir, "apps", "values", env+".yaml")
+}
+
+// Load reads apps/values.yaml and apps/values/<env>.yaml and returns the merged
+// catalog, with the environment file taking precedence.
+func Load(baseDir, env string) (*Catalog, error) {
+	merged, err := LoadValues(baseDir, env)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode merged values: %w", err)
+	}
+	var raw rawValues
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid App of Apps values for %s: %w", env, err)
+	}
+
+	catalog := &Catalog{Environment: env, Repo: raw.Repo}
+	if raw.Environment != "" {
+		catalog.Environment = raw.Environment
+	}
+	for name, rc := range raw.Components {
+		if err := validateHealth(name, rc.Health); err != nil {
+			return nil, fmt.Errorf("invalid App of Apps values for %s: %w", env, err)
+		}
+		c := Component{
+			Name:              name,
+			Enabled:           rc.Enabled,
+			Namespace:         rc.Namespace,
+			SyncWave:          rc.SyncWave,
+			HasValues:         rc.HasValues == nil || *rc.HasValues,
+			CreateNamespace:   rc.CreateNamespace == nil || *rc.CreateNamespace,
+			SyncOptions:       rc.SyncOptions,
+			IgnoreDifferences: rc.IgnoreDifferences,
+			Health:            rc.Health,
+		}
+		catalog.Components = append(catalog.Components, c)
+	}
+	sort.Slice(catalog.Components, func(i, j int) bool {
+		a, b := catalog.Components[i], catalog.Components[j]
+		if wa, wb := waveNumber(a.SyncWave), waveNumber(b.SyncWave); wa != wb {
+			return wa < wb
+		}
+		return a.Name < b.Name
+	})
+	return catalog, nil
+}
+
+// LoadValues returns apps/values.yaml deep-merged with apps/values/<env>.yaml.
+// An environment without a values file is unknown; pass an empty env to get
+// apps/values.yaml on its own.
+func LoadValues(baseDir, env string) (map[string]interface{}, error) {
+	basePath := filepath.Join(baseDir, AppsValuesFile)
+	base, err := readValuesFile(basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w\n  hint: run from the repository root or pass --base-dir", basePath, err)
+		}
+		return nil, err
+	}
+	if env == "" {
+		return base, nil
+	}
+	override, err := readValuesFile(EnvValuesFile(baseDir, env))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, UnknownEnvironment(baseDir, env)
+		}
+		return nil, err
+	}
+	return MergeValues(base, override), nil
+}
+
+// UnknownEnvironment returns the error for an environment without an
+// apps/values/<env>.yaml file, listing the known ones.
+func UnknownEnvironment(baseDir, env string) error {
+	envs, _ := Environments(baseDir)
+	return fmt.Errorf("unknown environment %s (known: %v)\n  hint: environments are defined by apps/values/<env>.yaml", env, envs)
+}
+
+func readValuesFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	vals := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &vals); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return vals, nil
+}
+
+// MergeValues deep-merges override into base and returns the result. Nested
+// maps are merged key by key; any other value in override replaces the base value.
+func MergeValues(base, override map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		if ov, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k].(map[string]interface{}); ok {
+				out[k] = MergeValues(bv, ov)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// Get returns the named component.
+func (c *Catalog) Get(name string) (*Component, bool) {
+	for i := range c.Components {
+		if c.Components[i].Name == name {
+			return &c.Components[i], true
+		}
+	}
+	return nil, false
+}
+
+// Enabled returns the enabled components in sync-wave order.
+func (c *Catalog) Enabled() []Component {
+	var out []Component
+	for _, comp := range c.Components {
+		if comp.Enabled {
+			out = append(out, comp)
+		}
+	}
+	return out
+}
+
+// Names returns all component names in sync-wave order.
+func (c *Catalog) Names() []string {
+	names := make([]string, 0, len(c.Components))
+	for _, comp := range c.Components {
+		names = append(names, comp.Name)
+	}
+	return names
+}
+
+// Lookup returns the named component or an error listing the known ones.
+func (c *Catalog) Lookup(name string) (*Component, error) {
+	comp, ok := c.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("component %q not found in %s\n  hint: known components: %s", name, AppsValuesFile, strings.Join(c.Names(), ", "))
+	}
+	return comp, nil
+}
+
+// SetEnabled sets components.<name>.enabled in apps/values/<env>.yaml, keeping
+// comments and formatting. The component must exist in the merged catalog.
+// Returns false when the component is already in the requested state.
+func SetEnabled(baseDir, env, name string, enabled bool) (bool, error) {
+	catalog, err := Load(baseDir, env)
+	if err != nil {
+		return false, err
+	}
+	comp, err := catalog.Lookup(name)
+	if err != nil {
+		return false, err
+	}
+	if comp.Enabled == enabled {
+		return false, nil
+	}
+
+	path := EnvValuesFile(baseDir, env)
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		return false, err
+	}
+
+	original := doc.Bytes()
+	if err := doc.Set(enabled, "components", name, "enabled"); err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	if !doc.Changed(original) {
+		return false, nil
+	}
+	return true, doc.Save(path, info.Mode().Perm())
+}
+
+// waveNumber parses a sync wave for ordering; unparsable waves sort last.
+func waveNumber(wave string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(wave))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+	return n
+}
@@ -0,0 +1,239 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAppsValues = `environment: dev
+
+repo:
+  url: git@github.com:example/platform.git
+  targetRevision: main
+  basePath: ""
+
+components:
+  argocd:
+    enabled: true
+    namespace: argocd
+    syncWave: "0"
+    syncOptions:
+      - ServerSideApply=true
+
+  reloader:
+    enabled: true
+    namespace: reloader
+    syncWave: "2"
+
+  prometheus-operator-crds:
+    enabled: true
+    namespace: monitoring
+    syncWave: "2"
+    hasValues: false
+
+  argocd-repo-secret:
+    enabled: true
+    namespace: argocd
+    syncWave: "10"
+    createNamespace: false
+`
+
+func writeTestRepo(t *testing.T, envValues string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "apps", "values"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "apps", "values.yaml"), []byte(testAppsValues), 0600))
+	if envValues != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "apps", "values", "dev.yaml"), []byte(envValues), 0600))
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeTestRepo(t, "environment: dev\ncomponents:\n  reloader:\n    enabled: false\n")
+
+	catalog, err := Load(dir, "dev")
+	require.NoError(t, err)
+
+	assert.Equal(t, "dev", catalog.Environment)
+	assert.Equal(t, "git@github.com:example/platform.git", catalog.Repo.URL)
+	// Sync waves sort numerically, not lexically.
+	assert.Equal(t, []string{"argocd", "prometheus-operator-crds", "reloader", "argocd-repo-secret"}, catalog.Names())
+
+	reloader, ok := catalog.Get("reloader")
+	require.True(t, ok)
+	assert.False(t, reloader.Enabled, "env file should override base")
+	assert.Equal(t, "reloader", reloader.Namespace)
+
+	crds, _ := catalog.Get("prometheus-operator-crds")
+	assert.False(t, crds.HasValues)
+	assert.True(t, crds.CreateNamespace)
+
+	secret, _ := catalog.Get("argocd-repo-secret")
+	assert.True(t, secret.HasValues)
+	assert.False(t, secret.CreateNamespace)
+
+	argocd, _ := catalog.Get("argocd")
+	assert.Equal(t, []string{"ServerSideApply=true"}, argocd.SyncOptions)
+
+	assert.Len(t, catalog.Enabled(), 3)
+}
+
+func TestLoad_MissingEnvFile(t *testing.T) {
+	dir := writeTestRepo(t, "environment: dev\n")
+
+	_, err := Load(dir, "staging")
+	assert.ErrorContains(t, err, "unknown environment staging (known: [dev])")
+
+	catalog, err := Load(dir, "")
+	require.NoError(t, err, "an empty env reads apps/values.yaml on its own")
+	assert.Len(t, catalog.Enabled(), 4)
+}
+
+func TestLoad_MissingBaseValues(t *testing.T) {
+	_, err := Load(t.TempDir(), "dev")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "apps/values.yaml")
+}
+
+func TestCatalog_Lookup(t *testing.T) {
+	catalog, err := Load(writeTestRepo(t, "environment: dev\n"), "dev")
+	require.NoError(t, err)
+
+	_, err = catalog.Lookup("vault")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "known components: argocd")
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{
+		"repo":   map[string]interface{}{"url": "a", "targetRevision": "main"},
+		"list":   []interface{}{"x"},
+		"keep":   "yes",
+		"scalar": map[string]interface{}{"nested": true},
+	}
+	override := map[string]interface{}{
+		"repo":   map[string]interface{}{"targetRevision": "v1"},
+		"list":   []interface{}{"y"},
+		"scalar": "flat",
+	}
+
+	merged := MergeValues(base, override)
+	assert.Equal(t, map[string]interface{}{"url": "a", "targetRevision": "v1"}, merged["repo"])
+	assert.Equal(t, []interface{}{"y"}, merged["list"])
+	assert.Equal(t, "yes", merged["keep"])
+	assert.Equal(t, "flat", merged["scalar"])
+	// base must not be mutated
+	assert.Equal(t, "main", base["repo"].(map[string]interface{})["targetRevision"])
+}
+
+func TestSetEnabled_PreservesComments(t *testing.T) {
+	envValues := `# Development overrides
+environment: dev
+
+components:
+  # reloader is noisy in dev
+  reloader:
+    enabled: true # keep for now
+`
+	dir := writeTestRepo(t, envValues)
+
+	changed, err := SetEnabled(dir, "dev", "reloader", false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := os.ReadFile(EnvValuesFile(dir, "dev"))
+	require.NoError(t, err)
+	expected := `# Development overrides
+environment: dev
+
+components:
+  # reloader is noisy in dev
+  reloader:
+    enabled: false # keep for now
+`
+	assert.Equal(t, expected, string(data))
+
+	changed, err = SetEnabled(dir, "dev", "reloader", false)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSetEnabled_AddsOverride(t *testing.T) {
+	dir := writeTestRepo(t, "# dev overrides\nenvironment: dev\n")
+
+	changed, err := SetEnabled(dir, "dev", "argocd-repo-secret", false)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := os.ReadFile(EnvValuesFile(dir, "dev"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# dev overrides")
+	assert.Contains(t, string(data), "components:\n  argocd-repo-secret:\n    enabled: false\n")
+
+	catalog, err := Load(dir, "dev")
+	require.NoError(t, err)
+	comp, _ := catalog.Get("argocd-repo-secret")
+	assert.False(t, comp.Enabled)
+}
+
+func TestSetEnabled_UnknownComponent(t *testing.T) {
+	dir := writeTestRepo(t, "environment: dev\n")
+
+	_, err := SetEnabled(dir, "dev", "nope", true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `component "nope" not found`)
+}
+
+func TestLoadChartInfo(t *testing.T) {
+	dir := t.TempDir()
+	chartDir := filepath.Join(dir, "components", "vault")
+	require.NoError(t, os.MkdirAll(filepath.Join(chartDir, "values"), 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(chartDir, "templates"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte(`apiVersion: v2
+name: vault
+description: HashiCorp Vault managed by App of Apps
+version: 1.0.0
+dependencies:
+  - name: vault
+    version: 0.32.0
+    repository: https://helm.releases.hashicorp.com
+`), 0600))
+	for _, f := range []string{"values/base.yaml", "values/dev.yaml", "templates/job.yaml"} {
+		require.NoError(t, os.WriteFile(filepath.Join(chartDir, f), []byte("{}\n"), 0600))
+	}
+
+	info, err := LoadChartInfo(dir, "vault")
+	require.NoError(t, err)
+	assert.Equal(t, "vault", info.Name)
+	assert.Equal(t, filepath.Join("components", "vault"), info.Dir)
+	assert.Equal(t, []Dependency{{Name: "vault", Version: "0.32.0", Repository: "https://helm.releases.hashicorp.com"}}, info.Dependencies)
+	assert.Equal(t, []string{"values/base.yaml", "values/dev.yaml"}, info.ValueFiles)
+	assert.Equal(t, []string{"templates/job.yaml"}, info.Templates)
+
+	_, err = LoadChartInfo(dir, "missing")
+	assert.Error(t, err)
+}
+
+func TestLoadComponentValues(t *testing.T) {
+	dir := t.TempDir()
+	valuesDir := filepath.Join(dir, "components", "argocd", "values")
+	require.NoError(t, os.MkdirAll(valuesDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(valuesDir, "base.yaml"), []byte("argo-cd:\n  server:\n    replicas: 1\n    insecure: true\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(valuesDir, "prod.yaml"), []byte("argo-cd:\n  server:\n    replicas: 3\n"), 0600))
+
+	vals, err := LoadComponentValues(dir, "argocd", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"replicas": 3, "insecure": true}, vals["argo-cd"].(map[string]interface{})["server"], "the environment file wins")
+
+	vals, err = LoadComponentValues(dir, "argocd", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, 1, vals["argo-cd"].(map[string]interface{})["server"].(map[string]interface{})["replicas"])
+
+	_, err = LoadComponentValues(dir, "missing", "dev")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,111 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dependency is an entry of a component's Chart.yaml dependencies.
+type Dependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Repository string `yaml:"repository"`
+	Alias      string `yaml:"alias,omitempty"`
+	Condition  string `yaml:"condition,omitempty"`
+}
+
+// ChartInfo describes a component's wrapper chart on disk.
+type ChartInfo struct {
+	// Dir is the chart directory, relative to the base directory.
+	Dir          string
+	Name         string       `yaml:"name"`
+	Version      string       `yaml:"version"`
+	Description  string       `yaml:"description"`
+	Dependencies []Dependency `yaml:"dependencies"`
+	// ValueFiles lists files under values/, relative to Dir.
+	ValueFiles []string `yaml:"-"`
+	// Templates lists files under templates/, relative to Dir.
+	Templates []string `yaml:"-"`
+}
+
+// ChartDir returns the wrapper chart directory for a component.
+func ChartDir(baseDir, name string) string {
+	return filepath.Join(baseDir, ComponentsDir, name)
+}
+
+// LoadChartInfo reads components/<name>/Chart.yaml and lists its value files and templates.
+func LoadChartInfo(baseDir, name string) (*ChartInfo, error) {
+	dir := ChartDir(baseDir, name)
+	chartPath := filepath.Join(dir, "Chart.yaml")
+	data, err := os.ReadFile(chartPath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", chartPath, err)
+	}
+
+	info := &ChartInfo{}
+	if err := yaml.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", chartPath, err)
+	}
+	info.Dir = filepath.Join(ComponentsDir, name)
+
+	if info.ValueFiles, err = listFiles(dir, "values"); err != nil {
+		return nil, err
+	}
+	if info.Templates, err = listFiles(dir, "templates"); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// listFiles returns the regular files below dir/sub, relative to dir and sorted.
+func listFiles(dir, sub string) ([]string, error) {
+	root := filepath.Join(dir, sub)
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// LoadComponentValues returns a component's effective values for env:
+// values/base.yaml merged with values/<env>.yaml, the environment file
+// taking precedence as it does in the Application's valueFiles list.
+// A missing environment file is ignored; a missing base.yaml is an error
+// satisfying os.IsNotExist.
+func LoadComponentValues(baseDir, name, env string) (map[string]interface{}, error) {
+	dir := filepath.Join(ChartDir(baseDir, name), "values")
+	base, err := readValuesFile(filepath.Join(dir, "base.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	override, err := readValuesFile(filepath.Join(dir, env+".yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return base, nil
+		}
+		return nil, err
+	}
+	return MergeValues(base, override), nil
+}
@@ -0,0 +1,128 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InstanceLabel is the label Helm charts and ArgoCD set to the release or
+// Application name. Components without health workloads are checked through
+// the workloads carrying it.
+const InstanceLabel = "app.kubernetes.io/instance"
+
+// WorkloadKinds are the kinds a health spec can list as workloads.
+var WorkloadKinds = []string{"Deployment", "StatefulSet", "DaemonSet"}
+
+// HealthSpec is the health section of a component in the App of Apps
+// values: what must be ready before the component counts as healthy.
+type HealthSpec struct {
+	// Workloads must have all their replicas updated and ready. When unset,
+	// the workloads labelled with InstanceLabel=<component> in the
+	// component's namespace are checked; an empty list checks none.
+	Workloads []HealthWorkload `yaml:"workloads"`
+	// CRDs must be Established.
+	CRDs []string `yaml:"crds"`
+	// Resources are custom resources whose conditions must be True.
+	Resources []HealthResource `yaml:"resources"`
+	// Timeout bounds the wait for the component, within the overall
+	// health timeout; zero leaves only the overall one.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// HealthWorkload is a Deployment, StatefulSet or DaemonSet.
+type HealthWorkload struct {
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+// String returns the workload as kind/name, e.g. deployment/argocd-server.
+func (w HealthWorkload) String() string {
+	return strings.ToLower(w.Kind) + "/" + w.Name
+}
+
+// HealthResource is a custom resource with conditions to check.
+type HealthResource struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+	// Namespace is ignored for cluster-scoped kinds.
+	Namespace string `yaml:"namespace"`
+	// Conditions must have status True; Ready when unset.
+	Conditions []string `yaml:"conditions"`
+}
+
+// String returns the resource as kind/name.
+func (r HealthResource) String() string {
+	return strings.ToLower(r.Kind) + "/" + r.Name
+}
+
+// HealthSpec returns the component's health spec with defaults applied:
+// workloads and resources without a namespace are in the component's
+// namespace, and resources without conditions need Ready. Workloads stay
+// nil when the component declares none, for the caller to discover.
+func (c Component) HealthSpec() HealthSpec {
+	var spec HealthSpec
+	if c.Health != nil {
+		spec = *c.Health
+	}
+	out := HealthSpec{CRDs: spec.CRDs, Timeout: spec.Timeout}
+	if spec.Workloads != nil {
+		out.Workloads = make([]HealthWorkload, 0, len(spec.Workloads))
+	}
+	for _, w := range spec.Workloads {
+		if w.Namespace == "" {
+			w.Namespace = c.Namespace
+		}
+		out.Workloads = append(out.Workloads, w)
+	}
+	for _, r := range spec.Resources {
+		if r.Namespace == "" {
+			r.Namespace = c.Namespace
+		}
+		if len(r.Conditions) == 0 {
+			r.Conditions = []string{"Ready"}
+		}
+		out.Resources = append(out.Resources, r)
+	}
+	return out
+}
+
+// validateHealth reports the first incomplete entry of a health spec.
+func validateHealth(name string, spec *HealthSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if spec.Timeout < 0 {
+		return fmt.Errorf("components.%s.health.timeout: %s is negative", name, spec.Timeout)
+	}
+	for i, w := range spec.Workloads {
+		if !isWorkloadKind(w.Kind) {
+			return fmt.Errorf("components.%s.health.workloads[%d]: kind %q is not a workload\n  hint: use one of %s", name, i, w.Kind, strings.Join(WorkloadKinds, ", "))
+		}
+		if w.Name == "" {
+			return fmt.Errorf("components.%s.health.workloads[%d]: name is required", name, i)
+		}
+	}
+	for i, crd := range spec.CRDs {
+		if !strings.Contains(crd, ".") {
+			return fmt.Errorf("components.%s.health.crds[%d]: %q is not a CRD name\n  hint: CRDs are named <plural>.<group>, e.g. applications.argoproj.io", name, i, crd)
+		}
+	}
+	for i, r := range spec.Resources {
+		if r.APIVersion == "" || r.Kind == "" || r.Name == "" {
+			return fmt.Errorf("components.%s.health.resources[%d]: apiVersion, kind and name are required", name, i)
+		}
+	}
+	return nil
+}
+
+func isWorkloadKind(kind string) bool {
+	for _, k := range WorkloadKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthSpec(t *testing.T) {
+	dir := writeTestRepo(t, `components:
+  argocd:
+    health:
+      workloads:
+        - kind: Deployment
+          name: argocd-server
+        - kind: StatefulSet
+          name: argocd-application-controller
+          namespace: argocd-system
+      crds: [applications.argoproj.io]
+      timeout: 2m
+      resources:
+        - apiVersion: argoproj.io/v1alpha1
+          kind: AppProject
+          name: default
+  prometheus-operator-crds:
+    health:
+      workloads: []
+`)
+	catalog, err := Load(dir, "dev")
+	require.NoError(t, err)
+
+	argocd, _ := catalog.Get("argocd")
+	spec := argocd.HealthSpec()
+	assert.Equal(t, []HealthWorkload{
+		{Kind: "Deployment", Name: "argocd-server", Namespace: "argocd"},
+		{Kind: "StatefulSet", Name: "argocd-application-controller", Namespace: "argocd-system"},
+	}, spec.Workloads)
+	assert.Equal(t, []string{"applications.argoproj.io"}, spec.CRDs)
+	assert.Equal(t, 2*time.Minute, spec.Timeout)
+	assert.Equal(t, []HealthResource{{APIVersion: "argoproj.io/v1alpha1", Kind: "AppProject", Name: "default", Namespace: "argocd", Conditions: []string{"Ready"}}}, spec.Resources)
+	assert.Equal(t, "statefulset/argocd-application-controller", spec.Workloads[1].String())
+
+	// An empty list checks no workloads; no health section discovers them.
+	crds, _ := catalog.Get("prometheus-operator-crds")
+	assert.NotNil(t, crds.HealthSpec().Workloads)
+	assert.Empty(t, crds.HealthSpec().Workloads)
+	reloader, _ := catalog.Get("reloader")
+	assert.Nil(t, reloader.HealthSpec().Workloads)
+}
+
+func TestHealthSpec_Invalid(t *testing.T) {
+	dir := writeTestRepo(t, "components:\n  reloader:\n    health:\n      workloads:\n        - kind: Pod\n          name: reloader\n")
+	_, err := Load(dir, "dev")
+	assert.ErrorContains(t, err, `components.reloader.health.workloads[0]: kind "Pod" is not a workload`)
+
+	dir = writeTestRepo(t, "components:\n  reloader:\n    health:\n      crds: [reloader]\n")
+	_, err = Load(dir, "dev")
+	assert.ErrorContains(t, err, `"reloader" is not a CRD name`)
+}
+
+func TestHealthSpec_InvalidTimeout(t *testing.T) {
+	dir := writeTestRepo(t, "components:\n  reloader:\n    health:\n      timeout: soon\n")
+	_, err := Load(dir, "dev")
+	assert.ErrorContains(t, err, "invalid App of Apps values for dev")
+}
@@ -0,0 +1,207 @@
+package components
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// componentNamePattern matches names usable as an ArgoCD Application name and
+// a chart directory: a DNS-1123 label.
+var componentNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// ScaffoldOptions describes a new component's wrapper chart and catalog entry.
+type ScaffoldOptions struct {
+	Name        string
+	Description string
+	// Chart is the upstream chart the wrapper depends on.
+	Chart     Dependency
+	Namespace string
+	SyncWave  string
+}
+
+// wrapperChart is the Chart.yaml written for a new component.
+type wrapperChart struct {
+	APIVersion   string       `yaml:"apiVersion"`
+	Name         string       `yaml:"name"`
+	Description  string       `yaml:"description"`
+	Version      string       `yaml:"version"`
+	Type         string       `yaml:"type"`
+	Dependencies []Dependency `yaml:"dependencies"`
+}
+
+// catalogEntry is the apps/values.yaml entry written for a new component.
+type catalogEntry struct {
+	Enabled   bool   `yaml:"enabled"`
+	Namespace string `yaml:"namespace"`
+	SyncWave  string `yaml:"syncWave"`
+}
+
+// ValidateName checks that name can be used as a component name.
+func ValidateName(name string) error {
+	if len(name) > 53 || !componentNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid component name %q: use lowercase letters, digits and '-' (max 53 characters)", name)
+	}
+	return nil
+}
+
+// Environments returns the environments that have an apps/values/<env>.yaml file, sorted.
+func Environments(baseDir string) ([]string, error) {
+	dir := filepath.Join(baseDir, "apps", "values")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	var envs []string
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".yaml" {
+			continue
+		}
+		envs = append(envs, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	sort.Strings(envs)
+	return envs, nil
+}
+
+// Scaffold creates components/<name> with a Chart.yaml depending on the
+// upstream chart, values/base.yaml and one values file per environment, then
+// registers the component in apps/values.yaml. It returns the files written,
+// relative to baseDir. On failure nothing is left behind.
+func Scaffold(baseDir string, opts ScaffoldOptions) ([]string, error) {
+	if err := ValidateName(opts.Name); err != nil {
+		return nil, err
+	}
+	if opts.Chart.Name == "" || opts.Chart.Version == "" || opts.Chart.Repository == "" {
+		return nil, fmt.Errorf("chart name, version and repository are required")
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = opts.Name
+	}
+	if opts.Description == "" {
+		opts.Description = fmt.Sprintf("%s managed by App of Apps", opts.Chart.Name)
+	}
+
+	catalog, err := Load(baseDir, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := catalog.Get(opts.Name); exists {
+		return nil, fmt.Errorf("component %s is already registered in %s", opts.Name, AppsValuesFile)
+	}
+	dir := ChartDir(baseDir, opts.Name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("%s already exists\n  hint: remove it or pick another name", dir)
+	}
+
+	envs, err := Environments(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := writeWrapperChart(baseDir, opts, envs)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	if err := registerComponent(baseDir, opts); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return append(files, AppsValuesFile), nil
+}
+
+func writeWrapperChart(baseDir string, opts ScaffoldOptions, envs []string) ([]string, error) {
+	dir := ChartDir(baseDir, opts.Name)
+	if err := os.MkdirAll(filepath.Join(dir, "values"), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	chartYAML, err := encodeYAML(wrapperChart{
+		APIVersion:   "v2",
+		Name:         opts.Name,
+		Description:  opts.Description,
+		Version:      "1.0.0",
+		Type:         "application",
+		Dependencies: []Dependency{opts.Chart},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rel := filepath.Join(ComponentsDir, opts.Name)
+	contents := map[string]string{
+		"Chart.yaml": string(chartYAML),
+		filepath.Join("values", "base.yaml"): fmt.Sprintf(
+			"# Values for the %[1]s chart must be nested under %[1]q.\n"+
+				"# Defaults: helm show values %[1]s --repo %[2]s --version %[3]s\n"+
+				"%[1]s: {}\n",
+			opts.Chart.Name, opts.Chart.Repository, opts.Chart.Version),
+	}
+	for _, env := range envs {
+		contents[filepath.Join("values", env+".yaml")] = fmt.Sprintf("# %s overrides for %s\n%s: {}\n", env, opts.Name, opts.Chart.Name)
+	}
+
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var written []string
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents[name]), 0644); err != nil { // #nosec G306 -- chart files are committed to git
+			return nil, fmt.Errorf("failed to write %s: %w", filepath.Join(rel, name), err)
+		}
+		written = append(written, filepath.Join(rel, name))
+	}
+	return written, nil
+}
+
+// registerComponent appends the component to the components map in
+// apps/values.yaml without reformatting the rest of the file.
+func registerComponent(baseDir string, opts ScaffoldOptions) error {
+	path := filepath.Join(baseDir, AppsValuesFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		return err
+	}
+	entry := catalogEntry{Enabled: true, Namespace: opts.Namespace, SyncWave: opts.SyncWave}
+	if doc.Lookup("components") == nil {
+		err = doc.Set(map[string]catalogEntry{opts.Name: entry}, "components")
+	} else {
+		err = doc.AddMappingEntry(opts.Name, entry, "components")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to register %s in %s: %w", opts.Name, path, err)
+	}
+	return doc.Save(path, info.Mode().Perm())
+}
+
+func encodeYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,95 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scaffoldRepo(t *testing.T) string {
+	t.Helper()
+	dir := writeTestRepo(t, "environment: dev\n")
+	for _, env := range []string{"staging", "prod"} {
+		require.NoError(t, os.WriteFile(EnvValuesFile(dir, env), []byte("environment: "+env+"\n"), 0600))
+	}
+	return dir
+}
+
+func testScaffoldOptions() ScaffoldOptions {
+	return ScaffoldOptions{
+		Name:      "cert-manager",
+		Chart:     Dependency{Name: "cert-manager", Version: "1.16.2", Repository: "https://charts.jetstack.io"},
+		Namespace: "cert-manager",
+		SyncWave:  "2",
+	}
+}
+
+func TestEnvironments(t *testing.T) {
+	envs, err := Environments(scaffoldRepo(t))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "prod", "staging"}, envs)
+
+	envs, err = Environments(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, envs)
+}
+
+func TestScaffold(t *testing.T) {
+	dir := scaffoldRepo(t)
+
+	files, err := Scaffold(dir, testScaffoldOptions())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"components/cert-manager/Chart.yaml",
+		"components/cert-manager/values/base.yaml",
+		"components/cert-manager/values/dev.yaml",
+		"components/cert-manager/values/prod.yaml",
+		"components/cert-manager/values/staging.yaml",
+		"apps/values.yaml",
+	}, files)
+
+	info, err := LoadChartInfo(dir, "cert-manager")
+	require.NoError(t, err)
+	assert.Equal(t, "cert-manager managed by App of Apps", info.Description)
+	assert.Equal(t, []Dependency{{Name: "cert-manager", Version: "1.16.2", Repository: "https://charts.jetstack.io"}}, info.Dependencies)
+
+	base, err := os.ReadFile(filepath.Join(dir, "components/cert-manager/values/base.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(base), "cert-manager: {}\n")
+
+	// The existing entries and their formatting are untouched.
+	values, err := os.ReadFile(filepath.Join(dir, AppsValuesFile))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(values), testAppsValues))
+	assert.Equal(t, "\n  cert-manager:\n    enabled: true\n    namespace: cert-manager\n    syncWave: \"2\"\n",
+		strings.TrimPrefix(string(values), testAppsValues))
+
+	catalog, err := Load(dir, "prod")
+	require.NoError(t, err)
+	comp, err := catalog.Lookup("cert-manager")
+	require.NoError(t, err)
+	assert.True(t, comp.Enabled)
+	assert.Equal(t, "2", comp.SyncWave)
+}
+
+func TestScaffold_Rejects(t *testing.T) {
+	dir := scaffoldRepo(t)
+
+	opts := testScaffoldOptions()
+	opts.Name = "Cert_Manager"
+	_, err := Scaffold(dir, opts)
+	assert.ErrorContains(t, err, "invalid component name")
+
+	opts = testScaffoldOptions()
+	opts.Name = "reloader"
+	_, err = Scaffold(dir, opts)
+	assert.ErrorContains(t, err, "already registered")
+
+	require.NoError(t, os.MkdirAll(ChartDir(dir, "cert-manager"), 0750))
+	_, err = Scaffold(dir, testScaffoldOptions())
+	assert.ErrorContains(t, err, "already exists")
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// CheckCommands are the commands custom checks can run in.
+var CheckCommands = []string{"doctor", "validate", "preflight", "preflight-cluster"}
+
+var checkIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// CustomCheck is a check declared in the checks section of the repository
+// config file. It runs either a command or a Kubernetes query.
+type CustomCheck struct {
+	ID          string `yaml:"id"`
+	Name        string `yaml:"name,omitempty"`
+	Category    string `yaml:"category,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	// Commands defaults to validate.
+	Commands  []string `yaml:"commands,omitempty"`
+	Backends  []string `yaml:"backends,omitempty"`
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	// Timeout is a Go duration such as 30s.
+	Timeout string `yaml:"timeout,omitempty"`
+	// Severity of a failing check: error (default) or warning.
+	Severity   string           `yaml:"severity,omitempty"`
+	Exec       *ExecCheck       `yaml:"exec,omitempty"`
+	Kubernetes *KubernetesCheck `yaml:"kubernetes,omitempty"`
+}
+
+// ExecCheck runs a command in the base directory. It passes when the
+// command exits with status 0.
+type ExecCheck struct {
+	Command []string `yaml:"command"`
+}
+
+// KubernetesCheck counts the objects matching a query. It passes when at
+// least MinCount objects match, or none when Absent is set.
+type KubernetesCheck struct {
+	APIVersion    string `yaml:"apiVersion"`
+	Kind          string `yaml:"kind"`
+	Namespace     string `yaml:"namespace,omitempty"`
+	Name          string `yaml:"name,omitempty"`
+	LabelSelector string `yaml:"labelSelector,omitempty"`
+	// MinCount defaults to 1.
+	MinCount int  `yaml:"minCount,omitempty"`
+	Absent   bool `yaml:"absent,omitempty"`
+}
+
+// TimeoutDuration returns the parsed timeout, 0 when unset.
+func (c CustomCheck) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(c.Timeout)
+	return d
+}
+
+// Validate checks that the check is complete and consistent.
+func (c CustomCheck) Validate() error {
+	if !checkIDPattern.MatchString(c.ID) {
+		return fmt.Errorf("check ID %q must be lowercase letters, digits and dashes", c.ID)
+	}
+	if (c.Exec == nil) == (c.Kubernetes == nil) {
+		return fmt.Errorf("check %s must set exactly one of exec and kubernetes", c.ID)
+	}
+	if c.Exec != nil && len(c.Exec.Command) == 0 {
+		return fmt.Errorf("check %s: exec.command is empty", c.ID)
+	}
+	if k := c.Kubernetes; k != nil {
+		if k.APIVersion == "" || k.Kind == "" {
+			return fmt.Errorf("check %s: kubernetes.apiVersion and kubernetes.kind are required", c.ID)
+		}
+		if k.Absent && k.MinCount > 0 {
+			return fmt.Errorf("check %s: kubernetes.absent and kubernetes.minCount are exclusive", c.ID)
+		}
+	}
+	for _, cmd := range c.Commands {
+		if !slices.Contains(CheckCommands, cmd) {
+			return fmt.Errorf("check %s: unknown command %q (use %s)", c.ID, cmd, strings.Join(CheckCommands, ", "))
+		}
+	}
+	for _, backend := range c.Backends {
+		if err := ValidateEncryption(backend); err != nil {
+			return fmt.Errorf("check %s: %w", c.ID, err)
+		}
+	}
+	if c.Severity != "" && c.Severity != "error" && c.Severity != "warning" {
+		return fmt.Errorf("check %s: severity must be error or warning, not %q", c.ID, c.Severity)
+	}
+	if c.Timeout != "" {
+		if d, err := time.ParseDuration(c.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("check %s: invalid timeout %q (use a duration such as 30s)", c.ID, c.Timeout)
+		}
+	}
+	return nil
+}
+
+// LoadCustomChecks returns the checks declared in the checks section of the
+// repository config file. A missing file yields no checks.
+func LoadCustomChecks(baseDir string) ([]CustomCheck, error) {
+	path := filepath.Join(baseDir, RepoConfigFile)
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	node := doc.Lookup("checks")
+	if node == nil {
+		return nil, nil
+	}
+	// Re-decode strictly, so that misspelled fields are reported.
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	var checks []CustomCheck
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&checks); err != nil {
+		return nil, fmt.Errorf("failed to parse checks in %s: %w", path, err)
+	}
+	seen := map[string]bool{}
+	for i, c := range checks {
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("%s: check %s is declared twice", path, c.ID)
+		}
+		seen[c.ID] = true
+		if len(c.Commands) == 0 {
+			checks[i].Commands = []string{"validate"}
+		}
+		if c.Category == "" {
+			checks[i].Category = "custom"
+		}
+		if c.Kubernetes != nil && c.Kubernetes.MinCount == 0 && !c.Kubernetes.Absent {
+			checks[i].Kubernetes.MinCount = 1
+		}
+	}
+	return checks, nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCustomChecks(t *testing.T) {
+	dir := t.TempDir()
+
+	checks, err := LoadCustomChecks(dir)
+	require.NoError(t, err)
+	assert.Empty(t, checks)
+
+	marker := filepath.Join(dir, RepoConfigFile)
+	require.NoError(t, os.WriteFile(marker, []byte(`encryption:
+  dev: sops
+checks:
+  - id: chart-docs
+    exec:
+      command: [make, docs-check]
+    timeout: 30s
+  - id: cert-manager
+    commands: [doctor, preflight]
+    dependsOn: [cluster-access]
+    severity: warning
+    kubernetes:
+      apiVersion: apps/v1
+      kind: Deployment
+      namespace: cert-manager
+`), 0644))
+
+	checks, err = LoadCustomChecks(dir)
+	require.NoError(t, err)
+	require.Len(t, checks, 2)
+	assert.Equal(t, []string{"validate"}, checks[0].Commands)
+	assert.Equal(t, "custom", checks[0].Category)
+	assert.Equal(t, 30*time.Second, checks[0].TimeoutDuration())
+	assert.Equal(t, []string{"doctor", "preflight"}, checks[1].Commands)
+	assert.Equal(t, 1, checks[1].Kubernetes.MinCount)
+}
+
+func TestLoadCustomChecksErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		checks  string
+		wantErr string
+	}{
+		{"unknown field", "  - id: a\n    exec:\n      cmd: [true]\n", "field cmd not found"},
+		{"no kind", "  - id: a\n", "exactly one of exec and kubernetes"},
+		{"both kinds", "  - id: a\n    exec:\n      command: [true]\n    kubernetes:\n      apiVersion: v1\n      kind: Pod\n", "exactly one of exec and kubernetes"},
+		{"bad id", "  - id: My_Check\n    exec:\n      command: [true]\n", "lowercase letters"},
+		{"duplicate", "  - id: a\n    exec:\n      command: [true]\n  - id: a\n    exec:\n      command: [true]\n", "declared twice"},
+		{"bad command", "  - id: a\n    commands: [status]\n    exec:\n      command: [true]\n", `unknown command "status"`},
+		{"bad timeout", "  - id: a\n    timeout: soon\n    exec:\n      command: [true]\n", "invalid timeout"},
+		{"bad severity", "  - id: a\n    severity: fatal\n    exec:\n      command: [true]\n", "severity must be error or warning"},
+		{"absent and min", "  - id: a\n    kubernetes:\n      apiVersion: v1\n      kind: Pod\n      absent: true\n      minCount: 2\n", "exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, RepoConfigFile), []byte("checks:\n"+tt.checks), 0644))
+			_, err := LoadCustomChecks(dir)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// RepoConfigFile is the repository's cluster-bootstrap settings: the
+// encryption backend of each environment, so commands do not need
+// --encryption on every run, the pinned SSH host keys of Git servers (see
+// LoadKnownHostPins), custom checks (see LoadCustomChecks) and version
+// constraints (see LoadVersionConstraints).
+const RepoConfigFile = ".cluster-bootstrap.yaml"
+
+// Encryption backends.
+const (
+	EncryptionSOPS     = "sops"
+	EncryptionGitCrypt = "git-crypt"
+)
+
+// ValidateEncryption checks that backend is a supported encryption backend.
+func ValidateEncryption(backend string) error {
+	if backend != EncryptionSOPS && backend != EncryptionGitCrypt {
+		return fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", backend)
+	}
+	return nil
+}
+
+// LoadEncryptionMarker returns the recorded backend per environment.
+// A missing repository config file yields an empty map.
+func LoadEncryptionMarker(baseDir string) (map[string]string, error) {
+	path := filepath.Join(baseDir, RepoConfigFile)
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	backends := map[string]string{}
+	if err := doc.Decode(&backends, "encryption"); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for env, backend := range backends {
+		if err := ValidateEncryption(backend); err != nil {
+			return nil, fmt.Errorf("%s: environment %s: %w", path, env, err)
+		}
+	}
+	return backends, nil
+}
+
+// EncryptionBackend returns the recorded backend for env, if any.
+func EncryptionBackend(baseDir, env string) (string, bool, error) {
+	backends, err := LoadEncryptionMarker(baseDir)
+	if err != nil {
+		return "", false, err
+	}
+	backend, ok := backends[env]
+	return backend, ok, nil
+}
+
+// SetEncryptionBackend records the backend for env in the repository config
+// file, creating it if needed. Other content of the file is preserved.
+func SetEncryptionBackend(baseDir, env, backend string) error {
+	if err := ValidateEncryption(backend); err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, RepoConfigFile)
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if doc, err = yamledit.Parse([]byte("# Encryption backend per environment, read by cluster-bootstrap.\nencryption:\n")); err != nil {
+			return err
+		}
+	}
+	if err := doc.Set(backend, "encryption", env); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	return doc.Save(path, 0644)
+}
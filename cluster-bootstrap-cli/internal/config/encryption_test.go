@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionMarker(t *testing.T) {
+	dir := t.TempDir()
+
+	backends, err := LoadEncryptionMarker(dir)
+	require.NoError(t, err)
+	assert.Empty(t, backends)
+	_, ok, err := EncryptionBackend(dir, "dev")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, SetEncryptionBackend(dir, "dev", EncryptionGitCrypt))
+	require.NoError(t, SetEncryptionBackend(dir, "prod", EncryptionSOPS))
+	require.NoError(t, SetEncryptionBackend(dir, "dev", EncryptionSOPS))
+	assert.ErrorContains(t, SetEncryptionBackend(dir, "dev", "vault"), "unsupported encryption backend")
+
+	backends, err = LoadEncryptionMarker(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dev": "sops", "prod": "sops"}, backends)
+
+	data, err := os.ReadFile(filepath.Join(dir, RepoConfigFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Encryption backend per environment")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RepoConfigFile), []byte("encryption:\n  dev: vault\n"), 0644))
+	_, err = LoadEncryptionMarker(dir)
+	assert.ErrorContains(t, err, "environment dev")
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// LoadKnownHostPins returns the host key fingerprints pinned in the
+// knownHosts section of the repository config file, by known_hosts host name
+// (host, or [host]:port for other ports). A missing file yields an empty map.
+func LoadKnownHostPins(baseDir string) (map[string][]string, error) {
+	path := filepath.Join(baseDir, RepoConfigFile)
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string][]string{}, nil
+		}
+		return nil, err
+	}
+	pins := map[string][]string{}
+	if err := doc.Decode(&pins, "knownHosts"); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return pins, nil
+}
+
+// SetKnownHostPins records the fingerprints pinned for host in the
+// repository config file, creating it if needed. Other content of the file is preserved.
+func SetKnownHostPins(baseDir, host string, fingerprints []string) error {
+	path := filepath.Join(baseDir, RepoConfigFile)
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if doc, err = yamledit.Parse([]byte("# SSH host key fingerprints per Git server, read by cluster-bootstrap.\nknownHosts:\n")); err != nil {
+			return err
+		}
+	}
+	if err := doc.Set(fingerprints, "knownHosts", host); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	return doc.Save(path, 0644)
+}
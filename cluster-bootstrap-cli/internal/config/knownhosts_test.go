@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownHostPins(t *testing.T) {
+	dir := t.TempDir()
+
+	pins, err := LoadKnownHostPins(dir)
+	require.NoError(t, err)
+	assert.Empty(t, pins)
+
+	require.NoError(t, SetEncryptionBackend(dir, "dev", EncryptionSOPS))
+	require.NoError(t, SetKnownHostPins(dir, "[git.example.com]:2222", []string{"SHA256:abc"}))
+	require.NoError(t, SetKnownHostPins(dir, "github.com", []string{"SHA256:def"}))
+
+	pins, err = LoadKnownHostPins(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"[git.example.com]:2222": {"SHA256:abc"},
+		"github.com":             {"SHA256:def"},
+	}, pins)
+
+	// The encryption section is untouched.
+	backend, ok, err := EncryptionBackend(dir, "dev")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, EncryptionSOPS, backend)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RepoConfigFile), []byte("knownHosts: [a]\n"), 0644))
+	_, err = LoadKnownHostPins(dir)
+	assert.ErrorContains(t, err, "failed to parse")
+}
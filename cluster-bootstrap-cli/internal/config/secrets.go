@@ -2,8 +2,11 @@ package config
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
@@ -24,6 +27,9 @@ type RepoSecrets struct {
 	URL            string `yaml:"url"`
 	TargetRevision string `yaml:"targetRevision"`
 	SSHPrivateKey  string `yaml:"sshPrivateKey"`
+	// HostKeyFingerprints pins the SSH host keys of the repository server
+	// (SHA256:... as printed by ssh-keygen -lf).
+	HostKeyFingerprints []string `yaml:"hostKeyFingerprints,omitempty"`
 }
 
 // SecretsFileName returns the SOPS-encrypted secrets file name for the given environment.
@@ -55,6 +61,22 @@ func LoadSecrets(filePath string, sopsOpts *sops.Options) (*EnvironmentSecrets,
 // It returns an error if the file still contains the git-crypt magic header,
 // which means it has not been decrypted (git-crypt unlock has not been run).
 func LoadSecretsPlaintext(filePath string) (*EnvironmentSecrets, error) {
+	data, err := ReadSecretsPlaintext(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets EnvironmentSecrets
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+
+	return &secrets, nil
+}
+
+// ReadSecretsPlaintext returns the raw contents of a git-crypt managed
+// secrets file, failing if the working tree copy is still encrypted.
+func ReadSecretsPlaintext(filePath string) ([]byte, error) {
 	data, err := os.ReadFile(filePath) // #nosec G304
 	if err != nil {
 		return nil, fmt.Errorf("failed to read secrets file: %w", err)
@@ -63,11 +85,31 @@ func LoadSecretsPlaintext(filePath string) (*EnvironmentSecrets, error) {
 	if bytes.HasPrefix(data, gitCryptMagic) {
 		return nil, fmt.Errorf("file %s is still encrypted by git-crypt; run 'git-crypt unlock' first", filePath)
 	}
+	return data, nil
+}
 
+// ParseSecrets strictly decodes plaintext secrets and checks the schema:
+// unknown keys are rejected, repo.url and repo.sshPrivateKey are required
+// and repo.targetRevision must not contain spaces.
+func ParseSecrets(data []byte) (*EnvironmentSecrets, error) {
 	var secrets EnvironmentSecrets
-	if err := yaml.Unmarshal(data, &secrets); err != nil {
-		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&secrets); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("secrets file is empty")
+		}
+		return nil, fmt.Errorf("invalid secrets: %w", err)
 	}
 
+	if strings.TrimSpace(secrets.Repo.URL) == "" {
+		return nil, fmt.Errorf("repo.url is required in secrets file")
+	}
+	if strings.TrimSpace(secrets.Repo.SSHPrivateKey) == "" {
+		return nil, fmt.Errorf("repo.sshPrivateKey is required in secrets file")
+	}
+	if strings.ContainsAny(secrets.Repo.TargetRevision, " \t\n") {
+		return nil, fmt.Errorf("repo.targetRevision must not contain spaces")
+	}
 	return &secrets, nil
 }
@@ -78,3 +78,28 @@ func TestLoadSecretsPlaintext_FileNotFound(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to read secrets file")
 }
+
+func TestParseSecrets(t *testing.T) {
+	valid := "repo:\n  url: git@github.com:user/repo.git\n  targetRevision: main\n  sshPrivateKey: key\n"
+	secrets, err := ParseSecrets([]byte(valid))
+	require.NoError(t, err)
+	assert.Equal(t, "main", secrets.Repo.TargetRevision)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"empty", "", "empty"},
+		{"unknown key", valid + "  password: x\n", "field password not found"},
+		{"missing url", "repo:\n  sshPrivateKey: key\n", "repo.url is required"},
+		{"missing key", "repo:\n  url: git@github.com:user/repo.git\n", "repo.sshPrivateKey is required"},
+		{"revision with spaces", "repo:\n  url: u\n  targetRevision: my branch\n  sshPrivateKey: key\n", "must not contain spaces"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSecrets([]byte(tt.content))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
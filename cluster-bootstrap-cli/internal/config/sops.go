@@ -3,21 +3,202 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
 )
 
-// SOPSConfig represents the .sops.yaml configuration file.
+// SOPSConfig represents the .sops.yaml configuration file. A config read
+// from disk keeps its parsed document, so writing it back preserves other
+// top-level keys, comments and fields this package does not model.
 type SOPSConfig struct {
 	CreationRules []CreationRule `yaml:"creation_rules"`
+
+	doc *yamledit.Document
 }
 
-// CreationRule defines a SOPS creation rule.
+// CreationRule defines a SOPS creation rule. The key fields (Age, PGP, KMS,
+// GCPKMS, AzureKeyVault, HCVaultTransitURI) hold comma-separated keys; a YAML
+// list in .sops.yaml is read as such and kept as a list unless changed.
 type CreationRule struct {
-	PathRegex string `yaml:"path_regex,omitempty"`
-	Age       string `yaml:"age,omitempty"`
-	KMS       string `yaml:"kms,omitempty"`
-	GCPKMS    string `yaml:"gcp_kms,omitempty"`
+	PathRegex               string     `yaml:"path_regex,omitempty"`
+	Age                     string     `yaml:"age,omitempty"`
+	PGP                     string     `yaml:"pgp,omitempty"`
+	KMS                     string     `yaml:"kms,omitempty"`
+	AWSProfile              string     `yaml:"aws_profile,omitempty"`
+	GCPKMS                  string     `yaml:"gcp_kms,omitempty"`
+	AzureKeyVault           string     `yaml:"azure_keyvault,omitempty"`
+	HCVaultTransitURI       string     `yaml:"hc_vault_transit_uri,omitempty"`
+	KeyGroups               []KeyGroup `yaml:"key_groups,omitempty"`
+	ShamirThreshold         int        `yaml:"shamir_threshold,omitempty"`
+	UnencryptedSuffix       string     `yaml:"unencrypted_suffix,omitempty"`
+	EncryptedSuffix         string     `yaml:"encrypted_suffix,omitempty"`
+	UnencryptedRegex        string     `yaml:"unencrypted_regex,omitempty"`
+	EncryptedRegex          string     `yaml:"encrypted_regex,omitempty"`
+	UnencryptedCommentRegex string     `yaml:"unencrypted_comment_regex,omitempty"`
+	EncryptedCommentRegex   string     `yaml:"encrypted_comment_regex,omitempty"`
+	MACOnlyEncrypted        bool       `yaml:"mac_only_encrypted,omitempty"`
+
+	// node and loaded are the rule as read from disk, used to re-encode
+	// only the fields that changed.
+	node   *yaml.Node
+	loaded *CreationRule
+}
+
+// KeyGroup is one entry of a rule's key_groups. With shamir_threshold, a
+// file can be decrypted by keys from that many groups.
+type KeyGroup struct {
+	Merge   []KeyGroup   `yaml:"merge,omitempty"`
+	Age     []string     `yaml:"age,omitempty"`
+	PGP     []string     `yaml:"pgp,omitempty"`
+	KMS     []KMSKey     `yaml:"kms,omitempty"`
+	GCPKMS  []GCPKMSKey  `yaml:"gcp_kms,omitempty"`
+	AzureKV []AzureKVKey `yaml:"azure_keyvault,omitempty"`
+	Vault   []string     `yaml:"hc_vault,omitempty"`
+}
+
+// KMSKey is an AWS KMS key in a key group.
+type KMSKey struct {
+	ARN        string             `yaml:"arn"`
+	Role       string             `yaml:"role,omitempty"`
+	Context    map[string]*string `yaml:"context,omitempty"`
+	AWSProfile string             `yaml:"aws_profile,omitempty"`
+}
+
+// GCPKMSKey is a GCP KMS key in a key group.
+type GCPKMSKey struct {
+	ResourceID string `yaml:"resource_id"`
+}
+
+// AzureKVKey is an Azure Key Vault key in a key group.
+type AzureKVKey struct {
+	VaultURL string `yaml:"vaultUrl"`
+	Key      string `yaml:"key"`
+	Version  string `yaml:"version"`
+}
+
+// Providers lists the SOPS providers a creation rule can use, with the
+// .sops.yaml key holding their keys.
+var Providers = []struct{ Name, Field string }{
+	{"age", "age"},
+	{"pgp", "pgp"},
+	{"aws-kms", "kms"},
+	{"gcp-kms", "gcp_kms"},
+	{"azure-kv", "azure_keyvault"},
+	{"hc-vault-transit", "hc_vault_transit_uri"},
+}
+
+// keys returns the field holding provider's keys, or nil if unsupported.
+func (r *CreationRule) keys(provider string) *string {
+	switch provider {
+	case "age":
+		return &r.Age
+	case "pgp":
+		return &r.PGP
+	case "aws-kms":
+		return &r.KMS
+	case "gcp-kms":
+		return &r.GCPKMS
+	case "azure-kv":
+		return &r.AzureKeyVault
+	case "hc-vault-transit":
+		return &r.HCVaultTransitURI
+	}
+	return nil
+}
+
+// UnmarshalYAML decodes a rule, accepting key lists as well as
+// comma-separated strings, and remembers the node for re-encoding.
+func (r *CreationRule) UnmarshalYAML(node *yaml.Node) error {
+	normalized := *node
+	normalized.Content = slices.Clone(node.Content)
+	for i := 0; i+1 < len(normalized.Content); i += 2 {
+		value := normalized.Content[i+1]
+		if !isKeyField(normalized.Content[i].Value) || value.Kind != yaml.SequenceNode {
+			continue
+		}
+		var keys []string
+		if err := value.Decode(&keys); err != nil {
+			return err
+		}
+		normalized.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: strings.Join(keys, ",")}
+	}
+
+	type plain CreationRule
+	var decoded plain
+	if err := normalized.Decode(&decoded); err != nil {
+		return err
+	}
+	*r = CreationRule(decoded)
+	loaded := *r
+	r.node = node
+	r.loaded = &loaded
+	return nil
+}
+
+// MarshalYAML encodes a rule. A rule read from disk keeps its original node;
+// only fields that changed since it was read are replaced or removed, so
+// unknown fields, comments and list-style keys survive.
+func (r CreationRule) MarshalYAML() (interface{}, error) {
+	type plain CreationRule
+	var fresh yaml.Node
+	if err := fresh.Encode(plain(r)); err != nil {
+		return nil, err
+	}
+	if r.node == nil || r.loaded == nil {
+		return &fresh, nil
+	}
+
+	out := *r.node
+	out.Content = slices.Clone(r.node.Content)
+	current, loaded := reflect.ValueOf(r), reflect.ValueOf(*r.loaded)
+	fields := current.Type()
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "" {
+			continue
+		}
+		if reflect.DeepEqual(current.Field(i).Interface(), loaded.Field(i).Interface()) {
+			continue
+		}
+		setOrDelete(&out, name, yamledit.MappingValue(&fresh, name))
+	}
+	return &out, nil
+}
+
+func isKeyField(name string) bool {
+	for _, p := range Providers {
+		if p.Field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrDelete sets key in mapping to value, or removes it when value is nil.
+func setOrDelete(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		if value == nil {
+			mapping.Content = slices.Delete(mapping.Content, i, i+2)
+		} else {
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+		}
+		return
+	}
+	if value != nil {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
 }
 
 // ReadSopsConfig reads and parses an existing .sops.yaml file.
@@ -27,14 +208,63 @@ func ReadSopsConfig(path string) (*SOPSConfig, error) {
 		return nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	var cfg SOPSConfig
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	doc, err := yamledit.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	cfg := SOPSConfig{doc: doc}
+	if err := doc.Decode(&cfg.CreationRules, "creation_rules"); err != nil {
 		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
 	}
 
 	return &cfg, nil
 }
 
+// Marshal encodes the config. A config read from disk is re-encoded from
+// its original document, with creation_rules replaced.
+func (c *SOPSConfig) Marshal() ([]byte, error) {
+	if c.doc == nil {
+		data, err := yaml.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal SOPS config: %w", err)
+		}
+		return data, nil
+	}
+
+	// Nodes are assigned directly rather than through yaml.Node.Encode,
+	// which would drop their comments.
+	root := c.doc.Root()
+	rules := yamledit.MappingValue(root, "creation_rules")
+	if rules == nil || rules.Kind != yaml.SequenceNode {
+		rules = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setOrDelete(root, "creation_rules", rules)
+	}
+	rules.Content = nil
+	for _, rule := range c.CreationRules {
+		node, err := rule.MarshalYAML()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal SOPS config: %w", err)
+		}
+		rules.Content = append(rules.Content, node.(*yaml.Node))
+	}
+	if err := c.doc.Replace(root); err != nil {
+		return nil, fmt.Errorf("failed to marshal SOPS config: %w", err)
+	}
+	return c.doc.Bytes(), nil
+}
+
+// Save writes the config to path.
+func (c *SOPSConfig) Save(path string) error {
+	data, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
 // EnvPathRegex returns the path_regex pattern for a given environment name.
 func EnvPathRegex(envName string) string {
 	return fmt.Sprintf(`secrets\.%s\.enc\.yaml$`, envName)
@@ -42,58 +272,178 @@ func EnvPathRegex(envName string) string {
 
 // UpsertSopsRule ensures a creation rule exists in .sops.yaml for the given environment.
 // If the file doesn't exist, it creates it. If a rule with a matching path_regex
-// already exists, it updates the provider/key. Otherwise it appends a new rule.
-func UpsertSopsRule(outputPath, provider, key, envName string) error {
-	pathRegex := EnvPathRegex(envName)
-
-	rule := CreationRule{
-		PathRegex: pathRegex,
+// already exists, it replaces its keys with the given recipients and keeps its
+// other fields. Otherwise a new rule is added, before any broader rule that
+// already matches the environment's secrets file, since SOPS uses the first
+// matching rule.
+func UpsertSopsRule(outputPath, provider string, keys []string, envName string) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("at least one %s key is required", provider)
 	}
-
-	switch provider {
-	case "age":
-		rule.Age = key
-	case "aws-kms":
-		rule.KMS = key
-	case "gcp-kms":
-		rule.GCPKMS = key
-	default:
+	if (&CreationRule{}).keys(provider) == nil {
 		return fmt.Errorf("unsupported SOPS provider: %s", provider)
 	}
+	return upsertRule(outputPath, envName, func(rule *CreationRule) error {
+		if len(rule.KeyGroups) > 0 {
+			return fmt.Errorf("the %s rule in %s uses key_groups\n  hint: edit its key groups in %s by hand", envName, outputPath, outputPath)
+		}
+		for _, p := range Providers {
+			*rule.keys(p.Name) = ""
+		}
+		*rule.keys(provider) = strings.Join(keys, ",")
+		return nil
+	})
+}
+
+// UpsertSopsKeyGroups ensures the environment's creation rule encrypts with
+// the given key groups. A threshold above zero sets shamir_threshold: that
+// many groups are needed to decrypt.
+func UpsertSopsKeyGroups(outputPath string, groups []KeyGroup, threshold int, envName string) error {
+	if len(groups) == 0 {
+		return fmt.Errorf("at least one key group is required")
+	}
+	if threshold < 0 || threshold > len(groups) {
+		return fmt.Errorf("shamir threshold %d must be 0 (none) or between 1 and the number of key groups (%d)", threshold, len(groups))
+	}
+	return upsertRule(outputPath, envName, func(rule *CreationRule) error {
+		for _, p := range Providers {
+			*rule.keys(p.Name) = ""
+		}
+		rule.KeyGroups = groups
+		rule.ShamirThreshold = threshold
+		return nil
+	})
+}
 
-	var cfg SOPSConfig
+// upsertRule applies update to the environment's rule, adding the rule if
+// it does not exist, and writes the config.
+func upsertRule(outputPath, envName string, update func(*CreationRule) error) error {
+	pathRegex := EnvPathRegex(envName)
 
+	cfg := &SOPSConfig{}
 	if _, err := os.Stat(outputPath); err == nil {
-		existing, err := ReadSopsConfig(outputPath)
-		if err != nil {
+		if cfg, err = ReadSopsConfig(outputPath); err != nil {
 			return err
 		}
-		cfg = *existing
 	}
 
-	// Find existing rule with matching path_regex and update, or append
-	found := false
-	for i, r := range cfg.CreationRules {
-		if r.PathRegex == pathRegex {
-			cfg.CreationRules[i] = rule
-			found = true
-			break
+	i := slices.IndexFunc(cfg.CreationRules, func(r CreationRule) bool { return r.PathRegex == pathRegex })
+	if i < 0 {
+		i = len(cfg.CreationRules)
+		if at, _ := cfg.RuleFor(SecretsFileName(envName)); at >= 0 {
+			i = at
 		}
+		cfg.CreationRules = slices.Insert(cfg.CreationRules, i, CreationRule{PathRegex: pathRegex})
 	}
-	if !found {
-		cfg.CreationRules = append(cfg.CreationRules, rule)
+	if err := update(&cfg.CreationRules[i]); err != nil {
+		return err
 	}
 
-	data, err := yaml.Marshal(cfg)
+	return cfg.Save(outputPath)
+}
+
+// RemoveSopsRule deletes the creation rule for the given environment from
+// .sops.yaml. It reports whether a rule was removed.
+func RemoveSopsRule(outputPath, envName string) (bool, error) {
+	cfg, err := ReadSopsConfig(outputPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal SOPS config: %w", err)
+		return false, err
+	}
+	pathRegex := EnvPathRegex(envName)
+	i := slices.IndexFunc(cfg.CreationRules, func(r CreationRule) bool { return r.PathRegex == pathRegex })
+	if i < 0 {
+		return false, nil
+	}
+	cfg.CreationRules = slices.Delete(cfg.CreationRules, i, i+1)
+	if err := cfg.Save(outputPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RuleFor returns the index and the first creation rule whose path_regex
+// matches filePath, as SOPS selects it, or -1 if none matches. A rule
+// without path_regex matches every file.
+func (c *SOPSConfig) RuleFor(filePath string) (int, *CreationRule) {
+	filePath = filepath.ToSlash(filePath)
+	for i := range c.CreationRules {
+		rule := &c.CreationRules[i]
+		if rule.PathRegex == "" {
+			return i, rule
+		}
+		re, err := regexp.Compile(rule.PathRegex)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(filePath) {
+			return i, rule
+		}
+	}
+	return -1, nil
+}
+
+// Provider returns the SOPS provider the rule encrypts with. Rules that mix
+// providers, use key groups or have no keys return an error.
+func (r *CreationRule) Provider() (string, error) {
+	if len(r.KeyGroups) > 0 {
+		return "", fmt.Errorf("creation rule %q uses key_groups", r.PathRegex)
+	}
+	var providers []string
+	for _, p := range Providers {
+		if *r.keys(p.Name) != "" {
+			providers = append(providers, p.Name)
+		}
 	}
+	switch len(providers) {
+	case 0:
+		return "", fmt.Errorf("creation rule %q has no keys", r.PathRegex)
+	case 1:
+		return providers[0], nil
+	default:
+		return "", fmt.Errorf("creation rule %q mixes providers (%s)", r.PathRegex, strings.Join(providers, ", "))
+	}
+}
 
-	if err := os.WriteFile(outputPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write %s: %w", outputPath, err)
+// Recipients returns the rule's keys for provider, split on commas.
+func (r *CreationRule) Recipients(provider string) []string {
+	field := r.keys(provider)
+	if field == nil {
+		return nil
 	}
+	return SplitKeys(*field)
+}
 
-	return nil
+// SplitKeys splits a comma-separated key list, dropping blank entries.
+func SplitKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// KeyProvider infers the SOPS provider of a recipient: an age public key,
+// a PGP fingerprint, an AWS KMS key ARN, a GCP KMS key resource ID, an Azure
+// Key Vault key URL or a HashiCorp Vault transit key URI.
+func KeyProvider(key string) (string, error) {
+	switch {
+	case strings.HasPrefix(key, "age1"):
+		return "age", nil
+	case pgpFingerprint.MatchString(key):
+		return "pgp", nil
+	case strings.HasPrefix(key, "arn:aws"):
+		return "aws-kms", nil
+	case strings.HasPrefix(key, "projects/") && strings.Contains(key, "/cryptoKeys/"):
+		return "gcp-kms", nil
+	case strings.HasPrefix(key, "https://") && strings.Contains(key, ".vault.azure."):
+		return "azure-kv", nil
+	case strings.HasPrefix(key, "http") && strings.Contains(key, "/v1/") && strings.Contains(key, "/keys/"):
+		return "hc-vault-transit", nil
+	default:
+		return "", fmt.Errorf("unrecognized recipient %q\n  hint: use an age public key (age1...), a PGP fingerprint, an AWS KMS key ARN, a GCP KMS key resource ID, an Azure Key Vault key URL or a Vault transit key URI", key)
+	}
 }
 
 // WriteSopsConfig writes the .sops.yaml file with the given provider configuration.
@@ -101,30 +451,14 @@ func WriteSopsConfig(outputPath, provider, key string) error {
 	rule := CreationRule{
 		PathRegex: `\.enc\.yaml$`,
 	}
-
-	switch provider {
-	case "age":
-		rule.Age = key
-	case "aws-kms":
-		rule.KMS = key
-	case "gcp-kms":
-		rule.GCPKMS = key
-	default:
+	field := rule.keys(provider)
+	if field == nil {
 		return fmt.Errorf("unsupported SOPS provider: %s", provider)
 	}
+	*field = key
 
-	config := SOPSConfig{
+	cfg := SOPSConfig{
 		CreationRules: []CreationRule{rule},
 	}
-
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("failed to marshal SOPS config: %w", err)
-	}
-
-	if err := os.WriteFile(outputPath, data, 0600); err != nil {
-		return fmt.Errorf("failed to write %s: %w", outputPath, err)
-	}
-
-	return nil
+	return cfg.Save(outputPath)
 }
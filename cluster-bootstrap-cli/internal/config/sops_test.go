@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -120,7 +121,7 @@ func TestUpsertSopsRule(t *testing.T) {
 		tmpDir := t.TempDir()
 		outPath := filepath.Join(tmpDir, ".sops.yaml")
 
-		err := UpsertSopsRule(outPath, "age", "age1newkey", "dev")
+		err := UpsertSopsRule(outPath, "age", []string{"age1newkey"}, "dev")
 		require.NoError(t, err)
 
 		cfg, err := ReadSopsConfig(outPath)
@@ -135,11 +136,11 @@ func TestUpsertSopsRule(t *testing.T) {
 		outPath := filepath.Join(tmpDir, ".sops.yaml")
 
 		// Create initial rule
-		err := UpsertSopsRule(outPath, "age", "age1oldkey", "dev")
+		err := UpsertSopsRule(outPath, "age", []string{"age1oldkey"}, "dev")
 		require.NoError(t, err)
 
 		// Update same environment
-		err = UpsertSopsRule(outPath, "age", "age1newkey", "dev")
+		err = UpsertSopsRule(outPath, "age", []string{"age1newkey"}, "dev")
 		require.NoError(t, err)
 
 		cfg, err := ReadSopsConfig(outPath)
@@ -152,10 +153,10 @@ func TestUpsertSopsRule(t *testing.T) {
 		tmpDir := t.TempDir()
 		outPath := filepath.Join(tmpDir, ".sops.yaml")
 
-		err := UpsertSopsRule(outPath, "age", "age1devkey", "dev")
+		err := UpsertSopsRule(outPath, "age", []string{"age1devkey"}, "dev")
 		require.NoError(t, err)
 
-		err = UpsertSopsRule(outPath, "age", "age1prodkey", "prod")
+		err = UpsertSopsRule(outPath, "age", []string{"age1prodkey"}, "prod")
 		require.NoError(t, err)
 
 		cfg, err := ReadSopsConfig(outPath)
@@ -165,11 +166,41 @@ func TestUpsertSopsRule(t *testing.T) {
 		assert.Equal(t, "age1prodkey", cfg.CreationRules[1].Age)
 	})
 
+	t.Run("multiple recipients", func(t *testing.T) {
+		outPath := filepath.Join(t.TempDir(), ".sops.yaml")
+
+		require.NoError(t, UpsertSopsRule(outPath, "age", []string{"age1alice", "age1bob"}, "dev"))
+
+		cfg, err := ReadSopsConfig(outPath)
+		require.NoError(t, err)
+		require.Len(t, cfg.CreationRules, 1)
+		assert.Equal(t, "age1alice,age1bob", cfg.CreationRules[0].Age)
+		assert.Equal(t, []string{"age1alice", "age1bob"}, cfg.CreationRules[0].Recipients("age"))
+
+		assert.ErrorContains(t, UpsertSopsRule(outPath, "age", nil, "dev"), "at least one age key")
+	})
+
+	t.Run("inserts before a broader matching rule", func(t *testing.T) {
+		outPath := filepath.Join(t.TempDir(), ".sops.yaml")
+		require.NoError(t, WriteSopsConfig(outPath, "age", "age1shared"))
+
+		require.NoError(t, UpsertSopsRule(outPath, "age", []string{"age1prod"}, "prod"))
+
+		cfg, err := ReadSopsConfig(outPath)
+		require.NoError(t, err)
+		require.Len(t, cfg.CreationRules, 2)
+		i, rule := cfg.RuleFor("/repo/secrets.prod.enc.yaml")
+		assert.Equal(t, 0, i)
+		assert.Equal(t, "age1prod", rule.Age)
+		_, rule = cfg.RuleFor("/repo/secrets.dev.enc.yaml")
+		assert.Equal(t, "age1shared", rule.Age)
+	})
+
 	t.Run("unsupported provider returns error", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		outPath := filepath.Join(tmpDir, ".sops.yaml")
 
-		err := UpsertSopsRule(outPath, "invalid", "key", "dev")
+		err := UpsertSopsRule(outPath, "invalid", []string{"key"}, "dev")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unsupported SOPS provider")
 	})
@@ -179,7 +210,172 @@ func TestUpsertSopsRule(t *testing.T) {
 		outPath := filepath.Join(tmpDir, ".sops.yaml")
 		_ = os.WriteFile(outPath, []byte("not: [valid yaml"), 0644)
 
-		err := UpsertSopsRule(outPath, "age", "age1key", "dev")
+		err := UpsertSopsRule(outPath, "age", []string{"age1key"}, "dev")
 		require.Error(t, err)
 	})
 }
+
+func TestRemoveSopsRule(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), ".sops.yaml")
+	require.NoError(t, UpsertSopsRule(outPath, "age", []string{"age1dev"}, "dev"))
+	require.NoError(t, UpsertSopsRule(outPath, "age", []string{"age1prod"}, "prod"))
+
+	removed, err := RemoveSopsRule(outPath, "dev")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	cfg, err := ReadSopsConfig(outPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.CreationRules, 1)
+	assert.Equal(t, "age1prod", cfg.CreationRules[0].Age)
+
+	removed, err = RemoveSopsRule(outPath, "dev")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+const handWrittenSops = `# Team SOPS config
+stores:
+  yaml:
+    indent: 2
+creation_rules:
+  # prod needs two of three groups
+  - path_regex: secrets\.prod\.enc\.yaml$
+    shamir_threshold: 2
+    key_groups:
+      - age:
+          - age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8
+      - pgp:
+          - 85D77543B3D624B63CEA9E6DBC17301B491B3F21
+      - kms:
+          - arn: arn:aws:kms:eu-west-1:123456789012:key/abc
+            role: arn:aws:iam::123456789012:role/sops
+        hc_vault:
+          - https://vault.example.com:8200/v1/transit/keys/prod
+    encrypted_regex: ^(sshPrivateKey|password)$
+    future_option: true
+  - path_regex: secrets\.dev\.enc\.yaml$
+    age:
+      - age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8
+    azure_keyvault: https://team.vault.azure.net/keys/sops/0123456789abcdef # shared vault
+`
+
+func TestSopsConfigRoundTrip(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), ".sops.yaml")
+	require.NoError(t, os.WriteFile(outPath, []byte(handWrittenSops), 0600))
+
+	cfg, err := ReadSopsConfig(outPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.CreationRules, 2)
+	prod, dev := cfg.CreationRules[0], cfg.CreationRules[1]
+	assert.Equal(t, 2, prod.ShamirThreshold)
+	require.Len(t, prod.KeyGroups, 3)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/sops", prod.KeyGroups[2].KMS[0].Role)
+	assert.Equal(t, "^(sshPrivateKey|password)$", prod.EncryptedRegex)
+	assert.Equal(t, []string{"age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"}, dev.Recipients("age"))
+	assert.NoError(t, cfg.Validate())
+
+	data, err := cfg.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, handWrittenSops, string(data), "an unchanged config is written back as read")
+
+	// Changing one rule leaves the other, and unmodelled fields, untouched.
+	require.NoError(t, UpsertSopsRule(outPath, "age", []string{"age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"}, "staging"))
+	require.NoError(t, UpsertSopsRule(outPath, "azure-kv", []string{"https://team.vault.azure.net/keys/sops/0123456789abcdef"}, "dev"))
+	out, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), handWrittenSops[:strings.Index(handWrittenSops, "  - path_regex: secrets\\.dev")])
+	assert.Contains(t, string(out), "azure_keyvault: https://team.vault.azure.net/keys/sops/0123456789abcdef # shared vault")
+	assert.NotContains(t, string(out), "age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8\n    azure")
+
+	assert.ErrorContains(t, UpsertSopsRule(outPath, "age", []string{"age1x"}, "prod"), "uses key_groups")
+}
+
+func TestUpsertSopsKeyGroups(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), ".sops.yaml")
+	alice, err := NewKeyGroup([]string{"age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"})
+	require.NoError(t, err)
+	kms, err := NewKeyGroup([]string{"arn:aws:kms:eu-west-1:123456789012:key/abc", "https://team.vault.azure.net/keys/sops/v1"})
+	require.NoError(t, err)
+	assert.Equal(t, []AzureKVKey{{VaultURL: "https://team.vault.azure.net", Key: "sops", Version: "v1"}}, kms.AzureKV)
+
+	assert.ErrorContains(t, UpsertSopsKeyGroups(outPath, []KeyGroup{alice}, 2, "prod"), "shamir threshold")
+	assert.ErrorContains(t, UpsertSopsKeyGroups(outPath, []KeyGroup{alice}, -1, "prod"), "must be 0 (none)")
+	require.NoError(t, UpsertSopsKeyGroups(outPath, []KeyGroup{alice, kms}, 1, "prod"))
+
+	cfg, err := ReadSopsConfig(outPath)
+	require.NoError(t, err)
+	require.Len(t, cfg.CreationRules, 1)
+	assert.Equal(t, 1, cfg.CreationRules[0].ShamirThreshold)
+	assert.Len(t, cfg.CreationRules[0].KeyGroups, 2)
+	assert.NoError(t, cfg.Validate())
+	_, err = cfg.CreationRules[0].Provider()
+	assert.ErrorContains(t, err, "uses key_groups")
+}
+
+func TestCreationRuleValidate(t *testing.T) {
+	valid := []struct{ provider, key string }{
+		{"age", "age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"},
+		{"pgp", "85D77543B3D624B63CEA9E6DBC17301B491B3F21"},
+		{"aws-kms", "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"},
+		{"aws-kms", "arn:aws:kms:us-east-1:123456789012:alias/sops+arn:aws:iam::123456789012:role/sops"},
+		{"gcp-kms", "projects/p/locations/global/keyRings/r/cryptoKeys/k"},
+		{"azure-kv", "https://team.vault.azure.net/keys/sops/0123"},
+		{"azure-kv", "https://team.vault.azure.net/keys/sops"},
+		{"hc-vault-transit", "https://vault.example.com:8200/v1/transit/keys/prod"},
+	}
+	for _, tt := range valid {
+		assert.NoError(t, ValidateKey(tt.provider, tt.key), tt.key)
+		provider, err := KeyProvider(tt.key)
+		require.NoError(t, err)
+		assert.Equal(t, tt.provider, provider)
+	}
+
+	rule := CreationRule{
+		PathRegex: "secrets\\.dev\\.enc\\.yaml$",
+		Age:       "age1xxx",
+		PGP:       "85D7 7543",
+		KMS:       "arn:aws:kms:us-east-1:1:key/a",
+		GCPKMS:    "projects/p/cryptoKeys/k",
+	}
+	err := rule.Validate()
+	for _, provider := range []string{"age", "pgp", "aws-kms", "gcp-kms"} {
+		assert.ErrorContains(t, err, "invalid "+provider+" key")
+	}
+	assert.ErrorContains(t, ValidateKey("azure-kv", "https://team.vault.azure.net/secrets/sops"), "expected https://<vault>/keys/<name>")
+	assert.NoError(t, (&CreationRule{KeyGroups: []KeyGroup{{AzureKV: []AzureKVKey{{VaultURL: "https://team.vault.azure.net", Key: "sops"}}}}}).Validate())
+	assert.ErrorContains(t, (&CreationRule{PathRegex: "("}).Validate(), "invalid path_regex")
+	assert.ErrorContains(t, (&CreationRule{PathRegex: "x"}).Validate(), "no keys")
+	assert.ErrorContains(t, (&CreationRule{KeyGroups: []KeyGroup{{Age: []string{"age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8"}}}, ShamirThreshold: 2}).Validate(), "exceeds the number of key groups")
+	assert.ErrorContains(t, (&CreationRule{KeyGroups: []KeyGroup{{}}}).Validate(), "key group 1 has no keys")
+}
+
+func TestCreationRuleProvider(t *testing.T) {
+	provider, err := (&CreationRule{KMS: "arn:aws:kms:eu-west-1:1:key/a"}).Provider()
+	require.NoError(t, err)
+	assert.Equal(t, "aws-kms", provider)
+
+	_, err = (&CreationRule{Age: "age1a", KMS: "arn:aws:kms:eu-west-1:1:key/a"}).Provider()
+	assert.ErrorContains(t, err, "mixes providers")
+	_, err = (&CreationRule{PathRegex: "x"}).Provider()
+	assert.ErrorContains(t, err, "no keys")
+}
+
+func TestKeyProvider(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"age1wj3m2ayk4a8nwxc8r678l06q4h4xxa0gqa2l6eyqf037wcdgxaqqla9fr8", "age"},
+		{"arn:aws:kms:us-east-1:123456789:key/test", "aws-kms"},
+		{"projects/p/locations/global/keyRings/r/cryptoKeys/k", "gcp-kms"},
+	}
+	for _, tt := range tests {
+		provider, err := KeyProvider(tt.key)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, provider)
+	}
+
+	_, err := KeyProvider("ssh-ed25519 AAAA")
+	assert.ErrorContains(t, err, "unrecognized recipient")
+}
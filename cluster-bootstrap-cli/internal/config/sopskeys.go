@@ -0,0 +1,176 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	sopsage "github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/hcvault"
+)
+
+var (
+	// pgpFingerprint matches a v4 (40 hex digits) or v5 (64 hex digits) key fingerprint.
+	pgpFingerprint = regexp.MustCompile(`^([0-9A-Fa-f]{40}|[0-9A-Fa-f]{64})$`)
+	// awsKMSARN matches a key or alias ARN, optionally followed by +<role ARN>.
+	awsKMSARN = regexp.MustCompile(`^arn:aws[\w-]*:kms:[a-z0-9-]+:\d{12}:(key|alias)/[^+\s]+(\+arn:aws[\w-]*:iam::\d{12}:role/\S+)?$`)
+	gcpKMSID  = regexp.MustCompile(`^projects/[^/\s]+/locations/[^/\s]+/keyRings/[^/\s]+/cryptoKeys/[^/\s]+$`)
+	// azureKVURL matches a Key Vault key URL with an optional version. It is
+	// checked locally: the SOPS parser looks up versionless keys in the vault.
+	azureKVURL = regexp.MustCompile(`^(https://[^/\s]+)/keys/([^/\s]+)(?:/([^/\s]*))?$`)
+)
+
+// ValidateKey checks that key is well formed for provider, using the same
+// parsers SOPS uses where it has them.
+func ValidateKey(provider, key string) error {
+	var err error
+	switch provider {
+	case "age":
+		_, err = sopsage.MasterKeyFromRecipient(key)
+	case "pgp":
+		if !pgpFingerprint.MatchString(key) {
+			err = errors.New("expected a 40 or 64 digit hex fingerprint without spaces")
+		}
+	case "aws-kms":
+		if !awsKMSARN.MatchString(key) {
+			err = errors.New("expected arn:aws:kms:<region>:<account>:key/<id> or alias/<name>")
+		}
+	case "gcp-kms":
+		if !gcpKMSID.MatchString(key) {
+			err = errors.New("expected projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>")
+		}
+	case "azure-kv":
+		if !azureKVURL.MatchString(key) {
+			err = errors.New("expected https://<vault>/keys/<name>[/<version>]")
+		}
+	case "hc-vault-transit":
+		_, err = hcvault.NewMasterKeyFromURI(key)
+	default:
+		return fmt.Errorf("unsupported SOPS provider: %s", provider)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s key %q: %w", provider, key, err)
+	}
+	return nil
+}
+
+// Validate checks the rule's path_regex, every key it lists and its
+// shamir_threshold. All problems are returned joined.
+func (r *CreationRule) Validate() error {
+	var errs []error
+	if _, err := regexp.Compile(r.PathRegex); err != nil {
+		errs = append(errs, fmt.Errorf("invalid path_regex: %w", err))
+	}
+
+	hasKeys := false
+	for _, p := range Providers {
+		for _, key := range r.Recipients(p.Name) {
+			hasKeys = true
+			errs = append(errs, ValidateKey(p.Name, key))
+		}
+	}
+	if hasKeys && len(r.KeyGroups) > 0 {
+		errs = append(errs, errors.New("key_groups cannot be combined with top-level keys"))
+	}
+	for i, group := range r.KeyGroups {
+		if group.empty() {
+			errs = append(errs, fmt.Errorf("key group %d has no keys", i+1))
+		}
+		errs = append(errs, group.validate(fmt.Sprintf("key group %d", i+1)))
+	}
+	if !hasKeys && len(r.KeyGroups) == 0 {
+		errs = append(errs, errors.New("no keys"))
+	}
+	if r.ShamirThreshold > 0 && r.ShamirThreshold > len(r.KeyGroups) {
+		errs = append(errs, fmt.Errorf("shamir_threshold %d exceeds the number of key groups (%d)", r.ShamirThreshold, len(r.KeyGroups)))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("creation rule %q: %w", r.PathRegex, err)
+	}
+	return nil
+}
+
+// Validate checks every creation rule. All problems are returned joined.
+func (c *SOPSConfig) Validate() error {
+	var errs []error
+	for i := range c.CreationRules {
+		errs = append(errs, c.CreationRules[i].Validate())
+	}
+	return errors.Join(errs...)
+}
+
+func (g *KeyGroup) empty() bool {
+	if len(g.Age)+len(g.PGP)+len(g.KMS)+len(g.GCPKMS)+len(g.AzureKV)+len(g.Vault) > 0 {
+		return false
+	}
+	for i := range g.Merge {
+		if !g.Merge[i].empty() {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *KeyGroup) validate(name string) error {
+	var errs []error
+	for _, key := range g.Age {
+		errs = append(errs, ValidateKey("age", key))
+	}
+	for _, key := range g.PGP {
+		errs = append(errs, ValidateKey("pgp", key))
+	}
+	for _, key := range g.KMS {
+		errs = append(errs, ValidateKey("aws-kms", key.ARN))
+	}
+	for _, key := range g.GCPKMS {
+		errs = append(errs, ValidateKey("gcp-kms", key.ResourceID))
+	}
+	for _, key := range g.AzureKV {
+		errs = append(errs, ValidateKey("azure-kv", key.VaultURL+"/keys/"+key.Key+"/"+key.Version))
+	}
+	for _, key := range g.Vault {
+		errs = append(errs, ValidateKey("hc-vault-transit", key))
+	}
+	for i := range g.Merge {
+		errs = append(errs, g.Merge[i].validate(fmt.Sprintf("%s merge %d", name, i+1)))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// NewKeyGroup builds a key group from recipients of any provider, inferring
+// each key's provider.
+func NewKeyGroup(keys []string) (KeyGroup, error) {
+	var group KeyGroup
+	for _, key := range keys {
+		provider, err := KeyProvider(key)
+		if err != nil {
+			return group, err
+		}
+		if err := ValidateKey(provider, key); err != nil {
+			return group, err
+		}
+		switch provider {
+		case "age":
+			group.Age = append(group.Age, key)
+		case "pgp":
+			group.PGP = append(group.PGP, key)
+		case "aws-kms":
+			group.KMS = append(group.KMS, KMSKey{ARN: key})
+		case "gcp-kms":
+			group.GCPKMS = append(group.GCPKMS, GCPKMSKey{ResourceID: key})
+		case "azure-kv":
+			m := azureKVURL.FindStringSubmatch(key)
+			group.AzureKV = append(group.AzureKV, AzureKVKey{VaultURL: m[1], Key: m[2], Version: m[3]})
+		case "hc-vault-transit":
+			group.Vault = append(group.Vault, key)
+		}
+	}
+	if group.empty() {
+		return group, errors.New("a key group needs at least one key")
+	}
+	return group, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// VersionConstraints are the version constraints declared in the versions
+// section of the repository config file. They override the built-in
+// constraints.
+type VersionConstraints struct {
+	// Kubernetes constrains the cluster's server version.
+	Kubernetes string `yaml:"kubernetes,omitempty"`
+	// Tools constrain command line tools by name, e.g. helm.
+	Tools map[string]string `yaml:"tools,omitempty"`
+	// Charts constrain the Kubernetes version per chart name, replacing the
+	// chart's own kubeVersion.
+	Charts map[string]string `yaml:"charts,omitempty"`
+}
+
+// LoadVersionConstraints returns the versions section of the repository
+// config file. A missing file or section yields empty constraints.
+func LoadVersionConstraints(baseDir string) (*VersionConstraints, error) {
+	path := filepath.Join(baseDir, RepoConfigFile)
+	var vc VersionConstraints
+	doc, err := yamledit.Load(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &vc, nil
+		}
+		return nil, err
+	}
+	if err := doc.Decode(&vc, "versions"); err != nil {
+		return nil, fmt.Errorf("failed to parse versions in %s: %w", path, err)
+	}
+	if err := vc.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &vc, nil
+}
+
+// Validate checks that every constraint parses.
+func (vc *VersionConstraints) Validate() error {
+	if err := validateConstraint("versions.kubernetes", vc.Kubernetes); err != nil {
+		return err
+	}
+	for _, section := range []struct {
+		name        string
+		constraints map[string]string
+	}{{"versions.tools", vc.Tools}, {"versions.charts", vc.Charts}} {
+		names := make([]string, 0, len(section.constraints))
+		for name := range section.constraints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := validateConstraint(section.name+"."+name, section.constraints[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateConstraint(field, constraint string) error {
+	if constraint == "" {
+		return nil
+	}
+	if _, err := semver.NewConstraint(constraint); err != nil {
+		return fmt.Errorf("%s: invalid version constraint %q: %w", field, constraint, err)
+	}
+	return nil
+}
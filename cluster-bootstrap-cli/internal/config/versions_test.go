@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadVersionConstraints(t *testing.T) {
+	dir := t.TempDir()
+	vc, err := LoadVersionConstraints(dir)
+	require.NoError(t, err)
+	assert.Empty(t, vc.Kubernetes)
+
+	marker := filepath.Join(dir, RepoConfigFile)
+	require.NoError(t, os.WriteFile(marker, []byte("versions:\n  kubernetes: \">= 1.30\"\n  tools:\n    helm: \">= 3.16\"\n  charts:\n    argo-cd: \">=1.29.0-0\"\n"), 0644))
+	vc, err = LoadVersionConstraints(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ">= 1.30", vc.Kubernetes)
+	assert.Equal(t, map[string]string{"helm": ">= 3.16"}, vc.Tools)
+	assert.Equal(t, map[string]string{"argo-cd": ">=1.29.0-0"}, vc.Charts)
+
+	require.NoError(t, os.WriteFile(marker, []byte("versions:\n  tools:\n    helm: \"newest\"\n"), 0644))
+	_, err = LoadVersionConstraints(dir)
+	assert.ErrorContains(t, err, `versions.tools.helm: invalid version constraint "newest"`)
+}
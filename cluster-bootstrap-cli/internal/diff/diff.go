@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Unified returns a unified diff between a and b with three lines of context.
+// It returns an empty string when the inputs are identical.
+func Unified(a, b, fromName, toName string) (string, error) {
+	if a == b {
+		return "", nil
+	}
+	out, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(ensureNewline(a)),
+		B:        difflib.SplitLines(ensureNewline(b)),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to compute diff: %w", err)
+	}
+	return out, nil
+}
+
+// Stats counts added and removed lines in a unified diff, ignoring file headers.
+func Stats(unified string) (added, removed int) {
+	for _, line := range strings.Split(unified, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func ensureNewline(s string) string {
+	if s == "" || strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
@@ -0,0 +1,28 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified_Identical(t *testing.T) {
+	out, err := Unified("a: 1\n", "a: 1\n", "old", "new")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestUnified_Changes(t *testing.T) {
+	out, err := Unified("a: 1\nb: 2\n", "a: 1\nb: 3\nc: 4", "9.4.17", "9.5.0")
+	require.NoError(t, err)
+	assert.Contains(t, out, "--- 9.4.17")
+	assert.Contains(t, out, "+++ 9.5.0")
+	assert.Contains(t, out, "-b: 2")
+	assert.Contains(t, out, "+b: 3")
+	assert.Contains(t, out, "+c: 4")
+
+	added, removed := Stats(out)
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+}
@@ -0,0 +1,122 @@
+// Package drift compares the Applications an environment's apps chart
+// renders with the Applications live in the cluster.
+package drift
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+// Kind classifies a difference.
+type Kind string
+
+const (
+	// Missing Applications are rendered by the apps chart but not live.
+	Missing Kind = "missing"
+	// Unexpected Applications are live but no longer rendered (orphaned).
+	Unexpected Kind = "unexpected"
+	// Changed Applications exist on both sides with a different field.
+	Changed Kind = "changed"
+	// RepoMismatch Applications point at a repoURL other than the secrets file's.
+	RepoMismatch Kind = "repo-mismatch"
+)
+
+// Difference is one drifted Application or field.
+type Difference struct {
+	Application string `json:"application"`
+	Kind        Kind   `json:"kind"`
+	// Field is set for Changed and RepoMismatch.
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Live     string `json:"live,omitempty"`
+}
+
+// Compare reports the differences between the expected and live Applications.
+// The root app of apps is never reported as unexpected. When secretsRepoURL
+// is set, every live Application, including the root, must use it.
+// Missing and changed entries follow the expected order; unexpected entries
+// are sorted by name.
+func Compare(expected, live []render.Application, secretsRepoURL string) []Difference {
+	liveByName := make(map[string]render.Application, len(live))
+	for _, app := range live {
+		liveByName[app.Name] = app
+	}
+	expectedNames := make(map[string]bool, len(expected))
+
+	var diffs []Difference
+	for _, want := range expected {
+		expectedNames[want.Name] = true
+		got, ok := liveByName[want.Name]
+		if !ok {
+			diffs = append(diffs, Difference{Application: want.Name, Kind: Missing})
+			continue
+		}
+		fields := []struct {
+			name        string
+			want, got   string
+			equivalence func(a, b string) bool
+		}{
+			{"repoURL", want.RepoURL, got.RepoURL, sameRepoURL},
+			{"path", want.Path, got.Path, samePath},
+			{"targetRevision", want.TargetRevision, got.TargetRevision, sameRevision},
+			{"namespace", want.Namespace, got.Namespace, nil},
+			{"syncWave", want.SyncWave, got.SyncWave, nil},
+		}
+		for _, f := range fields {
+			same := f.want == f.got
+			if f.equivalence != nil {
+				same = f.equivalence(f.want, f.got)
+			}
+			if !same {
+				diffs = append(diffs, Difference{Application: want.Name, Kind: Changed, Field: f.name, Expected: f.want, Live: f.got})
+			}
+		}
+		// The repoURL field check already covers apps whose rendered URL is the secrets URL.
+		if secretsRepoURL != "" && !sameRepoURL(want.RepoURL, secretsRepoURL) && !sameRepoURL(got.RepoURL, secretsRepoURL) {
+			diffs = append(diffs, Difference{Application: want.Name, Kind: RepoMismatch, Field: "repoURL", Expected: secretsRepoURL, Live: got.RepoURL})
+		}
+	}
+
+	var unexpected []Difference
+	for _, got := range live {
+		if expectedNames[got.Name] {
+			continue
+		}
+		if got.Name == render.AppOfAppsRelease {
+			if secretsRepoURL != "" && !sameRepoURL(got.RepoURL, secretsRepoURL) {
+				diffs = append(diffs, Difference{Application: got.Name, Kind: RepoMismatch, Field: "repoURL", Expected: secretsRepoURL, Live: got.RepoURL})
+			}
+			continue
+		}
+		unexpected = append(unexpected, Difference{Application: got.Name, Kind: Unexpected})
+	}
+	sort.Slice(unexpected, func(i, j int) bool { return unexpected[i].Application < unexpected[j].Application })
+	return append(diffs, unexpected...)
+}
+
+// sameRepoURL treats URLs that differ only by a trailing slash or .git suffix as equal, as ArgoCD does.
+func sameRepoURL(a, b string) bool {
+	return normalizeRepoURL(a) == normalizeRepoURL(b)
+}
+
+func normalizeRepoURL(u string) string {
+	u = strings.TrimSuffix(strings.TrimSpace(u), "/")
+	return strings.ToLower(strings.TrimSuffix(u, ".git"))
+}
+
+func samePath(a, b string) bool {
+	return strings.Trim(a, "/") == strings.Trim(b, "/")
+}
+
+// sameRevision treats an empty revision as HEAD, which is ArgoCD's default.
+func sameRevision(a, b string) bool {
+	if a == "" {
+		a = "HEAD"
+	}
+	if b == "" {
+		b = "HEAD"
+	}
+	return a == b
+}
@@ -0,0 +1,71 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+const repoURL = "git@github.com:example/platform.git"
+
+func app(name, ns, wave string) render.Application {
+	return render.Application{
+		Name:           name,
+		Namespace:      ns,
+		SyncWave:       wave,
+		RepoURL:        repoURL,
+		TargetRevision: "main",
+		Path:           "components/" + name,
+	}
+}
+
+func TestCompare_NoDrift(t *testing.T) {
+	expected := []render.Application{app("vault", "vault", "1"), app("reloader", "reloader", "2")}
+	live := []render.Application{app("reloader", "reloader", "2"), app("vault", "vault", "1")}
+	live[0].RepoURL = "git@github.com:example/platform" // .git suffix is not drift
+	root := render.Application{Name: render.AppOfAppsRelease, RepoURL: repoURL, Path: "apps"}
+
+	assert.Empty(t, Compare(expected, append(live, root), repoURL))
+}
+
+func TestCompare(t *testing.T) {
+	expected := []render.Application{app("vault", "vault", "1"), app("reloader", "reloader", "2"), app("trivy", "trivy-system", "3")}
+
+	vault := app("vault", "secrets", "1")
+	vault.TargetRevision = "release-1"
+	reloader := app("reloader", "reloader", "2")
+	reloader.RepoURL = "git@github.com:example/fork.git"
+	live := []render.Application{
+		vault,
+		reloader,
+		app("zeta", "zeta", "5"),
+		app("old", "old", "4"),
+		{Name: render.AppOfAppsRelease, RepoURL: "https://github.com/example/platform.git", Path: "apps"},
+	}
+
+	diffs := Compare(expected, live, repoURL)
+	assert.Equal(t, []Difference{
+		{Application: "vault", Kind: Changed, Field: "targetRevision", Expected: "main", Live: "release-1"},
+		{Application: "vault", Kind: Changed, Field: "namespace", Expected: "vault", Live: "secrets"},
+		{Application: "reloader", Kind: Changed, Field: "repoURL", Expected: repoURL, Live: "git@github.com:example/fork.git"},
+		{Application: "trivy", Kind: Missing},
+		{Application: render.AppOfAppsRelease, Kind: RepoMismatch, Field: "repoURL", Expected: repoURL, Live: "https://github.com/example/platform.git"},
+		{Application: "old", Kind: Unexpected},
+		{Application: "zeta", Kind: Unexpected},
+	}, diffs)
+}
+
+func TestCompare_SecretsRepoURL(t *testing.T) {
+	// apps/values.yaml and the cluster agree, but the secrets file points elsewhere.
+	expected := []render.Application{app("vault", "vault", "1")}
+	live := []render.Application{app("vault", "vault", "1")}
+
+	diffs := Compare(expected, live, "git@github.com:example/moved.git")
+	assert.Equal(t, []Difference{
+		{Application: "vault", Kind: RepoMismatch, Field: "repoURL", Expected: "git@github.com:example/moved.git", Live: repoURL},
+	}, diffs)
+
+	assert.Empty(t, Compare(expected, live, ""), "no secrets URL skips the check")
+}
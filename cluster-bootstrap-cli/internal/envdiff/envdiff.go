@@ -0,0 +1,321 @@
+// Package envdiff compares two environments: the App of Apps component map,
+// each component's merged values, and the rendered manifests per resource.
+package envdiff
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/diff"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// ChangeKind says how a value or resource differs between the environments.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is a difference at one values path. Added means the path only
+// exists in the second environment (From is nil), Removed only in the first
+// (To is nil).
+type Change struct {
+	Path string      `json:"path"`
+	Kind ChangeKind  `json:"kind"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ResourceChange is a rendered resource that differs between the environments.
+type ResourceChange struct {
+	// ID is group/kind/namespace/name, as render.Object.ID.
+	ID   string     `json:"id"`
+	Kind ChangeKind `json:"kind"`
+	// Diff is a unified diff of the resource YAML for Changed resources.
+	Diff string `json:"diff,omitempty"`
+}
+
+// ComponentDiff holds the differences of one component.
+type ComponentDiff struct {
+	Name string `json:"name"`
+	// EnabledIn lists the environments the component is enabled in.
+	EnabledIn []string         `json:"enabledIn"`
+	Values    []Change         `json:"values,omitempty"`
+	Resources []ResourceChange `json:"resources,omitempty"`
+}
+
+// Empty reports whether the component is identical in both environments.
+func (c ComponentDiff) Empty() bool {
+	return len(c.EnabledIn) == 2 && len(c.Values) == 0 && len(c.Resources) == 0
+}
+
+// Report is the comparison of two environments.
+type Report struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Apps are differences in the merged App of Apps values (components map and repo).
+	Apps []Change `json:"apps"`
+	// Components lists every component enabled in either environment, in sync-wave order.
+	Components []ComponentDiff `json:"components"`
+	// Rendered is false when manifests were not compared.
+	Rendered bool `json:"rendered"`
+}
+
+// Options controls a comparison.
+type Options struct {
+	BaseDir string
+	From    string
+	To      string
+	// Only restricts the component comparison to these names.
+	Only []string
+	// Render compares rendered manifests as well as values.
+	Render      bool
+	KubeVersion string
+	Verbose     bool
+}
+
+// Compare compares opts.From with opts.To.
+func Compare(opts Options) (*Report, error) {
+	report := &Report{From: opts.From, To: opts.To, Apps: []Change{}, Components: []ComponentDiff{}, Rendered: opts.Render}
+
+	fromApps, err := components.LoadValues(opts.BaseDir, opts.From)
+	if err != nil {
+		return nil, err
+	}
+	toApps, err := components.LoadValues(opts.BaseDir, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"repo", "components"} {
+		report.Apps = append(report.Apps, prefixed(key, Values(asMap(fromApps[key]), asMap(toApps[key])))...)
+	}
+
+	fromCatalog, err := components.Load(opts.BaseDir, opts.From)
+	if err != nil {
+		return nil, err
+	}
+	toCatalog, err := components.Load(opts.BaseDir, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	names, err := componentNames(opts, fromCatalog, toCatalog)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromRender, toRender map[string][]render.Object
+	if opts.Render {
+		if fromRender, err = renderObjects(opts, opts.From); err != nil {
+			return nil, err
+		}
+		if toRender, err = renderObjects(opts, opts.To); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range names {
+		comp := ComponentDiff{Name: name, EnabledIn: []string{}}
+		if entry, ok := fromCatalog.Get(name); ok && entry.Enabled {
+			comp.EnabledIn = append(comp.EnabledIn, opts.From)
+		}
+		if entry, ok := toCatalog.Get(name); ok && entry.Enabled {
+			comp.EnabledIn = append(comp.EnabledIn, opts.To)
+		}
+
+		fromVals, err := componentValues(opts.BaseDir, name, opts.From)
+		if err != nil {
+			return nil, err
+		}
+		toVals, err := componentValues(opts.BaseDir, name, opts.To)
+		if err != nil {
+			return nil, err
+		}
+		comp.Values = Values(fromVals, toVals)
+
+		if opts.Render {
+			comp.Resources, err = Resources(fromRender[name], toRender[name], opts.From, opts.To)
+			if err != nil {
+				return nil, fmt.Errorf("component %s: %w", name, err)
+			}
+		}
+		report.Components = append(report.Components, comp)
+	}
+	return report, nil
+}
+
+// componentNames returns the components enabled in either catalog, in the
+// first catalog's order followed by any only enabled in the second.
+func componentNames(opts Options, from, to *components.Catalog) ([]string, error) {
+	seen := map[string]bool{}
+	var names []string
+	for _, c := range []*components.Catalog{from, to} {
+		for _, comp := range c.Enabled() {
+			if !seen[comp.Name] {
+				seen[comp.Name] = true
+				names = append(names, comp.Name)
+			}
+		}
+	}
+	if len(opts.Only) == 0 {
+		return names, nil
+	}
+	var out []string
+	for _, name := range opts.Only {
+		if !seen[name] {
+			return nil, fmt.Errorf("component %s is not enabled in %s or %s", name, opts.From, opts.To)
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// componentValues treats a component without values/base.yaml (hasValues: false) as having no values.
+// The argocd component gets the values bootstrap installs ArgoCD with.
+func componentValues(baseDir, name, env string) (map[string]interface{}, error) {
+	var vals map[string]interface{}
+	var err error
+	if name == "argocd" {
+		vals, err = helm.LoadArgoCDValues(baseDir, env)
+	} else {
+		vals, err = components.LoadComponentValues(baseDir, name, env)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string]interface{}{}, nil
+		}
+		return nil, err
+	}
+	return vals, nil
+}
+
+func renderObjects(opts Options, env string) (map[string][]render.Object, error) {
+	result, err := render.Environment(render.Options{
+		BaseDir:     opts.BaseDir,
+		Env:         env,
+		KubeVersion: opts.KubeVersion,
+		Verbose:     opts.Verbose,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", env, err)
+	}
+	out := make(map[string][]render.Object, len(result.Components))
+	for _, comp := range result.Components {
+		out[comp.Name] = comp.Objects
+	}
+	return out, nil
+}
+
+// Values compares two values trees and returns the changed leaf paths,
+// sorted by path. Lists are compared as a whole.
+func Values(from, to map[string]interface{}) []Change {
+	var changes []Change
+	compareValues(nil, from, to, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func compareValues(path []string, from, to map[string]interface{}, changes *[]Change) {
+	keys := map[string]bool{}
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+	for k := range keys {
+		keyPath := append(append([]string{}, path...), k)
+		p := yamledit.JoinPath(keyPath...)
+		a, inFrom := from[k]
+		b, inTo := to[k]
+		am, aIsMap := a.(map[string]interface{})
+		bm, bIsMap := b.(map[string]interface{})
+		switch {
+		case aIsMap && bIsMap:
+			compareValues(keyPath, am, bm, changes)
+		case !inTo:
+			*changes = append(*changes, Change{Path: p, Kind: Removed, From: a})
+		case !inFrom:
+			*changes = append(*changes, Change{Path: p, Kind: Added, To: b})
+		case !reflect.DeepEqual(a, b):
+			*changes = append(*changes, Change{Path: p, Kind: Changed, From: a, To: b})
+		}
+	}
+}
+
+func prefixed(prefix string, changes []Change) []Change {
+	for i := range changes {
+		changes[i].Path = prefix + "." + changes[i].Path
+	}
+	return changes
+}
+
+func asMap(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+// Resources compares rendered objects by ID. Added and removed resources come
+// first, then changed ones, each sorted by ID.
+func Resources(from, to []render.Object, fromName, toName string) ([]ResourceChange, error) {
+	fromByID := make(map[string]render.Object, len(from))
+	for _, obj := range from {
+		fromByID[obj.ID()] = obj
+	}
+	toByID := make(map[string]render.Object, len(to))
+	for _, obj := range to {
+		toByID[obj.ID()] = obj
+	}
+
+	var added, removed, changed []ResourceChange
+	for id, obj := range fromByID {
+		other, ok := toByID[id]
+		if !ok {
+			removed = append(removed, ResourceChange{ID: id, Kind: Removed})
+			continue
+		}
+		a, b := stripSource(obj.YAML), stripSource(other.YAML)
+		if a == b {
+			continue
+		}
+		unified, err := diff.Unified(a, b, fromName+"/"+id, toName+"/"+id)
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, ResourceChange{ID: id, Kind: Changed, Diff: unified})
+	}
+	for id := range toByID {
+		if _, ok := fromByID[id]; !ok {
+			added = append(added, ResourceChange{ID: id, Kind: Added})
+		}
+	}
+
+	var out []ResourceChange
+	for _, group := range [][]ResourceChange{removed, added, changed} {
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+		out = append(out, group...)
+	}
+	return out, nil
+}
+
+// stripSource removes Helm's "# Source:" comment and document separators,
+// which are not part of the resource.
+func stripSource(doc string) string {
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		if strings.HasPrefix(line, "# Source:") || line == "---" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
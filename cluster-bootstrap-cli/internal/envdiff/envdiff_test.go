@@ -0,0 +1,161 @@
+package envdiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+// writeTree creates staging and prod environments on top of the repository's
+// real apps/templates/application.yaml.
+func writeTree(t *testing.T) string {
+	t.Helper()
+	tmpl, err := os.ReadFile("../../../apps/templates/application.yaml")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "apps/Chart.yaml"), "apiVersion: v2\nname: apps\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "apps/templates/application.yaml"), string(tmpl))
+	writeFile(t, filepath.Join(dir, "apps/values.yaml"), `environment: base
+repo:
+  url: git@github.com:example/platform.git
+  targetRevision: main
+components:
+  web:
+    enabled: true
+    namespace: web
+    syncWave: "2"
+  debug:
+    enabled: false
+    namespace: debug
+    syncWave: "3"
+    hasValues: false
+`)
+	writeFile(t, filepath.Join(dir, "apps/values/staging.yaml"), `environment: staging
+components:
+  debug:
+    enabled: true
+`)
+	writeFile(t, filepath.Join(dir, "apps/values/prod.yaml"), `environment: prod
+repo:
+  targetRevision: v1.4.0
+components:
+  web:
+    syncWave: "1"
+`)
+
+	writeFile(t, filepath.Join(dir, "components/web/Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "components/web/values/base.yaml"), `replicas: 1
+image: nginx:1.27
+podAnnotations:
+  prometheus.io/scrape: "true"
+`)
+	writeFile(t, filepath.Join(dir, "components/web/values/staging.yaml"), "debugPort: 9000\n")
+	writeFile(t, filepath.Join(dir, "components/web/values/prod.yaml"), "replicas: 3\npodAnnotations:\n  prometheus.io/scrape: \"false\"\n")
+	writeFile(t, filepath.Join(dir, "components/web/templates/deploy.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+{{- if .Values.debugPort }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}-debug
+spec:
+  ports:
+    - port: {{ .Values.debugPort }}
+{{- end }}
+`)
+	writeFile(t, filepath.Join(dir, "components/debug/Chart.yaml"), "apiVersion: v2\nname: debug\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "components/debug/templates/cm.yaml"), "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: debug\n")
+	return dir
+}
+
+func TestCompare(t *testing.T) {
+	dir := writeTree(t)
+
+	report, err := Compare(Options{BaseDir: dir, From: "staging", To: "prod", Render: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Change{
+		{Path: "repo.targetRevision", Kind: Changed, From: "main", To: "v1.4.0"},
+		{Path: "components.debug.enabled", Kind: Changed, From: true, To: false},
+		{Path: "components.web.syncWave", Kind: Changed, From: "2", To: "1"},
+	}, report.Apps)
+
+	require.Len(t, report.Components, 2)
+	debug, web := report.Components[1], report.Components[0]
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, []string{"staging", "prod"}, web.EnabledIn)
+	assert.Equal(t, []Change{
+		{Path: "debugPort", Kind: Removed, From: 9000},
+		{Path: `podAnnotations."prometheus.io/scrape"`, Kind: Changed, From: "true", To: "false"},
+		{Path: "replicas", Kind: Changed, From: 1, To: 3},
+	}, web.Values)
+
+	require.Len(t, web.Resources, 2)
+	assert.Equal(t, ResourceChange{ID: "/Service/web/web-debug", Kind: Removed}, web.Resources[0])
+	assert.Equal(t, "apps/Deployment/web/web", web.Resources[1].ID)
+	assert.Equal(t, Changed, web.Resources[1].Kind)
+	assert.Contains(t, web.Resources[1].Diff, "-  replicas: 1")
+	assert.Contains(t, web.Resources[1].Diff, "+  replicas: 3")
+	assert.NotContains(t, web.Resources[1].Diff, "# Source")
+
+	assert.Equal(t, "debug", debug.Name)
+	assert.Equal(t, []string{"staging"}, debug.EnabledIn)
+	assert.Empty(t, debug.Values, "hasValues: false components have no values")
+	assert.Equal(t, []ResourceChange{{ID: "/ConfigMap/debug/debug", Kind: Removed}}, debug.Resources)
+	assert.False(t, debug.Empty())
+}
+
+func TestCompare_ValuesOnly(t *testing.T) {
+	dir := writeTree(t)
+
+	report, err := Compare(Options{BaseDir: dir, From: "prod", To: "prod", Only: []string{"web"}})
+	require.NoError(t, err)
+	assert.Empty(t, report.Apps)
+	require.Len(t, report.Components, 1)
+	assert.True(t, report.Components[0].Empty())
+	assert.False(t, report.Rendered)
+
+	_, err = Compare(Options{BaseDir: dir, From: "prod", To: "prod", Only: []string{"debug"}})
+	assert.ErrorContains(t, err, "not enabled")
+}
+
+func TestComponentValues_ArgoCD(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "components/argocd/values/base.yaml"), "replicas: 1\nserver:\n  insecure: true\n")
+	writeFile(t, filepath.Join(dir, "components/argocd/values/prod.yaml"), "replicas: 3\nserver:\n  ingress: true\n")
+
+	vals, err := componentValues(dir, "argocd", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicas": 3.0,
+		"server":   map[string]interface{}{"insecure": true, "ingress": true},
+	}, vals, "argocd env values override base values")
+}
+
+func TestValues_NestedAdditions(t *testing.T) {
+	changes := Values(
+		map[string]interface{}{"a": map[string]interface{}{"b": 1}, "list": []interface{}{1, 2}},
+		map[string]interface{}{"a": "flat", "list": []interface{}{1, 2}, "new": map[string]interface{}{"x": true}},
+	)
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, string(c.Kind)+" "+c.Path)
+	}
+	assert.Equal(t, "changed a,added new", strings.Join(paths, ","))
+}
@@ -0,0 +1,425 @@
+// Package gitremote checks access to a Git repository in-process, without
+// the git and ssh binaries: it lists the refs a server advertises over SSH
+// or smart HTTP and resolves revisions against them.
+package gitremote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Error kinds, matched with errors.Is.
+var (
+	// ErrAuth means the server rejected the credentials.
+	ErrAuth = errors.New("authentication failed")
+	// ErrUnknownHost means the server's host key is not known or pinned.
+	ErrUnknownHost = errors.New("unknown host key")
+	// ErrHostKeyMismatch means the server's host key differs from the known one.
+	ErrHostKeyMismatch = errors.New("host key mismatch")
+	// ErrRepoNotFound means the server has no such repository, or hides it.
+	ErrRepoNotFound = errors.New("repository not found")
+	// ErrRefNotFound means the revision is not a branch, tag or commit of the repository.
+	ErrRefNotFound = errors.New("revision not found")
+	// ErrTimeout means the server did not answer in time.
+	ErrTimeout = errors.New("timed out")
+	// ErrUnreachable means the server could not be reached.
+	ErrUnreachable = errors.New("unreachable")
+)
+
+// Error is a failed repository check. Kind is one of the Err* values.
+type Error struct {
+	Kind error
+	URL  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.URL == "" {
+		return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+	}
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %v", e.URL, e.Kind)
+	}
+	return fmt.Sprintf("%s: %v: %v", e.URL, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// Options configures ListRefs.
+type Options struct {
+	URL string
+	// PrivateKey is the PEM encoded SSH key, for SSH URLs. It is only held
+	// in memory.
+	PrivateKey string
+	// HostKeyCallback verifies the server of SSH URLs. It should return
+	// errors wrapping ErrUnknownHost or ErrHostKeyMismatch, or a
+	// *knownhosts.KeyError.
+	HostKeyCallback ssh.HostKeyCallback
+	// HTTPClient is used for HTTP(S) URLs; http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// Refs maps ref names, and HEAD, to commit SHAs. Annotated tags map to the
+// commit they point at.
+type Refs map[string]string
+
+// ListRefs returns the refs the repository advertises, as git ls-remote does.
+func ListRefs(ctx context.Context, opts Options) (Refs, error) {
+	var refs Refs
+	var err error
+	if strings.HasPrefix(opts.URL, "http://") || strings.HasPrefix(opts.URL, "https://") {
+		refs, err = listHTTP(ctx, opts)
+	} else {
+		refs, err = listSSH(ctx, opts)
+	}
+	if err != nil {
+		return nil, classify(ctx, opts.URL, err)
+	}
+	return refs, nil
+}
+
+// classify turns err into an *Error.
+func classify(ctx context.Context, repoURL string, err error) error {
+	var gitErr *Error
+	if errors.As(err, &gitErr) {
+		if gitErr.URL == "" {
+			gitErr.URL = repoURL
+		}
+		return err
+	}
+	kind := ErrUnreachable
+	var netErr net.Error
+	var keyErr *knownhosts.KeyError
+	switch {
+	case errors.Is(err, ErrUnknownHost), errors.Is(err, ErrHostKeyMismatch), errors.Is(err, ErrAuth):
+		for _, k := range []error{ErrUnknownHost, ErrHostKeyMismatch, ErrAuth} {
+			if errors.Is(err, k) {
+				kind = k
+			}
+		}
+	case errors.As(err, &keyErr):
+		kind = ErrUnknownHost
+		if len(keyErr.Want) > 0 {
+			kind = ErrHostKeyMismatch
+		}
+	case errors.Is(err, context.DeadlineExceeded), ctx.Err() != nil,
+		errors.As(err, &netErr) && netErr.Timeout():
+		kind = ErrTimeout
+	case strings.Contains(err.Error(), "unable to authenticate"):
+		kind = ErrAuth
+	}
+	return &Error{Kind: kind, URL: repoURL, Err: err}
+}
+
+// sshTarget returns the host:port and repository path of an SSH URL, either
+// user@host:path or ssh://user@host[:port]/path.
+func sshTarget(repoURL string) (user, addr, path string, err error) {
+	if strings.HasPrefix(repoURL, "ssh://") {
+		u, err := url.Parse(repoURL)
+		if err != nil || u.Hostname() == "" {
+			return "", "", "", fmt.Errorf("invalid SSH URL %q", repoURL)
+		}
+		port := u.Port()
+		if port == "" {
+			port = "22"
+		}
+		return u.User.Username(), net.JoinHostPort(u.Hostname(), port), u.Path, nil
+	}
+	at := strings.Index(repoURL, "@")
+	colon := strings.Index(repoURL, ":")
+	if at <= 0 || colon < at+2 || strings.Contains(repoURL, "://") {
+		return "", "", "", fmt.Errorf("not an SSH URL: %q", repoURL)
+	}
+	return repoURL[:at], net.JoinHostPort(repoURL[at+1:colon], "22"), repoURL[colon+1:], nil
+}
+
+func listSSH(ctx context.Context, opts Options) (Refs, error) {
+	user, addr, path, err := sshTarget(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(opts.PrivateKey))
+	if err != nil {
+		return nil, &Error{Kind: ErrAuth, URL: opts.URL, Err: fmt.Errorf("cannot use private key: %w", err)}
+	}
+	if user == "" {
+		user = "git"
+	}
+	hostKeyCallback := opts.HostKeyCallback
+	if hostKeyCallback == nil {
+		return nil, errors.New("no host key callback")
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	// Unblock reads when ctx is cancelled without a deadline.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = session.Close() }()
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+	if err := session.Start("git-upload-pack '" + strings.ReplaceAll(path, "'", `'\''`) + "'"); err != nil {
+		return nil, err
+	}
+
+	refs, err := readAdvertisement(bufio.NewReader(stdout))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Wait for stderr to be copied; the server has closed the channel.
+		_ = session.Wait()
+		return nil, serverError(opts.URL, err, stderr.String())
+	}
+	// A flush ends the exchange without fetching anything.
+	_, _ = io.WriteString(stdin, "0000")
+	_ = stdin.Close()
+	return refs, nil
+}
+
+// serverError classifies a failed exchange by the message the server wrote.
+func serverError(repoURL string, err error, message string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return err
+	}
+	return &Error{Kind: messageKind(message), URL: repoURL, Err: errors.New(message)}
+}
+
+// messageKind classifies an error message written by a Git server.
+func messageKind(message string) error {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "not found"), strings.Contains(lower, "does not exist"), strings.Contains(lower, "does not appear to be a git repository"):
+		return ErrRepoNotFound
+	case strings.Contains(lower, "permission denied"), strings.Contains(lower, "access denied"), strings.Contains(lower, "not authorized"):
+		return ErrAuth
+	}
+	return ErrUnreachable
+}
+
+func listHTTP(ctx context.Context, opts Options) (Refs, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(opts.URL, "/")+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, &Error{Kind: ErrAuth, URL: opts.URL, Err: errors.New(resp.Status)}
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &Error{Kind: ErrRepoNotFound, URL: opts.URL, Err: errors.New(resp.Status)}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &Error{Kind: ErrUnreachable, URL: opts.URL, Err: errors.New(resp.Status)}
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-git-upload-pack-advertisement") {
+		return nil, &Error{Kind: ErrRepoNotFound, URL: opts.URL, Err: errors.New("not a smart HTTP Git server")}
+	}
+
+	r := bufio.NewReader(resp.Body)
+	// The advertisement starts with "# service=git-upload-pack" and a flush.
+	if _, err := readPktLine(r); err != nil {
+		return nil, err
+	}
+	if line, err := readPktLine(r); err != nil || line != nil {
+		return nil, errors.New("malformed service advertisement")
+	}
+	return readAdvertisement(r)
+}
+
+// readPktLine reads one pkt-line. A flush packet returns nil, nil.
+func readPktLine(r *bufio.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("malformed pkt-line length %q", size)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	if n < 4 {
+		return nil, fmt.Errorf("malformed pkt-line length %d", n)
+	}
+	line := make([]byte, n-4)
+	if _, err := io.ReadFull(r, line); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line, []byte("\n")), nil
+}
+
+// readAdvertisement reads ref lines up to the flush packet.
+func readAdvertisement(r *bufio.Reader) (Refs, error) {
+	refs := Refs{}
+	for first := true; ; first = false {
+		line, err := readPktLine(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("connection closed during ref advertisement: %w", io.ErrUnexpectedEOF)
+			}
+			return nil, err
+		}
+		if line == nil {
+			return refs, nil
+		}
+		if msg, ok := bytes.CutPrefix(line, []byte("ERR ")); ok {
+			return nil, &Error{Kind: messageKind(string(msg)), Err: errors.New(string(msg))}
+		}
+		if first {
+			if version, ok := bytes.CutPrefix(line, []byte("version ")); ok {
+				if string(version) != "1" {
+					return nil, fmt.Errorf("unsupported protocol version %s", version)
+				}
+				first = true
+				continue
+			}
+			line, _, _ = bytes.Cut(line, []byte{0})
+		}
+		sha, name, ok := strings.Cut(string(line), " ")
+		if !ok || len(sha) != 40 {
+			return nil, fmt.Errorf("malformed ref line %q", line)
+		}
+		if name == "capabilities^{}" {
+			// An empty repository.
+			continue
+		}
+		if tag, peeled := strings.CutSuffix(name, "^{}"); peeled {
+			refs[tag] = sha
+			continue
+		}
+		if _, seen := refs[name]; !seen {
+			refs[name] = sha
+		}
+	}
+}
+
+// Revision is a resolved target revision.
+type Revision struct {
+	// Kind is branch, tag, ref, commit or HEAD.
+	Kind string
+	// Ref is the matching ref, if any.
+	Ref string
+	SHA string
+	// Verified is false for a commit SHA that is not the tip of any
+	// advertised ref: it may exist, but cannot be checked without fetching.
+	Verified bool
+}
+
+// Resolve finds rev among the refs as Argo CD would: a branch, a tag, a full
+// ref name, HEAD or a commit SHA.
+func (r Refs) Resolve(rev string) (*Revision, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" || rev == "HEAD" {
+		if sha, ok := r["HEAD"]; ok {
+			return &Revision{Kind: "HEAD", Ref: "HEAD", SHA: sha, Verified: true}, nil
+		}
+		return nil, &Error{Kind: ErrRefNotFound, Err: errors.New("the repository has no HEAD")}
+	}
+	if sha, ok := r["refs/heads/"+rev]; ok {
+		return &Revision{Kind: "branch", Ref: "refs/heads/" + rev, SHA: sha, Verified: true}, nil
+	}
+	if sha, ok := r["refs/tags/"+rev]; ok {
+		return &Revision{Kind: "tag", Ref: "refs/tags/" + rev, SHA: sha, Verified: true}, nil
+	}
+	if sha, ok := r[rev]; ok && strings.HasPrefix(rev, "refs/") {
+		return &Revision{Kind: "ref", Ref: rev, SHA: sha, Verified: true}, nil
+	}
+	if isHex(rev) && len(rev) >= 7 && len(rev) <= 40 {
+		rev = strings.ToLower(rev)
+		names := make([]string, 0, len(r))
+		for name := range r {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if sha := r[name]; strings.HasPrefix(sha, rev) {
+				return &Revision{Kind: "commit", Ref: name, SHA: sha, Verified: true}, nil
+			}
+		}
+		if len(rev) == 40 {
+			return &Revision{Kind: "commit", SHA: rev}, nil
+		}
+	}
+	return nil, &Error{Kind: ErrRefNotFound, Err: fmt.Errorf("%q is not a branch, tag or commit SHA of the repository", rev)}
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// UserKnownHosts returns a host key callback for ~/.ssh/known_hosts, or nil
+// when the file does not exist.
+func UserKnownHosts() (ssh.HostKeyCallback, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, nil
+	}
+	path := filepath.Join(home, ".ssh", "known_hosts")
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	return knownhosts.New(path)
+}
@@ -0,0 +1,302 @@
+package gitremote
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	mainSHA   = "1111111111111111111111111111111111111111"
+	tagSHA    = "2222222222222222222222222222222222222222"
+	commitSHA = "3333333333333333333333333333333333333333"
+)
+
+// advertisement is what git-upload-pack sends for a repository with a main
+// branch and an annotated v1.0.0 tag.
+var advertisement = []string{
+	mainSHA + " HEAD\x00multi_ack symref=HEAD:refs/heads/main\n",
+	mainSHA + " refs/heads/main\n",
+	tagSHA + " refs/tags/v1.0.0\n",
+	commitSHA + " refs/tags/v1.0.0^{}\n",
+}
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+func newKey(t *testing.T) (ssh.Signer, string) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+	return signer, string(pem.EncodeToMemory(block))
+}
+
+type gitServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+	// repos maps repository paths to their advertisement.
+	repos map[string][]string
+}
+
+// serveGit runs an SSH Git server that accepts clientKey and answers
+// git-upload-pack with the advertisement of the requested repository.
+func serveGit(t *testing.T, clientKey ssh.PublicKey) *gitServer {
+	t.Helper()
+	hostSigner, _ := newKey(t)
+	srv := &gitServer{
+		hostKey: hostSigner.PublicKey(),
+		repos:   map[string][]string{"/org/repo.git": advertisement},
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	config.AddHostKey(hostSigner)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.handle(conn, config)
+		}
+	}()
+	srv.addr = ln.Addr().String()
+	return srv
+}
+
+func (s *gitServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	defer func() { _ = conn.Close() }()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		for req := range requests {
+			if req.Type != "exec" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			var payload struct{ Command string }
+			_ = ssh.Unmarshal(req.Payload, &payload)
+			_ = req.Reply(true, nil)
+			s.uploadPack(ch, payload.Command)
+			_ = ch.Close()
+			break
+		}
+	}
+}
+
+func (s *gitServer) uploadPack(ch ssh.Channel, command string) {
+	path := strings.Trim(strings.TrimPrefix(command, "git-upload-pack "), "'")
+	lines, ok := s.repos[path]
+	if !ok {
+		_, _ = fmt.Fprintf(ch.Stderr(), "ERROR: Repository not found.\n")
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{1}))
+		return
+	}
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString(pktLine(line))
+	}
+	out.WriteString("0000")
+	_, _ = ch.Write([]byte(out.String()))
+	// Wait for the client's flush.
+	buf := make([]byte, 4)
+	_, _ = ch.Read(buf)
+	_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+}
+
+func (s *gitServer) url(path string) string {
+	return "ssh://git@" + s.addr + path
+}
+
+func TestListRefs_SSH(t *testing.T) {
+	signer, key := newKey(t)
+	srv := serveGit(t, signer.PublicKey())
+
+	refs, err := ListRefs(context.Background(), Options{
+		URL:             srv.url("/org/repo.git"),
+		PrivateKey:      key,
+		HostKeyCallback: ssh.FixedHostKey(srv.hostKey),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Refs{
+		"HEAD":             mainSHA,
+		"refs/heads/main":  mainSHA,
+		"refs/tags/v1.0.0": commitSHA,
+	}, refs)
+}
+
+func knownHosts(t *testing.T, content string) ssh.HostKeyCallback {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0600))
+	callback, err := knownhosts.New(path)
+	require.NoError(t, err)
+	return callback
+}
+
+func TestListRefs_Errors(t *testing.T) {
+	signer, key := newKey(t)
+	_, otherKey := newKey(t)
+	srv := serveGit(t, signer.PublicKey())
+	otherHost, _ := newKey(t)
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closed.Addr().String()
+	require.NoError(t, closed.Close())
+
+	// A server that accepts connections but never answers.
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = silent.Close() })
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	tests := map[string]struct {
+		opts    Options
+		timeout time.Duration
+		want    error
+	}{
+		"wrong key": {
+			opts: Options{URL: srv.url("/org/repo.git"), PrivateKey: otherKey, HostKeyCallback: ssh.FixedHostKey(srv.hostKey)},
+			want: ErrAuth,
+		},
+		"unknown host": {
+			opts: Options{URL: srv.url("/org/repo.git"), PrivateKey: key, HostKeyCallback: knownHosts(t, "")},
+			want: ErrUnknownHost,
+		},
+		"host key mismatch": {
+			opts: Options{URL: srv.url("/org/repo.git"), PrivateKey: key, HostKeyCallback: knownHosts(t,
+				knownhosts.Line([]string{knownhosts.Normalize(srv.addr)}, otherHost.PublicKey()))},
+			want: ErrHostKeyMismatch,
+		},
+		"missing repository": {
+			opts: Options{URL: srv.url("/org/missing.git"), PrivateKey: key, HostKeyCallback: ssh.FixedHostKey(srv.hostKey)},
+			want: ErrRepoNotFound,
+		},
+		"connection refused": {
+			opts: Options{URL: "ssh://git@" + closedAddr + "/org/repo.git", PrivateKey: key, HostKeyCallback: ssh.FixedHostKey(srv.hostKey)},
+			want: ErrUnreachable,
+		},
+		"timeout": {
+			opts:    Options{URL: "ssh://git@" + silent.Addr().String() + "/org/repo.git", PrivateKey: key, HostKeyCallback: ssh.FixedHostKey(srv.hostKey)},
+			timeout: 200 * time.Millisecond,
+			want:    ErrTimeout,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			_, err := ListRefs(ctx, tt.opts)
+			var gitErr *Error
+			require.ErrorAs(t, err, &gitErr)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, tt.opts.URL, gitErr.URL)
+		})
+	}
+}
+
+func TestListRefs_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/org/repo.git/info/refs":
+			w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+			_, _ = fmt.Fprint(w, pktLine("# service=git-upload-pack\n")+"0000")
+			for _, line := range advertisement {
+				_, _ = fmt.Fprint(w, pktLine(line))
+			}
+			_, _ = fmt.Fprint(w, "0000")
+		case "/org/private.git/info/refs":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	refs, err := ListRefs(context.Background(), Options{URL: srv.URL + "/org/repo.git"})
+	require.NoError(t, err)
+	assert.Equal(t, mainSHA, refs["refs/heads/main"])
+
+	_, err = ListRefs(context.Background(), Options{URL: srv.URL + "/org/private.git"})
+	assert.ErrorIs(t, err, ErrAuth)
+	_, err = ListRefs(context.Background(), Options{URL: srv.URL + "/org/missing.git"})
+	assert.ErrorIs(t, err, ErrRepoNotFound)
+}
+
+func TestResolve(t *testing.T) {
+	refs := Refs{
+		"HEAD":             mainSHA,
+		"refs/heads/main":  mainSHA,
+		"refs/tags/v1.0.0": commitSHA,
+	}
+	tests := map[string]Revision{
+		"":                 {Kind: "HEAD", Ref: "HEAD", SHA: mainSHA, Verified: true},
+		"main":             {Kind: "branch", Ref: "refs/heads/main", SHA: mainSHA, Verified: true},
+		"v1.0.0":           {Kind: "tag", Ref: "refs/tags/v1.0.0", SHA: commitSHA, Verified: true},
+		"refs/tags/v1.0.0": {Kind: "ref", Ref: "refs/tags/v1.0.0", SHA: commitSHA, Verified: true},
+		"3333333":          {Kind: "commit", Ref: "refs/tags/v1.0.0", SHA: commitSHA, Verified: true},
+		strings.Repeat("4", 40): {
+			Kind: "commit", SHA: strings.Repeat("4", 40),
+		},
+	}
+	for rev, want := range tests {
+		got, err := refs.Resolve(rev)
+		require.NoError(t, err, rev)
+		assert.Equal(t, want, *got, rev)
+	}
+
+	for _, rev := range []string{"develop", "4444444", "v2"} {
+		_, err := refs.Resolve(rev)
+		assert.ErrorIs(t, err, ErrRefNotFound, rev)
+	}
+}
@@ -181,6 +181,29 @@ func InstallArgoCD(ctx context.Context, kubeconfig, kubeContext, env, baseDir st
 	return false, nil
 }
 
+// RenderArgoCD renders the ArgoCD chart InstallArgoCD installs, with the same
+// values, for kubeVersion. CRDs and hooks are included.
+func RenderArgoCD(baseDir, env, kubeVersion string, verbose bool) (string, error) {
+	settings := cli.New()
+	chartName, chartVersion, repoURL, err := loadChartConfig(baseDir, argoCDChartDep)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart config: %w\n  hint: ensure components/argocd/Chart.yaml exists and has the argo-cd dependency defined", err)
+	}
+	chartPath, err := fetchChart(settings, chartName, chartVersion, repoURL, verbose)
+	if err != nil {
+		return "", fmt.Errorf("%w\n  hint: verify the Helm repository is accessible and the chart version exists", err)
+	}
+	ch, err := loader.Load(chartPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart: %w", err)
+	}
+	vals, err := loadValues(baseDir, env)
+	if err != nil {
+		return "", fmt.Errorf("failed to load values: %w", err)
+	}
+	return RenderChart(ch, argoCDRelease, argoCDNamespace, vals, kubeVersion)
+}
+
 // fetchChart downloads the given chart from a Helm repository.
 func fetchChart(settings *cli.EnvSettings, chartName, chartVersion, repoURL string, verbose bool) (string, error) {
 	entry := &repo.Entry{
@@ -226,6 +249,11 @@ func fetchChart(settings *cli.EnvSettings, chartName, chartVersion, repoURL stri
 	return "", fmt.Errorf("failed to fetch chart from %s after %d attempts: %w", repoURL, maxAttempts, lastErr)
 }
 
+// LoadArgoCDValues returns the values InstallArgoCD passes to Helm for env.
+func LoadArgoCDValues(baseDir, env string) (map[string]interface{}, error) {
+	return loadValues(baseDir, env)
+}
+
 // loadValues reads base.yaml and the environment-specific values file, then merges them.
 func loadValues(baseDir, env string) (map[string]interface{}, error) {
 	baseFile := filepath.Join(baseDir, "components/argocd/values/base.yaml")
@@ -244,8 +272,9 @@ func loadValues(baseDir, env string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("failed to read env values %s: %w", envFile, err)
 	}
 
-	// Merge: env values override base values
-	merged := chartutil.MergeTables(baseVals.AsMap(), envVals.AsMap())
+	// Merge: env values override base values. MergeTables keeps the values
+	// of its first argument.
+	merged := chartutil.MergeTables(envVals.AsMap(), baseVals.AsMap())
 	return merged, nil
 }
 
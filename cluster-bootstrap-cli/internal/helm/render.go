@@ -0,0 +1,87 @@
+package helm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+// RenderChart renders a chart client-side, the same way `helm template` does,
+// and returns the combined manifest. CRDs from the chart's crds/ directory and
+// hook manifests are included. kubeVersion overrides the default capabilities
+// when non-empty (e.g. "v1.29.0").
+func RenderChart(ch *chart.Chart, releaseName, namespace string, vals map[string]interface{}, kubeVersion string) (string, error) {
+	cfg := &action.Configuration{Log: func(string, ...interface{}) {}}
+
+	install := action.NewInstall(cfg)
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.IncludeCRDs = true
+	install.ReleaseName = releaseName
+	install.Namespace = namespace
+
+	if kubeVersion != "" {
+		kv, err := chartutil.ParseKubeVersion(kubeVersion)
+		if err != nil {
+			return "", fmt.Errorf("invalid kube version %q: %w", kubeVersion, err)
+		}
+		install.KubeVersion = kv
+	}
+
+	if vals == nil {
+		vals = map[string]interface{}{}
+	}
+
+	rel, err := install.Run(ch, vals)
+	if err != nil {
+		return "", fmt.Errorf("failed to render chart %s: %w", ch.Name(), err)
+	}
+
+	var out strings.Builder
+	out.WriteString(rel.Manifest)
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&out, "\n---\n# Source: %s\n%s\n", hook.Path, strings.TrimSpace(hook.Manifest))
+	}
+	return out.String(), nil
+}
+
+// LoadChartWithDependencies loads the chart in dir and attaches any dependency
+// declared in Chart.yaml that is not already vendored under charts/, fetching
+// it from its repository. Nothing is written to dir.
+func LoadChartWithDependencies(settings *cli.EnvSettings, dir string, verbose bool) (*chart.Chart, error) {
+	ch, err := loader.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart %s: %w", dir, err)
+	}
+
+	vendored := make(map[string]bool)
+	for _, dep := range ch.Dependencies() {
+		vendored[dep.Name()] = true
+	}
+	for _, dep := range ch.Metadata.Dependencies {
+		if vendored[dep.Name] {
+			continue
+		}
+		if strings.HasPrefix(dep.Repository, "file://") {
+			sub, err := LoadChartWithDependencies(settings, filepath.Join(dir, strings.TrimPrefix(dep.Repository, "file://")), verbose)
+			if err != nil {
+				return nil, err
+			}
+			ch.AddDependency(sub)
+			continue
+		}
+		sub, err := fetchAndLoadChart(settings, dep.Name, dep.Version, dep.Repository, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch dependency %s of %s: %w", dep.Name, dir, err)
+		}
+		ch.AddDependency(sub)
+	}
+	return ch, nil
+}
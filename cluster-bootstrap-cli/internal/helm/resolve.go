@@ -0,0 +1,79 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// ResolveChartReference splits a chart reference into a repository URL and chart name.
+// ref is either <repo>/<chart>, where <repo> is a repository added with
+// `helm repo add`, or a full repository URL followed by /<chart>.
+func ResolveChartReference(settings *cli.EnvSettings, ref string) (repoURL, chartName string, err error) {
+	ref = strings.TrimSuffix(strings.TrimSpace(ref), "/")
+	idx := strings.LastIndex(ref, "/")
+	if idx <= 0 || idx == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid chart reference %q: expected <repo>/<chart>", ref)
+	}
+	prefix, chartName := ref[:idx], ref[idx+1:]
+
+	if strings.Contains(prefix, "://") {
+		if _, err := url.Parse(prefix); err != nil {
+			return "", "", fmt.Errorf("invalid repository URL %q: %w", prefix, err)
+		}
+		return prefix, chartName, nil
+	}
+
+	repoFile, err := repo.LoadFile(settings.RepositoryConfig)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", "", fmt.Errorf("helm repository %q not found: no repositories configured\n  hint: run 'helm repo add %s <url>' or pass the full URL, e.g. https://charts.example.com/%s", prefix, prefix, chartName)
+		}
+		return "", "", fmt.Errorf("failed to read helm repositories from %s: %w", settings.RepositoryConfig, err)
+	}
+	entry := repoFile.Get(prefix)
+	if entry == nil {
+		return "", "", fmt.Errorf("helm repository %q not found in %s\n  hint: run 'helm repo add %s <url>' or pass the full URL, e.g. https://charts.example.com/%s", prefix, settings.RepositoryConfig, prefix, chartName)
+	}
+	return entry.URL, chartName, nil
+}
+
+// ResolveChartVersion downloads the repository index and returns the chart
+// version matching version, which may be an exact version, a semver
+// constraint, or empty for the latest release.
+func ResolveChartVersion(settings *cli.EnvSettings, repoURL, chartName, version string) (*repo.ChartVersion, error) {
+	if registry.IsOCI(repoURL) {
+		return nil, fmt.Errorf("cannot verify OCI chart %s/%s: only HTTP(S) chart repositories are supported\n  hint: pass --skip-verify to scaffold without checking", repoURL, chartName)
+	}
+
+	chartRepo, err := repo.NewChartRepository(&repo.Entry{Name: "component-repo", URL: repoURL}, getter.All(settings))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chart repository: %w", err)
+	}
+	chartRepo.CachePath = settings.RepositoryCache
+
+	indexPath, err := chartRepo.DownloadIndexFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download repo index from %s: %w\n  hint: check the repository URL and network access", repoURL, err)
+	}
+	index, err := repo.LoadIndexFile(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load repo index from %s: %w", repoURL, err)
+	}
+
+	cv, err := index.Get(chartName, version)
+	if err != nil {
+		if _, ok := index.Entries[chartName]; !ok {
+			return nil, fmt.Errorf("chart %s not found in %s", chartName, repoURL)
+		}
+		return nil, fmt.Errorf("chart %s has no version matching %q in %s\n  hint: list versions with 'helm search repo %s --versions'", chartName, version, repoURL, chartName)
+	}
+	return cv, nil
+}
@@ -0,0 +1,99 @@
+package helm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func testSettings(t *testing.T) *cli.EnvSettings {
+	t.Helper()
+	dir := t.TempDir()
+	settings := cli.New()
+	settings.RepositoryConfig = filepath.Join(dir, "repositories.yaml")
+	settings.RepositoryCache = filepath.Join(dir, "cache")
+	return settings
+}
+
+func TestResolveChartReference(t *testing.T) {
+	settings := testSettings(t)
+	require.NoError(t, os.WriteFile(settings.RepositoryConfig, []byte(`apiVersion: ""
+repositories:
+  - name: stakater
+    url: https://stakater.github.io/stakater-charts
+`), 0600))
+
+	repoURL, chartName, err := ResolveChartReference(settings, "stakater/reloader")
+	require.NoError(t, err)
+	assert.Equal(t, "https://stakater.github.io/stakater-charts", repoURL)
+	assert.Equal(t, "reloader", chartName)
+
+	repoURL, chartName, err = ResolveChartReference(settings, "https://charts.example.com/sub/my-chart")
+	require.NoError(t, err)
+	assert.Equal(t, "https://charts.example.com/sub", repoURL)
+	assert.Equal(t, "my-chart", chartName)
+
+	_, _, err = ResolveChartReference(settings, "unknown/chart")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "helm repo add unknown")
+
+	_, _, err = ResolveChartReference(settings, "reloader")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected <repo>/<chart>")
+}
+
+func TestResolveChartReference_NoRepositoryFile(t *testing.T) {
+	_, _, err := ResolveChartReference(testSettings(t), "stakater/reloader")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no repositories configured")
+}
+
+const testIndex = `apiVersion: v1
+entries:
+  reloader:
+    - name: reloader
+      version: 2.2.9
+      urls: [reloader-2.2.9.tgz]
+    - name: reloader
+      version: 2.1.0
+      urls: [reloader-2.1.0.tgz]
+generated: "2026-01-01T00:00:00Z"
+`
+
+func TestResolveChartVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testIndex))
+	}))
+	defer srv.Close()
+	settings := testSettings(t)
+
+	cv, err := ResolveChartVersion(settings, srv.URL, "reloader", "")
+	require.NoError(t, err)
+	assert.Equal(t, "2.2.9", cv.Version)
+
+	cv, err = ResolveChartVersion(settings, srv.URL, "reloader", "~2.1")
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", cv.Version)
+
+	_, err = ResolveChartVersion(settings, srv.URL, "reloader", "9.9.9")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no version matching")
+
+	_, err = ResolveChartVersion(settings, srv.URL, "missing", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chart missing not found")
+
+	_, err = ResolveChartVersion(settings, "oci://registry.example.com/charts", "reloader", "1.0.0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--skip-verify")
+}
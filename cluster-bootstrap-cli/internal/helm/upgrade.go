@@ -0,0 +1,243 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/releaseutil"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/diff"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// CRD change kinds reported by CompareCharts.
+const (
+	CRDAdded   = "added"
+	CRDRemoved = "removed"
+	CRDChanged = "changed"
+)
+
+// CRDChange describes how a CustomResourceDefinition differs between two chart versions.
+type CRDChange struct {
+	Name   string
+	Change string
+}
+
+// UpgradePlan describes the effect of moving a chart dependency to another version.
+type UpgradePlan struct {
+	ChartName         string
+	RepoURL           string
+	CurrentVersion    string
+	TargetVersion     string
+	CurrentAppVersion string
+	TargetAppVersion  string
+	// KubeVersion is the kubeVersion constraint declared by the target chart.
+	KubeVersion string
+	ValuesDiff  string
+	CRDChanges  []CRDChange
+}
+
+// PlanArgoCDUpgrade downloads the current and target argo-cd charts and compares them.
+func PlanArgoCDUpgrade(baseDir, targetVersion string, verbose bool) (*UpgradePlan, error) {
+	chartName, currentVersion, repoURL, err := loadChartConfig(baseDir, argoCDChartDep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart config: %w", err)
+	}
+
+	settings := cli.New()
+	current, err := fetchAndLoadChart(settings, chartName, currentVersion, repoURL, verbose)
+	if err != nil {
+		return nil, err
+	}
+	target, err := fetchAndLoadChart(settings, chartName, targetVersion, repoURL, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  hint: verify that version %s of %s exists in %s", err, targetVersion, chartName, repoURL)
+	}
+
+	plan, err := CompareCharts(current, target)
+	if err != nil {
+		return nil, err
+	}
+	plan.RepoURL = repoURL
+	return plan, nil
+}
+
+func fetchAndLoadChart(settings *cli.EnvSettings, name, version, repoURL string, verbose bool) (*chart.Chart, error) {
+	path, err := fetchChart(settings, name, version, repoURL, verbose)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart %s-%s: %w", name, version, err)
+	}
+	return ch, nil
+}
+
+// CompareCharts builds an upgrade plan from two loaded versions of the same chart:
+// the diff of their default values and the CRDs each one renders.
+func CompareCharts(current, target *chart.Chart) (*UpgradePlan, error) {
+	plan := &UpgradePlan{
+		ChartName:         target.Metadata.Name,
+		CurrentVersion:    current.Metadata.Version,
+		TargetVersion:     target.Metadata.Version,
+		CurrentAppVersion: current.Metadata.AppVersion,
+		TargetAppVersion:  target.Metadata.AppVersion,
+		KubeVersion:       target.Metadata.KubeVersion,
+	}
+
+	currentValues, err := marshalValues(current.Values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal values for %s: %w", plan.CurrentVersion, err)
+	}
+	targetValues, err := marshalValues(target.Values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal values for %s: %w", plan.TargetVersion, err)
+	}
+	plan.ValuesDiff, err = diff.Unified(string(currentValues), string(targetValues),
+		fmt.Sprintf("%s-%s/values.yaml", plan.ChartName, plan.CurrentVersion),
+		fmt.Sprintf("%s-%s/values.yaml", plan.ChartName, plan.TargetVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	currentCRDs, err := chartCRDs(current)
+	if err != nil {
+		return nil, err
+	}
+	targetCRDs, err := chartCRDs(target)
+	if err != nil {
+		return nil, err
+	}
+	plan.CRDChanges = compareCRDs(currentCRDs, targetCRDs)
+
+	return plan, nil
+}
+
+// marshalValues renders a values map as YAML with the two-space indentation
+// used by values files in this repo.
+func marshalValues(vals map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(vals); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// CheckKubeVersion verifies the target chart's kubeVersion constraint against a server version.
+func (p *UpgradePlan) CheckKubeVersion(serverVersion string) error {
+	if p.KubeVersion == "" {
+		return nil
+	}
+	if !chartutil.IsCompatibleRange(p.KubeVersion, serverVersion) {
+		return fmt.Errorf("%s-%s requires Kubernetes %s but the cluster runs %s\n  hint: upgrade the cluster first or pick an older chart version",
+			p.ChartName, p.TargetVersion, p.KubeVersion, serverVersion)
+	}
+	return nil
+}
+
+// chartCRDs renders the chart with its default values and returns the
+// CustomResourceDefinition specs it produces, keyed by name. Charts such as
+// argo-cd ship CRDs as templates rather than in crds/, so rendering is the
+// only reliable way to find them.
+func chartCRDs(ch *chart.Chart) (map[string]interface{}, error) {
+	// kubeVersion is checked against the live cluster separately; ignore it
+	// here so the default client-only capabilities do not block rendering.
+	meta := *ch.Metadata
+	meta.KubeVersion = ""
+	unconstrained := *ch
+	unconstrained.Metadata = &meta
+
+	manifest, err := RenderChart(&unconstrained, "crd-check", argoCDNamespace, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	crds := make(map[string]interface{})
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var obj struct {
+			Kind     string `yaml:"kind"`
+			Metadata struct {
+				Name string `yaml:"name"`
+			} `yaml:"metadata"`
+			Spec interface{} `yaml:"spec"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			continue
+		}
+		if obj.Kind == "CustomResourceDefinition" && obj.Metadata.Name != "" {
+			crds[obj.Metadata.Name] = obj.Spec
+		}
+	}
+	return crds, nil
+}
+
+func compareCRDs(current, target map[string]interface{}) []CRDChange {
+	var changes []CRDChange
+	for name, spec := range target {
+		old, ok := current[name]
+		switch {
+		case !ok:
+			changes = append(changes, CRDChange{Name: name, Change: CRDAdded})
+		case !reflect.DeepEqual(old, spec):
+			changes = append(changes, CRDChange{Name: name, Change: CRDChanged})
+		}
+	}
+	for name := range current {
+		if _, ok := target[name]; !ok {
+			changes = append(changes, CRDChange{Name: name, Change: CRDRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// SetArgoCDChartVersion rewrites the argo-cd dependency version in
+// components/argocd/Chart.yaml, preserving the rest of the file.
+func SetArgoCDChartVersion(baseDir, version string) error {
+	return SetChartDependencyVersion(filepath.Join(baseDir, "components/argocd/Chart.yaml"), argoCDChartDep, version)
+}
+
+// SetChartDependencyVersion rewrites the version of a named dependency in a Chart.yaml.
+func SetChartDependencyVersion(chartPath, dependencyName, version string) error {
+	info, err := os.Stat(chartPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", chartPath, err)
+	}
+	doc, err := yamledit.Load(chartPath)
+	if err != nil {
+		return err
+	}
+
+	dep := yamledit.FindSequenceItem(doc.Lookup("dependencies"), "name", dependencyName)
+	if dep == nil {
+		return fmt.Errorf("dependency %s not found in %s", dependencyName, chartPath)
+	}
+	versionNode := yamledit.MappingValue(dep, "version")
+	if versionNode == nil {
+		return fmt.Errorf("dependency %s in %s has no version field", dependencyName, chartPath)
+	}
+	if strings.TrimSpace(versionNode.Value) == version {
+		return nil
+	}
+	if err := doc.SetScalar(versionNode, version); err != nil {
+		return fmt.Errorf("failed to update %s: %w", chartPath, err)
+	}
+
+	return doc.Save(chartPath, info.Mode().Perm())
+}
@@ -0,0 +1,159 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+const crdTemplate = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: %s
+spec:
+  group: argoproj.io
+  scope: Namespaced
+  versions:
+    - name: %s
+      served: true
+      storage: true
+`
+
+func testChart(version string, values map[string]interface{}, kubeVersion string, crds map[string]string) *chart.Chart {
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{
+			APIVersion:  chart.APIVersionV2,
+			Name:        "argo-cd",
+			Version:     version,
+			AppVersion:  "v" + version,
+			KubeVersion: kubeVersion,
+		},
+		Values: values,
+	}
+	for name, storageVersion := range crds {
+		ch.Templates = append(ch.Templates, &chart.File{
+			Name: "templates/crds/" + name + ".yaml",
+			Data: []byte(fmt.Sprintf(crdTemplate, name, storageVersion)),
+		})
+	}
+	ch.Templates = append(ch.Templates, &chart.File{
+		Name: "templates/configmap.yaml",
+		Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: argocd-cm\ndata:\n  replicas: {{ .Values.server.replicas | quote }}\n"),
+	})
+	return ch
+}
+
+func TestCompareCharts(t *testing.T) {
+	current := testChart("9.4.17",
+		map[string]interface{}{"server": map[string]interface{}{"replicas": 1}},
+		"",
+		map[string]string{
+			"applications.argoproj.io":    "v1alpha1",
+			"appprojects.argoproj.io":     "v1alpha1",
+			"applicationsets.argoproj.io": "v1alpha1",
+		})
+	target := testChart("9.5.0",
+		map[string]interface{}{"server": map[string]interface{}{"replicas": 2}},
+		">=1.25.0-0",
+		map[string]string{
+			"applications.argoproj.io": "v1alpha1",
+			"appprojects.argoproj.io":  "v1beta1",
+			"rollouts.argoproj.io":     "v1alpha1",
+		})
+
+	plan, err := CompareCharts(current, target)
+	require.NoError(t, err)
+
+	assert.Equal(t, "9.4.17", plan.CurrentVersion)
+	assert.Equal(t, "9.5.0", plan.TargetVersion)
+	assert.Equal(t, "v9.5.0", plan.TargetAppVersion)
+	assert.Equal(t, ">=1.25.0-0", plan.KubeVersion)
+	assert.Contains(t, plan.ValuesDiff, "-  replicas: 1")
+	assert.Contains(t, plan.ValuesDiff, "+  replicas: 2")
+
+	assert.Equal(t, []CRDChange{
+		{Name: "applicationsets.argoproj.io", Change: CRDRemoved},
+		{Name: "appprojects.argoproj.io", Change: CRDChanged},
+		{Name: "rollouts.argoproj.io", Change: CRDAdded},
+	}, plan.CRDChanges)
+}
+
+func TestCompareCharts_NoChanges(t *testing.T) {
+	values := map[string]interface{}{"server": map[string]interface{}{"replicas": 1}}
+	crds := map[string]string{"applications.argoproj.io": "v1alpha1"}
+
+	plan, err := CompareCharts(testChart("1.0.0", values, "", crds), testChart("1.0.0", values, "", crds))
+	require.NoError(t, err)
+	assert.Empty(t, plan.ValuesDiff)
+	assert.Empty(t, plan.CRDChanges)
+}
+
+func TestUpgradePlan_CheckKubeVersion(t *testing.T) {
+	plan := &UpgradePlan{ChartName: "argo-cd", TargetVersion: "9.5.0", KubeVersion: ">=1.25.0-0"}
+
+	assert.NoError(t, plan.CheckKubeVersion("v1.29.2"))
+	assert.NoError(t, plan.CheckKubeVersion("v1.30.1-eks-1234"))
+
+	err := plan.CheckKubeVersion("v1.24.9")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires Kubernetes >=1.25.0-0")
+
+	assert.NoError(t, (&UpgradePlan{}).CheckKubeVersion("v1.20.0"))
+}
+
+func TestSetChartDependencyVersion(t *testing.T) {
+	dir := t.TempDir()
+	chartPath := filepath.Join(dir, "Chart.yaml")
+	original := `apiVersion: v2
+name: argocd
+description: ArgoCD component managed by App of Apps
+version: 1.0.0
+type: application
+dependencies:
+  - name: argo-cd
+    version: 9.4.17
+    repository: https://argoproj.github.io/argo-helm
+`
+	require.NoError(t, os.WriteFile(chartPath, []byte(original), 0644))
+
+	require.NoError(t, SetChartDependencyVersion(chartPath, "argo-cd", "9.5.0"))
+	info, err := os.Stat(chartPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm(), "Chart.yaml keeps its mode")
+
+	data, err := os.ReadFile(chartPath)
+	require.NoError(t, err)
+	expected := `apiVersion: v2
+name: argocd
+description: ArgoCD component managed by App of Apps
+version: 1.0.0
+type: application
+dependencies:
+  - name: argo-cd
+    version: 9.5.0
+    repository: https://argoproj.github.io/argo-helm
+`
+	assert.Equal(t, expected, string(data))
+
+	err = SetChartDependencyVersion(chartPath, "missing", "1.0.0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency missing not found")
+}
+
+func TestSetArgoCDChartVersion(t *testing.T) {
+	dir := t.TempDir()
+	chartDir := filepath.Join(dir, "components", "argocd")
+	require.NoError(t, os.MkdirAll(chartDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: argocd\nversion: 1.0.0\ndependencies:\n  - name: argo-cd\n    version: 9.4.17\n    repository: https://argoproj.github.io/argo-helm\n"), 0600))
+
+	require.NoError(t, SetArgoCDChartVersion(dir, "9.6.1"))
+
+	_, version, _, err := loadChartConfig(dir, argoCDChartDep)
+	require.NoError(t, err)
+	assert.Equal(t, "9.6.1", version)
+}
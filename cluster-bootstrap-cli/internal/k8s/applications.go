@@ -0,0 +1,100 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ListApplications returns the ArgoCD Applications in the argocd namespace.
+func (c *Client) ListApplications(ctx context.Context) ([]unstructured.Unstructured, error) {
+	list, err := c.DynamicClient.Resource(ApplicationGVR).Namespace(argoCDNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("applications.argoproj.io not found: %w\n  hint: is ArgoCD installed? run 'cluster-bootstrap bootstrap <env>' first", err)
+		}
+		if apierrors.IsForbidden(err) {
+			return nil, fmt.Errorf("permission denied: cannot list applications in argocd namespace: %w\n  hint: verify your cluster role has permission to list applications.argoproj.io", err)
+		}
+		return nil, fmt.Errorf("failed to list applications: %w", err)
+	}
+	return list.Items, nil
+}
+
+// refreshAnnotation asks the ArgoCD application controller to refresh an
+// Application; the controller removes it once the refresh is done.
+const refreshAnnotation = "argocd.argoproj.io/refresh"
+
+// Repository connection states, named after ArgoCD's ConnectionState.
+const (
+	ConnectionSuccessful = "Successful"
+	ConnectionFailed     = "Failed"
+)
+
+// RepoConnectionState is whether ArgoCD could fetch an Application's source
+// during its last refresh.
+type RepoConnectionState struct {
+	Status  string
+	Message string
+}
+
+// HardRefreshApplication makes ArgoCD re-fetch the Application's source from
+// Git instead of using cached manifests, so new repository credentials are used.
+func (c *Client) HardRefreshApplication(ctx context.Context, name string) error {
+	patch := []byte(`{"metadata":{"annotations":{"` + refreshAnnotation + `":"hard"}}}`)
+	_, err := c.DynamicClient.Resource(ApplicationGVR).Namespace(argoCDNamespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("application %s not found in argocd namespace: %w\n  hint: run 'cluster-bootstrap bootstrap <env>' first", name, err)
+		}
+		if apierrors.IsForbidden(err) {
+			return fmt.Errorf("permission denied: cannot patch application %s: %w\n  hint: verify your cluster role has permission to patch applications.argoproj.io", name, err)
+		}
+		return fmt.Errorf("failed to refresh application %s: %w", name, err)
+	}
+	return nil
+}
+
+// WaitForRepoConnection polls the Application until ArgoCD has finished a
+// requested refresh and returns the resulting connection state. A
+// ComparisonError condition means the repository could not be fetched.
+func (c *Client) WaitForRepoConnection(ctx context.Context, name string, interval time.Duration) (*RepoConnectionState, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		app, err := c.DynamicClient.Resource(ApplicationGVR).Namespace(argoCDNamespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get application %s: %w", name, err)
+		}
+		if err == nil {
+			if _, pending := app.GetAnnotations()[refreshAnnotation]; !pending {
+				return repoConnectionState(app), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout waiting for ArgoCD to refresh application %s\n  hint: check the argocd-application-controller logs", name)
+		case <-ticker.C:
+		}
+	}
+}
+
+func repoConnectionState(app *unstructured.Unstructured) *RepoConnectionState {
+	conditions, _, _ := unstructured.NestedSlice(app.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "ComparisonError" {
+			continue
+		}
+		message, _ := cond["message"].(string)
+		return &RepoConnectionState{Status: ConnectionFailed, Message: message}
+	}
+	return &RepoConnectionState{Status: ConnectionSuccessful}
+}
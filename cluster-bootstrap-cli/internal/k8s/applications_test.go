@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRepoConnection(t *testing.T) {
+	app := newArgoObject("Application", "app-of-apps")
+	fake := newFakeArgoDynamicClient(app)
+	client := &Client{DynamicClient: fake}
+	ctx := context.Background()
+
+	require.NoError(t, client.HardRefreshApplication(ctx, "app-of-apps"))
+	got, err := fake.Resource(ApplicationGVR).Namespace("argocd").Get(ctx, "app-of-apps", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "hard", got.GetAnnotations()[refreshAnnotation])
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForRepoConnection(timeout, "app-of-apps", 10*time.Millisecond)
+	assert.ErrorContains(t, err, "timeout waiting for ArgoCD to refresh")
+
+	// The controller clears the annotation and reports a repository error.
+	got.SetAnnotations(nil)
+	require.NoError(t, unstructured.SetNestedSlice(got.Object, []interface{}{
+		map[string]interface{}{"type": "ComparisonError", "message": "ssh: handshake failed: unable to authenticate"},
+	}, "status", "conditions"))
+	_, err = fake.Resource(ApplicationGVR).Namespace("argocd").Update(ctx, got, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	state, err := client.WaitForRepoConnection(ctx, "app-of-apps", 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, ConnectionFailed, state.Status)
+	assert.Contains(t, state.Message, "handshake failed")
+
+	assert.ErrorContains(t, client.HardRefreshApplication(ctx, "missing"), "not found")
+}
@@ -13,6 +13,9 @@ import (
 
 const argoCDNamespace = "argocd"
 
+// AppOfAppsName is the name of the root Application created by bootstrap.
+const AppOfAppsName = "app-of-apps"
+
 // ApplyAppOfApps creates or updates the App of Apps root Application CR.
 // Returns a boolean indicating if it was created (true) or updated (false) when not in dry-run mode.
 // NOTE: This function's signature was changed to return an additional boolean value, which is a
@@ -23,7 +26,7 @@ func (c *Client) ApplyAppOfApps(ctx context.Context, repoURL, targetRevision, en
 			"apiVersion": "argoproj.io/v1alpha1",
 			"kind":       "Application",
 			"metadata": map[string]interface{}{
-				"name":      "app-of-apps",
+				"name":      AppOfAppsName,
 				"namespace": argoCDNamespace,
 			},
 			"spec": map[string]interface{}{
@@ -67,11 +70,11 @@ func (c *Client) ApplyAppOfApps(ctx context.Context, repoURL, targetRevision, en
 	}
 
 	// Check if Application already exists
-	_, err := c.DynamicClient.Resource(gvr).Namespace(argoCDNamespace).Get(ctx, "app-of-apps", metav1.GetOptions{})
+	_, err := c.DynamicClient.Resource(gvr).Namespace(argoCDNamespace).Get(ctx, AppOfAppsName, metav1.GetOptions{})
 	exists := err == nil
 
 	_, err = c.DynamicClient.Resource(gvr).Namespace(argoCDNamespace).Apply(
-		ctx, "app-of-apps", app, metav1.ApplyOptions{FieldManager: "cluster-bootstrap"},
+		ctx, AppOfAppsName, app, metav1.ApplyOptions{FieldManager: "cluster-bootstrap"},
 	)
 	if err != nil {
 		if apierrors.IsForbidden(err) {
@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// ApplicationGVR is the GroupVersionResource for ArgoCD Applications.
+	ApplicationGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+	// AppProjectGVR is the GroupVersionResource for ArgoCD AppProjects.
+	AppProjectGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "appprojects"}
+)
+
+// BackupArgoCDResources returns every Application and AppProject in the argocd
+// namespace as a multi-document YAML stream that can be re-applied with kubectl.
+// Server-populated fields (status, uid, resourceVersion, managedFields) are stripped.
+// Returns the YAML and the number of objects it contains.
+func (c *Client) BackupArgoCDResources(ctx context.Context) ([]byte, int, error) {
+	var out bytes.Buffer
+	count := 0
+
+	for _, gvr := range []schema.GroupVersionResource{AppProjectGVR, ApplicationGVR} {
+		list, err := c.DynamicClient.Resource(gvr).Namespace(argoCDNamespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if apierrors.IsForbidden(err) {
+				return nil, 0, fmt.Errorf("permission denied: cannot list %s in argocd namespace: %w\n  hint: verify your cluster role has permission to list %s.argoproj.io", gvr.Resource, err, gvr.Resource)
+			}
+			return nil, 0, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+
+		for i := range list.Items {
+			obj := list.Items[i].DeepCopy()
+			sanitizeForBackup(obj)
+			data, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to marshal %s/%s: %w", gvr.Resource, obj.GetName(), err)
+			}
+			out.WriteString("---\n")
+			out.Write(data)
+			count++
+		}
+	}
+
+	return out.Bytes(), count, nil
+}
+
+func sanitizeForBackup(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "status")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+}
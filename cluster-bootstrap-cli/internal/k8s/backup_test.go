@@ -0,0 +1,90 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newArgoObject(kind, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       "argocd",
+			"uid":             "1234",
+			"resourceVersion": "42",
+		},
+		"spec":   map[string]interface{}{"project": "default"},
+		"status": map[string]interface{}{"sync": map[string]interface{}{"status": "Synced"}},
+	}}
+}
+
+func newFakeArgoDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			ApplicationGVR: "ApplicationList",
+			AppProjectGVR:  "AppProjectList",
+		}, objects...)
+}
+
+func TestBackupArgoCDResources(t *testing.T) {
+	client := &Client{DynamicClient: newFakeArgoDynamicClient(
+		newArgoObject("Application", "vault"),
+		newArgoObject("Application", "reloader"),
+		newArgoObject("AppProject", "default"),
+	)}
+
+	data, count, err := client.BackupArgoCDResources(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	out := string(data)
+	assert.Contains(t, out, "kind: AppProject")
+	assert.Contains(t, out, "name: vault")
+	assert.NotContains(t, out, "status:")
+	assert.NotContains(t, out, "uid:")
+	assert.NotContains(t, out, "resourceVersion:")
+
+	// The stream must parse back into objects.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	docs := 0
+	for {
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			break
+		}
+		docs++
+	}
+	assert.Equal(t, 3, docs)
+}
+
+func TestBackupArgoCDResources_Empty(t *testing.T) {
+	client := &Client{DynamicClient: newFakeArgoDynamicClient()}
+
+	data, count, err := client.BackupArgoCDResources(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.Empty(t, data)
+}
+
+func TestListApplications(t *testing.T) {
+	client := &Client{DynamicClient: newFakeArgoDynamicClient(
+		newArgoObject("Application", "vault"),
+		newArgoObject("AppProject", "default"),
+	)}
+
+	apps, err := client.ListApplications(context.Background())
+	require.NoError(t, err)
+	require.Len(t, apps, 1)
+	assert.Equal(t, "vault", apps[0].GetName())
+}
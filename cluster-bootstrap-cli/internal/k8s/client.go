@@ -18,18 +18,7 @@ type Client struct {
 // If kubeconfig is empty, it uses the default loading rules.
 // If context is empty, it uses the current context.
 func NewClient(kubeconfig, context string) (*Client, error) {
-	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	if kubeconfig != "" {
-		loadingRules.ExplicitPath = kubeconfig
-	}
-
-	configOverrides := &clientcmd.ConfigOverrides{}
-	if context != "" {
-		configOverrides.CurrentContext = context
-	}
-
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		loadingRules, configOverrides).ClientConfig()
+	config, err := clientConfig(kubeconfig, context).ClientConfig()
 	if err != nil {
 		return nil, wrapKubeconfigError(err, kubeconfig, context)
 	}
@@ -50,6 +39,22 @@ func NewClient(kubeconfig, context string) (*Client, error) {
 	}, nil
 }
 
+// clientConfig returns the kubeconfig loader for kubeconfig and context,
+// following the default loading rules when kubeconfig is empty.
+func clientConfig(kubeconfig, context string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loadingRules.ExplicitPath = kubeconfig
+	}
+
+	configOverrides := &clientcmd.ConfigOverrides{}
+	if context != "" {
+		configOverrides.CurrentContext = context
+	}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+}
+
 // wrapKubeconfigError enhances error messages for kubeconfig issues.
 func wrapKubeconfigError(err error, kubeconfig, context string) error {
 	if kubeconfig != "" {
@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+)
+
+// GetSSHKnownHosts returns the known_hosts content of the
+// argocd-ssh-known-hosts-cm ConfigMap. found is false when it does not exist.
+func (c *Client) GetSSHKnownHosts(ctx context.Context) (data string, found bool, err error) {
+	cm, err := c.Clientset.CoreV1().ConfigMaps("argocd").Get(ctx, knownhosts.ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		if apierrors.IsForbidden(err) {
+			return "", false, fmt.Errorf("permission denied: cannot get configmap %s in argocd namespace: %w\n  hint: verify your cluster role has permission to get configmaps", knownhosts.ConfigMapName, err)
+		}
+		return "", false, fmt.Errorf("failed to get configmap %s: %w", knownhosts.ConfigMapName, err)
+	}
+	return cm.Data[knownhosts.ConfigMapKey], true, nil
+}
+
+// ApplySSHKnownHosts writes known_hosts content to the
+// argocd-ssh-known-hosts-cm ConfigMap, creating it if needed. Other keys of
+// an existing ConfigMap are kept. Returns true when it was created.
+func (c *Client) ApplySSHKnownHosts(ctx context.Context, data string) (bool, error) {
+	cms := c.Clientset.CoreV1().ConfigMaps("argocd")
+	existing, err := cms.Get(ctx, knownhosts.ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get configmap %s: %w", knownhosts.ConfigMapName, err)
+		}
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      knownhosts.ConfigMapName,
+				Namespace: "argocd",
+				Labels: map[string]string{
+					"app.kubernetes.io/name":    knownhosts.ConfigMapName,
+					"app.kubernetes.io/part-of": "argocd",
+				},
+				Annotations: map[string]string{
+					"cluster-bootstrap/origin": "known-hosts",
+				},
+			},
+			Data: map[string]string{knownhosts.ConfigMapKey: data},
+		}
+		if _, err := cms.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			if apierrors.IsForbidden(err) {
+				return false, fmt.Errorf("permission denied: cannot create configmaps in argocd namespace: %w\n  hint: verify your cluster role has permission to create configmaps", err)
+			}
+			return false, fmt.Errorf("failed to create configmap %s: %w", knownhosts.ConfigMapName, err)
+		}
+		return true, nil
+	}
+
+	if existing.Data == nil {
+		existing.Data = map[string]string{}
+	}
+	existing.Data[knownhosts.ConfigMapKey] = data
+	if _, err := cms.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		if apierrors.IsForbidden(err) {
+			return false, fmt.Errorf("permission denied: cannot update configmaps in argocd namespace: %w\n  hint: verify your cluster role has permission to update configmaps", err)
+		}
+		return false, fmt.Errorf("failed to update configmap %s: %w", knownhosts.ConfigMapName, err)
+	}
+	return false, nil
+}
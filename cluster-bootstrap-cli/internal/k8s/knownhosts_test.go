@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/knownhosts"
+)
+
+func TestSSHKnownHosts(t *testing.T) {
+	ctx := context.Background()
+	//nolint:staticcheck // SA1019: fake.NewSimpleClientset is deprecated but alternative requires generated apply configs
+	client := &Client{Clientset: fake.NewSimpleClientset()}
+
+	_, found, err := client.GetSSHKnownHosts(ctx)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	created, err := client.ApplySSHKnownHosts(ctx, "github.com ssh-ed25519 AAAA\n")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	cm, err := client.Clientset.CoreV1().ConfigMaps("argocd").Get(ctx, knownhosts.ConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "argocd", cm.Labels["app.kubernetes.io/part-of"])
+
+	// Other keys of the ConfigMap are kept on update.
+	cm.Data["extra"] = "kept"
+	_, err = client.Clientset.CoreV1().ConfigMaps("argocd").Update(ctx, cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	created, err = client.ApplySSHKnownHosts(ctx, "git.example.com ssh-ed25519 BBBB\n")
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	data, found, err := client.GetSSHKnownHosts(ctx)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "git.example.com ssh-ed25519 BBBB\n", data)
+
+	cm, err = client.Clientset.CoreV1().ConfigMaps("argocd").Get(ctx, knownhosts.ConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{knownhosts.ConfigMapKey: data, "extra": "kept"}, cm.Data)
+}
@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// CurrentContext returns the name of the context a client for kubeconfig
+// and context uses: context when set, otherwise the kubeconfig's current
+// context. The context must exist.
+func CurrentContext(kubeconfig, context string) (string, error) {
+	_, name, err := loadContext(kubeconfig, context)
+	return name, err
+}
+
+// CheckAuthPlugin verifies that the exec credential plugin of the context's
+// user, if it has one, is installed. It returns the plugin command, or an
+// empty string for users without a plugin.
+func CheckAuthPlugin(kubeconfig, context string) (string, error) {
+	raw, name, err := loadContext(kubeconfig, context)
+	if err != nil {
+		return "", err
+	}
+	user := raw.Contexts[name].AuthInfo
+	auth, ok := raw.AuthInfos[user]
+	if !ok {
+		return "", nil
+	}
+	if auth.AuthProvider != nil {
+		return "", fmt.Errorf("user %s uses the %s auth provider, which client-go no longer supports\n  hint: switch the user to the provider's exec credential plugin, e.g. gke-gcloud-auth-plugin or kubelogin", user, auth.AuthProvider.Name)
+	}
+	if auth.Exec == nil {
+		return "", nil
+	}
+	if _, err := exec.LookPath(auth.Exec.Command); err != nil {
+		hint := "install it and make sure it is in your PATH"
+		if auth.Exec.InstallHint != "" {
+			hint = auth.Exec.InstallHint
+		}
+		return auth.Exec.Command, fmt.Errorf("credential plugin %s of user %s not found: %w\n  hint: %s", auth.Exec.Command, user, err, hint)
+	}
+	return auth.Exec.Command, nil
+}
+
+// Ping checks that the cluster of kubeconfig and context answers a discovery
+// call within timeout, and returns its version.
+func Ping(ctx context.Context, kubeconfig, kubeContext string, timeout time.Duration) (string, error) {
+	config, err := clientConfig(kubeconfig, kubeContext).ClientConfig()
+	if err != nil {
+		return "", wrapKubeconfigError(err, kubeconfig, kubeContext)
+	}
+	config.Timeout = timeout
+
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return "", wrapClusterConnectionError(err)
+	}
+
+	type answer struct {
+		version string
+		err     error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		info, err := client.ServerVersion()
+		if err != nil {
+			done <- answer{err: err}
+			return
+		}
+		done <- answer{version: info.GitVersion}
+	}()
+
+	select {
+	case a := <-done:
+		if a.err != nil {
+			return "", wrapPingError(a.err, config.Host, timeout)
+		}
+		return a.version, nil
+	case <-time.After(timeout):
+		return "", fmt.Errorf("cluster at %s did not answer within %s\n  hint: verify the cluster is running and reachable from this machine", config.Host, timeout)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func loadContext(kubeconfig, context string) (*clientcmdapi.Config, string, error) {
+	raw, err := clientConfig(kubeconfig, context).RawConfig()
+	if err != nil {
+		return nil, "", wrapKubeconfigError(err, kubeconfig, context)
+	}
+	name := context
+	if name == "" {
+		name = raw.CurrentContext
+	}
+	if name == "" {
+		return nil, "", fmt.Errorf("no current context set\n  hint: set a current context in the kubeconfig or pass --context")
+	}
+	if _, ok := raw.Contexts[name]; !ok {
+		return nil, "", fmt.Errorf("context %s not found in kubeconfig\n  hint: pass one of the contexts in the kubeconfig with --context", name)
+	}
+	return &raw, name, nil
+}
+
+func wrapPingError(err error, host string, timeout time.Duration) error {
+	var netErr net.Error
+	switch {
+	case apierrors.IsUnauthorized(err):
+		return fmt.Errorf("cluster at %s rejected the credentials: %w\n  hint: refresh the credentials of the kubeconfig user", host, err)
+	case apierrors.IsForbidden(err):
+		return fmt.Errorf("cluster at %s denied the discovery call: %w\n  hint: verify your user may read the API version", host, err)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("cluster at %s did not answer within %s: %w\n  hint: verify the cluster is running and reachable from this machine", host, timeout, err)
+	}
+	return fmt.Errorf("cannot connect to cluster at %s: %w\n  hint: verify kubeconfig/context and cluster access", host, err)
+}
@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+  - name: dev
+    cluster:
+      server: %[1]s
+      insecure-skip-tls-verify: true
+contexts:
+  - name: dev
+    context:
+      cluster: dev
+      user: token
+  - name: cloud
+    context:
+      cluster: dev
+      user: cloud
+  - name: legacy
+    context:
+      cluster: dev
+      user: legacy
+  - name: anonymous
+    context:
+      cluster: dev
+      user: anonymous
+users:
+  - name: token
+    user:
+      token: secret
+  - name: cloud
+    user:
+      exec:
+        apiVersion: client.authentication.k8s.io/v1beta1
+        command: cluster-bootstrap-missing-plugin
+        installHint: install the cloud CLI
+  - name: legacy
+    user:
+      auth-provider:
+        name: gcp
+  - name: anonymous
+    user: {}
+`
+
+func writeKubeconfig(t *testing.T, server string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	content := []byte(fmt.Sprintf(testKubeconfig, server))
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestCurrentContext(t *testing.T) {
+	path := writeKubeconfig(t, "https://127.0.0.1:6443")
+
+	name, err := CurrentContext(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", name)
+
+	name, err = CurrentContext(path, "cloud")
+	require.NoError(t, err)
+	assert.Equal(t, "cloud", name)
+
+	_, err = CurrentContext(path, "prod")
+	assert.ErrorContains(t, err, "context prod not found in kubeconfig")
+}
+
+func TestCheckAuthPlugin(t *testing.T) {
+	path := writeKubeconfig(t, "https://127.0.0.1:6443")
+
+	plugin, err := CheckAuthPlugin(path, "dev")
+	require.NoError(t, err)
+	assert.Empty(t, plugin)
+
+	plugin, err = CheckAuthPlugin(path, "cloud")
+	assert.Equal(t, "cluster-bootstrap-missing-plugin", plugin)
+	assert.ErrorContains(t, err, "credential plugin cluster-bootstrap-missing-plugin of user cloud not found")
+	assert.ErrorContains(t, err, "hint: install the cloud CLI")
+
+	_, err = CheckAuthPlugin(path, "legacy")
+	assert.ErrorContains(t, err, "uses the gcp auth provider")
+}
+
+func TestPing(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Unauthorized","code":401}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"major":"1","minor":"31","gitVersion":"v1.31.2"}`))
+	}))
+	defer server.Close()
+	path := writeKubeconfig(t, server.URL)
+
+	version, err := Ping(context.Background(), path, "", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.31.2", version)
+
+	_, err = Ping(context.Background(), path, "anonymous", time.Second)
+	assert.ErrorContains(t, err, "rejected the credentials")
+
+	server.Close()
+	_, err = Ping(context.Background(), path, "", time.Second)
+	assert.ErrorContains(t, err, "cannot connect to cluster at "+server.URL)
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ResourceQuery selects objects by kind and optionally namespace, name and
+// labels.
+type ResourceQuery struct {
+	APIVersion    string
+	Kind          string
+	Namespace     string
+	Name          string
+	LabelSelector string
+}
+
+func (q ResourceQuery) String() string {
+	s := q.Kind
+	if q.Name != "" {
+		s += "/" + q.Name
+	}
+	if q.Namespace != "" {
+		s += " in " + q.Namespace
+	}
+	if q.LabelSelector != "" {
+		s += " with labels " + q.LabelSelector
+	}
+	return s
+}
+
+// CountResources returns the number of objects matching q. The kind is
+// resolved to its resource through discovery.
+func (c *Client) CountResources(ctx context.Context, q ResourceQuery) (int, error) {
+	gv, err := schema.ParseGroupVersion(q.APIVersion)
+	if err != nil {
+		return 0, fmt.Errorf("invalid apiVersion %q: %w", q.APIVersion, err)
+	}
+	resources, err := c.Clientset.Discovery().ServerResourcesForGroupVersion(q.APIVersion)
+	if err != nil {
+		return 0, fmt.Errorf("API %s is not served by the cluster: %w", q.APIVersion, err)
+	}
+	var gvr schema.GroupVersionResource
+	namespaced := false
+	for _, res := range resources.APIResources {
+		if res.Kind == q.Kind && res.Group == "" && res.Version == "" && !strings.Contains(res.Name, "/") {
+			gvr = gv.WithResource(res.Name)
+			namespaced = res.Namespaced
+			break
+		}
+	}
+	if gvr.Resource == "" {
+		return 0, fmt.Errorf("kind %s is not served by %s", q.Kind, q.APIVersion)
+	}
+
+	ri := c.DynamicClient.Resource(gvr)
+	if namespaced && q.Namespace != "" {
+		if q.Name != "" {
+			return countGet(ri.Namespace(q.Namespace).Get(ctx, q.Name, metav1.GetOptions{}))
+		}
+		list, err := ri.Namespace(q.Namespace).List(ctx, metav1.ListOptions{LabelSelector: q.LabelSelector})
+		if err != nil {
+			return 0, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+		}
+		return len(list.Items), nil
+	}
+	if q.Name != "" && !namespaced {
+		return countGet(ri.Get(ctx, q.Name, metav1.GetOptions{}))
+	}
+	opts := metav1.ListOptions{LabelSelector: q.LabelSelector}
+	if q.Name != "" {
+		opts.FieldSelector = "metadata.name=" + q.Name
+	}
+	list, err := ri.List(ctx, opts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	return len(list.Items), nil
+}
+
+func countGet(_ interface{}, err error) (int, error) {
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
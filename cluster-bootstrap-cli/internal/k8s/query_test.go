@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCountResources(t *testing.T) {
+	objects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "argocd"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "argocd", Labels: map[string]string{"app": "argocd"}}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "argocd"}},
+	}
+	clientset := fake.NewSimpleClientset()
+	clientset.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "namespaces", Kind: "Namespace"},
+			{Name: "namespaces/status", Kind: "Namespace"},
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+		},
+	}}
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := &Client{Clientset: clientset, DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme, objects...)}
+	ctx := context.Background()
+
+	tests := []struct {
+		query ResourceQuery
+		want  int
+	}{
+		{ResourceQuery{APIVersion: "v1", Kind: "Namespace", Name: "argocd"}, 1},
+		{ResourceQuery{APIVersion: "v1", Kind: "Namespace", Name: "vault"}, 0},
+		{ResourceQuery{APIVersion: "v1", Kind: "ConfigMap", Namespace: "argocd"}, 2},
+		{ResourceQuery{APIVersion: "v1", Kind: "ConfigMap", Namespace: "argocd", LabelSelector: "app=argocd"}, 1},
+		{ResourceQuery{APIVersion: "v1", Kind: "ConfigMap", Namespace: "argocd", Name: "b"}, 1},
+	}
+	for _, tt := range tests {
+		got, err := client.CountResources(ctx, tt.query)
+		require.NoError(t, err, tt.query.String())
+		assert.Equal(t, tt.want, got, tt.query.String())
+	}
+
+	_, err := client.CountResources(ctx, ResourceQuery{APIVersion: "v1", Kind: "Secret"})
+	assert.ErrorContains(t, err, "kind Secret is not served by v1")
+	_, err = client.CountResources(ctx, ResourceQuery{APIVersion: "argoproj.io/v1alpha1", Kind: "Application"})
+	assert.ErrorContains(t, err, "not served by the cluster")
+}
@@ -0,0 +1,267 @@
+// Package knownhosts fetches and pins the SSH host keys of Git servers and
+// maintains the known_hosts data ArgoCD verifies them against.
+package knownhosts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// ConfigMapName is the ConfigMap ArgoCD reads SSH known hosts from.
+	ConfigMapName = "argocd-ssh-known-hosts-cm"
+	// ConfigMapKey is the ConfigMap key holding known_hosts content.
+	ConfigMapKey = "ssh_known_hosts"
+)
+
+// hostKeyAlgorithms are negotiated one at a time, so that every key type a
+// server has is fetched, as ssh-keyscan does.
+var hostKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+}
+
+// errKeyReceived aborts the handshake once the host key is known.
+var errKeyReceived = errors.New("host key received")
+
+// Address returns the host:port of an SSH repository URL, either
+// user@host:path or ssh://[user@]host[:port]/path. ok is false for other
+// URLs.
+func Address(repoURL string) (addr string, ok bool) {
+	repoURL = strings.TrimSpace(repoURL)
+	if strings.HasPrefix(repoURL, "ssh://") {
+		u, err := url.Parse(repoURL)
+		if err != nil || u.Hostname() == "" {
+			return "", false
+		}
+		port := u.Port()
+		if port == "" {
+			port = "22"
+		}
+		return net.JoinHostPort(u.Hostname(), port), true
+	}
+	if strings.Contains(repoURL, "://") {
+		return "", false
+	}
+	at := strings.Index(repoURL, "@")
+	colon := strings.Index(repoURL, ":")
+	if at <= 0 || colon < at+2 {
+		return "", false
+	}
+	return net.JoinHostPort(repoURL[at+1:colon], "22"), true
+}
+
+// Host returns the known_hosts name of addr: the bare host for port 22,
+// [host]:port otherwise.
+func Host(addr string) string {
+	return knownhosts.Normalize(addr)
+}
+
+// HostAddress is the inverse of Host: it returns host:port for a known_hosts
+// host name.
+func HostAddress(host string) string {
+	if strings.HasPrefix(host, "[") {
+		if end := strings.Index(host, "]:"); end > 0 {
+			return net.JoinHostPort(host[1:end], host[end+2:])
+		}
+	}
+	return net.JoinHostPort(host, "22")
+}
+
+// Fetch returns the host keys the SSH server at addr offers, one per key
+// type it supports. No authentication is attempted.
+func Fetch(ctx context.Context, addr string, timeout time.Duration) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	var lastErr error
+	for _, algo := range hostKeyAlgorithms {
+		key, err := fetchKey(ctx, addr, algo, timeout)
+		var netErr net.Error
+		if errors.As(err, &netErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+		}
+		if err != nil {
+			// The server does not support this key type.
+			lastErr = err
+			continue
+		}
+		if !slices.ContainsFunc(keys, func(k ssh.PublicKey) bool { return sameKey(k, key) }) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no host keys received from %s: %w", addr, lastErr)
+	}
+	return keys, nil
+}
+
+func fetchKey(ctx context.Context, addr, algo string, timeout time.Duration) (ssh.PublicKey, error) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	var key ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User:              "git",
+		HostKeyAlgorithms: []string{algo},
+		HostKeyCallback: func(_ string, _ net.Addr, k ssh.PublicKey) error {
+			key = k
+			return errKeyReceived
+		},
+		Timeout: timeout,
+	}
+	_, _, _, err = ssh.NewClientConn(conn, addr, config)
+	if key != nil {
+		return key, nil
+	}
+	return nil, err
+}
+
+// Fingerprint returns the OpenSSH SHA256 fingerprint of key.
+func Fingerprint(key ssh.PublicKey) string {
+	return ssh.FingerprintSHA256(key)
+}
+
+// NormalizeFingerprint returns a pinned fingerprint in the form Fingerprint
+// produces: SHA256:<unpadded base64>. The prefix may be omitted.
+func NormalizeFingerprint(fp string) string {
+	fp = strings.TrimRight(strings.TrimSpace(fp), "=")
+	if !strings.HasPrefix(fp, "SHA256:") {
+		fp = "SHA256:" + fp
+	}
+	return fp
+}
+
+// MismatchError reports that none of a server's host keys match the
+// fingerprints pinned for it.
+type MismatchError struct {
+	Host    string
+	Offered []string
+	Pinned  []string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for %s: server offers %s, pinned %s",
+		e.Host, strings.Join(e.Offered, ", "), strings.Join(e.Pinned, ", "))
+}
+
+// Verify returns the keys whose fingerprint is pinned. Without pins all keys
+// are returned. A *MismatchError is returned when pins are given and no key
+// matches them.
+func Verify(host string, keys []ssh.PublicKey, pins []string) ([]ssh.PublicKey, error) {
+	if len(pins) == 0 {
+		return keys, nil
+	}
+	pinned := make([]string, len(pins))
+	for i, pin := range pins {
+		pinned[i] = NormalizeFingerprint(pin)
+	}
+	var matched []ssh.PublicKey
+	var offered []string
+	for _, key := range keys {
+		fp := Fingerprint(key)
+		offered = append(offered, fp)
+		if slices.Contains(pinned, fp) {
+			matched = append(matched, key)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, &MismatchError{Host: host, Offered: offered, Pinned: pinned}
+	}
+	return matched, nil
+}
+
+func sameKey(a, b ssh.PublicKey) bool {
+	return a.Type() == b.Type() && string(a.Marshal()) == string(b.Marshal())
+}
+
+// File is known_hosts content. Lines that are not plain entries, such as
+// comments, hashed hosts and @cert-authority lines, are kept untouched.
+type File struct {
+	lines []string
+}
+
+// Parse reads known_hosts content.
+func Parse(data string) *File {
+	f := &File{}
+	for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		if line != "" || len(f.lines) > 0 {
+			f.lines = append(f.lines, line)
+		}
+	}
+	return f
+}
+
+// entry parses a plain known_hosts line.
+func entry(line string) (hosts []string, key ssh.PublicKey, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "@") {
+		return nil, nil, false
+	}
+	_, hosts, key, _, _, err := ssh.ParseKnownHosts([]byte(trimmed))
+	if err != nil {
+		return nil, nil, false
+	}
+	return hosts, key, true
+}
+
+// Keys returns the keys listed for host.
+func (f *File) Keys(host string) []ssh.PublicKey {
+	var keys []ssh.PublicKey
+	for _, line := range f.lines {
+		if hosts, key, ok := entry(line); ok && slices.Contains(hosts, host) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Set replaces the keys listed for host and reports whether the content
+// changed. Lines listing other hosts too keep them.
+func (f *File) Set(host string, keys []ssh.PublicKey) bool {
+	before := f.String()
+	var lines []string
+	for _, line := range f.lines {
+		hosts, key, ok := entry(line)
+		if !ok || !slices.Contains(hosts, host) {
+			lines = append(lines, line)
+			continue
+		}
+		if others := slices.DeleteFunc(slices.Clone(hosts), func(h string) bool { return h == host }); len(others) > 0 {
+			lines = append(lines, knownhosts.Line(others, key))
+		}
+	}
+	for _, key := range keys {
+		lines = append(lines, knownhosts.Line([]string{host}, key))
+	}
+	f.lines = lines
+	return f.String() != before
+}
+
+// Line returns a known_hosts line for host and key.
+func Line(host string, key ssh.PublicKey) string {
+	return knownhosts.Line([]string{host}, key)
+}
+
+// String returns the content, ending with a newline.
+func (f *File) String() string {
+	if len(f.lines) == 0 {
+		return ""
+	}
+	return strings.Join(f.lines, "\n") + "\n"
+}
@@ -0,0 +1,147 @@
+package knownhosts
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSigner(t *testing.T, key interface{}) ssh.Signer {
+	t.Helper()
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	return signer
+}
+
+// serveSSH runs an SSH server that offers the host keys of signers and
+// rejects every client.
+func serveSSH(t *testing.T, signers ...ssh.Signer) string {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, errors.New("denied")
+		},
+	}
+	for _, s := range signers {
+		config.AddHostKey(s)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+				_ = conn.Close()
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestAddress(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:org/repo.git":                 "github.com:22",
+		"ssh://git@git.example.com:2222/org/repo.git": "git.example.com:2222",
+		"ssh://git.example.com/org/repo.git":          "git.example.com:22",
+	}
+	for url, want := range tests {
+		addr, ok := Address(url)
+		assert.True(t, ok, url)
+		assert.Equal(t, want, addr, url)
+	}
+	for _, url := range []string{"https://github.com/org/repo.git", "github.com:org/repo", ""} {
+		_, ok := Address(url)
+		assert.False(t, ok, url)
+	}
+}
+
+func TestHost(t *testing.T) {
+	assert.Equal(t, "github.com", Host("github.com:22"))
+	assert.Equal(t, "[git.example.com]:2222", Host("git.example.com:2222"))
+	assert.Equal(t, "git.example.com:2222", HostAddress("[git.example.com]:2222"))
+	assert.Equal(t, "github.com:22", HostAddress("github.com"))
+}
+
+func TestFetch(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ed, rsaSigner := newSigner(t, edKey), newSigner(t, rsaKey)
+	addr := serveSSH(t, ed, rsaSigner)
+
+	keys, err := Fetch(context.Background(), addr, 5*time.Second)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, Fingerprint(ed.PublicKey()), Fingerprint(keys[0]))
+	assert.Equal(t, Fingerprint(rsaSigner.PublicKey()), Fingerprint(keys[1]))
+}
+
+func TestFetch_ConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	_, err = Fetch(context.Background(), addr, time.Second)
+	assert.ErrorContains(t, err, "failed to connect")
+}
+
+func TestVerify(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key := newSigner(t, priv).PublicKey()
+	fp := Fingerprint(key)
+
+	keys, err := Verify("github.com", []ssh.PublicKey{key}, nil)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	// Pins may omit the prefix and keep base64 padding.
+	keys, err = Verify("github.com", []ssh.PublicKey{key}, []string{fp[len("SHA256:"):] + "="})
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	_, err = Verify("github.com", []ssh.PublicKey{key}, []string{"SHA256:+DiY3wvvV6TuJJhbpZisF/zLDA0zPMSvHdkr4UvCOqU"})
+	var mismatch *MismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, []string{fp}, mismatch.Offered)
+}
+
+func TestFileSet(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key := newSigner(t, priv).PublicKey()
+	_, oldPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	old := newSigner(t, oldPriv).PublicKey()
+
+	f := Parse("# managed\n" +
+		"git.example.com,other.example.com " + string(ssh.MarshalAuthorizedKey(old)) +
+		"@cert-authority *.example.com " + string(ssh.MarshalAuthorizedKey(old)))
+	require.Len(t, f.Keys("git.example.com"), 1)
+
+	assert.True(t, f.Set("git.example.com", []ssh.PublicKey{key}))
+	assert.False(t, f.Set("git.example.com", []ssh.PublicKey{key}))
+
+	got := f.Keys("git.example.com")
+	require.Len(t, got, 1)
+	assert.True(t, sameKey(key, got[0]))
+	assert.Len(t, f.Keys("other.example.com"), 1)
+	assert.Contains(t, f.String(), "# managed\n")
+	assert.Contains(t, f.String(), "@cert-authority *.example.com")
+}
@@ -0,0 +1,182 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the policy configuration file, relative to the base directory.
+const DefaultConfigFile = "policy.yaml"
+
+// Severity controls how a rule's findings are reported.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	SeverityOff     Severity = "off"
+)
+
+func (s Severity) valid() bool {
+	switch s {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return true
+	}
+	return false
+}
+
+// RuleConfig overrides a rule's default settings.
+type RuleConfig struct {
+	Severity Severity `yaml:"severity"`
+}
+
+// Waiver suppresses findings that match all of its non-empty selectors.
+type Waiver struct {
+	Rule      string `yaml:"rule"`
+	Component string `yaml:"component,omitempty"`
+	Kind      string `yaml:"kind,omitempty"`
+	Name      string `yaml:"name,omitempty"`
+	Container string `yaml:"container,omitempty"`
+	Reason    string `yaml:"reason"`
+	// Expires is an optional YYYY-MM-DD date after which the waiver no longer applies.
+	Expires string `yaml:"expires,omitempty"`
+}
+
+// EnvironmentConfig holds per-environment overrides, applied on top of the top-level settings.
+type EnvironmentConfig struct {
+	Rules   map[string]RuleConfig `yaml:"rules"`
+	Waivers []Waiver              `yaml:"waivers"`
+}
+
+// Config is the policy configuration:
+//
+//	rules:
+//	  resource-limits:
+//	    severity: error
+//	waivers:
+//	  - rule: image-latest-tag
+//	    component: vault
+//	    name: vault-auto-unseal
+//	    reason: upstream image has no versioned tags we trust yet
+//	    expires: 2026-12-31
+//	environments:
+//	  dev:
+//	    rules:
+//	      resource-limits:
+//	        severity: off
+type Config struct {
+	Rules        map[string]RuleConfig        `yaml:"rules"`
+	Waivers      []Waiver                     `yaml:"waivers"`
+	Environments map[string]EnvironmentConfig `yaml:"environments"`
+}
+
+// LoadConfig reads a policy configuration file. A missing file yields an empty config.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks that rule IDs, severities and waivers are well formed.
+func (c *Config) Validate() error {
+	check := func(scope string, rules map[string]RuleConfig, waivers []Waiver) error {
+		for id, rc := range rules {
+			if _, ok := ruleByID(id); !ok {
+				return fmt.Errorf("%sunknown rule %q (known: %s)", scope, id, strings.Join(RuleIDs(), ", "))
+			}
+			if !rc.Severity.valid() {
+				return fmt.Errorf("%srule %s: invalid severity %q (use error, warning, info or off)", scope, id, rc.Severity)
+			}
+		}
+		for i, w := range waivers {
+			if _, ok := ruleByID(w.Rule); !ok {
+				return fmt.Errorf("%swaiver %d: unknown rule %q", scope, i+1, w.Rule)
+			}
+			if strings.TrimSpace(w.Reason) == "" {
+				return fmt.Errorf("%swaiver %d (%s): reason is required", scope, i+1, w.Rule)
+			}
+			if w.Expires != "" {
+				if _, err := time.Parse("2006-01-02", w.Expires); err != nil {
+					return fmt.Errorf("%swaiver %d (%s): expires must be YYYY-MM-DD", scope, i+1, w.Rule)
+				}
+			}
+		}
+		return nil
+	}
+	if err := check("", c.Rules, c.Waivers); err != nil {
+		return err
+	}
+	for env, ec := range c.Environments {
+		if err := check(fmt.Sprintf("environments.%s: ", env), ec.Rules, ec.Waivers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeverityFor returns the effective severity of a rule in env.
+func (c *Config) SeverityFor(env string, rule Rule) Severity {
+	sev := rule.DefaultSeverity
+	if rc, ok := c.Rules[rule.ID]; ok {
+		sev = rc.Severity
+	}
+	if rc, ok := c.Environments[env].Rules[rule.ID]; ok {
+		sev = rc.Severity
+	}
+	return sev
+}
+
+// waiverFor returns the first waiver in env that matches f, ignoring expired ones.
+func (c *Config) waiverFor(env string, f Finding, now time.Time) *Waiver {
+	waivers := slices.Concat(c.Waivers, c.Environments[env].Waivers)
+	for i := range waivers {
+		w := &waivers[i]
+		if w.matches(f) && !w.expired(now) {
+			return w
+		}
+	}
+	return nil
+}
+
+func (w *Waiver) matches(f Finding) bool {
+	return w.Rule == f.Rule &&
+		(w.Component == "" || w.Component == f.Component) &&
+		(w.Kind == "" || w.Kind == f.Kind) &&
+		(w.Name == "" || w.Name == f.Name) &&
+		(w.Container == "" || w.Container == f.Container)
+}
+
+func (w *Waiver) expired(now time.Time) bool {
+	if w.Expires == "" {
+		return false
+	}
+	t, err := time.Parse("2006-01-02", w.Expires)
+	if err != nil {
+		return false
+	}
+	// A waiver is valid through the end of its expiry date.
+	return now.After(t.AddDate(0, 0, 1))
+}
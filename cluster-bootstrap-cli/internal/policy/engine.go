@@ -0,0 +1,214 @@
+package policy
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// Finding is a single rule violation in a rendered environment.
+type Finding struct {
+	Rule      string
+	Severity  Severity
+	Component string
+	Kind      string
+	Name      string
+	Namespace string
+	Container string
+	Message   string
+	// File is the template (or values file) that produced the finding,
+	// relative to the base directory when it exists locally. Line is 1-based
+	// and 0 when unknown.
+	File string
+	Line int
+	// Waived is set when a waiver matched; WaiverReason is its reason.
+	Waived       bool
+	WaiverReason string
+}
+
+// Evaluate runs every enabled rule against a rendered environment. Rules with
+// severity off are skipped; findings matched by an unexpired waiver are kept
+// but marked Waived. Findings are ordered by component and then rule.
+func Evaluate(result *render.Result, baseDir string, cfg *Config, now time.Time) []Finding {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	env := result.Environment
+	locator := newLocator(baseDir)
+
+	var findings []Finding
+	for _, comp := range result.Components {
+		for _, rule := range Rules {
+			if rule.checkObject == nil {
+				continue
+			}
+			sev := cfg.SeverityFor(env, rule)
+			if sev == SeverityOff {
+				continue
+			}
+			for _, obj := range comp.Objects {
+				for _, v := range rule.checkObject(obj) {
+					f := Finding{
+						Rule:      rule.ID,
+						Severity:  sev,
+						Component: comp.Name,
+						Kind:      obj.Kind,
+						Name:      obj.Name,
+						Namespace: obj.Namespace,
+						Container: v.container,
+						Message:   v.message,
+					}
+					f.File, f.Line = locator.template(comp.ChartDir, obj.Source, v.needles)
+					findings = append(findings, f)
+				}
+			}
+		}
+	}
+
+	if rule, ok := ruleByID(RulePodSecurityLabels); ok {
+		if sev := cfg.SeverityFor(env, rule); sev != SeverityOff {
+			findings = append(findings, checkPodSecurityLabels(result, locator, sev)...)
+		}
+	}
+
+	for i := range findings {
+		if w := cfg.waiverFor(env, findings[i], now); w != nil {
+			findings[i].Waived = true
+			findings[i].WaiverReason = w.Reason
+		}
+	}
+	return findings
+}
+
+// checkPodSecurityLabels reports components whose destination namespace is
+// not labelled for Pod Security Admission, either by a rendered Namespace
+// object or through the Application's managedNamespaceMetadata.
+func checkPodSecurityLabels(result *render.Result, locator *locator, sev Severity) []Finding {
+	labelled := map[string]bool{}
+	all := append([]render.Component{result.AppOfApps}, result.Components...)
+	for _, comp := range all {
+		for _, obj := range comp.Objects {
+			switch obj.Kind {
+			case "Namespace":
+				if hasLabel(nestedMap(obj.Content, "metadata", "labels")) {
+					labelled[obj.Name] = true
+				}
+			case "Application":
+				labels := nestedMap(obj.Content, "spec", "syncPolicy", "managedNamespaceMetadata", "labels")
+				if hasLabel(labels) {
+					labelled[render.ApplicationFromObject(obj).Namespace] = true
+				}
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, comp := range result.Components {
+		ns := comp.Namespace
+		if ns == "" || labelled[ns] {
+			continue
+		}
+		file, line := locator.componentNamespace(comp.Name)
+		findings = append(findings, Finding{
+			Rule:      RulePodSecurityLabels,
+			Severity:  sev,
+			Component: comp.Name,
+			Kind:      "Namespace",
+			Name:      ns,
+			Message:   "namespace " + ns + " has no " + PodSecurityEnforceLabel + " label",
+			File:      file,
+			Line:      line,
+		})
+	}
+	return findings
+}
+
+func hasLabel(labels map[string]interface{}) bool {
+	v, ok := labels[PodSecurityEnforceLabel].(string)
+	return ok && v != ""
+}
+
+// locator maps findings back to files in the base directory, caching file reads.
+type locator struct {
+	baseDir string
+	files   map[string][]string
+	apps    *yamledit.Document
+	appsErr error
+	appsSet bool
+}
+
+func newLocator(baseDir string) *locator {
+	return &locator{baseDir: baseDir, files: map[string][]string{}}
+}
+
+// template resolves a Helm "# Source:" path such as "vault/templates/job.yaml"
+// to the chart file and the first line containing one of the needles.
+// Templates of downloaded subcharts are not available locally and are
+// reported as Helm named them, without a line.
+func (l *locator) template(chartDir, source string, needles []string) (string, int) {
+	if source == "" {
+		return filepath.ToSlash(chartDir), 0
+	}
+	_, rel, ok := strings.Cut(source, "/")
+	if !ok || strings.HasPrefix(rel, "charts/") {
+		return source, 0
+	}
+	file := filepath.Join(chartDir, filepath.FromSlash(rel))
+	lines := l.read(file)
+	if lines == nil {
+		return source, 0
+	}
+	for _, needle := range needles {
+		for i, text := range lines {
+			if strings.Contains(text, needle) {
+				return filepath.ToSlash(file), i + 1
+			}
+		}
+	}
+	return filepath.ToSlash(file), 0
+}
+
+// componentNamespace points at components.<name>.namespace in apps/values.yaml.
+func (l *locator) componentNamespace(name string) (string, int) {
+	if !l.appsSet {
+		l.appsSet = true
+		l.apps, l.appsErr = yamledit.Load(filepath.Join(l.baseDir, components.AppsValuesFile))
+	}
+	if l.appsErr != nil {
+		return components.AppsValuesFile, 0
+	}
+	node := l.apps.Lookup("components", name, "namespace")
+	if node == nil {
+		node = l.apps.Lookup("components", name)
+	}
+	if node == nil {
+		return components.AppsValuesFile, 0
+	}
+	return components.AppsValuesFile, node.Line
+}
+
+func (l *locator) read(file string) []string {
+	if lines, ok := l.files[file]; ok {
+		return lines
+	}
+	data, err := os.ReadFile(filepath.Join(l.baseDir, file)) // #nosec G304
+	if err != nil {
+		l.files[file] = nil
+		return nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	l.files[file] = lines
+	return lines
+}
@@ -0,0 +1,214 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+const jobTemplate = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: unseal
+spec:
+  template:
+    spec:
+      containers:
+        - name: unseal
+          image: bitnami/kubectl:latest
+          securityContext:
+            privileged: true
+      volumes:
+        - name: host
+          hostPath:
+            path: /var/run
+`
+
+const deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.27
+          resources:
+            requests:
+              cpu: 10m
+              memory: 32Mi
+            limits:
+              memory: 64Mi
+`
+
+// writeResult creates a base directory holding the templates and apps values
+// the findings point at, and a render result as render.Environment returns it.
+func writeResult(t *testing.T) (string, *render.Result) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "apps/values.yaml"), `components:
+  vault:
+    enabled: true
+    namespace: vault
+  web:
+    enabled: true
+    namespace: web
+`)
+	writeFile(t, filepath.Join(dir, "components/vault/templates/job.yaml"), jobTemplate)
+	writeFile(t, filepath.Join(dir, "components/web/templates/deploy.yaml"), deploymentTemplate)
+
+	vaultObjects, err := render.ParseManifest("---\n# Source: vault/templates/job.yaml\n"+jobTemplate, "vault")
+	require.NoError(t, err)
+	webObjects, err := render.ParseManifest("---\n# Source: web/templates/deploy.yaml\n"+deploymentTemplate+`---
+# Source: web/templates/namespace.yaml
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+  labels:
+    pod-security.kubernetes.io/enforce: baseline
+`, "web")
+	require.NoError(t, err)
+
+	return dir, &render.Result{
+		Environment: "dev",
+		Components: []render.Component{
+			{Application: render.Application{Name: "vault", Namespace: "vault"}, ChartDir: "components/vault", Objects: vaultObjects},
+			{Application: render.Application{Name: "web", Namespace: "web"}, ChartDir: "components/web", Objects: webObjects},
+		},
+	}
+}
+
+func findingsByRule(findings []Finding) map[string]Finding {
+	out := map[string]Finding{}
+	for _, f := range findings {
+		out[f.Rule] = f
+	}
+	return out
+}
+
+func TestEvaluateDefaults(t *testing.T) {
+	dir, result := writeResult(t)
+	findings := Evaluate(result, dir, &Config{}, time.Now())
+
+	for _, f := range findings {
+		assert.Equal(t, "vault", f.Component, "web is compliant: %+v", f)
+	}
+	byRule := findingsByRule(findings)
+	require.Len(t, byRule, 6)
+
+	latest := byRule[RuleImageLatestTag]
+	assert.Equal(t, SeverityWarning, latest.Severity)
+	assert.Equal(t, "unseal", latest.Container)
+	assert.Equal(t, "components/vault/templates/job.yaml", latest.File)
+	assert.Equal(t, 10, latest.Line)
+
+	assert.Equal(t, SeverityError, byRule[RulePrivileged].Severity)
+	assert.Equal(t, 12, byRule[RulePrivileged].Line)
+	assert.Equal(t, 15, byRule[RuleHostPath].Line)
+
+	psa := byRule[RulePodSecurityLabels]
+	assert.Equal(t, "apps/values.yaml", psa.File)
+	assert.Equal(t, 4, psa.Line)
+	assert.Equal(t, "vault", psa.Name)
+}
+
+func TestEvaluateSeverityAndWaivers(t *testing.T) {
+	dir, result := writeResult(t)
+	cfg := &Config{
+		Rules: map[string]RuleConfig{RuleResourceLimits: {Severity: SeverityError}},
+		Waivers: []Waiver{
+			{Rule: RuleImageLatestTag, Component: "vault", Reason: "pinned upstream soon"},
+			{Rule: RuleHostPath, Reason: "expired", Expires: "2020-01-01"},
+		},
+		Environments: map[string]EnvironmentConfig{
+			"dev": {Rules: map[string]RuleConfig{
+				RuleResourceRequests:  {Severity: SeverityOff},
+				RulePodSecurityLabels: {Severity: SeverityOff},
+			}},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+
+	byRule := findingsByRule(Evaluate(result, dir, cfg, time.Now()))
+	assert.NotContains(t, byRule, RuleResourceRequests)
+	assert.NotContains(t, byRule, RulePodSecurityLabels)
+	assert.Equal(t, SeverityError, byRule[RuleResourceLimits].Severity)
+	assert.True(t, byRule[RuleImageLatestTag].Waived)
+	assert.Equal(t, "pinned upstream soon", byRule[RuleImageLatestTag].WaiverReason)
+	assert.False(t, byRule[RuleHostPath].Waived)
+
+	result.Environment = "prod"
+	byRule = findingsByRule(Evaluate(result, dir, cfg, time.Now()))
+	assert.Contains(t, byRule, RuleResourceRequests)
+}
+
+func TestUsesLatestTag(t *testing.T) {
+	cases := map[string]bool{
+		"nginx":                       true,
+		"nginx:latest":                true,
+		"registry:5000/team/app":      true,
+		"registry:5000/team/app:1.2":  false,
+		"bitnami/kubectl:1.31":        false,
+		"nginx@sha256:0123456789abcd": false,
+	}
+	for image, want := range cases {
+		assert.Equal(t, want, usesLatestTag(image), image)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := LoadConfig(filepath.Join(dir, DefaultConfigFile))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Rules)
+
+	path := filepath.Join(dir, DefaultConfigFile)
+	writeFile(t, path, `rules:
+  host-path-volume:
+    severity: error
+environments:
+  dev:
+    waivers:
+      - rule: privileged-container
+        component: node-exporter
+        reason: needs host access
+        expires: 2030-01-31
+`)
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, SeverityError, cfg.SeverityFor("prod", Rule{ID: RuleHostPath, DefaultSeverity: SeverityWarning}))
+	assert.Len(t, cfg.Environments["dev"].Waivers, 1)
+
+	for name, content := range map[string]string{
+		"unknown rule":   "rules:\n  nope:\n    severity: error\n",
+		"bad severity":   "rules:\n  host-path-volume:\n    severity: fatal\n",
+		"missing reason": "waivers:\n  - rule: host-path-volume\n",
+		"bad expiry":     "waivers:\n  - rule: host-path-volume\n    reason: x\n    expires: soon\n",
+		"unknown field":  "rule:\n  host-path-volume: {}\n",
+	} {
+		writeFile(t, path, content)
+		_, err := LoadConfig(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestWaiverExpiry(t *testing.T) {
+	w := Waiver{Rule: RuleHostPath, Reason: "x", Expires: "2026-01-31"}
+	assert.False(t, w.expired(time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, w.expired(time.Date(2026, 2, 1, 1, 0, 0, 0, time.UTC)))
+}
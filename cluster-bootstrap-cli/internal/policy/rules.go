@@ -0,0 +1,267 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+// Rule IDs.
+const (
+	RuleImageLatestTag    = "image-latest-tag"
+	RuleResourceRequests  = "resource-requests"
+	RuleResourceLimits    = "resource-limits"
+	RulePrivileged        = "privileged-container"
+	RuleHostPath          = "host-path-volume"
+	RulePodSecurityLabels = "pod-security-labels"
+)
+
+// PodSecurityEnforceLabel is the namespace label read by Pod Security Admission.
+const PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// Rule is a built-in policy check.
+type Rule struct {
+	ID              string
+	Description     string
+	DefaultSeverity Severity
+	// checkObject inspects a single rendered object; nil for rules that
+	// work on the whole environment.
+	checkObject func(obj render.Object) []violation
+}
+
+// violation is a rule hit before severity, waivers and locations are applied.
+type violation struct {
+	container string
+	message   string
+	// needles are searched for, in order, in the template source to find the line.
+	needles []string
+}
+
+// Rules lists the built-in rules.
+var Rules = []Rule{
+	{
+		ID:              RuleImageLatestTag,
+		Description:     "container images must be pinned to a tag other than latest",
+		DefaultSeverity: SeverityWarning,
+		checkObject:     checkLatestTag,
+	},
+	{
+		ID:              RuleResourceRequests,
+		Description:     "containers must set cpu and memory requests",
+		DefaultSeverity: SeverityWarning,
+		checkObject:     checkRequests,
+	},
+	{
+		ID:              RuleResourceLimits,
+		Description:     "containers must set a memory limit",
+		DefaultSeverity: SeverityInfo,
+		checkObject:     checkLimits,
+	},
+	{
+		ID:              RulePrivileged,
+		Description:     "containers must not run privileged",
+		DefaultSeverity: SeverityError,
+		checkObject:     checkPrivileged,
+	},
+	{
+		ID:              RuleHostPath,
+		Description:     "pods must not mount hostPath volumes",
+		DefaultSeverity: SeverityWarning,
+		checkObject:     checkHostPath,
+	},
+	{
+		ID:              RulePodSecurityLabels,
+		Description:     "component namespaces must carry a " + PodSecurityEnforceLabel + " label",
+		DefaultSeverity: SeverityWarning,
+	},
+}
+
+// RuleIDs returns the IDs of all built-in rules.
+func RuleIDs() []string {
+	ids := make([]string, 0, len(Rules))
+	for _, r := range Rules {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func ruleByID(id string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// container is a container or init container of a pod template.
+type container struct {
+	name string
+	spec map[string]interface{}
+}
+
+// podSpec returns the pod spec of workload kinds, or nil.
+func podSpec(obj render.Object) map[string]interface{} {
+	spec, _ := obj.Content["spec"].(map[string]interface{})
+	switch obj.Kind {
+	case "Pod":
+		return spec
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		return nestedMap(spec, "template", "spec")
+	case "CronJob":
+		return nestedMap(spec, "jobTemplate", "spec", "template", "spec")
+	}
+	return nil
+}
+
+func containers(pod map[string]interface{}) []container {
+	var out []container
+	for _, field := range []string{"initContainers", "containers"} {
+		list, _ := pod[field].([]interface{})
+		for _, item := range list {
+			c, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := c["name"].(string)
+			out = append(out, container{name: name, spec: c})
+		}
+	}
+	return out
+}
+
+func nestedMap(m map[string]interface{}, path ...string) map[string]interface{} {
+	for _, key := range path {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+	return m
+}
+
+func checkLatestTag(obj render.Object) []violation {
+	pod := podSpec(obj)
+	if pod == nil {
+		return nil
+	}
+	var out []violation
+	for _, c := range containers(pod) {
+		image, _ := c.spec["image"].(string)
+		if image == "" || !usesLatestTag(image) {
+			continue
+		}
+		out = append(out, violation{
+			container: c.name,
+			message:   fmt.Sprintf("container %s uses image %s", c.name, image),
+			needles:   []string{image, "image:"},
+		})
+	}
+	return out
+}
+
+// usesLatestTag reports whether an image reference is untagged or tagged latest.
+// Digest-pinned references are always accepted.
+func usesLatestTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	name := image
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	idx := strings.LastIndex(name, ":")
+	if idx < 0 {
+		return true
+	}
+	return name[idx+1:] == "latest"
+}
+
+func checkRequests(obj render.Object) []violation {
+	pod := podSpec(obj)
+	if pod == nil {
+		return nil
+	}
+	var out []violation
+	for _, c := range containers(pod) {
+		requests := nestedMap(c.spec, "resources", "requests")
+		var missing []string
+		for _, res := range []string{"cpu", "memory"} {
+			if requests[res] == nil {
+				missing = append(missing, "requests."+res)
+			}
+		}
+		if len(missing) > 0 {
+			out = append(out, violation{
+				container: c.name,
+				message:   fmt.Sprintf("container %s has no %s", c.name, strings.Join(missing, " or ")),
+				needles:   []string{"resources:", "name: " + c.name},
+			})
+		}
+	}
+	return out
+}
+
+func checkLimits(obj render.Object) []violation {
+	pod := podSpec(obj)
+	if pod == nil {
+		return nil
+	}
+	var out []violation
+	for _, c := range containers(pod) {
+		if nestedMap(c.spec, "resources", "limits")["memory"] == nil {
+			out = append(out, violation{
+				container: c.name,
+				message:   fmt.Sprintf("container %s has no limits.memory", c.name),
+				needles:   []string{"resources:", "name: " + c.name},
+			})
+		}
+	}
+	return out
+}
+
+func checkPrivileged(obj render.Object) []violation {
+	pod := podSpec(obj)
+	if pod == nil {
+		return nil
+	}
+	var out []violation
+	for _, c := range containers(pod) {
+		if privileged, _ := nestedMap(c.spec, "securityContext")["privileged"].(bool); privileged {
+			out = append(out, violation{
+				container: c.name,
+				message:   fmt.Sprintf("container %s runs privileged", c.name),
+				needles:   []string{"privileged:"},
+			})
+		}
+	}
+	return out
+}
+
+func checkHostPath(obj render.Object) []violation {
+	pod := podSpec(obj)
+	if pod == nil {
+		return nil
+	}
+	var out []violation
+	volumes, _ := pod["volumes"].([]interface{})
+	for _, item := range volumes {
+		v, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hostPath := nestedMap(v, "hostPath")
+		if hostPath == nil {
+			continue
+		}
+		name, _ := v["name"].(string)
+		path, _ := hostPath["path"].(string)
+		out = append(out, violation{
+			message: fmt.Sprintf("volume %s mounts host path %s", name, path),
+			needles: []string{"hostPath:"},
+		})
+	}
+	return out
+}
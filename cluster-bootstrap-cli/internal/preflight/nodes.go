@@ -0,0 +1,48 @@
+package preflight
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// pressureConditions are node conditions that fail preflight when true.
+var pressureConditions = []corev1.NodeConditionType{
+	corev1.NodeMemoryPressure,
+	corev1.NodeDiskPressure,
+	corev1.NodePIDPressure,
+	corev1.NodeNetworkUnavailable,
+}
+
+// NodeProblem is a node condition that gets in the way of scheduling.
+type NodeProblem struct {
+	Node      string
+	Condition corev1.NodeConditionType
+	Message   string
+	// Pressure is set for pressure conditions; otherwise the node is not
+	// ready.
+	Pressure bool
+}
+
+// NodeProblems returns the pressure conditions set on nodes and the nodes
+// that are not ready.
+func NodeProblems(nodes []corev1.Node) []NodeProblem {
+	var out []NodeProblem
+	for _, n := range nodes {
+		for _, c := range n.Status.Conditions {
+			for _, p := range pressureConditions {
+				if c.Type == p && c.Status == corev1.ConditionTrue {
+					out = append(out, NodeProblem{Node: n.Name, Condition: c.Type, Message: c.Message, Pressure: true})
+				}
+			}
+		}
+		if !ready(n) {
+			msg := "no Ready condition reported"
+			for _, c := range n.Status.Conditions {
+				if c.Type == corev1.NodeReady {
+					msg = c.Message
+				}
+			}
+			out = append(out, NodeProblem{Node: n.Name, Condition: corev1.NodeReady, Message: msg})
+		}
+	}
+	return out
+}
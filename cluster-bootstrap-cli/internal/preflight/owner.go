@@ -0,0 +1,86 @@
+package preflight
+
+import (
+	"strings"
+)
+
+// Labels and annotations tools use to mark the objects they manage.
+const (
+	argoCDTrackingAnnotation = "argocd.argoproj.io/tracking-id"
+	instanceLabel            = "app.kubernetes.io/instance"
+	managedByLabel           = "app.kubernetes.io/managed-by"
+	helmReleaseName          = "meta.helm.sh/release-name"
+	helmReleaseNamespace     = "meta.helm.sh/release-namespace"
+	fluxKustomizationName    = "kustomize.toolkit.fluxcd.io/name"
+	fluxKustomizationNS      = "kustomize.toolkit.fluxcd.io/namespace"
+	fluxHelmReleaseName      = "helm.toolkit.fluxcd.io/name"
+	fluxHelmReleaseNS        = "helm.toolkit.fluxcd.io/namespace"
+)
+
+// Owner is the tool managing a cluster object.
+type Owner struct {
+	// Tool is argocd, helm, flux or the app.kubernetes.io/managed-by value.
+	Tool string
+	// Kind is the kind of owner within the tool, e.g. a Flux HelmRelease.
+	Kind string
+	// Name is the ArgoCD Application, or namespace/name of a Helm release
+	// or Flux object.
+	Name string
+}
+
+// IsZero reports whether no owner was found.
+func (o Owner) IsZero() bool {
+	return o.Tool == ""
+}
+
+func (o Owner) String() string {
+	switch {
+	case o.IsZero():
+		return "nothing"
+	case o.Kind != "" && o.Name != "":
+		return o.Tool + " " + o.Kind + " " + o.Name
+	case o.Name != "":
+		return o.Tool + " " + o.Name
+	}
+	return o.Tool
+}
+
+// OwnerOf returns the owner recorded in an object's labels and annotations.
+// ArgoCD is recognised by its tracking annotation, or by the instance label
+// it sets with label tracking when no Helm release is recorded; helm
+// template output carries managed-by Helm without release annotations.
+func OwnerOf(labels, annotations map[string]string) Owner {
+	if id := annotations[argoCDTrackingAnnotation]; id != "" {
+		app, _, _ := strings.Cut(id, ":")
+		// Applications outside the control plane namespace are tracked as
+		// <namespace>_<name>.
+		if _, name, ok := strings.Cut(app, "_"); ok {
+			app = name
+		}
+		return Owner{Tool: "argocd", Kind: "application", Name: app}
+	}
+	if name := labels[fluxHelmReleaseName]; name != "" {
+		return Owner{Tool: "flux", Kind: "helmrelease", Name: qualified(labels[fluxHelmReleaseNS], name)}
+	}
+	if name := labels[fluxKustomizationName]; name != "" {
+		return Owner{Tool: "flux", Kind: "kustomization", Name: qualified(labels[fluxKustomizationNS], name)}
+	}
+	if name := annotations[helmReleaseName]; name != "" {
+		return Owner{Tool: "helm", Kind: "release", Name: qualified(annotations[helmReleaseNamespace], name)}
+	}
+	managedBy := labels[managedByLabel]
+	if instance := labels[instanceLabel]; instance != "" && (managedBy == "" || strings.EqualFold(managedBy, "helm")) {
+		return Owner{Tool: "argocd", Kind: "application", Name: instance}
+	}
+	if managedBy != "" {
+		return Owner{Tool: strings.ToLower(managedBy)}
+	}
+	return Owner{}
+}
+
+func qualified(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
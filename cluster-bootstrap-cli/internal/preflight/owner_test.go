@@ -0,0 +1,58 @@
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnerOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{
+			name:        "argocd tracking annotation",
+			annotations: map[string]string{"argocd.argoproj.io/tracking-id": "vault:apps/Deployment:vault/vault"},
+			want:        "argocd application vault",
+		},
+		{
+			name:        "argocd application in another namespace",
+			annotations: map[string]string{"argocd.argoproj.io/tracking-id": "team-a_vault:apps/Deployment:vault/vault"},
+			want:        "argocd application vault",
+		},
+		{
+			name:   "argocd label tracking of helm template output",
+			labels: map[string]string{"app.kubernetes.io/instance": "reloader", "app.kubernetes.io/managed-by": "Helm"},
+			want:   "argocd application reloader",
+		},
+		{
+			name:        "helm release",
+			labels:      map[string]string{"app.kubernetes.io/instance": "monitoring", "app.kubernetes.io/managed-by": "Helm"},
+			annotations: map[string]string{"meta.helm.sh/release-name": "monitoring", "meta.helm.sh/release-namespace": "observability"},
+			want:        "helm release observability/monitoring",
+		},
+		{
+			name:        "flux helm release wins over its helm annotations",
+			labels:      map[string]string{"helm.toolkit.fluxcd.io/name": "podinfo", "helm.toolkit.fluxcd.io/namespace": "apps"},
+			annotations: map[string]string{"meta.helm.sh/release-name": "apps-podinfo"},
+			want:        "flux helmrelease apps/podinfo",
+		},
+		{
+			name:   "other tool",
+			labels: map[string]string{"app.kubernetes.io/instance": "db", "app.kubernetes.io/managed-by": "Terraform"},
+			want:   "terraform",
+		},
+		{
+			name: "no owner",
+			want: "nothing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, OwnerOf(tt.labels, tt.annotations).String())
+		})
+	}
+}
@@ -0,0 +1,258 @@
+// Package preflight compares the rendered manifests of an environment with
+// the cluster they are about to be installed in: capacity, storage, node
+// health and objects other tools already own.
+package preflight
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+// Resources is an amount of CPU and memory.
+type Resources struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// Add adds o to r.
+func (r *Resources) Add(o Resources) {
+	r.CPU.Add(o.CPU)
+	r.Memory.Add(o.Memory)
+}
+
+// Sub subtracts o from r.
+func (r *Resources) Sub(o Resources) {
+	r.CPU.Sub(o.CPU)
+	r.Memory.Sub(o.Memory)
+}
+
+// Times returns r multiplied by n.
+func (r Resources) Times(n int64) Resources {
+	return Resources{
+		CPU:    *resource.NewMilliQuantity(r.CPU.MilliValue()*n, resource.DecimalSI),
+		Memory: *resource.NewQuantity(r.Memory.Value()*n, resource.BinarySI),
+	}
+}
+
+// Fits reports whether r fits in capacity.
+func (r Resources) Fits(capacity Resources) bool {
+	return r.CPU.Cmp(capacity.CPU) <= 0 && r.Memory.Cmp(capacity.Memory) <= 0
+}
+
+// IsZero reports whether r requests nothing.
+func (r Resources) IsZero() bool {
+	return r.CPU.IsZero() && r.Memory.IsZero()
+}
+
+func (r Resources) String() string {
+	return fmt.Sprintf("%s CPU, %s memory", FormatCPU(r.CPU), FormatMemory(r.Memory))
+}
+
+// FormatCPU formats q in cores, e.g. "1.25".
+func FormatCPU(q resource.Quantity) string {
+	return strconv.FormatFloat(float64(q.MilliValue())/1000, 'f', -1, 64)
+}
+
+// FormatMemory formats q in Gi, or Mi below one Gi.
+func FormatMemory(q resource.Quantity) string {
+	bytes := q.Value()
+	if bytes >= 1<<30 || bytes <= -(1<<30) {
+		return fmt.Sprintf("%.1fGi", float64(bytes)/(1<<30))
+	}
+	return fmt.Sprintf("%dMi", bytes>>20)
+}
+
+// Workload is the scheduling footprint of one rendered object.
+type Workload struct {
+	Object render.Object
+	// Pod is the requests of a single pod.
+	Pod Resources
+	// Replicas is the number of pods; DaemonSets set PerNode instead.
+	Replicas int64
+	PerNode  bool
+}
+
+// Total returns the requests of all the workload's pods on a cluster with
+// the given number of schedulable nodes.
+func (w Workload) Total(nodes int) Resources {
+	if w.PerNode {
+		return w.Pod.Times(int64(nodes))
+	}
+	return w.Pod.Times(w.Replicas)
+}
+
+// Workloads returns the objects in objs that create pods, with the requests
+// of their pods. Replicas default to one as the API server defaults them.
+func Workloads(objs []render.Object) []Workload {
+	var out []Workload
+	for _, obj := range objs {
+		var spec map[string]interface{}
+		w := Workload{Object: obj, Replicas: 1}
+		switch obj.Kind {
+		case "Pod":
+			spec, _, _ = unstructured.NestedMap(obj.Content, "spec")
+		case "Deployment", "ReplicaSet", "ReplicationController", "StatefulSet":
+			spec, _, _ = unstructured.NestedMap(obj.Content, "spec", "template", "spec")
+			w.Replicas = intField(obj.Content, 1, "spec", "replicas")
+		case "DaemonSet":
+			spec, _, _ = unstructured.NestedMap(obj.Content, "spec", "template", "spec")
+			w.PerNode = true
+			w.Replicas = 0
+		case "Job":
+			spec, _, _ = unstructured.NestedMap(obj.Content, "spec", "template", "spec")
+			w.Replicas = intField(obj.Content, 1, "spec", "parallelism")
+		case "CronJob":
+			spec, _, _ = unstructured.NestedMap(obj.Content, "spec", "jobTemplate", "spec", "template", "spec")
+			w.Replicas = intField(obj.Content, 1, "spec", "jobTemplate", "spec", "parallelism")
+		default:
+			continue
+		}
+		if spec == nil {
+			continue
+		}
+		w.Pod = PodRequests(spec)
+		out = append(out, w)
+	}
+	return out
+}
+
+// PodRequests returns the requests of a pod spec as the scheduler counts
+// them: the sum over the containers, or the largest init container when
+// that is higher. Containers that only set limits request their limits.
+func PodRequests(spec map[string]interface{}) Resources {
+	var sum Resources
+	for _, c := range containerList(spec, "containers") {
+		sum.Add(containerRequests(c))
+	}
+	for _, c := range containerList(spec, "initContainers") {
+		init := containerRequests(c)
+		if init.CPU.Cmp(sum.CPU) > 0 {
+			sum.CPU = init.CPU
+		}
+		if init.Memory.Cmp(sum.Memory) > 0 {
+			sum.Memory = init.Memory
+		}
+	}
+	return sum
+}
+
+// ScheduledRequests sums the requests of the pods in pods that are bound to
+// one of nodes and have not finished.
+func ScheduledRequests(pods []corev1.Pod, nodes []corev1.Node) Resources {
+	names := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		names[n.Name] = true
+	}
+	var sum Resources
+	for i := range pods {
+		pod := &pods[i]
+		if !names[pod.Spec.NodeName] || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pod.Spec)
+		if err != nil {
+			continue
+		}
+		sum.Add(PodRequests(spec))
+	}
+	return sum
+}
+
+// Schedulable returns the nodes that pods without tolerations can land on:
+// ready, not cordoned and without NoSchedule or NoExecute taints.
+func Schedulable(nodes []corev1.Node) []corev1.Node {
+	var out []corev1.Node
+	for _, n := range nodes {
+		if n.Spec.Unschedulable || !ready(n) {
+			continue
+		}
+		tainted := false
+		for _, t := range n.Spec.Taints {
+			if t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute {
+				tainted = true
+				break
+			}
+		}
+		if !tainted {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Allocatable sums the allocatable CPU and memory of nodes.
+func Allocatable(nodes []corev1.Node) Resources {
+	var sum Resources
+	for _, n := range nodes {
+		sum.CPU.Add(n.Status.Allocatable[corev1.ResourceCPU])
+		sum.Memory.Add(n.Status.Allocatable[corev1.ResourceMemory])
+	}
+	return sum
+}
+
+func containerList(spec map[string]interface{}, field string) []map[string]interface{} {
+	items, _ := spec[field].([]interface{})
+	var out []map[string]interface{}
+	for _, item := range items {
+		if c, ok := item.(map[string]interface{}); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func containerRequests(c map[string]interface{}) Resources {
+	requests, _, _ := unstructured.NestedMap(c, "resources", "requests")
+	limits, _, _ := unstructured.NestedMap(c, "resources", "limits")
+	return Resources{
+		CPU:    quantity(requests, limits, "cpu"),
+		Memory: quantity(requests, limits, "memory"),
+	}
+}
+
+func quantity(requests, limits map[string]interface{}, name string) resource.Quantity {
+	v, ok := requests[name]
+	if !ok {
+		v, ok = limits[name]
+	}
+	if !ok || v == nil {
+		return resource.Quantity{}
+	}
+	q, err := resource.ParseQuantity(fmt.Sprint(v))
+	if err != nil {
+		return resource.Quantity{}
+	}
+	return q
+}
+
+func intField(obj map[string]interface{}, def int64, fields ...string) int64 {
+	v, found, _ := unstructured.NestedFieldNoCopy(obj, fields...)
+	if !found {
+		return def
+	}
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return def
+}
+
+func ready(n corev1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+const workloads = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 3
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          resources:
+            requests:
+              memory: 2Gi
+      containers:
+        - name: api
+          resources:
+            requests:
+              cpu: 250m
+              memory: 256Mi
+        - name: proxy
+          resources:
+            limits:
+              cpu: 100m
+              memory: 64Mi
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          resources:
+            requests:
+              cpu: 50m
+              memory: 32Mi
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: backup
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+`
+
+func TestWorkloads(t *testing.T) {
+	objs, err := render.ParseManifest(workloads, "demo")
+	require.NoError(t, err)
+
+	got := Workloads(objs)
+	require.Len(t, got, 3)
+
+	assert.Equal(t, "api", got[0].Object.Name)
+	assert.Equal(t, int64(3), got[0].Replicas)
+	assert.Equal(t, "0.35 CPU, 2.0Gi memory", got[0].Pod.String(), "the init container's memory exceeds the containers'")
+	assert.Equal(t, "1.05 CPU, 6.0Gi memory", got[0].Total(4).String())
+
+	assert.True(t, got[1].PerNode)
+	assert.Equal(t, "0.2 CPU, 128Mi memory", got[1].Total(4).String())
+
+	assert.Equal(t, "backup", got[2].Object.Name)
+	assert.True(t, got[2].Pod.IsZero())
+}
+
+func TestSchedulableAndAllocatable(t *testing.T) {
+	node := func(name string, ready bool, mutate func(*corev1.Node)) corev1.Node {
+		status := corev1.ConditionTrue
+		if !ready {
+			status = corev1.ConditionFalse
+		}
+		n := corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+		if mutate != nil {
+			mutate(&n)
+		}
+		return n
+	}
+	nodes := []corev1.Node{
+		node("worker-1", true, nil),
+		node("worker-2", true, func(n *corev1.Node) {
+			n.Spec.Taints = []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectPreferNoSchedule}}
+		}),
+		node("control-plane", true, func(n *corev1.Node) {
+			n.Spec.Taints = []corev1.Taint{{Key: "node-role.kubernetes.io/control-plane", Effect: corev1.TaintEffectNoSchedule}}
+		}),
+		node("cordoned", true, func(n *corev1.Node) { n.Spec.Unschedulable = true }),
+		node("broken", false, nil),
+	}
+
+	schedulable := Schedulable(nodes)
+	require.Len(t, schedulable, 2)
+	assert.Equal(t, "worker-1", schedulable[0].Name)
+	assert.Equal(t, "worker-2", schedulable[1].Name)
+	assert.Equal(t, "4 CPU, 16.0Gi memory", Allocatable(schedulable).String())
+
+	pods := []corev1.Pod{
+		{Spec: corev1.PodSpec{NodeName: "worker-1", Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+		}}}},
+		{Spec: corev1.PodSpec{NodeName: "control-plane", Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		}}}},
+		{Spec: corev1.PodSpec{NodeName: "worker-2", Containers: []corev1.Container{{
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		}}}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	}
+	assert.Equal(t, "0.5 CPU, 0Mi memory", ScheduledRequests(pods, schedulable).String())
+
+	problems := NodeProblems(nodes)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "broken", problems[0].Node)
+	assert.False(t, problems[0].Pressure)
+}
@@ -0,0 +1,69 @@
+package preflight
+
+import (
+	"sort"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+// Default StorageClass annotations, current and beta.
+const (
+	DefaultClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// Claim is a PersistentVolumeClaim a rendered object creates, directly or
+// through a StatefulSet volumeClaimTemplate.
+type Claim struct {
+	Object render.Object
+	Name   string
+	// Class is spec.storageClassName; nil uses the default StorageClass and
+	// an empty class binds only to pre-provisioned volumes.
+	Class *string
+}
+
+// Claims returns the claims created by objs.
+func Claims(objs []render.Object) []Claim {
+	var out []Claim
+	for _, obj := range objs {
+		switch obj.Kind {
+		case "PersistentVolumeClaim":
+			out = append(out, Claim{Object: obj, Name: obj.Name, Class: storageClassName(obj.Content)})
+		case "StatefulSet":
+			templates, _, _ := unstructured.NestedSlice(obj.Content, "spec", "volumeClaimTemplates")
+			for _, t := range templates {
+				tmpl, ok := t.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _, _ := unstructured.NestedString(tmpl, "metadata", "name")
+				out = append(out, Claim{Object: obj, Name: name, Class: storageClassName(tmpl)})
+			}
+		}
+	}
+	return out
+}
+
+// DefaultClasses returns the names of the StorageClasses marked default.
+// More than one is allowed by the API; the newest wins.
+func DefaultClasses(classes []storagev1.StorageClass) []string {
+	var out []string
+	for _, c := range classes {
+		if c.Annotations[DefaultClassAnnotation] == "true" || c.Annotations[betaDefaultClassAnnotation] == "true" {
+			out = append(out, c.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func storageClassName(obj map[string]interface{}) *string {
+	name, found, err := unstructured.NestedString(obj, "spec", "storageClassName")
+	if !found || err != nil {
+		return nil
+	}
+	return &name
+}
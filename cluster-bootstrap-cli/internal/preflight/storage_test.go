@@ -0,0 +1,62 @@
+package preflight
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+func TestClaims(t *testing.T) {
+	objs, err := render.ParseManifest(`apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: static
+spec:
+  storageClassName: ""
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  volumeClaimTemplates:
+    - metadata:
+        name: data
+    - metadata:
+        name: wal
+      spec:
+        storageClassName: fast
+`, "demo")
+	require.NoError(t, err)
+
+	claims := Claims(objs)
+	require.Len(t, claims, 3)
+	require.NotNil(t, claims[0].Class)
+	assert.Equal(t, "", *claims[0].Class)
+	assert.Equal(t, "data", claims[1].Name)
+	assert.Nil(t, claims[1].Class)
+	assert.Equal(t, "db", claims[2].Object.Name)
+	assert.Equal(t, "fast", *claims[2].Class)
+}
+
+func TestDefaultClasses(t *testing.T) {
+	class := func(name, annotation string) storagev1.StorageClass {
+		sc := storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if annotation != "" {
+			sc.Annotations = map[string]string{annotation: "true"}
+		}
+		return sc
+	}
+	classes := []storagev1.StorageClass{
+		class("standard", DefaultClassAnnotation),
+		class("fast", ""),
+		class("legacy", "storageclass.beta.kubernetes.io/is-default-class"),
+	}
+	assert.Equal(t, []string{"legacy", "standard"}, DefaultClasses(classes))
+	assert.Empty(t, DefaultClasses(classes[1:2]))
+}
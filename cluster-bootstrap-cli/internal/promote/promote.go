@@ -0,0 +1,325 @@
+// Package promote copies component values and the target revision from one
+// environment to another, editing files in place so comments survive.
+package promote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// Options selects what to promote.
+type Options struct {
+	BaseDir    string
+	From       string
+	To         string
+	Components []string
+	// Keys restricts promotion to these values paths (yamledit.SplitPath
+	// syntax). Empty promotes every value set in the source environment file.
+	Keys []string
+}
+
+// Edit is one value written to the target environment file. From is the
+// value the target environment had before, after merging with base.yaml.
+type Edit struct {
+	Path string
+	From interface{}
+	To   interface{}
+}
+
+// FileChange is the planned update of one component's environment file.
+type FileChange struct {
+	Component string
+	// File is relative to the base directory.
+	File     string
+	Created  bool
+	Edits    []Edit
+	Original []byte
+	Updated  []byte
+	perm     os.FileMode
+}
+
+// Write saves the updated file.
+func (c *FileChange) Write(baseDir string) error {
+	path := filepath.Join(baseDir, c.File)
+	if err := os.WriteFile(path, c.Updated, c.perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Plan computes the value changes needed for each selected component of
+// opts.To to match opts.From. Without keys, every leaf set in the source
+// environment file is copied; keys only present in the target file are kept.
+// With keys, each key's effective source value (base.yaml plus the source
+// file) is copied. Components that are already in sync yield no change.
+func Plan(opts Options) ([]*FileChange, error) {
+	if opts.From == opts.To {
+		return nil, fmt.Errorf("source and target environment are both %s", opts.From)
+	}
+	envs, err := components.Environments(opts.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range []string{opts.From, opts.To} {
+		if !slices.Contains(envs, env) {
+			return nil, components.UnknownEnvironment(opts.BaseDir, env)
+		}
+	}
+
+	keys := make([][]string, 0, len(opts.Keys))
+	for _, k := range opts.Keys {
+		path, err := yamledit.SplitPath(k)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, path)
+	}
+
+	var changes []*FileChange
+	for _, name := range opts.Components {
+		change, err := planComponent(opts, name, keys)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", name, err)
+		}
+		if change != nil {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func planComponent(opts Options, name string, keys [][]string) (*FileChange, error) {
+	valuesDir := filepath.Join(components.ChartDir(opts.BaseDir, name), "values")
+	if _, err := os.Stat(filepath.Join(valuesDir, "base.yaml")); err != nil {
+		if _, dirErr := os.Stat(components.ChartDir(opts.BaseDir, name)); dirErr != nil {
+			return nil, fmt.Errorf("no chart at %s\n  hint: run 'cluster-bootstrap components list' to see known components", components.ChartDir(opts.BaseDir, name))
+		}
+		return nil, fmt.Errorf("no values/base.yaml; components with hasValues: false have nothing to promote")
+	}
+
+	fromVals, err := components.LoadComponentValues(opts.BaseDir, name, opts.From)
+	if err != nil {
+		return nil, err
+	}
+	toVals, err := components.LoadComponentValues(opts.BaseDir, name, opts.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the (path, value) pairs to copy.
+	type leaf struct {
+		path  []string
+		value interface{}
+	}
+	var leaves []leaf
+	if len(keys) > 0 {
+		for _, key := range keys {
+			v, ok := lookup(fromVals, key)
+			if !ok {
+				return nil, fmt.Errorf("%s is not set in %s", yamledit.JoinPath(key...), opts.From)
+			}
+			// Promote mappings leaf by leaf so existing scalars are patched in place.
+			flattenValues(key, v, func(path []string, v interface{}) {
+				leaves = append(leaves, leaf{path, v})
+			})
+		}
+	} else {
+		fromFile := filepath.Join(valuesDir, opts.From+".yaml")
+		doc, err := yamledit.Load(fromFile)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		err = walkLeaves(doc.Root(), nil, func(path []string, node *yaml.Node) error {
+			var v interface{}
+			if err := node.Decode(&v); err != nil {
+				return fmt.Errorf("failed to decode %s in %s: %w", yamledit.JoinPath(path...), fromFile, err)
+			}
+			leaves = append(leaves, leaf{path, v})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	toFile := filepath.Join(valuesDir, opts.To+".yaml")
+	rel, err := filepath.Rel(opts.BaseDir, toFile)
+	if err != nil {
+		rel = toFile
+	}
+	change := &FileChange{Component: name, File: rel, perm: 0644}
+	original, err := os.ReadFile(toFile) // #nosec G304
+	switch {
+	case err == nil:
+		change.Original = original
+		if info, statErr := os.Stat(toFile); statErr == nil {
+			change.perm = info.Mode().Perm()
+		}
+	case os.IsNotExist(err):
+		change.Created = true
+	default:
+		return nil, fmt.Errorf("failed to read %s: %w", toFile, err)
+	}
+
+	doc, err := yamledit.Parse(change.Original)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", toFile, err)
+	}
+	for _, l := range leaves {
+		current, _ := lookup(toVals, l.path)
+		if reflect.DeepEqual(current, l.value) {
+			continue
+		}
+		// An empty mapping in the source adds nothing to an existing mapping.
+		if m, ok := l.value.(map[string]interface{}); ok && len(m) == 0 {
+			if _, isMap := current.(map[string]interface{}); isMap {
+				continue
+			}
+		}
+		if err := doc.Set(l.value, l.path...); err != nil {
+			return nil, fmt.Errorf("failed to set %s in %s: %w", yamledit.JoinPath(l.path...), toFile, err)
+		}
+		change.Edits = append(change.Edits, Edit{Path: yamledit.JoinPath(l.path...), From: current, To: l.value})
+	}
+	if len(change.Edits) == 0 {
+		return nil, nil
+	}
+	change.Updated = doc.Bytes()
+	return change, nil
+}
+
+// walkLeaves calls fn for every non-mapping value under node, in document order.
+// Empty mappings are leaves too.
+func walkLeaves(node *yaml.Node, path []string, fn func([]string, *yaml.Node) error) error {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		if len(path) == 0 {
+			return nil
+		}
+		return fn(path, node)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyPath := append(append([]string{}, path...), node.Content[i].Value)
+		if err := walkLeaves(node.Content[i+1], keyPath, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenValues calls fn for every non-mapping value under v, in key order.
+func flattenValues(path []string, v interface{}, fn func([]string, interface{})) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		fn(path, v)
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		flattenValues(append(append([]string{}, path...), k), m[k], fn)
+	}
+}
+
+func lookup(vals map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = vals
+	for _, key := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// SecretsChange is the planned update of repo.targetRevision in a secrets file.
+type SecretsChange struct {
+	File    string
+	Old     string
+	New     string
+	Updated []byte
+	perm    os.FileMode
+}
+
+// Write saves the updated secrets file.
+func (c *SecretsChange) Write() error {
+	if err := os.WriteFile(c.File, c.Updated, c.perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", c.File, err)
+	}
+	return nil
+}
+
+// PlanTargetRevision sets repo.targetRevision in a secrets file. SOPS files
+// are decrypted and re-encrypted in memory using the .sops.yaml rule for the
+// file, so plaintext never touches the disk; git-crypt files are edited as
+// they are. It returns nil when the revision is already set.
+func PlanTargetRevision(path, encryption, revision string, sopsOpts *sops.Options) (*SecretsChange, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("secrets file %s not found: %w", path, err)
+	}
+
+	var plaintext []byte
+	switch encryption {
+	case "sops":
+		plaintext, err = sops.Decrypt(path, sopsOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+	case "git-crypt":
+		plaintext, err = config.ReadSecretsPlaintext(path)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", encryption)
+	}
+
+	doc, err := yamledit.Parse(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	change := &SecretsChange{File: path, New: revision, perm: info.Mode().Perm()}
+	if node := doc.Lookup("repo", "targetRevision"); node != nil {
+		change.Old = node.Value
+	}
+	if change.Old == revision {
+		return nil, nil
+	}
+	if err := doc.Set(revision, "repo", "targetRevision"); err != nil {
+		return nil, fmt.Errorf("failed to set repo.targetRevision in %s: %w", path, err)
+	}
+
+	change.Updated = doc.Bytes()
+	if encryption == "sops" {
+		change.Updated, err = sops.EncryptData(change.Updated, path, sopsOpts)
+		if err != nil {
+			return nil, fmt.Errorf("%w\n  hint: re-encryption uses the .sops.yaml creation rule matching %s", err, filepath.Base(path))
+		}
+	}
+	if !bytes.HasSuffix(change.Updated, []byte("\n")) {
+		change.Updated = append(change.Updated, '\n')
+	}
+	return change, nil
+}
@@ -0,0 +1,146 @@
+package promote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func writeTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, env := range []string{"dev", "staging", "prod"} {
+		writeFile(t, filepath.Join(dir, "apps/values", env+".yaml"), "environment: "+env+"\n")
+	}
+	writeFile(t, filepath.Join(dir, "components/vault/values/base.yaml"), `vault:
+  server:
+    replicas: 1
+    image: vault:1.17
+`)
+	writeFile(t, filepath.Join(dir, "components/vault/values/staging.yaml"), `vault:
+  server:
+    replicas: 3 # HA
+    image: vault:1.18
+  ui:
+    enabled: true
+`)
+	writeFile(t, filepath.Join(dir, "components/vault/values/prod.yaml"), `# Production overrides
+vault:
+  server:
+    replicas: 5 # keep odd for raft
+    image: vault:1.17
+
+  extra: kept
+`)
+	writeFile(t, filepath.Join(dir, "components/crds/Chart.yaml"), "apiVersion: v2\nname: crds\nversion: 1.0.0\n")
+	return dir
+}
+
+func TestPlan_AllValues(t *testing.T) {
+	dir := writeTree(t)
+
+	changes, err := Plan(Options{BaseDir: dir, From: "staging", To: "prod", Components: []string{"vault"}})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	change := changes[0]
+	assert.Equal(t, filepath.Join("components", "vault", "values", "prod.yaml"), change.File)
+	assert.Equal(t, []Edit{
+		{Path: "vault.server.replicas", From: 5, To: 3},
+		{Path: "vault.server.image", From: "vault:1.17", To: "vault:1.18"},
+		{Path: "vault.ui.enabled", From: nil, To: true},
+	}, change.Edits)
+
+	out := string(change.Updated)
+	assert.Contains(t, out, "# Production overrides")
+	assert.Contains(t, out, "replicas: 3 # keep odd for raft")
+	assert.Contains(t, out, "extra: kept", "keys only in the target are kept")
+
+	require.NoError(t, change.Write(dir))
+	again, err := Plan(Options{BaseDir: dir, From: "staging", To: "prod", Components: []string{"vault"}})
+	require.NoError(t, err)
+	assert.Empty(t, again, "promoting twice is a no-op")
+}
+
+func TestPlan_Keys(t *testing.T) {
+	dir := writeTree(t)
+
+	changes, err := Plan(Options{BaseDir: dir, From: "prod", To: "dev", Components: []string{"vault"}, Keys: []string{"vault.server"}})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].Created)
+	assert.Equal(t, []Edit{{Path: "vault.server.replicas", From: 1, To: 5}}, changes[0].Edits, "image matches base in both environments")
+	assert.Equal(t, "vault:\n  server:\n    replicas: 5\n", string(changes[0].Updated))
+
+	_, err = Plan(Options{BaseDir: dir, From: "prod", To: "dev", Components: []string{"vault"}, Keys: []string{"vault.missing"}})
+	assert.ErrorContains(t, err, "vault.missing is not set in prod")
+}
+
+func TestPlan_Errors(t *testing.T) {
+	dir := writeTree(t)
+
+	_, err := Plan(Options{BaseDir: dir, From: "dev", To: "dev", Components: []string{"vault"}})
+	assert.ErrorContains(t, err, "both dev")
+
+	_, err = Plan(Options{BaseDir: dir, From: "dev", To: "qa", Components: []string{"vault"}})
+	assert.ErrorContains(t, err, "unknown environment qa")
+
+	_, err = Plan(Options{BaseDir: dir, From: "dev", To: "prod", Components: []string{"crds"}})
+	assert.ErrorContains(t, err, "hasValues: false")
+
+	_, err = Plan(Options{BaseDir: dir, From: "dev", To: "prod", Components: []string{"nope"}})
+	assert.ErrorContains(t, err, "no chart")
+}
+
+func TestPlanTargetRevision_GitCrypt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.SecretsFileNamePlain("prod"))
+	writeFile(t, path, "repo:\n  url: git@github.com:example/platform.git\n  targetRevision: main # tracked branch\n")
+
+	change, err := PlanTargetRevision(path, "git-crypt", "v1.4.0", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "main", change.Old)
+	assert.Contains(t, string(change.Updated), "targetRevision: v1.4.0 # tracked branch")
+
+	require.NoError(t, change.Write())
+	change, err = PlanTargetRevision(path, "git-crypt", "v1.4.0", nil)
+	require.NoError(t, err)
+	assert.Nil(t, change)
+}
+
+func TestPlanTargetRevision_SOPS(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	writeFile(t, keyFile, identity.String()+"\n")
+	writeFile(t, filepath.Join(dir, ".sops.yaml"), "creation_rules:\n  - path_regex: secrets\\.prod\\.enc\\.yaml$\n    age: "+identity.Recipient().String()+"\n")
+	opts := &sops.Options{AgeKeyFile: keyFile}
+
+	path := filepath.Join(dir, config.SecretsFileName("prod"))
+	encrypted, err := sops.EncryptData([]byte("repo:\n  url: git@github.com:example/platform.git\n  targetRevision: main\n  sshPrivateKey: secret\n"), path, opts)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, encrypted, 0600))
+
+	change, err := PlanTargetRevision(path, "sops", "v2.0.0", opts)
+	require.NoError(t, err)
+	assert.NotContains(t, string(change.Updated), "v2.0.0", "the written file stays encrypted")
+	require.NoError(t, change.Write())
+
+	secrets, err := config.LoadSecrets(path, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", secrets.Repo.TargetRevision)
+	assert.Equal(t, "secret", secrets.Repo.SSHPrivateKey)
+}
@@ -0,0 +1,313 @@
+// Package rbac works out the permissions a bootstrap needs and checks them
+// with SelfSubjectAccessReviews before anything is changed.
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+// reviewParallelism bounds the SelfSubjectAccessReviews in flight.
+const reviewParallelism = 8
+
+// Requirement is a set of verbs the run needs on a resource.
+type Requirement struct {
+	Group    string
+	Resource string
+	// Namespace is empty for cluster-scoped resources.
+	Namespace string
+	Verbs     []string
+	// Reasons say what needs the permission, e.g. "ArgoCD chart".
+	Reasons []string
+}
+
+type key struct {
+	group, resource, namespace string
+}
+
+// Set collects requirements, merging verbs and reasons per resource and
+// namespace.
+type Set struct {
+	reqs map[key]*Requirement
+}
+
+// NewSet returns an empty set.
+func NewSet() *Set {
+	return &Set{reqs: make(map[key]*Requirement)}
+}
+
+// Add adds verbs on a resource in namespace, or cluster-wide when namespace
+// is empty.
+func (s *Set) Add(group, resource, namespace, reason string, verbs ...string) {
+	k := key{group, resource, namespace}
+	req, ok := s.reqs[k]
+	if !ok {
+		req = &Requirement{Group: group, Resource: resource, Namespace: namespace}
+		s.reqs[k] = req
+	}
+	for _, v := range verbs {
+		if !slices.Contains(req.Verbs, v) {
+			req.Verbs = append(req.Verbs, v)
+		}
+	}
+	if reason != "" && !slices.Contains(req.Reasons, reason) {
+		req.Reasons = append(req.Reasons, reason)
+	}
+}
+
+// AddObjects adds verbs on the resources of objs, resolving kinds with
+// mapper. Namespaced objects without a namespace are placed in
+// defaultNamespace. Kinds the mapper does not know, such as custom
+// resources whose CRD is installed alongside them, fall back to the
+// lowercase plural of the kind.
+func (s *Set) AddObjects(mapper meta.RESTMapper, objs []render.Object, defaultNamespace, reason string, verbs ...string) {
+	for _, obj := range objs {
+		gv, err := schema.ParseGroupVersion(obj.APIVersion)
+		if err != nil || obj.Kind == "" {
+			continue
+		}
+		resource := guessResource(obj.Kind)
+		namespaced := !render.IsClusterScoped(obj.Kind)
+		if mapper != nil {
+			if mapping, err := mapper.RESTMapping(gv.WithKind(obj.Kind).GroupKind(), gv.Version); err == nil {
+				resource = mapping.Resource.Resource
+				namespaced = mapping.Scope.Name() == meta.RESTScopeNameNamespace
+			}
+		}
+		namespace := ""
+		if namespaced {
+			namespace = obj.Namespace
+			if namespace == "" {
+				namespace = defaultNamespace
+			}
+		}
+		s.Add(gv.Group, resource, namespace, reason, verbs...)
+	}
+}
+
+// Requirements returns the requirements sorted by group, resource and
+// namespace.
+func (s *Set) Requirements() []Requirement {
+	out := make([]Requirement, 0, len(s.reqs))
+	for _, req := range s.reqs {
+		out = append(out, *req)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		return a.Namespace < b.Namespace
+	})
+	return out
+}
+
+// Denial is a verb a SelfSubjectAccessReview denied.
+type Denial struct {
+	Group     string
+	Resource  string
+	Namespace string
+	Verb      string
+	Reasons   []string
+}
+
+// Review asks the API server whether the current user may perform every
+// verb of reqs. It returns the denied verbs in the order of reqs and the
+// number of verbs reviewed.
+func Review(ctx context.Context, clientset kubernetes.Interface, reqs []Requirement) (denials []Denial, reviewed int, err error) {
+	type job struct {
+		req  Requirement
+		verb string
+	}
+	var jobs []job
+	for _, req := range reqs {
+		for _, verb := range req.Verbs {
+			jobs = append(jobs, job{req, verb})
+		}
+	}
+
+	allowed := make([]bool, len(jobs))
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, reviewParallelism)
+	for i, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			review := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: j.req.Namespace,
+						Verb:      j.verb,
+						Group:     j.req.Group,
+						Resource:  j.req.Resource,
+					},
+				},
+			}
+			res, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to review %s %s: %w", j.verb, qualifiedResource(j.req.Group, j.req.Resource), err)
+				return
+			}
+			allowed[i] = res.Status.Allowed
+		}()
+	}
+	wg.Wait()
+
+	for i, j := range jobs {
+		if errs[i] != nil {
+			return nil, 0, errs[i]
+		}
+		if !allowed[i] {
+			denials = append(denials, Denial{
+				Group:     j.req.Group,
+				Resource:  j.req.Resource,
+				Namespace: j.req.Namespace,
+				Verb:      j.verb,
+				Reasons:   j.req.Reasons,
+			})
+		}
+	}
+	return denials, len(jobs), nil
+}
+
+// Table returns denials as aligned lines, one resource and namespace per
+// line with the denied verbs.
+func Table(denials []Denial) []string {
+	type row struct {
+		resource, namespace, reasons string
+		verbs                        []string
+	}
+	var rows []*row
+	index := make(map[key]*row)
+	for _, d := range denials {
+		k := key{d.Group, d.Resource, d.Namespace}
+		r, ok := index[k]
+		if !ok {
+			ns := d.Namespace
+			if ns == "" {
+				ns = "(cluster)"
+			}
+			r = &row{resource: qualifiedResource(d.Group, d.Resource), namespace: ns, reasons: strings.Join(d.Reasons, ", ")}
+			index[k] = r
+			rows = append(rows, r)
+		}
+		r.verbs = append(r.verbs, d.Verb)
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RESOURCE\tNAMESPACE\tVERBS\tNEEDED FOR")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.resource, r.namespace, strings.Join(r.verbs, ","), r.reasons)
+	}
+	_ = w.Flush()
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+type clusterRole struct {
+	APIVersion string       `yaml:"apiVersion"`
+	Kind       string       `yaml:"kind"`
+	Metadata   roleMetadata `yaml:"metadata"`
+	Rules      []policyRule `yaml:"rules"`
+}
+
+type roleMetadata struct {
+	Name string `yaml:"name"`
+}
+
+type policyRule struct {
+	APIGroups []string `yaml:"apiGroups,flow"`
+	Resources []string `yaml:"resources,flow"`
+	Verbs     []string `yaml:"verbs,flow"`
+}
+
+// ClusterRole returns a ClusterRole named name that grants denials, as
+// YAML. Resources of one API group that need the same verbs share a rule.
+// Namespaced permissions are granted in every namespace the role is bound
+// in; bind it with RoleBindings to keep them to those namespaces.
+func ClusterRole(name string, denials []Denial) (string, error) {
+	type gr struct{ group, resource string }
+	verbs := make(map[gr][]string)
+	var order []gr
+	for _, d := range denials {
+		k := gr{d.Group, d.Resource}
+		if _, ok := verbs[k]; !ok {
+			order = append(order, k)
+		}
+		if !slices.Contains(verbs[k], d.Verb) {
+			verbs[k] = append(verbs[k], d.Verb)
+		}
+	}
+
+	var rules []policyRule
+	byVerbs := make(map[string]int)
+	for _, k := range order {
+		v := slices.Clone(verbs[k])
+		sort.Strings(v)
+		id := k.group + "|" + strings.Join(v, ",")
+		if i, ok := byVerbs[id]; ok {
+			rules[i].Resources = append(rules[i].Resources, k.resource)
+			continue
+		}
+		byVerbs[id] = len(rules)
+		rules = append(rules, policyRule{APIGroups: []string{k.group}, Resources: []string{k.resource}, Verbs: v})
+	}
+	for i := range rules {
+		sort.Strings(rules[i].Resources)
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].APIGroups[0] < rules[j].APIGroups[0] })
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(clusterRole{
+		APIVersion: "rbac.authorization.k8s.io/v1",
+		Kind:       "ClusterRole",
+		Metadata:   roleMetadata{Name: name},
+		Rules:      rules,
+	}); err != nil {
+		return "", fmt.Errorf("failed to encode ClusterRole: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func qualifiedResource(group, resource string) string {
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
+
+// guessResource returns the lowercase plural of kind, as the API server
+// names resources unless a CRD says otherwise.
+func guessResource(kind string) string {
+	r := strings.ToLower(kind)
+	switch {
+	case strings.HasSuffix(r, "s"), strings.HasSuffix(r, "x"), strings.HasSuffix(r, "ch"), strings.HasSuffix(r, "sh"):
+		return r + "es"
+	case strings.HasSuffix(r, "y") && len(r) > 1 && !strings.ContainsRune("aeiou", rune(r[len(r)-2])):
+		return r[:len(r)-1] + "ies"
+	}
+	return r + "s"
+}
@@ -0,0 +1,129 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/render"
+)
+
+const chart = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: argocd-server
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: argocd-server
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: applications.argoproj.io
+---
+apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: default
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: argocd-server
+  namespace: argocd
+`
+
+func TestSetAddObjects(t *testing.T) {
+	objs, err := render.ParseManifest(chart, "argocd")
+	require.NoError(t, err)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, meta.RESTScopeNamespace)
+	mapper.AddSpecific(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"},
+		schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+		schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicy"},
+		meta.RESTScopeNamespace)
+
+	set := NewSet()
+	set.Add("", "serviceaccounts", "argocd", "service account", "get")
+	set.AddObjects(mapper, objs, "argocd", "ArgoCD chart", "get", "create")
+
+	var got []string
+	for _, req := range set.Requirements() {
+		got = append(got, req.Group+"/"+req.Resource+"@"+req.Namespace)
+	}
+	assert.Equal(t, []string{
+		"/serviceaccounts@argocd",
+		"apiextensions.k8s.io/customresourcedefinitions@",
+		"argoproj.io/appprojects@argocd",
+		"networking.k8s.io/networkpolicies@argocd",
+		"rbac.authorization.k8s.io/clusterroles@",
+	}, got)
+
+	sa := set.Requirements()[0]
+	assert.Equal(t, []string{"get", "create"}, sa.Verbs)
+	assert.Equal(t, []string{"service account", "ArgoCD chart"}, sa.Reasons)
+}
+
+func TestGuessResource(t *testing.T) {
+	for kind, want := range map[string]string{
+		"AppProject":    "appprojects",
+		"Ingress":       "ingresses",
+		"NetworkPolicy": "networkpolicies",
+		"Gateway":       "gateways",
+		"Prefix":        "prefixes",
+	} {
+		assert.Equal(t, want, guessResource(kind), kind)
+	}
+}
+
+func TestReview(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = attrs.Resource != "customresourcedefinitions" && !(attrs.Resource == "secrets" && attrs.Verb != "get")
+		return true, review, nil
+	})
+
+	set := NewSet()
+	set.Add("", "secrets", "argocd", "repository credentials", "get", "create", "update")
+	set.Add("apiextensions.k8s.io", "customresourcedefinitions", "", "ArgoCD chart", "get", "create")
+	set.Add("", "namespaces", "", "argocd namespace", "get", "create")
+
+	denials, reviewed, err := Review(context.Background(), clientset, set.Requirements())
+	require.NoError(t, err)
+	assert.Equal(t, 7, reviewed)
+	require.Len(t, denials, 4)
+
+	assert.Equal(t, []string{
+		"RESOURCE                                        NAMESPACE  VERBS          NEEDED FOR",
+		"secrets                                         argocd     create,update  repository credentials",
+		"customresourcedefinitions.apiextensions.k8s.io  (cluster)  get,create     ArgoCD chart",
+	}, Table(denials))
+
+	role, err := ClusterRole("cluster-bootstrap-dev", denials)
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: cluster-bootstrap-dev
+rules:
+  - apiGroups: [""]
+    resources: [secrets]
+    verbs: [create, update]
+  - apiGroups: [apiextensions.k8s.io]
+    resources: [customresourcedefinitions]
+    verbs: [create, get]
+`, role)
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// Object is a single resource from a rendered manifest.
+type Object struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	// Namespace is metadata.namespace, or the release namespace for
+	// namespaced kinds that do not set one. Empty for cluster-scoped kinds.
+	Namespace string
+	// Source is the chart template that produced the object, taken from
+	// the "# Source:" comment Helm adds to every document.
+	Source string
+	// Content is the decoded object.
+	Content map[string]interface{}
+	// YAML is the document as rendered, including the Source comment.
+	YAML string
+}
+
+// ID returns a stable identifier for the object: group/kind/namespace/name.
+func (o Object) ID() string {
+	group := ""
+	if idx := strings.LastIndex(o.APIVersion, "/"); idx >= 0 {
+		group = o.APIVersion[:idx]
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", group, o.Kind, o.Namespace, o.Name)
+}
+
+// clusterScopedKinds lists common kinds that are not namespaced. Without
+// cluster discovery this is the best guess available for rendered output.
+var clusterScopedKinds = map[string]bool{
+	"APIService":                     true,
+	"CSIDriver":                      true,
+	"ClusterIssuer":                  true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"ClusterSecretStore":             true,
+	"ClusterExternalSecret":          true,
+	"CustomResourceDefinition":       true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"PersistentVolume":               true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"StorageClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+	"ValidatingAdmissionPolicy":      true,
+}
+
+// IsClusterScoped reports whether kind is a known cluster-scoped kind.
+func IsClusterScoped(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// ParseManifest splits a rendered manifest into objects, in the order Helm
+// rendered them. Empty documents (templates that render nothing) are skipped.
+func ParseManifest(manifest, defaultNamespace string) ([]Object, error) {
+	docs := releaseutil.SplitManifests(manifest)
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+
+	var objects []Object
+	for _, key := range keys {
+		doc := docs[key]
+		var content map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &content); err != nil {
+			return nil, fmt.Errorf("failed to parse rendered document %s: %w", sourceComment(doc), err)
+		}
+		if len(content) == 0 {
+			continue
+		}
+
+		obj := Object{Source: sourceComment(doc), Content: content, YAML: strings.TrimSpace(doc) + "\n"}
+		obj.APIVersion, _ = content["apiVersion"].(string)
+		obj.Kind, _ = content["kind"].(string)
+		if meta, ok := content["metadata"].(map[string]interface{}); ok {
+			obj.Name, _ = meta["name"].(string)
+			obj.Namespace, _ = meta["namespace"].(string)
+		}
+		if obj.Namespace == "" && !IsClusterScoped(obj.Kind) {
+			obj.Namespace = defaultNamespace
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+func sourceComment(doc string) string {
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# Source: ") {
+			return strings.TrimPrefix(line, "# Source: ")
+		}
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+	}
+	return ""
+}
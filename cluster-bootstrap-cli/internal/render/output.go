@@ -0,0 +1,131 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IndexFile is the name of the manifest index written next to the rendered files.
+const IndexFile = "index.yaml"
+
+// IndexEntry summarises one rendered file.
+type IndexEntry struct {
+	Name       string         `yaml:"name" json:"name"`
+	File       string         `yaml:"file" json:"file"`
+	Chart      string         `yaml:"chart" json:"chart"`
+	Namespace  string         `yaml:"namespace" json:"namespace"`
+	SyncWave   string         `yaml:"syncWave,omitempty" json:"syncWave,omitempty"`
+	ValueFiles []string       `yaml:"valueFiles,omitempty" json:"valueFiles,omitempty"`
+	Resources  int            `yaml:"resources" json:"resources"`
+	Kinds      map[string]int `yaml:"kinds" json:"kinds"`
+	Namespaces []string       `yaml:"namespaces" json:"namespaces"`
+}
+
+// Index lists every file of a render, the app of apps first and then
+// components in sync-wave order.
+type Index struct {
+	Environment string       `yaml:"environment" json:"environment"`
+	Files       []IndexEntry `yaml:"files" json:"files"`
+}
+
+// BuildIndex summarises a render result.
+func BuildIndex(result *Result) *Index {
+	index := &Index{Environment: result.Environment}
+	index.Files = append(index.Files, indexEntry(result.AppOfApps))
+	for _, comp := range result.Components {
+		index.Files = append(index.Files, indexEntry(comp))
+	}
+	return index
+}
+
+func indexEntry(comp Component) IndexEntry {
+	entry := IndexEntry{
+		Name:       comp.Name,
+		File:       comp.Name + ".yaml",
+		Chart:      filepath.ToSlash(comp.ChartDir),
+		Namespace:  comp.Namespace,
+		SyncWave:   comp.SyncWave,
+		ValueFiles: comp.ValueFiles,
+		Resources:  len(comp.Objects),
+		Kinds:      map[string]int{},
+		Namespaces: []string{},
+	}
+	seen := map[string]bool{}
+	for _, obj := range comp.Objects {
+		entry.Kinds[obj.Kind]++
+		if obj.Namespace != "" && !seen[obj.Namespace] {
+			seen[obj.Namespace] = true
+			entry.Namespaces = append(entry.Namespaces, obj.Namespace)
+		}
+	}
+	sort.Strings(entry.Namespaces)
+	return entry
+}
+
+// Write stores one file per rendered chart plus index.yaml in outDir and
+// returns the paths written. Files listed in a previous index.yaml in outDir
+// are removed first, so disabled components do not linger.
+func Write(result *Result, outDir string) ([]string, error) {
+	if err := os.MkdirAll(outDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+	if err := removePreviousRender(outDir); err != nil {
+		return nil, err
+	}
+
+	index := BuildIndex(result)
+	comps := append([]Component{result.AppOfApps}, result.Components...)
+
+	var written []string
+	for i, comp := range comps {
+		path := filepath.Join(outDir, index.Files[i].File)
+		if err := os.WriteFile(path, []byte(comp.Manifest), 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(index); err != nil {
+		return nil, fmt.Errorf("failed to encode index: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode index: %w", err)
+	}
+	indexPath := filepath.Join(outDir, IndexFile)
+	if err := os.WriteFile(indexPath, buf.Bytes(), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", indexPath, err)
+	}
+	return append(written, indexPath), nil
+}
+
+func removePreviousRender(outDir string) error {
+	data, err := os.ReadFile(filepath.Join(outDir, IndexFile)) // #nosec G304
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read previous index: %w", err)
+	}
+	var previous Index
+	if err := yaml.Unmarshal(data, &previous); err != nil {
+		return fmt.Errorf("%s in %s is not a render index: %w\n  hint: choose an empty --out directory", IndexFile, outDir, err)
+	}
+	for _, f := range previous.Files {
+		// Only remove plain file names; never follow paths out of outDir.
+		if f.File == "" || filepath.Base(f.File) != f.File {
+			continue
+		}
+		if err := os.Remove(filepath.Join(outDir, f.File)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale %s: %w", f.File, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/components"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/helm"
+)
+
+const (
+	// AppOfAppsRelease is the name of the root Application created by bootstrap.
+	AppOfAppsRelease = "app-of-apps"
+	appOfAppsNS      = "argocd"
+	appsChartDir     = "apps"
+)
+
+// Options controls how an environment is rendered.
+type Options struct {
+	BaseDir string
+	Env     string
+	// KubeVersion overrides the Kubernetes version charts are rendered for (e.g. "v1.30.0").
+	KubeVersion string
+	// Only restricts component rendering to these names; empty renders all enabled components.
+	Only    []string
+	Verbose bool
+}
+
+// Application is the subset of a rendered ArgoCD Application needed to render its source.
+type Application struct {
+	Name           string
+	Namespace      string
+	SyncWave       string
+	RepoURL        string
+	TargetRevision string
+	// Path is spec.source.path as ArgoCD sees it, relative to the repository root.
+	Path string
+	// ValueFiles are spec.source.helm.valueFiles, relative to Path.
+	ValueFiles []string
+}
+
+// Component is the rendered output of one component chart.
+type Component struct {
+	Application
+	// ChartDir is the local chart directory, relative to the base directory.
+	ChartDir string
+	Manifest string
+	Objects  []Object
+}
+
+// Result is the rendered GitOps tree for one environment.
+type Result struct {
+	Environment string
+	// AppOfApps is the rendered apps chart: one Application per enabled component.
+	AppOfApps    Component
+	Components   []Component
+	Applications []Application
+}
+
+// Environment renders the apps chart for opts.Env and then every Application
+// it produces, using the same chart path and value files ArgoCD would.
+func Environment(opts Options) (*Result, error) {
+	catalog, err := components.Load(opts.BaseDir, opts.Env)
+	if err != nil {
+		return nil, err
+	}
+	settings := cli.New()
+	result, err := renderApps(settings, opts, catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range opts.Only {
+		found := false
+		for _, app := range result.Applications {
+			if app.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("component %s is not enabled in %s", name, opts.Env)
+		}
+		selected[name] = true
+	}
+
+	for _, app := range result.Applications {
+		if len(selected) > 0 && !selected[app.Name] {
+			continue
+		}
+		comp, err := renderApplication(settings, opts, catalog.Repo.BasePath, app)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", app.Name, err)
+		}
+		result.Components = append(result.Components, *comp)
+	}
+	return result, nil
+}
+
+// Applications renders only the apps chart for opts.Env: the Applications
+// ArgoCD is expected to create, in sync-wave order. Components are not rendered.
+func Applications(opts Options) (*Result, error) {
+	catalog, err := components.Load(opts.BaseDir, opts.Env)
+	if err != nil {
+		return nil, err
+	}
+	return renderApps(cli.New(), opts, catalog)
+}
+
+func renderApps(settings *cli.EnvSettings, opts Options, catalog *components.Catalog) (*Result, error) {
+	appsDir := filepath.Join(opts.BaseDir, appsChartDir)
+	appsChart, err := helm.LoadChartWithDependencies(settings, appsDir, opts.Verbose)
+	if err != nil {
+		return nil, err
+	}
+	envVals, err := readValueFiles(filepath.Join(opts.BaseDir, appsChartDir), []string{path.Join("values", opts.Env+".yaml")}, true)
+	if err != nil {
+		return nil, err
+	}
+	appsManifest, err := helm.RenderChart(appsChart, AppOfAppsRelease, appOfAppsNS, envVals, opts.KubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	appsObjects, err := ParseManifest(appsManifest, appOfAppsNS)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Environment: opts.Env,
+		AppOfApps: Component{
+			Application: Application{Name: AppOfAppsRelease, Namespace: appOfAppsNS, Path: appsChartDir},
+			ChartDir:    appsChartDir,
+			Manifest:    appsManifest,
+			Objects:     appsObjects,
+		},
+	}
+
+	for _, obj := range appsObjects {
+		if obj.Kind != "Application" {
+			continue
+		}
+		result.Applications = append(result.Applications, ApplicationFromObject(obj))
+	}
+	// The apps template ranges over the components map alphabetically; order
+	// by sync wave instead, as the catalog does.
+	order := make(map[string]int)
+	for i, name := range catalog.Names() {
+		order[name] = i
+	}
+	sort.SliceStable(result.Applications, func(i, j int) bool {
+		return order[result.Applications[i].Name] < order[result.Applications[j].Name]
+	})
+	return result, nil
+}
+
+func renderApplication(settings *cli.EnvSettings, opts Options, basePath string, app Application) (*Component, error) {
+	chartDir, err := localChartDir(basePath, app.Path)
+	if err != nil {
+		return nil, err
+	}
+	localDir := filepath.Join(opts.BaseDir, chartDir)
+
+	if opts.Verbose {
+		fmt.Printf("  Rendering %s from %s (values: %s)\n", app.Name, chartDir, strings.Join(app.ValueFiles, ", "))
+	}
+
+	ch, err := helm.LoadChartWithDependencies(settings, localDir, opts.Verbose)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := readValueFiles(localDir, app.ValueFiles, false)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := helm.RenderChart(ch, app.Name, app.Namespace, vals, opts.KubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := ParseManifest(manifest, app.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &Component{Application: app, ChartDir: chartDir, Manifest: manifest, Objects: objects}, nil
+}
+
+// localChartDir maps an Application source path to a directory relative to the
+// base directory by removing the repo's basePath prefix.
+func localChartDir(basePath, sourcePath string) (string, error) {
+	basePath = strings.Trim(basePath, "/")
+	sourcePath = strings.Trim(sourcePath, "/")
+	if basePath == "" {
+		return filepath.FromSlash(sourcePath), nil
+	}
+	rel := strings.TrimPrefix(sourcePath, basePath+"/")
+	if rel == sourcePath {
+		return "", fmt.Errorf("source path %s is outside repo.basePath %s", sourcePath, basePath)
+	}
+	return filepath.FromSlash(rel), nil
+}
+
+// readValueFiles reads and merges value files relative to dir; later files
+// take precedence, as with helm -f. Missing files are an error unless optional.
+func readValueFiles(dir string, files []string, optional bool) (map[string]interface{}, error) {
+	merged := map[string]interface{}{}
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		data, err := os.ReadFile(p) // #nosec G304
+		if err != nil {
+			if os.IsNotExist(err) && optional {
+				continue
+			}
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("value file %s not found\n  hint: ArgoCD fails to sync when a listed value file is missing; create it or set hasValues: false", p)
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		vals, err := chartutil.ReadValues(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+		merged = components.MergeValues(merged, vals.AsMap())
+	}
+	return merged, nil
+}
+
+// ApplicationFromObject extracts the source and destination of a rendered ArgoCD Application.
+func ApplicationFromObject(obj Object) Application {
+	app := Application{Name: obj.Name}
+	if meta, ok := obj.Content["metadata"].(map[string]interface{}); ok {
+		if ann, ok := meta["annotations"].(map[string]interface{}); ok {
+			app.SyncWave, _ = ann["argocd.argoproj.io/sync-wave"].(string)
+		}
+	}
+	spec, _ := obj.Content["spec"].(map[string]interface{})
+	if dest, ok := spec["destination"].(map[string]interface{}); ok {
+		app.Namespace, _ = dest["namespace"].(string)
+	}
+	if src, ok := spec["source"].(map[string]interface{}); ok {
+		app.RepoURL, _ = src["repoURL"].(string)
+		if rev, ok := src["targetRevision"]; ok && rev != nil {
+			app.TargetRevision = fmt.Sprint(rev)
+		}
+		app.Path, _ = src["path"].(string)
+		if h, ok := src["helm"].(map[string]interface{}); ok {
+			if files, ok := h["valueFiles"].([]interface{}); ok {
+				for _, f := range files {
+					app.ValueFiles = append(app.ValueFiles, fmt.Sprint(f))
+				}
+			}
+		}
+	}
+	return app
+}
@@ -0,0 +1,186 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+// writeTree creates a minimal GitOps tree that uses the repository's real
+// apps/templates/application.yaml, so the test follows the actual wiring.
+func writeTree(t *testing.T, basePath string) string {
+	t.Helper()
+	tmpl, err := os.ReadFile("../../../apps/templates/application.yaml")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "apps/Chart.yaml"), "apiVersion: v2\nname: apps\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "apps/templates/application.yaml"), string(tmpl))
+	writeFile(t, filepath.Join(dir, "apps/values.yaml"), `environment: dev
+repo:
+  url: git@github.com:example/platform.git
+  targetRevision: main
+  basePath: "`+basePath+`"
+components:
+  web:
+    enabled: true
+    namespace: web
+    syncWave: "3"
+  crds:
+    enabled: true
+    namespace: kube-system
+    syncWave: "1"
+    hasValues: false
+  disabled:
+    enabled: false
+    namespace: off
+    syncWave: "0"
+`)
+	writeFile(t, filepath.Join(dir, "apps/values/dev.yaml"), "environment: dev\n")
+
+	writeFile(t, filepath.Join(dir, "components/web/Chart.yaml"), "apiVersion: v2\nname: web\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "components/web/values/base.yaml"), "replicas: 1\nimage: nginx:1.27\n")
+	writeFile(t, filepath.Join(dir, "components/web/values/dev.yaml"), "replicas: 2\n")
+	writeFile(t, filepath.Join(dir, "components/web/templates/deploy.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  replicas: {{ .Values.replicas }}
+  template:
+    spec:
+      containers:
+        - name: web
+          image: {{ .Values.image }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+`)
+	writeFile(t, filepath.Join(dir, "components/crds/Chart.yaml"), "apiVersion: v2\nname: crds\nversion: 1.0.0\n")
+	writeFile(t, filepath.Join(dir, "components/crds/templates/crd.yaml"), `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`)
+	return dir
+}
+
+func TestEnvironment(t *testing.T) {
+	dir := writeTree(t, "")
+
+	result, err := Environment(Options{BaseDir: dir, Env: "dev"})
+	require.NoError(t, err)
+
+	require.Len(t, result.Applications, 2)
+	assert.Equal(t, "crds", result.Applications[0].Name, "applications are ordered by sync wave")
+	assert.Empty(t, result.Applications[0].ValueFiles, "hasValues: false drops value files")
+	assert.Equal(t, []string{"values/base.yaml", "values/dev.yaml"}, result.Applications[1].ValueFiles)
+
+	require.Len(t, result.Components, 2)
+	web := result.Components[1]
+	assert.Equal(t, filepath.Join("components", "web"), web.ChartDir)
+	require.Len(t, web.Objects, 2)
+	// Helm orders output by install order: Service before Deployment.
+	deploy := web.Objects[1]
+	assert.Equal(t, "Deployment", deploy.Kind)
+	assert.Equal(t, "web", deploy.Namespace, "release namespace is the default")
+	assert.Equal(t, "web/templates/deploy.yaml", deploy.Source)
+	assert.Contains(t, web.Manifest, "replicas: 2", "env values override base values")
+
+	crds := result.Components[0]
+	require.Len(t, crds.Objects, 1)
+	assert.Empty(t, crds.Objects[0].Namespace, "cluster-scoped kinds get no namespace")
+}
+
+func TestEnvironment_BasePath(t *testing.T) {
+	dir := writeTree(t, "k8s")
+
+	result, err := Environment(Options{BaseDir: dir, Env: "dev", Only: []string{"web"}})
+	require.NoError(t, err)
+	require.Len(t, result.Components, 1)
+	assert.Equal(t, "k8s/components/web", result.Components[0].Path)
+	assert.Equal(t, filepath.Join("components", "web"), result.Components[0].ChartDir)
+}
+
+func TestEnvironment_MissingValueFile(t *testing.T) {
+	dir := writeTree(t, "")
+	require.NoError(t, os.Remove(filepath.Join(dir, "components/web/values/dev.yaml")))
+
+	_, err := Environment(Options{BaseDir: dir, Env: "dev"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "component web")
+	assert.Contains(t, err.Error(), "hasValues: false")
+}
+
+func TestEnvironment_OnlyUnknown(t *testing.T) {
+	_, err := Environment(Options{BaseDir: writeTree(t, ""), Env: "dev", Only: []string{"disabled"}})
+	assert.ErrorContains(t, err, "not enabled")
+}
+
+func TestApplications(t *testing.T) {
+	dir := writeTree(t, "")
+	// A broken component chart does not matter when only the apps chart is rendered.
+	require.NoError(t, os.Remove(filepath.Join(dir, "components/web/Chart.yaml")))
+
+	result, err := Applications(Options{BaseDir: dir, Env: "dev"})
+	require.NoError(t, err)
+	require.Len(t, result.Applications, 2)
+	assert.Equal(t, "crds", result.Applications[0].Name)
+	assert.Equal(t, "web", result.Applications[1].Name)
+	assert.Equal(t, "main", result.Applications[1].TargetRevision)
+	assert.Empty(t, result.Components)
+}
+
+func TestWrite(t *testing.T) {
+	result, err := Environment(Options{BaseDir: writeTree(t, ""), Env: "dev"})
+	require.NoError(t, err)
+
+	out := t.TempDir()
+	writeFile(t, filepath.Join(out, IndexFile), "files:\n  - file: old.yaml\n")
+	writeFile(t, filepath.Join(out, "old.yaml"), "stale")
+	writeFile(t, filepath.Join(out, "keep.txt"), "mine")
+
+	files, err := Write(result, out)
+	require.NoError(t, err)
+	assert.Len(t, files, 4)
+	assert.NoFileExists(t, filepath.Join(out, "old.yaml"))
+	assert.FileExists(t, filepath.Join(out, "keep.txt"))
+	assert.FileExists(t, filepath.Join(out, "app-of-apps.yaml"))
+
+	data, err := os.ReadFile(filepath.Join(out, IndexFile))
+	require.NoError(t, err)
+	var index Index
+	require.NoError(t, yaml.Unmarshal(data, &index))
+	assert.Equal(t, "dev", index.Environment)
+	require.Len(t, index.Files, 3)
+	assert.Equal(t, map[string]int{"Application": 2}, index.Files[0].Kinds)
+	web := index.Files[2]
+	assert.Equal(t, "web.yaml", web.File)
+	assert.Equal(t, "3", web.SyncWave)
+	assert.Equal(t, map[string]int{"Deployment": 1, "Service": 1}, web.Kinds)
+	assert.Equal(t, []string{"web"}, web.Namespaces)
+}
+
+func TestParseManifest(t *testing.T) {
+	manifest := "---\n# Source: c/templates/a.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n# Source: c/templates/empty.yaml\n# nothing here\n---\n# Source: c/templates/ns.yaml\napiVersion: v1\nkind: Namespace\nmetadata:\n  name: n\n"
+
+	objs, err := ParseManifest(manifest, "default")
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	assert.Equal(t, "/ConfigMap/default/a", objs[0].ID())
+	assert.Equal(t, "c/templates/a.yaml", objs[0].Source)
+	assert.Equal(t, "/Namespace//n", objs[1].ID())
+}
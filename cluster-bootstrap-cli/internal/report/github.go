@@ -0,0 +1,65 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// WriteGitHub renders warnings and failures as GitHub Actions workflow
+// commands, which the runner turns into annotations, followed by a summary
+// line.
+func WriteGitHub(w io.Writer, r *Report) error {
+	for _, res := range r.Results {
+		var command string
+		switch res.Status {
+		case StatusFail:
+			command = "error"
+		case StatusWarn:
+			command = "warning"
+		default:
+			continue
+		}
+		if len(res.Locations) == 0 {
+			if err := writeWorkflowCommand(w, command, res.Name, Location{}, res.message()); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, loc := range res.Locations {
+			msg := res.message()
+			if loc.Message != "" {
+				msg = loc.Message
+			}
+			if err := writeWorkflowCommand(w, command, res.Name, loc, msg); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s: %d ok, %d warning(s), %d failure(s), %d skipped\n",
+		r.Command, r.Summary.OK, r.Summary.Warn, r.Summary.Fail, r.Summary.Skipped)
+	return err
+}
+
+func writeWorkflowCommand(w io.Writer, command, title string, loc Location, message string) error {
+	props := []string{"title=" + escapeProperty(title)}
+	if loc.File != "" {
+		props = append(props, "file="+escapeProperty(filepath.ToSlash(loc.File)))
+		if loc.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", loc.Line))
+		}
+	}
+	_, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeData(message))
+	return err
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
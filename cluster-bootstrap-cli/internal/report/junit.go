@@ -0,0 +1,85 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit renders r as a JUnit XML test suite with one test case per
+// check. Failed checks are failures and skipped checks are skipped;
+// warnings pass, with the warning in system-out, since JUnit has no
+// warning state.
+func WriteJUnit(w io.Writer, r *Report) error {
+	name := "cluster-bootstrap " + r.Command
+	if r.Environment != "" {
+		name += " " + r.Environment
+	}
+	suite := junitSuite{Name: name, Tests: len(r.Results), Failures: r.Summary.Fail, Skipped: r.Summary.Skipped}
+	for _, res := range r.Results {
+		tc := junitCase{Name: res.Name, ClassName: r.Command + "." + res.ID}
+		if len(res.Locations) > 0 {
+			tc.File = res.Locations[0].File
+		}
+		var out []string
+		switch res.Status {
+		case StatusFail:
+			tc.Failure = &junitMessage{Message: res.Error, Type: res.ID, Body: res.message()}
+		case StatusSkip:
+			tc.Skipped = &junitMessage{Message: res.Note}
+		case StatusWarn:
+			out = append(out, "WARN: "+res.message())
+		default:
+			if res.Note != "" {
+				out = append(out, res.Note)
+			}
+		}
+		out = append(out, res.Details...)
+		tc.SystemOut = strings.Join(out, "\n")
+		suite.Cases = append(suite.Cases, tc)
+	}
+	doc := junitSuites{Name: name, Tests: suite.Tests, Failures: suite.Failures, Skipped: suite.Skipped, Suites: []junitSuite{suite}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,173 @@
+// Package report renders the results of validate and doctor checks in
+// machine-readable formats: JSON, JUnit XML, SARIF and GitHub Actions
+// workflow commands.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusSkip is a check that did not run, e.g. because of a --skip flag.
+	StatusSkip Status = "skip"
+)
+
+// Exit codes of a report: failures take precedence over warnings.
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitWarning = 2
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatJUnit  Format = "junit"
+	FormatSARIF  Format = "sarif"
+	FormatGitHub Format = "github"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{FormatText, FormatJSON, FormatJUnit, FormatSARIF, FormatGitHub}
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unsupported output format: %s (use %s)", s, strings.Join(names, ", "))
+}
+
+// Location points a result at a file, e.g. the template a policy finding
+// comes from. Paths are relative to the base directory when possible.
+type Location struct {
+	File string `json:"file"`
+	// Line is 1-based, 0 when unknown.
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	// ID is stable across releases, unlike Name.
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Status    Status     `json:"status"`
+	Note      string     `json:"note,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Hint      string     `json:"hint,omitempty"`
+	Details   []string   `json:"details,omitempty"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Summary counts results by status.
+type Summary struct {
+	OK      int `json:"ok"`
+	Warn    int `json:"warn"`
+	Fail    int `json:"fail"`
+	Skipped int `json:"skipped"`
+}
+
+// Report is the outcome of a validate or doctor run.
+type Report struct {
+	// Command is validate or doctor.
+	Command     string   `json:"command"`
+	Environment string   `json:"environment,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Summary     Summary  `json:"summary"`
+	Results     []Result `json:"results"`
+}
+
+// New returns a report of results, with the summary filled in.
+func New(command, environment, version string, results []Result) *Report {
+	r := &Report{Command: command, Environment: environment, Version: version, Results: results}
+	for _, res := range results {
+		switch res.Status {
+		case StatusOK:
+			r.Summary.OK++
+		case StatusWarn:
+			r.Summary.Warn++
+		case StatusFail:
+			r.Summary.Fail++
+		case StatusSkip:
+			r.Summary.Skipped++
+		}
+	}
+	return r
+}
+
+// ExitCode returns ExitFailure when a check failed, ExitWarning when a check
+// warned, and ExitOK otherwise. Skipped checks do not count as warnings.
+func (r *Report) ExitCode() int {
+	switch {
+	case r.Summary.Fail > 0:
+		return ExitFailure
+	case r.Summary.Warn > 0:
+		return ExitWarning
+	}
+	return ExitOK
+}
+
+// SplitHint separates an error message from the "hint:" line the CLI
+// appends to errors.
+func SplitHint(message string) (text, hint string) {
+	var lines, hints []string
+	for _, line := range strings.Split(message, "\n") {
+		if h, ok := strings.CutPrefix(strings.TrimSpace(line), "hint:"); ok {
+			hints = append(hints, strings.TrimSpace(h))
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), strings.Join(hints, "\n")
+}
+
+// Write renders r in format f. FormatText is rendered by the caller.
+func Write(w io.Writer, f Format, r *Report) error {
+	switch f {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatJUnit:
+		return WriteJUnit(w, r)
+	case FormatSARIF:
+		return WriteSARIF(w, r)
+	case FormatGitHub:
+		return WriteGitHub(w, r)
+	}
+	return fmt.Errorf("format %s is rendered by the caller", f)
+}
+
+// message returns the text describing a warning or failure.
+func (res Result) message() string {
+	parts := []string{res.Name}
+	if res.Error != "" {
+		parts = append(parts, res.Error)
+	} else if res.Note != "" {
+		parts = append(parts, res.Note)
+	}
+	msg := strings.Join(parts, ": ")
+	if res.Hint != "" {
+		msg += "\nhint: " + res.Hint
+	}
+	return msg
+}
@@ -0,0 +1,98 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *Report {
+	return New("validate", "dev", "1.2.3", []Result{
+		{ID: "helm", Name: "helm available", Status: StatusOK},
+		{ID: "helm-lint", Name: "helm lint", Status: StatusSkip, Note: "skipped"},
+		{ID: "sops-config", Name: ".sops.yaml", Status: StatusWarn, Note: "missing creation rule for environment",
+			Hint: "update .sops.yaml", Locations: []Location{{File: ".sops.yaml"}}},
+		{ID: "policy/latest-tag", Name: "policy latest-tag [vault]", Status: StatusFail, Error: "images must be pinned",
+			Locations: []Location{
+				{File: "components/vault/templates/job.yaml", Line: 22, Message: "Job/unseal: image uses latest"},
+				{File: "components/vault/templates/sts.yaml", Line: 7, Message: "StatefulSet/vault: image uses latest"},
+			}},
+	})
+}
+
+func TestNew(t *testing.T) {
+	r := testReport()
+	assert.Equal(t, Summary{OK: 1, Warn: 1, Fail: 1, Skipped: 1}, r.Summary)
+	assert.Equal(t, ExitFailure, r.ExitCode())
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("sarif")
+	require.NoError(t, err)
+	assert.Equal(t, FormatSARIF, f)
+	_, err = ParseFormat("yaml")
+	assert.ErrorContains(t, err, "use text, json, junit, sarif, github")
+}
+
+func TestSplitHint(t *testing.T) {
+	text, hint := SplitHint("git ls-remote failed\n  output: denied\n  hint: add the deploy key")
+	assert.Equal(t, "git ls-remote failed\n  output: denied", text)
+	assert.Equal(t, "add the deploy key", hint)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSON, testReport()))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *testReport(), decoded)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, testReport()))
+	var doc junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, 4, doc.Tests)
+	assert.Equal(t, 1, doc.Failures)
+	assert.Equal(t, 1, doc.Skipped)
+	cases := doc.Suites[0].Cases
+	require.Len(t, cases, 4)
+	assert.Equal(t, "validate.policy/latest-tag", cases[3].ClassName)
+	require.NotNil(t, cases[3].Failure)
+	assert.Equal(t, "images must be pinned", cases[3].Failure.Message)
+	assert.NotNil(t, cases[1].Skipped)
+	assert.Contains(t, cases[2].SystemOut, "WARN: .sops.yaml: missing creation rule for environment")
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, testReport()))
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	run := log.Runs[0]
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+	assert.Len(t, run.Tool.Driver.Rules, 4)
+	require.Len(t, run.Results, 3)
+	assert.Equal(t, "warning", run.Results[0].Level)
+	assert.Equal(t, "error", run.Results[1].Level)
+	loc := run.Results[1].Locations[0].PhysicalLocation
+	assert.Equal(t, "components/vault/templates/job.yaml", loc.ArtifactLocation.URI)
+	assert.Equal(t, 22, loc.Region.StartLine)
+	assert.Equal(t, "Job/unseal: image uses latest", run.Results[1].Message.Text)
+}
+
+func TestWriteGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteGitHub(&buf, testReport()))
+	assert.Equal(t, ""+
+		"::warning title=.sops.yaml,file=.sops.yaml::.sops.yaml: missing creation rule for environment%0Ahint: update .sops.yaml\n"+
+		"::error title=policy latest-tag [vault],file=components/vault/templates/job.yaml,line=22::Job/unseal: image uses latest\n"+
+		"::error title=policy latest-tag [vault],file=components/vault/templates/sts.yaml,line=7::StatefulSet/vault: image uses latest\n"+
+		"validate: 1 ok, 1 warning(s), 1 failure(s), 1 skipped\n", buf.String())
+}
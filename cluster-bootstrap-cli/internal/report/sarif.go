@@ -0,0 +1,120 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifToolURI is the tool's information URI.
+	sarifToolURI = "https://github.com/user-cube/cluster-bootstrap"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// WriteSARIF renders r as a SARIF 2.1.0 log. Only warnings and failures are
+// results; every check is listed as a rule.
+func WriteSARIF(w io.Writer, r *Report) error {
+	driver := sarifDriver{Name: "cluster-bootstrap", Version: r.Version, InformationURI: sarifToolURI, Rules: []sarifRule{}}
+	results := []sarifResult{}
+	for _, res := range r.Results {
+		if !slices.ContainsFunc(driver.Rules, func(rule sarifRule) bool { return rule.ID == res.ID }) {
+			driver.Rules = append(driver.Rules, sarifRule{ID: res.ID, Name: res.Name, ShortDescription: sarifMessage{Text: res.Name}})
+		}
+		level := "warning"
+		switch res.Status {
+		case StatusFail:
+			level = "error"
+		case StatusWarn:
+		default:
+			continue
+		}
+		if len(res.Locations) == 0 {
+			results = append(results, sarifResult{RuleID: res.ID, Level: level, Message: sarifMessage{Text: res.message()}})
+			continue
+		}
+		// One result per location, so that each is annotated.
+		for _, loc := range res.Locations {
+			msg := res.message()
+			if loc.Message != "" {
+				msg = loc.Message
+			}
+			physical := sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(loc.File)}}
+			if loc.Line > 0 {
+				physical.Region = &sarifRegion{StartLine: loc.Line}
+			}
+			results = append(results, sarifResult{
+				RuleID:    res.ID,
+				Level:     level,
+				Message:   sarifMessage{Text: msg},
+				Locations: []sarifLocation{{PhysicalLocation: physical}},
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,231 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+// MigrateOptions configures moving an environment to another encryption backend.
+type MigrateOptions struct {
+	BaseDir string
+	Env     string
+	From    string
+	To      string
+	// Recipients are the SOPS keys for the environment's creation rule when
+	// migrating to sops. Empty means an existing rule must match the file.
+	Recipients []string
+	SopsOpts   *sops.Options
+	// Force overwrites an existing target file.
+	Force bool
+}
+
+// Migration is the outcome of a migration.
+type Migration struct {
+	Source string
+	Target string
+	// SopsRule reports whether the environment's .sops.yaml rule was added,
+	// updated or removed.
+	SopsRule bool
+	Warnings []string
+}
+
+// Migrate re-writes an environment's secrets file with the target backend.
+// The new file is read back and compared with the source before the source
+// is removed and the backend is recorded in the repository config file. On
+// failure the new file is removed and .sops.yaml and .gitattributes are
+// restored.
+func Migrate(opts MigrateOptions) (*Migration, error) {
+	if err := config.ValidateEncryption(opts.To); err != nil {
+		return nil, err
+	}
+	if opts.From == opts.To {
+		return nil, fmt.Errorf("environment %s already uses %s", opts.Env, opts.To)
+	}
+
+	m := &Migration{
+		Source: filepath.Join(opts.BaseDir, fileName(opts.Env, opts.From)),
+		Target: filepath.Join(opts.BaseDir, fileName(opts.Env, opts.To)),
+	}
+	source := &File{Path: m.Source, Encryption: opts.From, SopsOpts: opts.SopsOpts}
+	plaintext, err := source.Read()
+	if err != nil {
+		return nil, err
+	}
+	want, err := loadSecrets(source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Source, err)
+	}
+	if _, err := os.Stat(m.Target); err == nil && !opts.Force {
+		return nil, fmt.Errorf("%s already exists\n  hint: pass --force to overwrite it", m.Target)
+	}
+
+	sopsConfig := filepath.Join(opts.BaseDir, ".sops.yaml")
+	gitattributes := filepath.Join(opts.BaseDir, ".gitattributes")
+	restore, err := snapshot(sopsConfig, gitattributes)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*Migration, error) {
+		_ = os.Remove(m.Target)
+		if restoreErr := restore(); restoreErr != nil {
+			return nil, fmt.Errorf("%w (restoring configuration also failed: %v)", err, restoreErr)
+		}
+		return nil, err
+	}
+
+	switch opts.To {
+	case config.EncryptionSOPS:
+		if err := prepareSops(sopsConfig, opts, m); err != nil {
+			return fail(err)
+		}
+		if matchesGitCryptPattern(gitattributes) {
+			m.Warnings = append(m.Warnings, fmt.Sprintf("%s also matches the git-crypt pattern in .gitattributes; add '%s !filter !diff' after it to keep SOPS files out of git-crypt", filepath.Base(m.Target), config.SecretsFileName("*")))
+		}
+	case config.EncryptionGitCrypt:
+		gitCryptDir := filepath.Join(opts.BaseDir, ".git", "git-crypt")
+		if _, err := os.Stat(gitCryptDir); err != nil {
+			return nil, fmt.Errorf("git-crypt not initialised: %s not found\n  hint: run 'git-crypt init' first", gitCryptDir)
+		}
+		if err := config.EnsureGitCryptAttributes(opts.BaseDir); err != nil {
+			return fail(err)
+		}
+	}
+
+	target := &File{Path: m.Target, Encryption: opts.To, SopsOpts: opts.SopsOpts}
+	if err := target.Write(plaintext); err != nil {
+		return fail(err)
+	}
+	got, err := loadSecrets(target)
+	if err != nil {
+		return fail(fmt.Errorf("verification read of %s failed: %w", m.Target, err))
+	}
+	if !reflect.DeepEqual(want, got) {
+		return fail(fmt.Errorf("verification of %s failed: content differs from %s", m.Target, m.Source))
+	}
+
+	if err := os.Remove(m.Source); err != nil {
+		return fail(fmt.Errorf("failed to remove %s: %w", m.Source, err))
+	}
+	if opts.To == config.EncryptionGitCrypt {
+		if _, err := os.Stat(sopsConfig); err == nil {
+			removed, err := config.RemoveSopsRule(sopsConfig, opts.Env)
+			if err != nil {
+				m.Warnings = append(m.Warnings, fmt.Sprintf("could not remove the %s rule from .sops.yaml: %v", opts.Env, err))
+			}
+			m.SopsRule = removed
+		}
+	}
+	if err := config.SetEncryptionBackend(opts.BaseDir, opts.Env, opts.To); err != nil {
+		return m, fmt.Errorf("migrated %s but failed to record the backend: %w\n  hint: pass --encryption %s to later commands", m.Target, err, opts.To)
+	}
+	return m, nil
+}
+
+// prepareSops makes sure a creation rule covers the target file, adding or
+// updating the environment's rule when recipients are given.
+func prepareSops(sopsConfig string, opts MigrateOptions, m *Migration) error {
+	if len(opts.Recipients) > 0 {
+		provider, err := config.KeyProvider(opts.Recipients[0])
+		if err != nil {
+			return err
+		}
+		for _, key := range opts.Recipients[1:] {
+			p, err := config.KeyProvider(key)
+			if err != nil {
+				return err
+			}
+			if p != provider {
+				return fmt.Errorf("recipients mix %s and %s keys; use one provider per environment", provider, p)
+			}
+		}
+		if err := config.UpsertSopsRule(sopsConfig, provider, opts.Recipients, opts.Env); err != nil {
+			return err
+		}
+		m.SopsRule = true
+		return nil
+	}
+
+	hint := fmt.Sprintf("\n  hint: pass --recipient to add a creation rule for %s", opts.Env)
+	cfg, err := config.ReadSopsConfig(sopsConfig)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%s not found%s", sopsConfig, hint)
+		}
+		return err
+	}
+	if i, _ := cfg.RuleFor(m.Target); i < 0 {
+		return fmt.Errorf("no creation rule in %s matches %s%s", sopsConfig, filepath.Base(m.Target), hint)
+	}
+	return nil
+}
+
+func fileName(env, encryption string) string {
+	if encryption == config.EncryptionGitCrypt {
+		return config.SecretsFileNamePlain(env)
+	}
+	return config.SecretsFileName(env)
+}
+
+func loadSecrets(f *File) (*config.EnvironmentSecrets, error) {
+	if f.Encryption == config.EncryptionGitCrypt {
+		return config.LoadSecretsPlaintext(f.Path)
+	}
+	return config.LoadSecrets(f.Path, f.SopsOpts)
+}
+
+func matchesGitCryptPattern(gitattributes string) bool {
+	data, err := os.ReadFile(gitattributes) // #nosec G304
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == config.GitCryptAttributesPattern {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot records the current contents of paths and returns a function
+// that puts them back, removing files that did not exist.
+func snapshot(paths ...string) (func() error, error) {
+	type saved struct {
+		data   []byte
+		perm   os.FileMode
+		exists bool
+	}
+	states := make([]saved, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		states[i] = saved{data: data, perm: info.Mode().Perm(), exists: true}
+	}
+	return func() error {
+		var errs []error
+		for i, path := range paths {
+			if states[i].exists {
+				errs = append(errs, os.WriteFile(path, states[i].data, states[i].perm))
+			} else if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}, nil
+}
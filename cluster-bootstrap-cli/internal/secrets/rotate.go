@@ -0,0 +1,238 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"filippo.io/age"
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+)
+
+// RotateOptions configures a recipient and data key rotation.
+type RotateOptions struct {
+	BaseDir string
+	// SopsConfig is the .sops.yaml holding the creation rules. Empty means
+	// the one SOPS finds for the secrets files.
+	SopsConfig string
+	Envs       []string
+	Add        []string
+	Remove     []string
+	SopsOpts   *sops.Options
+	// SkipVerify skips decrypting the re-encrypted files, for when the local
+	// key is no longer a recipient.
+	SkipVerify bool
+}
+
+// Rotation is the outcome for one environment.
+type Rotation struct {
+	Env      string
+	File     string
+	Provider string
+	Before   []string
+	After    []string
+}
+
+// rotatableProviders are the providers whose recipients sops.Recipients
+// reads back, so a rotation can be checked.
+var rotatableProviders = []string{"age", "aws-kms", "gcp-kms"}
+
+type rotation struct {
+	Rotation
+	plaintext []byte
+	encrypted []byte
+	perm      os.FileMode
+}
+
+// Rotate updates each environment's creation rule with the added and removed
+// recipients and re-encrypts its secrets file under a fresh data key. Every
+// file is decrypted, re-encrypted and verified in memory before any of them
+// is written; on failure .sops.yaml is restored and no secrets file changes.
+// Without Add or Remove only the data key is rotated.
+func Rotate(opts RotateOptions) ([]Rotation, error) {
+	for _, key := range opts.Add {
+		if err := validateRecipient(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.Envs) == 0 {
+		return nil, fmt.Errorf("no environments to rotate")
+	}
+	if opts.SopsConfig == "" {
+		for _, env := range opts.Envs {
+			path, err := sops.ConfigPath(filepath.Join(opts.BaseDir, config.SecretsFileName(env)))
+			if err != nil {
+				return nil, fmt.Errorf("%w\n  hint: run 'cluster-bootstrap init' to create .sops.yaml", err)
+			}
+			if opts.SopsConfig != "" && path != opts.SopsConfig {
+				return nil, fmt.Errorf("%s uses %s but other environments use %s; rotate them separately", env, path, opts.SopsConfig)
+			}
+			opts.SopsConfig = path
+		}
+	}
+
+	cfg, err := config.ReadSopsConfig(opts.SopsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  hint: run 'cluster-bootstrap init' to create .sops.yaml", err)
+	}
+
+	var plans []*rotation
+	for _, env := range opts.Envs {
+		plan, err := planRotation(opts, cfg, env)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", env, err)
+		}
+		plans = append(plans, plan)
+	}
+
+	original, err := os.ReadFile(opts.SopsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", opts.SopsConfig, err)
+	}
+	restore := func(cause error) error {
+		if err := os.WriteFile(opts.SopsConfig, original, 0600); err != nil {
+			return fmt.Errorf("%w (restoring %s also failed: %v)", cause, opts.SopsConfig, err)
+		}
+		return cause
+	}
+
+	for _, plan := range plans {
+		if err := config.UpsertSopsRule(opts.SopsConfig, plan.Provider, plan.After, plan.Env); err != nil {
+			return nil, restore(err)
+		}
+	}
+	for _, plan := range plans {
+		if err := plan.encrypt(opts); err != nil {
+			return nil, restore(fmt.Errorf("%s: %w", plan.Env, err))
+		}
+	}
+
+	var results []Rotation
+	for _, plan := range plans {
+		if err := os.WriteFile(plan.File, plan.encrypted, plan.perm); err != nil {
+			return results, fmt.Errorf("failed to write %s: %w", plan.File, err)
+		}
+		results = append(results, plan.Rotation)
+	}
+	return results, nil
+}
+
+func planRotation(opts RotateOptions, cfg *config.SOPSConfig, env string) (*rotation, error) {
+	path := filepath.Join(opts.BaseDir, config.SecretsFileName(env))
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("secrets file %s not found: %w", path, err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+
+	_, rule := cfg.RuleFor(absPath)
+	if rule == nil {
+		return nil, fmt.Errorf("no creation rule in %s matches %s", opts.SopsConfig, filepath.Base(path))
+	}
+	provider, err := rule.Provider()
+	if err != nil {
+		return nil, fmt.Errorf("%w\n  hint: rotate rules with more than one provider by editing .sops.yaml", err)
+	}
+	if !slices.Contains(rotatableProviders, provider) {
+		return nil, fmt.Errorf("rotating %s recipients is not supported; rotate supports age, aws-kms and gcp-kms\n  hint: edit the rule in %s and run 'sops updatekeys %s', then 'sops rotate -i %s'", provider, opts.SopsConfig, path, path)
+	}
+
+	before := rule.Recipients(provider)
+	after := slices.Clone(before)
+	for _, key := range opts.Remove {
+		i := slices.Index(after, key)
+		if i < 0 {
+			return nil, fmt.Errorf("%s is not a recipient", key)
+		}
+		after = slices.Delete(after, i, i+1)
+	}
+	for _, key := range opts.Add {
+		keyProvider, _ := config.KeyProvider(key)
+		if keyProvider != provider {
+			return nil, fmt.Errorf("cannot add %s recipient %s to a %s rule", keyProvider, key, provider)
+		}
+		if !slices.Contains(after, key) {
+			after = append(after, key)
+		}
+	}
+	if len(after) == 0 {
+		return nil, fmt.Errorf("removing every recipient would make the file undecryptable")
+	}
+
+	plaintext, err := sops.Decrypt(path, opts.SopsOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w\n  hint: rotation needs a key that can decrypt the current file; pass --age-key-file", path, err)
+	}
+
+	return &rotation{
+		Rotation:  Rotation{Env: env, File: path, Provider: provider, Before: before, After: after},
+		plaintext: plaintext,
+		perm:      info.Mode().Perm(),
+	}, nil
+}
+
+// encrypt re-encrypts the plaintext with the updated rule, which generates a
+// new data key, and checks the result.
+func (r *rotation) encrypt(opts RotateOptions) error {
+	encrypted, err := sops.EncryptData(r.plaintext, r.File, opts.SopsOpts)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := sops.Recipients(encrypted)
+	if err != nil {
+		return err
+	}
+	if !sameKeys(recipients, r.After) {
+		return fmt.Errorf("re-encrypted file is wrapped for %v, expected %v", recipients, r.After)
+	}
+
+	if !opts.SkipVerify {
+		decrypted, err := sops.DecryptData(encrypted, opts.SopsOpts)
+		if err != nil {
+			return fmt.Errorf("verification failed: %w\n  hint: the local key must remain a recipient to verify; pass --skip-verify if it is being removed", err)
+		}
+		var want, got interface{}
+		if err := yaml.Unmarshal(r.plaintext, &want); err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		if err := yaml.Unmarshal(decrypted, &got); err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			return fmt.Errorf("verification failed: decrypted content differs from the original")
+		}
+	}
+
+	r.encrypted = encrypted
+	return nil
+}
+
+func validateRecipient(key string) error {
+	provider, err := config.KeyProvider(key)
+	if err != nil {
+		return err
+	}
+	if provider == "age" {
+		if _, err := age.ParseX25519Recipient(key); err != nil {
+			return fmt.Errorf("invalid age recipient %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func sameKeys(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
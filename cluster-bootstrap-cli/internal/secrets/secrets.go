@@ -0,0 +1,164 @@
+// Package secrets reads, edits and writes per-environment secrets files for
+// both encryption backends, keeping decrypted content in memory.
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/config"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/sops"
+	"github.com/user-cube/cluster-bootstrap/cluster-bootstrap-cli/internal/yamledit"
+)
+
+// RedactedValue replaces secret values in redacted output.
+const RedactedValue = "<redacted>"
+
+// PublicPaths are the keys shown by View without --reveal.
+var PublicPaths = []string{"repo.url", "repo.targetRevision"}
+
+// File is an environment secrets file and the backend that protects it.
+type File struct {
+	Path       string
+	Encryption string
+	SopsOpts   *sops.Options
+}
+
+// Read returns the plaintext contents of the file. SOPS files are decrypted
+// in memory; git-crypt files must already be unlocked.
+func (f *File) Read() ([]byte, error) {
+	if _, err := os.Stat(f.Path); err != nil {
+		return nil, fmt.Errorf("secrets file %s not found: %w\n  hint: run 'cluster-bootstrap init' to create it", f.Path, err)
+	}
+	switch f.Encryption {
+	case "sops":
+		data, err := sops.Decrypt(f.Path, f.SopsOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w\n  hint: pass --age-key-file or set SOPS_AGE_KEY_FILE", f.Path, err)
+		}
+		return data, nil
+	case "git-crypt":
+		return config.ReadSecretsPlaintext(f.Path)
+	default:
+		return nil, fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", f.Encryption)
+	}
+}
+
+// Write validates plaintext against the secrets schema and stores it.
+// SOPS files are re-encrypted with the .sops.yaml rule for the file; git-crypt
+// files are written as plaintext and encrypted by git-crypt on commit.
+// The file keeps its permissions.
+func (f *File) Write(plaintext []byte) error {
+	if _, err := config.ParseSecrets(plaintext); err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0600)
+	if info, err := os.Stat(f.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	out := plaintext
+	switch f.Encryption {
+	case "sops":
+		var err error
+		out, err = sops.EncryptData(plaintext, f.Path, f.SopsOpts)
+		if err != nil {
+			return fmt.Errorf("%w\n  hint: re-encryption uses the .sops.yaml creation rule matching %s", err, f.Path)
+		}
+	case "git-crypt":
+	default:
+		return fmt.Errorf("unsupported encryption backend: %s (use sops or git-crypt)", f.Encryption)
+	}
+
+	if err := os.WriteFile(f.Path, out, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// Redact replaces every scalar value outside PublicPaths with RedactedValue.
+// Keys and structure are kept so the layout of the file stays visible.
+func Redact(plaintext []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(plaintext, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	if root.Kind == 0 {
+		return nil, nil
+	}
+	redactNode(&root, nil)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode secrets: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode secrets: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func redactNode(node *yaml.Node, path []string) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			redactNode(child, path)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			redactNode(node.Content[i+1], append(append([]string{}, path...), node.Content[i].Value))
+		}
+	case yaml.ScalarNode, yaml.AliasNode:
+		joined := yamledit.JoinPath(path...)
+		for _, public := range PublicPaths {
+			if joined == public {
+				return
+			}
+		}
+		if node.Kind == yaml.ScalarNode && node.Value == "" {
+			return
+		}
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: RedactedValue, LineComment: node.LineComment}
+	}
+}
+
+// Assignment sets one string value in a secrets file.
+type Assignment struct {
+	Path  []string
+	Value string
+}
+
+// ParseAssignment parses path=value, e.g. repo.targetRevision=v2.
+func ParseAssignment(s string) (Assignment, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return Assignment{}, fmt.Errorf("invalid assignment %q\n  hint: use <path>=<value>, e.g. repo.targetRevision=v2", s)
+	}
+	path, err := yamledit.SplitPath(strings.TrimSpace(key))
+	if err != nil {
+		return Assignment{}, err
+	}
+	return Assignment{Path: path, Value: value}, nil
+}
+
+// Set applies assignments to plaintext, editing it in place so comments and
+// formatting survive, and returns the result. Values are always strings.
+func Set(plaintext []byte, assignments []Assignment) ([]byte, error) {
+	doc, err := yamledit.Parse(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	for _, a := range assignments {
+		if err := doc.Set(a.Value, a.Path...); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", yamledit.JoinPath(a.Path...), err)
+		}
+	}
+	return doc.Bytes(), nil
+}
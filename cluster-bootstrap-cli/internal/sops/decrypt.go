@@ -12,6 +12,7 @@ import (
 	"github.com/getsops/sops/v3/decrypt"
 	"github.com/getsops/sops/v3/keyservice"
 	"github.com/getsops/sops/v3/version"
+	"gopkg.in/yaml.v3"
 )
 
 // Options configures SOPS operations.
@@ -61,6 +62,61 @@ func Decrypt(filePath string, opts *Options) ([]byte, error) {
 	return out, nil
 }
 
+// DecryptData decrypts SOPS-encrypted YAML held in memory.
+func DecryptData(data []byte, opts *Options) ([]byte, error) {
+	restore := setAgeEnv(opts)
+	defer restore()
+
+	out, err := decrypt.Data(data, "yaml")
+	if err != nil {
+		return nil, fmt.Errorf("sops decrypt failed: %w", err)
+	}
+	return out, nil
+}
+
+// Recipients lists the keys an encrypted YAML file's data key is wrapped
+// for, read from its sops metadata: age recipients, AWS KMS ARNs and GCP KMS
+// resource IDs, including those inside key groups.
+func Recipients(data []byte) ([]string, error) {
+	type keys struct {
+		Age []struct {
+			Recipient string `yaml:"recipient"`
+		} `yaml:"age"`
+		KMS []struct {
+			ARN string `yaml:"arn"`
+		} `yaml:"kms"`
+		GCPKMS []struct {
+			ResourceID string `yaml:"resource_id"`
+		} `yaml:"gcp_kms"`
+	}
+	var file struct {
+		Sops *struct {
+			keys      `yaml:",inline"`
+			KeyGroups []keys `yaml:"key_groups"`
+		} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse sops metadata: %w", err)
+	}
+	if file.Sops == nil {
+		return nil, fmt.Errorf("no sops metadata found; the file is not encrypted with sops")
+	}
+
+	var recipients []string
+	for _, group := range append([]keys{file.Sops.keys}, file.Sops.KeyGroups...) {
+		for _, k := range group.Age {
+			recipients = append(recipients, k.Recipient)
+		}
+		for _, k := range group.KMS {
+			recipients = append(recipients, k.ARN)
+		}
+		for _, k := range group.GCPKMS {
+			recipients = append(recipients, k.ResourceID)
+		}
+	}
+	return recipients, nil
+}
+
 // Encrypt encrypts a plaintext YAML file using the .sops.yaml config and returns ciphertext bytes.
 func Encrypt(filePath string, opts *Options) ([]byte, error) {
 	return EncryptWithTarget(filePath, filePath, opts)
@@ -69,14 +125,19 @@ func Encrypt(filePath string, opts *Options) ([]byte, error) {
 // EncryptWithTarget encrypts a plaintext file using config rules for targetPath.
 // This allows creation_rules to match the final output filename.
 func EncryptWithTarget(filePath, targetPath string, opts *Options) ([]byte, error) {
-	restore := setAgeEnv(opts)
-	defer restore()
-
 	// Read plaintext
 	data, err := os.ReadFile(filePath) // #nosec G304
 	if err != nil {
 		return nil, fmt.Errorf("sops encrypt: failed to read file: %w", err)
 	}
+	return EncryptData(data, targetPath, opts)
+}
+
+// EncryptData encrypts plaintext in memory using the config rules for
+// targetPath, so decrypted content never has to be written to disk.
+func EncryptData(data []byte, targetPath string, opts *Options) ([]byte, error) {
+	restore := setAgeEnv(opts)
+	defer restore()
 
 	encConfig, err := loadEncryptionConfig(targetPath)
 	if err != nil {
@@ -198,6 +259,16 @@ func loadEncryptionConfig(filePath string) (*encryptionConfig, error) {
 	}, nil
 }
 
+// ConfigPath returns the .sops.yaml that applies to filePath, found by
+// searching its directory and parents as the sops CLI does.
+func ConfigPath(filePath string) (string, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", filePath, err)
+	}
+	return findSopsConfigPath(absPath)
+}
+
 func findSopsConfigPath(filePath string) (string, error) {
 	result, err := sopsconfig.LookupConfigFile(filePath)
 	if err == nil {
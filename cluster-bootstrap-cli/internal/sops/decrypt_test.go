@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"filippo.io/age"
 	sopslib "github.com/getsops/sops/v3"
 	"github.com/getsops/sops/v3/gcpkms"
 	"github.com/getsops/sops/v3/kms"
@@ -105,3 +106,38 @@ func TestLoadEncryptionConfig_KMSAndGCP(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, sopslib.DefaultUnencryptedSuffix, ageConfig.unencryptedSuffix)
 }
+
+func TestEncryptDataRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".sops.yaml"), []byte(`creation_rules:
+  - path_regex: secrets\.dev\.enc\.yaml$
+    age: `+identity.Recipient().String()+"\n"), 0600))
+
+	target := filepath.Join(dir, "secrets.dev.enc.yaml")
+	plaintext := []byte("repo:\n  url: git@github.com:example/platform.git # origin\n  targetRevision: main\n")
+	encrypted, err := EncryptData(plaintext, target, &Options{AgeKeyFile: keyFile})
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "targetRevision: main")
+	require.NoError(t, os.WriteFile(target, encrypted, 0600))
+
+	decrypted, err := Decrypt(target, &Options{AgeKeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Contains(t, string(decrypted), "targetRevision: main")
+	assert.Contains(t, string(decrypted), "# origin")
+
+	fromMemory, err := DecryptData(encrypted, &Options{AgeKeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, decrypted, fromMemory)
+
+	recipients, err := Recipients(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, []string{identity.Recipient().String()}, recipients)
+
+	_, err = Recipients(plaintext)
+	assert.ErrorContains(t, err, "no sops metadata")
+}
@@ -0,0 +1,142 @@
+// Package sshkey generates and inspects SSH deploy keys.
+package sshkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyPair is a generated deploy key.
+type KeyPair struct {
+	// PrivateKey is the OpenSSH PEM encoding of the private key.
+	PrivateKey string
+	// AuthorizedKey is the public key in authorized_keys format, with comment.
+	AuthorizedKey string
+	// Fingerprint is the OpenSSH SHA256 fingerprint of the public key.
+	Fingerprint string
+}
+
+// Generate creates an ed25519 key pair. The comment is stored in both the
+// private key and the authorized key line.
+func Generate(comment string) (*KeyPair, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	if comment != "" {
+		authorized += " " + comment
+	}
+	return &KeyPair{
+		PrivateKey:    string(pem.EncodeToMemory(block)),
+		AuthorizedKey: authorized,
+		Fingerprint:   ssh.FingerprintSHA256(sshPub),
+	}, nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the public half of a PEM
+// encoded private key. Passphrase-protected keys are not supported.
+func Fingerprint(privateKey string) (string, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return ssh.FingerprintSHA256(signer.PublicKey()), nil
+}
+
+// Info describes a private key.
+type Info struct {
+	// Type is the SSH key type, e.g. ssh-ed25519 or ssh-rsa; empty when
+	// an encrypted key does not include its public half.
+	Type string
+	Bits int
+	// Encrypted is true for passphrase-protected keys, which ArgoCD
+	// cannot use.
+	Encrypted   bool
+	Fingerprint string
+}
+
+func (k *Info) String() string {
+	var parts []string
+	if k.Type != "" {
+		parts = append(parts, k.Type)
+	} else {
+		parts = append(parts, "unknown type")
+	}
+	if k.Bits > 0 {
+		parts = append(parts, fmt.Sprintf("%d-bit", k.Bits))
+	}
+	if k.Encrypted {
+		parts = append(parts, "passphrase protected")
+	}
+	if k.Fingerprint != "" {
+		parts = append(parts, k.Fingerprint)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Inspect parses a PEM encoded SSH private key. Passphrase-protected keys
+// are described as far as possible instead of failing.
+func Inspect(privateKey string) (*Info, error) {
+	if strings.TrimSpace(privateKey) == "" {
+		return nil, errors.New("private key is empty")
+	}
+	var pub ssh.PublicKey
+	info := &Info{}
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case err == nil:
+		pub = signer.PublicKey()
+	case errors.As(err, &missing):
+		info.Encrypted = true
+		pub = missing.PublicKey
+		if pub == nil {
+			// Legacy PEM keys: the block type names the algorithm.
+			if block, _ := pem.Decode([]byte(privateKey)); block != nil && block.Type == "RSA PRIVATE KEY" {
+				info.Type = ssh.KeyAlgoRSA
+			}
+		}
+	default:
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	if pub != nil {
+		info.Type = pub.Type()
+		info.Fingerprint = ssh.FingerprintSHA256(pub)
+		info.Bits = keyBits(pub)
+	}
+	return info, nil
+}
+
+func keyBits(pub ssh.PublicKey) int {
+	cryptoPub, ok := pub.(ssh.CryptoPublicKey)
+	if !ok {
+		return 0
+	}
+	switch k := cryptoPub.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package yamledit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JoinPath renders a mapping path as dotted text. Keys containing dots,
+// spaces or quotes are quoted, as in podAnnotations."prometheus.io/scrape".
+func JoinPath(path ...string) string {
+	parts := make([]string, len(path))
+	for i, key := range path {
+		if key == "" || strings.ContainsAny(key, ". \"") {
+			key = strconv.Quote(key)
+		}
+		parts[i] = key
+	}
+	return strings.Join(parts, ".")
+}
+
+// SplitPath parses a dotted path written by JoinPath back into keys.
+func SplitPath(s string) ([]string, error) {
+	var path []string
+	for rest := s; ; {
+		if rest == "" {
+			return nil, fmt.Errorf("invalid path %q: empty key", s)
+		}
+		var key string
+		if rest[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: unterminated quote", s)
+			}
+			key, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+			if rest != "" && rest[0] != '.' {
+				return nil, fmt.Errorf("invalid path %q: expected . after %s", s, quoted)
+			}
+		} else {
+			idx := strings.IndexByte(rest, '.')
+			if idx < 0 {
+				idx = len(rest)
+			}
+			key, rest = rest[:idx], rest[idx:]
+			if key == "" {
+				return nil, fmt.Errorf("invalid path %q: empty key", s)
+			}
+		}
+		path = append(path, key)
+		if rest == "" {
+			return path, nil
+		}
+		rest = rest[1:]
+	}
+}
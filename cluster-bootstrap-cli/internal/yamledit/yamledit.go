@@ -0,0 +1,472 @@
+package yamledit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a parsed YAML document that can be edited in place.
+// Replacing an existing scalar patches the original bytes, so comments,
+// blank lines and quoting are preserved exactly. Structural changes
+// (adding keys, removing entries) re-encode the document through yaml.v3,
+// which keeps comments and key order but normalises indentation.
+type Document struct {
+	src  []byte
+	root *yaml.Node
+}
+
+// Load reads and parses a YAML file.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// Parse parses YAML bytes. Empty input yields an empty mapping document.
+func Parse(data []byte) (*Document, error) {
+	d := &Document{}
+	if err := d.reset(data); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Document) reset(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if root.Kind == 0 {
+		root = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	d.src = data
+	d.root = &root
+	return nil
+}
+
+// Root returns the top-level node of the document (usually a mapping).
+func (d *Document) Root() *yaml.Node {
+	if d.root.Kind == yaml.DocumentNode && len(d.root.Content) > 0 {
+		return d.root.Content[0]
+	}
+	return d.root
+}
+
+// Bytes returns the current document contents.
+func (d *Document) Bytes() []byte {
+	return d.src
+}
+
+// Save writes the document to path with the given permissions.
+func (d *Document) Save(path string, perm os.FileMode) error {
+	if err := os.WriteFile(path, d.src, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Lookup returns the node at the given mapping path, or nil if any segment is missing.
+func (d *Document) Lookup(path ...string) *yaml.Node {
+	node := d.Root()
+	for _, key := range path {
+		node = MappingValue(node, key)
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Decode unmarshals the node at path into out. Missing paths leave out untouched.
+func (d *Document) Decode(out interface{}, path ...string) error {
+	node := d.Lookup(path...)
+	if node == nil {
+		return nil
+	}
+	return node.Decode(out)
+}
+
+// SetScalar sets the scalar at node to value. The node must belong to this document.
+func (d *Document) SetScalar(node *yaml.Node, value string) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("node at line %d is not a scalar", node.Line)
+	}
+	return d.replaceScalar(node, value, &yaml.Node{Kind: yaml.ScalarNode, Value: value})
+}
+
+// replaceScalar patches the scalar in the source bytes and verifies that the
+// result parses to the same tree as an in-memory edit would. If it does not,
+// the in-memory edit is re-encoded instead.
+func (d *Document) replaceScalar(node *yaml.Node, text string, replacement *yaml.Node) error {
+	patched, ok := patchScalar(d.src, node, text)
+
+	node.Value = replacement.Value
+	node.Tag = replacement.Tag
+	if ok {
+		var want, got interface{}
+		if err := d.root.Decode(&want); err == nil {
+			if err := yaml.Unmarshal(patched, &got); err == nil && reflect.DeepEqual(want, got) {
+				return d.reset(patched)
+			}
+		}
+	}
+	node.Style = replacement.Style
+	return d.reencode()
+}
+
+// Set assigns value at the given mapping path, creating intermediate mappings
+// as needed. Scalar values replace existing scalars in place; anything else
+// is encoded as a new node.
+func (d *Document) Set(value interface{}, path ...string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("empty path")
+	}
+
+	var newNode yaml.Node
+	if err := newNode.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode value for %s: %w", strings.Join(path, "."), err)
+	}
+
+	if existing := d.Lookup(path...); existing != nil && existing.Kind == yaml.ScalarNode && newNode.Kind == yaml.ScalarNode {
+		if existing.Value == newNode.Value {
+			return nil
+		}
+		return d.replaceScalar(existing, scalarText(&newNode), &newNode)
+	}
+
+	parent := d.Root()
+	if parent.Kind != yaml.MappingNode {
+		return fmt.Errorf("document root is not a mapping")
+	}
+	for i, key := range path[:len(path)-1] {
+		next := MappingValue(parent, key)
+		if next == nil || next.Kind != yaml.MappingNode {
+			if next != nil && next.Kind == yaml.ScalarNode && next.Tag != "!!null" && next.Value != "" {
+				return fmt.Errorf("%s is not a mapping", strings.Join(path[:i+1], "."))
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(parent, key, next)
+		}
+		parent = next
+	}
+	setMappingValue(parent, path[len(path)-1], &newNode)
+	return d.reencode()
+}
+
+// AddMappingEntry appends key: value to the block mapping at path. The new
+// entry is inserted as text after the mapping's last entry, so the rest of the
+// file is left untouched; a blank line is added before it when the existing
+// entries are separated by blank lines. Falls back to re-encoding when the
+// mapping cannot be located safely. It is an error for key to exist already.
+func (d *Document) AddMappingEntry(key string, value interface{}, path ...string) error {
+	parent := d.Lookup(path...)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a mapping", strings.Join(path, "."))
+	}
+	if MappingValue(parent, key) != nil {
+		return fmt.Errorf("%s already exists", strings.Join(append(append([]string{}, path...), key), "."))
+	}
+
+	var valueNode yaml.Node
+	if err := valueNode.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode value for %s: %w", key, err)
+	}
+
+	patched, ok := insertMappingEntry(d.src, parent, key, value)
+	setMappingValue(parent, key, &valueNode)
+	if ok {
+		var want, got interface{}
+		if err := d.root.Decode(&want); err == nil {
+			if err := yaml.Unmarshal(patched, &got); err == nil && reflect.DeepEqual(want, got) {
+				return d.reset(patched)
+			}
+		}
+	}
+	return d.reencode()
+}
+
+// Delete removes the key at the given mapping path. It reports whether the key existed.
+func (d *Document) Delete(path ...string) (bool, error) {
+	if len(path) == 0 {
+		return false, fmt.Errorf("empty path")
+	}
+	parent := d.Lookup(path[:len(path)-1]...)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return false, nil
+	}
+	key := path[len(path)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true, d.reencode()
+		}
+	}
+	return false, nil
+}
+
+// Replace swaps the whole document for the given node tree and re-encodes it.
+func (d *Document) Replace(root *yaml.Node) error {
+	d.root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	return d.reencode()
+}
+
+// Changed reports whether the document differs from the given original bytes.
+func (d *Document) Changed(original []byte) bool {
+	return !bytes.Equal(d.src, original)
+}
+
+func (d *Document) reencode() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(d.root); err != nil {
+		return fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode yaml: %w", err)
+	}
+	return d.reset(buf.Bytes())
+}
+
+// MappingValue returns the value node for key in a mapping node, or nil.
+func MappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// MappingKeys returns the keys of a mapping node in document order.
+func MappingKeys(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+// FindSequenceItem returns the first mapping in a sequence whose key equals value.
+func FindSequenceItem(seq *yaml.Node, key, value string) *yaml.Node {
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, item := range seq.Content {
+		if v := MappingValue(item, key); v != nil && v.Value == value {
+			return item
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			// Keep comments attached to the old value so hand-written notes survive.
+			if value.LineComment == "" {
+				value.LineComment = node.Content[i+1].LineComment
+			}
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+}
+
+// scalarText renders a scalar node the way it would appear in a block mapping.
+func scalarText(node *yaml.Node) string {
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return node.Value
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// patchScalar replaces the single-line scalar token for node in src with text.
+// It returns false when the scalar cannot be located safely (block scalars,
+// multi-line flow scalars), in which case the caller re-encodes instead.
+func patchScalar(src []byte, node *yaml.Node, text string) ([]byte, bool) {
+	if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || node.Line == 0 {
+		return nil, false
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	if node.Line-1 >= len(lines) {
+		return nil, false
+	}
+	line := string(lines[node.Line-1])
+	start := node.Column - 1
+	if start < 0 || start > len(line) {
+		return nil, false
+	}
+	rest := line[start:]
+	end := scalarTokenLength(rest)
+	if end < 0 {
+		return nil, false
+	}
+
+	if !strings.Contains(text, "\n") && node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		quote := "\""
+		if node.Style&yaml.SingleQuotedStyle != 0 {
+			quote = "'"
+		}
+		if !strings.HasPrefix(text, "\"") && !strings.HasPrefix(text, "'") {
+			text = quote + strings.ReplaceAll(text, quote, "\\"+quote) + quote
+		}
+	}
+	if strings.Contains(text, "\n") {
+		return nil, false
+	}
+
+	patched := line[:start] + text + rest[end:]
+	lines[node.Line-1] = []byte(patched)
+	return bytes.Join(lines, nil), true
+}
+
+// scalarTokenLength returns the length of the scalar token at the start of s.
+func scalarTokenLength(s string) int {
+	if s == "" {
+		return 0
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				return i + 1
+			}
+		}
+		return -1
+	case '\'':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return -1
+	}
+
+	end := len(s)
+	if idx := strings.Index(s, " #"); idx >= 0 {
+		end = idx
+	}
+	if idx := strings.IndexAny(s[:end], "\r\n"); idx >= 0 {
+		end = idx
+	}
+	return len(strings.TrimRight(s[:end], " \t"))
+}
+
+// insertMappingEntry renders key: value at the indentation of the block
+// mapping's keys and inserts it after the mapping's last entry.
+func insertMappingEntry(src []byte, mapping *yaml.Node, key string, value interface{}) ([]byte, bool) {
+	if mapping.Style&yaml.FlowStyle != 0 || len(mapping.Content) < 2 {
+		return nil, false
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	firstKey := mapping.Content[0]
+	lastKey := mapping.Content[len(mapping.Content)-2]
+	indent := firstKey.Column - 1
+	if indent < 0 || lastKey.Line < 1 || lastKey.Line > len(lines) || lastKey.Column-1 != indent {
+		return nil, false
+	}
+
+	// The last entry ends before the first non-blank line indented at or
+	// below the mapping's keys; trailing blank lines belong to what follows.
+	end := lastKey.Line
+	for i := lastKey.Line; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(string(lines[i]))
+		if trimmed == "" {
+			continue
+		}
+		if lineIndent(string(lines[i])) <= indent {
+			break
+		}
+		end = i + 1
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{key: value}); err != nil {
+		return nil, false
+	}
+	if err := enc.Close(); err != nil {
+		return nil, false
+	}
+
+	var entry strings.Builder
+	if separatedByBlankLines(lines, mapping) {
+		entry.WriteString("\n")
+	}
+	pad := strings.Repeat(" ", indent)
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			entry.WriteString(pad + line)
+		}
+	}
+
+	var out [][]byte
+	out = append(out, lines[:end]...)
+	if end > 0 && !bytes.HasSuffix(lines[end-1], []byte("\n")) {
+		out = append(out, []byte("\n"))
+	}
+	out = append(out, []byte(entry.String()))
+	out = append(out, lines[end:]...)
+	return bytes.Join(out, nil), true
+}
+
+// separatedByBlankLines reports whether the line above the mapping's last key is blank.
+func separatedByBlankLines(lines [][]byte, mapping *yaml.Node) bool {
+	if len(mapping.Content) < 4 {
+		return false
+	}
+	lastKey := mapping.Content[len(mapping.Content)-2]
+	prev := lastKey.Line - 2
+	for prev >= 0 && strings.HasPrefix(strings.TrimSpace(string(lines[prev])), "#") {
+		prev--
+	}
+	return prev >= 0 && strings.TrimSpace(string(lines[prev])) == ""
+}
+
+func lineIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
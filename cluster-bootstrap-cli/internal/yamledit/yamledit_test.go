@@ -0,0 +1,230 @@
+package yamledit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chartYAML = `apiVersion: v2
+name: argocd
+# Pinned by renovate
+version: 1.0.0
+
+dependencies:
+  - name: argo-cd
+    version: 9.4.17 # keep in sync with docs
+    repository: https://argoproj.github.io/argo-helm
+`
+
+func TestSetScalar_PreservesFormatting(t *testing.T) {
+	doc, err := Parse([]byte(chartYAML))
+	require.NoError(t, err)
+
+	dep := FindSequenceItem(doc.Lookup("dependencies"), "name", "argo-cd")
+	require.NotNil(t, dep)
+	require.NoError(t, doc.SetScalar(MappingValue(dep, "version"), "9.5.0"))
+
+	expected := `apiVersion: v2
+name: argocd
+# Pinned by renovate
+version: 1.0.0
+
+dependencies:
+  - name: argo-cd
+    version: 9.5.0 # keep in sync with docs
+    repository: https://argoproj.github.io/argo-helm
+`
+	assert.Equal(t, expected, string(doc.Bytes()))
+}
+
+func TestSet_ExistingQuotedScalar(t *testing.T) {
+	src := "components:\n  vault:\n    syncWave: \"1\"\n\n  reloader:\n    syncWave: \"2\"\n"
+	doc, err := Parse([]byte(src))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set("3", "components", "vault", "syncWave"))
+	assert.Equal(t, "components:\n  vault:\n    syncWave: \"3\"\n\n  reloader:\n    syncWave: \"2\"\n", string(doc.Bytes()))
+}
+
+func TestSet_BoolReplacesInPlace(t *testing.T) {
+	src := "components:\n  vault:\n    enabled: true # core\n"
+	doc, err := Parse([]byte(src))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set(false, "components", "vault", "enabled"))
+	assert.Equal(t, "components:\n  vault:\n    enabled: false # core\n", string(doc.Bytes()))
+}
+
+func TestSet_CreatesIntermediateMappings(t *testing.T) {
+	doc, err := Parse([]byte("# Environment overrides\nenvironment: dev\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set(false, "components", "vault", "enabled"))
+	out := string(doc.Bytes())
+	assert.Contains(t, out, "# Environment overrides")
+	assert.Contains(t, out, "environment: dev")
+	assert.Contains(t, out, "components:\n  vault:\n    enabled: false\n")
+}
+
+func TestSet_RejectsScalarParent(t *testing.T) {
+	doc, err := Parse([]byte("components: disabled\n"))
+	require.NoError(t, err)
+
+	err = doc.Set(true, "components", "vault", "enabled")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "components is not a mapping")
+}
+
+func TestSet_EmptyDocument(t *testing.T) {
+	doc, err := Parse(nil)
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set("dev", "environment"))
+	assert.Equal(t, "environment: dev\n", string(doc.Bytes()))
+}
+
+func TestDelete(t *testing.T) {
+	doc, err := Parse([]byte("a:\n  b: 1\n  c: 2\n"))
+	require.NoError(t, err)
+
+	removed, err := doc.Delete("a", "b")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, "a:\n  c: 2\n", string(doc.Bytes()))
+
+	removed, err = doc.Delete("a", "missing")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestPatchScalar_FallsBackForFlowMappings(t *testing.T) {
+	doc, err := Parse([]byte("resources: {cpu: 100m, memory: 1Gi}\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.Set("200m", "resources", "cpu"))
+
+	var out map[string]map[string]string
+	require.NoError(t, doc.Decode(&out))
+	assert.Equal(t, "200m", out["resources"]["cpu"])
+	assert.Equal(t, "1Gi", out["resources"]["memory"])
+}
+
+func TestLoadAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Chart.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(chartYAML), 0600))
+
+	doc, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, doc.Set("2.0.0", "version"))
+	require.NoError(t, doc.Save(path, 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "version: 2.0.0\n")
+	assert.Contains(t, string(data), "# Pinned by renovate")
+}
+
+func TestLoad_Missing(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read")
+}
+
+func TestAddMappingEntry_AppendsAfterLastEntry(t *testing.T) {
+	src := `environment: dev
+
+components:
+  argocd:
+    enabled: true
+    syncWave: "0"
+
+  # Reloader restarts workloads on config changes
+  reloader:
+    enabled: true
+    syncOptions:
+      - ServerSideApply=true
+
+# trailing comment
+other: value
+`
+	doc, err := Parse([]byte(src))
+	require.NoError(t, err)
+
+	entry := struct {
+		Enabled  bool   `yaml:"enabled"`
+		SyncWave string `yaml:"syncWave"`
+	}{true, "3"}
+	require.NoError(t, doc.AddMappingEntry("my-app", entry, "components"))
+
+	expected := `environment: dev
+
+components:
+  argocd:
+    enabled: true
+    syncWave: "0"
+
+  # Reloader restarts workloads on config changes
+  reloader:
+    enabled: true
+    syncOptions:
+      - ServerSideApply=true
+
+  my-app:
+    enabled: true
+    syncWave: "3"
+
+# trailing comment
+other: value
+`
+	assert.Equal(t, expected, string(doc.Bytes()))
+}
+
+func TestAddMappingEntry_EndOfFileWithoutNewline(t *testing.T) {
+	doc, err := Parse([]byte("components:\n  a:\n    enabled: true"))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.AddMappingEntry("b", map[string]bool{"enabled": false}, "components"))
+	assert.Equal(t, "components:\n  a:\n    enabled: true\n  b:\n    enabled: false\n", string(doc.Bytes()))
+}
+
+func TestAddMappingEntry_Errors(t *testing.T) {
+	doc, err := Parse([]byte("components:\n  a:\n    enabled: true\nname: x\n"))
+	require.NoError(t, err)
+
+	assert.Error(t, doc.AddMappingEntry("a", true, "components"))
+	assert.Error(t, doc.AddMappingEntry("b", true, "name"))
+	assert.Error(t, doc.AddMappingEntry("b", true, "missing"))
+}
+
+func TestAddMappingEntry_FlowMappingFallsBack(t *testing.T) {
+	doc, err := Parse([]byte("components: {a: {enabled: true}}\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, doc.AddMappingEntry("b", map[string]bool{"enabled": true}, "components"))
+	var out map[string]map[string]map[string]bool
+	require.NoError(t, doc.Decode(&out))
+	assert.True(t, out["components"]["b"]["enabled"])
+	assert.True(t, out["components"]["a"]["enabled"])
+}
+
+func TestSplitPath(t *testing.T) {
+	for in, want := range map[string][]string{
+		"argo-cd.server.replicas":               {"argo-cd", "server", "replicas"},
+		`podAnnotations."prometheus.io/scrape"`: {"podAnnotations", "prometheus.io/scrape"},
+		`"a.b"`:                                 {"a.b"},
+	} {
+		got, err := SplitPath(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+		assert.Equal(t, in, JoinPath(got...))
+	}
+
+	for _, in := range []string{"", "a..b", "a.", `"a`, `"a"b`} {
+		_, err := SplitPath(in)
+		assert.Error(t, err, in)
+	}
+}